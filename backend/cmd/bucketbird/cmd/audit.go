@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit log commands",
+	Long:  `Query, export and prune the audit log of user and admin actions.`,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+}
+
+// auditFilterFlags are the filters shared by the list and export commands
+type auditFilterFlags struct {
+	actorID  string
+	action   string
+	bucketID string
+	outcome  string
+	since    string
+	until    string
+	limit    int
+}
+
+func (f *auditFilterFlags) register(cmd *cobra.Command, defaultLimit int) {
+	cmd.Flags().StringVar(&f.actorID, "actor-id", "", "Only events by this user ID")
+	cmd.Flags().StringVar(&f.action, "action", "", "Only events with this action, e.g. object.delete")
+	cmd.Flags().StringVar(&f.bucketID, "bucket-id", "", "Only events on this bucket ID")
+	cmd.Flags().StringVar(&f.outcome, "outcome", "", "Only events with this outcome (success or failure)")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only events at or after this time (RFC 3339, or a duration ago such as 24h)")
+	cmd.Flags().StringVar(&f.until, "until", "", "Only events before this time (RFC 3339, or a duration ago such as 1h)")
+	cmd.Flags().IntVar(&f.limit, "limit", defaultLimit, "Maximum number of events (0 for no limit)")
+}
+
+func (f *auditFilterFlags) filter() (repository.AuditFilter, error) {
+	filter := repository.AuditFilter{
+		Action:  f.action,
+		Outcome: f.outcome,
+		Limit:   f.limit,
+	}
+
+	if f.actorID != "" {
+		id, err := uuid.Parse(f.actorID)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --actor-id: %w", err)
+		}
+		filter.ActorID = &id
+	}
+	if f.bucketID != "" {
+		id, err := uuid.Parse(f.bucketID)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --bucket-id: %w", err)
+		}
+		filter.BucketID = &id
+	}
+	if f.since != "" {
+		since, err := parseAuditTime(f.since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --since: %w", err)
+		}
+		filter.Since = &since
+	}
+	if f.until != "" {
+		until, err := parseAuditTime(f.until)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --until: %w", err)
+		}
+		filter.Until = &until
+	}
+	return filter, nil
+}
+
+// parseAuditTime accepts an RFC 3339 timestamp or a duration before now
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	ago, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 timestamp or a duration, got %q", value)
+	}
+	return time.Now().Add(-ago), nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/internal/logging"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditExportFilter auditFilterFlags
+	auditExportFormat string
+	auditExportOutput string
+)
+
+var auditExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export audit events as JSON lines or CSV",
+	Long:  `Write every matching audit event, newest first, to a file or standard output.`,
+	Run:   runAuditExport,
+}
+
+func init() {
+	auditCmd.AddCommand(auditExportCmd)
+
+	auditExportFilter.register(auditExportCmd, 0)
+	auditExportCmd.Flags().StringVar(&auditExportFormat, "format", service.AuditFormatJSONL, "Output format: jsonl or csv")
+	auditExportCmd.Flags().StringVarP(&auditExportOutput, "output", "o", "", "Output file (defaults to standard output)")
+}
+
+func runAuditExport(cmd *cobra.Command, args []string) {
+	cfg := config.Load()
+	logger := logging.NewLogger(cfg.AppName, cfg.Env)
+
+	filter, err := auditExportFilter.filter()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if auditExportFormat != service.AuditFormatJSONL && auditExportFormat != service.AuditFormatCSV {
+		fmt.Fprintf(os.Stderr, "%v\n", service.ErrInvalidAuditFormat)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	// Connect to database
+	pool, err := pgxpool.New(ctx, cfg.DBDSN)
+	if err != nil {
+		logger.Error("failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	var out io.Writer = os.Stdout
+	if auditExportOutput != "" {
+		file, err := os.Create(auditExportOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	repos := repository.NewRepositories(pool)
+	auditService := service.NewAuditService(repos.Audit, cfg.AuditRetention, logger)
+	if err := auditService.Export(ctx, out, auditExportFormat, filter); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export audit events: %v\n", err)
+		os.Exit(1)
+	}
+
+	if auditExportOutput != "" {
+		fmt.Fprintf(os.Stderr, "Audit events written to %s\n", auditExportOutput)
+	}
+}
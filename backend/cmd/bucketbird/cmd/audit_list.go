@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/internal/logging"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+var auditListFilter auditFilterFlags
+
+var auditListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show recent audit events",
+	Long:  `Show the most recent audit events, newest first.`,
+	Run:   runAuditList,
+}
+
+func init() {
+	auditCmd.AddCommand(auditListCmd)
+
+	auditListFilter.register(auditListCmd, 50)
+}
+
+func runAuditList(cmd *cobra.Command, args []string) {
+	cfg := config.Load()
+	logger := logging.NewLogger(cfg.AppName, cfg.Env)
+
+	filter, err := auditListFilter.filter()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	// Connect to database
+	pool, err := pgxpool.New(ctx, cfg.DBDSN)
+	if err != nil {
+		logger.Error("failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	repos := repository.NewRepositories(pool)
+	auditService := service.NewAuditService(repos.Audit, cfg.AuditRetention, logger)
+
+	// Page through the log until the limit is reached
+	remaining := filter.Limit
+	var events []*repository.AuditEvent
+	for {
+		filter.Limit = remaining
+		page, err := auditService.List(ctx, filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list audit events: %v\n", err)
+			os.Exit(1)
+		}
+		events = append(events, page.Events...)
+		remaining -= len(page.Events)
+		if page.NextCursor == 0 || (auditListFilter.limit > 0 && remaining <= 0) {
+			break
+		}
+		filter.BeforeID = page.NextCursor
+	}
+
+	fmt.Println("\nAudit events:")
+	fmt.Println("================================================================================")
+	fmt.Printf("%-20s %-24s %-30s %-8s %s\n", "Time", "Action", "Actor", "Outcome", "Target")
+	fmt.Println("--------------------------------------------------------------------------------")
+
+	for _, event := range events {
+		actor := event.ActorEmail
+		if actor == "" && event.ActorID != nil {
+			actor = event.ActorID.String()
+		}
+		target := event.TargetID
+		if event.BucketName != "" {
+			target = event.BucketName
+			if event.ObjectKey != "" {
+				target += "/" + event.ObjectKey
+			}
+		}
+		if event.Error != "" {
+			target += " (" + event.Error + ")"
+		}
+		fmt.Printf(
+			"%-20s %-24s %-30s %-8s %s\n",
+			event.OccurredAt.UTC().Format("2006-01-02 15:04:05"),
+			event.Action,
+			actor,
+			event.Outcome,
+			target,
+		)
+	}
+
+	fmt.Println("--------------------------------------------------------------------------------")
+	fmt.Printf("Total: %d event(s)\n\n", len(events))
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/internal/logging"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+var auditPruneOlderThan time.Duration
+
+var auditPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old audit events",
+	Long: `Delete audit events older than --older-than, which defaults to BB_AUDIT_RETENTION.
+Running servers prune on the same schedule every hour.`,
+	Run: runAuditPrune,
+}
+
+func init() {
+	auditCmd.AddCommand(auditPruneCmd)
+
+	auditPruneCmd.Flags().DurationVar(&auditPruneOlderThan, "older-than", 0, "Delete events older than this, e.g. 2160h (defaults to BB_AUDIT_RETENTION)")
+}
+
+func runAuditPrune(cmd *cobra.Command, args []string) {
+	cfg := config.Load()
+	logger := logging.NewLogger(cfg.AppName, cfg.Env)
+
+	olderThan := auditPruneOlderThan
+	if olderThan == 0 {
+		olderThan = cfg.AuditRetention
+	}
+	if olderThan <= 0 {
+		fmt.Fprintln(os.Stderr, "Audit retention is disabled; pass --older-than to prune anyway")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	// Connect to database
+	pool, err := pgxpool.New(ctx, cfg.DBDSN)
+	if err != nil {
+		logger.Error("failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	repos := repository.NewRepositories(pool)
+	auditService := service.NewAuditService(repos.Audit, cfg.AuditRetention, logger)
+
+	before := time.Now().Add(-olderThan)
+	deleted, err := auditService.Prune(ctx, before)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to prune audit log: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Deleted %d audit event(s) older than %s\n", deleted, before.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/pkg/crypto"
+)
+
+// newEnvelope builds the envelope for the configured key provider. The keyring
+// is nil when no local encryption key is configured, which is allowed with Vault.
+func newEnvelope(cfg config.Config) (*crypto.Envelope, *crypto.Keyring, error) {
+	var keyring *crypto.Keyring
+	if cfg.HasLocalKey() {
+		var err error
+		keyring, err = crypto.NewKeyring(cfg.EncryptionKeyID, cfg.EncryptionKeys())
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var provider crypto.KeyProvider
+	switch cfg.KeyProvider {
+	case crypto.VaultKeyProviderName:
+		vault, err := crypto.NewVaultTransitProvider(crypto.VaultTransitConfig{
+			Address:   cfg.Vault.Address,
+			Token:     cfg.Vault.Token,
+			Mount:     cfg.Vault.TransitMount,
+			KeyName:   cfg.Vault.TransitKey,
+			Namespace: cfg.Vault.Namespace,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		provider = vault
+	default:
+		provider = crypto.NewLocalKeyProvider(keyring)
+	}
+
+	return crypto.NewEnvelope(provider, keyring), keyring, nil
+}
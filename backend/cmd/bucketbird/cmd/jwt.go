@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/pkg/jwt"
+)
+
+// newTokenManager builds the access token manager for the configured algorithm
+func newTokenManager(cfg config.Config) (*jwt.TokenManager, error) {
+	opts := jwt.Options{
+		TTL:      cfg.AccessTokenTTL,
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+	}
+
+	if cfg.JWTAlgorithm == jwt.AlgorithmHS256 {
+		opts.SigningKey = jwt.NewHMACKey(cfg.JWTSecret)
+	} else {
+		key, err := jwt.ParsePrivateKeyPEM(cfg.JWTKeyID, []byte(cfg.JWTPrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("BB_JWT_PRIVATE_KEY: %w", err)
+		}
+		if key.Algorithm != cfg.JWTAlgorithm {
+			return nil, fmt.Errorf("BB_JWT_PRIVATE_KEY is a %s key but BB_JWT_ALGORITHM is %s", key.Algorithm, cfg.JWTAlgorithm)
+		}
+		opts.SigningKey = key
+		if cfg.HasJWTSecret() {
+			opts.VerificationKeys = append(opts.VerificationKeys, jwt.NewHMACKey(cfg.JWTSecret))
+		}
+	}
+
+	for i, vk := range cfg.JWTVerificationKeys {
+		key, err := jwt.ParsePublicKeyPEM(vk.ID, vk.PEM)
+		if err != nil {
+			return nil, fmt.Errorf("BB_JWT_VERIFICATION_KEYS entry %d: %w", i+1, err)
+		}
+		opts.VerificationKeys = append(opts.VerificationKeys, key)
+	}
+
+	return jwt.NewTokenManager(opts)
+}
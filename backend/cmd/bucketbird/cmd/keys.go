@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Encryption key management commands",
+	Long:  `Inspect and rotate the keys used to encrypt stored credentials and 2FA secrets.`,
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/internal/logging"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+var keysRotateDryRun bool
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Re-encrypt stored secrets with the current key provider",
+	Long: `Move every stored credential, 2FA secret and webhook secret to the configured key provider
+(BB_KEY_PROVIDER). Values encrypted directly with a local key get their own
+data key; existing data keys are rewrapped with the provider's current master
+key (the active local key, or the latest Vault Transit key version). Old local
+keys must still be configured so existing values can be decrypted. The rotation
+runs in a single transaction and changes nothing if any value fails to decrypt.`,
+	Run: runKeysRotate,
+}
+
+func init() {
+	keysCmd.AddCommand(keysRotateCmd)
+
+	keysRotateCmd.Flags().BoolVar(&keysRotateDryRun, "dry-run", false, "Check that all values can be rotated without writing changes")
+}
+
+func runKeysRotate(cmd *cobra.Command, args []string) {
+	cfg := config.Load()
+	logger := logging.NewLogger(cfg.AppName, cfg.Env)
+
+	ctx := context.Background()
+
+	envelope, _, err := newEnvelope(cfg)
+	if err != nil {
+		logger.Error("invalid encryption key configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Connect to database
+	pool, err := pgxpool.New(ctx, cfg.DBDSN)
+	if err != nil {
+		logger.Error("failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	repos := repository.NewRepositories(pool)
+	rotationService := service.NewKeyRotationService(repos, envelope, logger)
+
+	result, err := rotationService.Rotate(ctx, keysRotateDryRun, func(p service.KeyRotationProgress) {
+		if p.Done == p.Total || p.Done%100 == 0 {
+			fmt.Printf("  %s: %d/%d\n", p.Kind, p.Done, p.Total)
+		}
+	})
+	if err != nil {
+		logger.Error("key rotation failed, no changes were written", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	verb := "Rotated"
+	if keysRotateDryRun {
+		verb = "Would rotate"
+	}
+	fmt.Printf("\n%s with the %s key provider:\n", verb, result.Provider)
+	for _, kind := range []string{service.EncryptedCredentials, service.EncryptedTOTPSecrets, service.EncryptedWebhookSecrets} {
+		count := result.Counts[kind]
+		fmt.Printf("  %-14s %d of %d\n", kind, count.Rotated, count.Total)
+	}
+	fmt.Println()
+}
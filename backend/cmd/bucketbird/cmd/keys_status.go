@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/internal/logging"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+var keysStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which keys protect stored secrets",
+	Long:  `Count stored credentials, 2FA secrets and webhook secrets per wrapping key, to tell when an old key can be retired.`,
+	Run:   runKeysStatus,
+}
+
+func init() {
+	keysCmd.AddCommand(keysStatusCmd)
+}
+
+func runKeysStatus(cmd *cobra.Command, args []string) {
+	cfg := config.Load()
+	logger := logging.NewLogger(cfg.AppName, cfg.Env)
+
+	ctx := context.Background()
+
+	envelope, keyring, err := newEnvelope(cfg)
+	if err != nil {
+		logger.Error("invalid encryption key configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Connect to database
+	pool, err := pgxpool.New(ctx, cfg.DBDSN)
+	if err != nil {
+		logger.Error("failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	repos := repository.NewRepositories(pool)
+	status, err := service.NewKeyRotationService(repos, envelope, logger).Status(ctx)
+	if err != nil {
+		logger.Error("failed to read key status", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	fmt.Printf("\nKey provider: %s\n", envelope.ProviderName())
+	if keyring != nil {
+		fmt.Printf("Local keys: %v (active: %s)\n", keyring.KeyIDs(), keyring.ActiveKeyID())
+	}
+	for _, kind := range []string{service.EncryptedCredentials, service.EncryptedTOTPSecrets, service.EncryptedWebhookSecrets} {
+		fmt.Printf("\n%s:\n", kind)
+		counts := status[kind]
+		if len(counts) == 0 {
+			fmt.Println("  (none)")
+			continue
+		}
+		ids := make([]string, 0, len(counts))
+		for id := range counts {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Printf("  %-14s %d\n", id, counts[id])
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/internal/service"
+	"bucketbird/backend/pkg/breached"
+	"bucketbird/backend/pkg/crypto"
+)
+
+// configurePasswordHashing applies the configured Argon2 cost to new password hashes
+func configurePasswordHashing(cfg config.Config) error {
+	h := cfg.PasswordHashing
+	if h.Time < 0 || h.MemoryKiB < 0 || h.Threads < 0 || h.Threads > 255 {
+		return fmt.Errorf("BB_ARGON2_TIME, BB_ARGON2_MEMORY or BB_ARGON2_THREADS is out of range")
+	}
+	params := crypto.Argon2Params{
+		Time:    uint32(h.Time),
+		Memory:  uint32(h.MemoryKiB),
+		Threads: uint8(h.Threads),
+	}
+	if err := crypto.SetPasswordParams(params); err != nil {
+		return fmt.Errorf("invalid password hashing parameters: %w", err)
+	}
+	return nil
+}
+
+// newPasswordPolicy builds the policy for new passwords and loads the breached password filter if one is configured
+func newPasswordPolicy(cfg config.Config) (*service.PasswordPolicy, error) {
+	p := cfg.PasswordPolicy
+	policy := &service.PasswordPolicy{
+		MinLength:        p.MinLength,
+		MaxLength:        p.MaxLength,
+		RequireUppercase: p.RequireUppercase,
+		RequireLowercase: p.RequireLowercase,
+		RequireDigit:     p.RequireDigit,
+		RequireSymbol:    p.RequireSymbol,
+		BannedWords:      p.BannedWords,
+	}
+	if p.BreachFilter != "" {
+		filter, err := breached.Load(p.BreachFilter)
+		if err != nil {
+			return nil, fmt.Errorf("BB_PASSWORD_BREACH_FILTER: %w", err)
+		}
+		policy.Breached = filter
+	}
+	return policy, nil
+}
+
+// printPasswordViolations lists the failed password rules, returning false for other errors
+func printPasswordViolations(err error) bool {
+	var policyErr *service.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	fmt.Fprintln(os.Stderr, "Error: Password does not meet the requirements:")
+	for _, v := range policyErr.Violations {
+		fmt.Fprintf(os.Stderr, "  - %s\n", v.Message)
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var passwordsCmd = &cobra.Command{
+	Use:   "passwords",
+	Short: "Password policy commands",
+	Long:  `Prepare the offline breached password list used by the password policy.`,
+}
+
+func init() {
+	rootCmd.AddCommand(passwordsCmd)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"bucketbird/backend/pkg/breached"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	buildFilterInput     string
+	buildFilterOutput    string
+	buildFilterFormat    string
+	buildFilterFalseRate float64
+)
+
+var passwordsBuildFilterCmd = &cobra.Command{
+	Use:   "build-filter",
+	Short: "Build a breached password filter from a password list",
+	Long: `Build the compact filter read by BB_PASSWORD_BREACH_FILTER.
+
+The input is either a Pwned Passwords SHA-1 download, one "HASH:COUNT" per
+line (--format sha1), or a plain list with one password per line
+(--format plain). The filter is a bloom filter: it never misses a listed
+password, and rejects an unlisted one at roughly the --false-positive-rate.`,
+	Run: runPasswordsBuildFilter,
+}
+
+func init() {
+	passwordsCmd.AddCommand(passwordsBuildFilterCmd)
+
+	passwordsBuildFilterCmd.Flags().StringVarP(&buildFilterInput, "input", "i", "", "Password list to read (required)")
+	passwordsBuildFilterCmd.Flags().StringVarP(&buildFilterOutput, "output", "o", "", "Filter file to write (required)")
+	passwordsBuildFilterCmd.Flags().StringVar(&buildFilterFormat, "format", "sha1", "Input format: sha1 or plain")
+	passwordsBuildFilterCmd.Flags().Float64Var(&buildFilterFalseRate, "false-positive-rate", 0.001, "Share of unlisted passwords wrongly rejected")
+
+	passwordsBuildFilterCmd.MarkFlagRequired("input")
+	passwordsBuildFilterCmd.MarkFlagRequired("output")
+}
+
+func runPasswordsBuildFilter(cmd *cobra.Command, args []string) {
+	if buildFilterFormat != "sha1" && buildFilterFormat != "plain" {
+		fmt.Fprintln(os.Stderr, "Error: --format must be sha1 or plain")
+		os.Exit(1)
+	}
+
+	// The first pass counts entries so the filter can be sized for them
+	var total uint64
+	if err := scanPasswordList(buildFilterInput, func(string) { total++ }); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", buildFilterInput, err)
+		os.Exit(1)
+	}
+
+	filter, err := breached.NewFilter(total, buildFilterFalseRate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var skipped uint64
+	err = scanPasswordList(buildFilterInput, func(line string) {
+		if buildFilterFormat == "plain" {
+			filter.Add(line)
+			return
+		}
+		sum, ok := breached.ParseHashLine(line)
+		if !ok {
+			skipped++
+			return
+		}
+		filter.AddHash(sum)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", buildFilterInput, err)
+		os.Exit(1)
+	}
+
+	out, err := os.Create(buildFilterOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", buildFilterOutput, err)
+		os.Exit(1)
+	}
+	size, err := filter.WriteTo(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", buildFilterOutput, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Wrote %s: %d passwords, %.1f MiB\n", buildFilterOutput, filter.Count(), float64(size)/(1<<20))
+	if skipped > 0 {
+		fmt.Printf("  Skipped %d lines that are not SHA-1 hashes\n", skipped)
+	}
+}
+
+// scanPasswordList calls fn for every non-empty line. Plain lists keep their
+// whitespace, since it is part of the password.
+func scanPasswordList(path string, fn func(line string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 1<<20)
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			fn(line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -10,16 +10,29 @@ import (
 	"syscall"
 	"time"
 
+	"bucketbird/backend/internal/api/access"
+	"bucketbird/backend/internal/api/admin"
 	"bucketbird/backend/internal/api/auth"
 	"bucketbird/backend/internal/api/buckets"
+	"bucketbird/backend/internal/api/costs"
 	"bucketbird/backend/internal/api/credentials"
+	"bucketbird/backend/internal/api/invites"
+	"bucketbird/backend/internal/api/jwks"
 	"bucketbird/backend/internal/api/profile"
+	"bucketbird/backend/internal/api/quotas"
+	"bucketbird/backend/internal/api/sessions"
+	"bucketbird/backend/internal/api/tagjobs"
+	"bucketbird/backend/internal/api/teams"
+	"bucketbird/backend/internal/api/tokens"
+	"bucketbird/backend/internal/api/usage"
+	"bucketbird/backend/internal/api/webhooks"
 	"bucketbird/backend/internal/config"
 	"bucketbird/backend/internal/logging"
+	"bucketbird/backend/internal/mail"
 	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/pricing"
 	"bucketbird/backend/internal/repository"
 	"bucketbird/backend/internal/service"
-	"bucketbird/backend/pkg/jwt"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
@@ -28,6 +41,17 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	// How often audit events older than BB_AUDIT_RETENTION are deleted
+	auditRetentionInterval = time.Hour
+	// How often the outbox is checked for events committed by other instances
+	eventPollInterval = 5 * time.Second
+	// How often lapsed quota reservations are removed
+	quotaReservationInterval = time.Minute
+	// How often observed request counts are written to the database
+	requestCountFlushInterval = time.Minute
+)
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the BucketBird API server",
@@ -63,40 +87,277 @@ func runServe(cmd *cobra.Command, args []string) {
 
 	// Initialize repositories
 	repos := repository.NewRepositories(pool)
+	// Access tokens are checked against their session on every request
+	repos.Sessions = repository.NewCachedSessionRepository(repos.Sessions, cfg.SessionCacheTTL)
+
+	// Initialize envelope encryption and make sure the key provider works
+	envelope, _, err := newEnvelope(cfg)
+	if err != nil {
+		logger.Error("invalid encryption key configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if err := envelope.Check(ctx); err != nil {
+		logger.Error("encryption key provider is not usable", slog.String("provider", envelope.ProviderName()), slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Apply the configured password hashing cost
+	if err := configurePasswordHashing(cfg); err != nil {
+		logger.Error("invalid password hashing configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	passwordPolicy, err := newPasswordPolicy(cfg)
+	if err != nil {
+		logger.Error("invalid password policy configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	// Initialize JWT token manager
-	tokenManager := jwt.NewTokenManager(cfg.JWTSecret, cfg.AccessTokenTTL)
+	tokenManager, err := newTokenManager(cfg)
+	if err != nil {
+		logger.Error("invalid JWT configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	// Initialize services
+	auditService := service.NewAuditService(repos.Audit, cfg.AuditRetention, logger)
+	eventBus := service.NewEventBus(repos, logger)
+
+	var ldapAuthenticator *service.LDAPAuthenticator
+	if cfg.LDAP.Enabled() {
+		ldapAuthenticator = service.NewLDAPAuthenticator(
+			service.LDAPConfig{
+				URL:                cfg.LDAP.URL,
+				StartTLS:           cfg.LDAP.StartTLS,
+				InsecureSkipVerify: cfg.LDAP.InsecureSkipVerify,
+				UserDNTemplate:     cfg.LDAP.UserDNTemplate,
+				BindDN:             cfg.LDAP.BindDN,
+				BindPassword:       cfg.LDAP.BindPassword,
+				BaseDN:             cfg.LDAP.BaseDN,
+				UserFilter:         cfg.LDAP.UserFilter,
+				EmailAttribute:     cfg.LDAP.EmailAttribute,
+				FirstNameAttribute: cfg.LDAP.FirstNameAttribute,
+				LastNameAttribute:  cfg.LDAP.LastNameAttribute,
+				GroupAttribute:     cfg.LDAP.GroupAttribute,
+				GroupBaseDN:        cfg.LDAP.GroupBaseDN,
+				GroupFilter:        cfg.LDAP.GroupFilter,
+				AdminGroups:        cfg.LDAP.AdminGroups,
+				LinkByEmail:        cfg.LDAP.LinkByEmail,
+			},
+			repos.Users,
+			repos.Identities,
+			logger,
+		)
+		logger.Info("ldap authentication enabled", slog.String("url", cfg.LDAP.URL))
+	}
+
+	settingsService := service.NewSettingsService(repos.Settings, logger)
+
+	twoFactorService := service.NewTwoFactorService(
+		repos.Users,
+		repos.Recovery,
+		settingsService,
+		envelope,
+		cfg.TOTPIssuer,
+		auditService,
+		logger,
+	)
+
+	lockoutService := service.NewLockoutService(
+		repos.Logins,
+		service.LockoutPolicy{
+			Threshold:    cfg.Lockout.Threshold,
+			BaseDuration: cfg.Lockout.BaseDuration,
+			MaxDuration:  cfg.Lockout.MaxDuration,
+			ResetAfter:   cfg.Lockout.ResetAfter,
+		},
+		logger,
+	)
+
+	var emailService *service.EmailService
+	if cfg.Email.Enabled() {
+		mailer, err := mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.Email.SMTPHost,
+			Port:     cfg.Email.SMTPPort,
+			Username: cfg.Email.SMTPUsername,
+			Password: cfg.Email.SMTPPassword,
+			From:     cfg.Email.From,
+			TLS:      cfg.Email.SMTPTLS,
+		})
+		if err != nil {
+			logger.Error("invalid smtp configuration", slog.Any("error", err))
+			os.Exit(1)
+		}
+		templates, err := mail.LoadTemplates()
+		if err != nil {
+			logger.Error("failed to load email templates", slog.Any("error", err))
+			os.Exit(1)
+		}
+		emailService = service.NewEmailService(
+			mailer,
+			templates,
+			repos.Users,
+			repos.EmailTokens,
+			repos.Sessions,
+			lockoutService,
+			passwordPolicy,
+			service.EmailConfig{
+				PublicURL:           cfg.Email.PublicURL,
+				RequireVerification: cfg.Email.RequireVerification,
+				VerificationTTL:     cfg.Email.VerificationTTL,
+				PasswordResetTTL:    cfg.Email.PasswordResetTTL,
+			},
+			auditService,
+			logger,
+		)
+		logger.Info("email delivery enabled", slog.String("smtp_host", cfg.Email.SMTPHost))
+	}
+
+	teamService := service.NewTeamService(repos.Teams, logger)
+	inviteService := service.NewInviteService(repos.Invites, teamService, logger)
+
 	authService := service.NewAuthService(
 		repos.Users,
 		repos.Sessions,
 		tokenManager,
 		cfg.RefreshTokenTTL,
+		cfg.PasswordLoginEnabled,
+		service.RegistrationMode(cfg.RegistrationMode),
+		passwordPolicy,
+		ldapAuthenticator,
+		twoFactorService,
+		lockoutService,
+		inviteService,
+		emailService,
+		auditService,
+		eventBus,
 		logger,
 	)
 
+	var oidcOptions *auth.OIDCOptions
+	if cfg.OIDC.Enabled() {
+		oidcService := service.NewOIDCService(
+			service.OIDCConfig{
+				IssuerURL:       cfg.OIDC.IssuerURL,
+				ClientID:        cfg.OIDC.ClientID,
+				ClientSecret:    cfg.OIDC.ClientSecret,
+				RedirectURL:     cfg.OIDC.RedirectURL,
+				Scopes:          cfg.OIDC.Scopes,
+				AdminClaim:      cfg.OIDC.AdminClaim,
+				AdminValues:     cfg.OIDC.AdminValues,
+				AutoCreateUsers: cfg.OIDC.AutoCreateUsers,
+				LinkByEmail:     cfg.OIDC.LinkByEmail,
+			},
+			repos.Users,
+			repos.Identities,
+			repos.OIDC,
+			authService,
+			logger,
+		)
+		oidcOptions = &auth.OIDCOptions{
+			Service:              oidcService,
+			ProviderName:         cfg.OIDC.ProviderName,
+			PostLoginRedirectURL: cfg.OIDC.PostLoginRedirectURL,
+		}
+		logger.Info("oidc single sign-on enabled", slog.String("issuer", cfg.OIDC.IssuerURL))
+	}
+
+	webhookService := service.NewWebhookService(
+		repos.Webhooks,
+		repos.Deliveries,
+		repos.Buckets,
+		envelope,
+		auditService,
+		service.WebhookConfig{
+			MaxAttempts:          cfg.Webhook.MaxAttempts,
+			Timeout:              cfg.Webhook.Timeout,
+			AllowPrivateNetworks: cfg.Webhook.AllowPrivateNetworks,
+			DeliveryRetention:    cfg.Webhook.DeliveryRetention,
+		},
+		logger,
+	)
+
+	quotaService := service.NewQuotaService(repos, eventBus, auditService, logger)
+
+	pricingSource, err := pricing.NewSource(cfg.PricingFile)
+	if err != nil {
+		logger.Error("failed to load pricing table", slog.String("file", cfg.PricingFile), slog.Any("error", err))
+		os.Exit(1)
+	}
+	requestCounter := service.NewRequestCounter(repos.Costs, logger)
+	costService := service.NewCostService(repos, pricingSource, logger)
+
 	bucketService := service.NewBucketService(
 		repos.Buckets,
 		repos.Credentials,
 		repos.Users,
-		cfg.EncryptionKey,
+		envelope,
+		auditService,
+		eventBus,
+		quotaService,
+		requestCounter,
+		logger,
+	)
+
+	usageService := service.NewUsageService(
+		repos,
+		bucketService,
+		eventBus,
+		auditService,
+		service.UsageConfig{
+			SnapshotInterval: cfg.Usage.SnapshotInterval,
+			Retention:        cfg.Usage.HistoryRetention,
+		},
 		logger,
 	)
 
+	accessService := service.NewAccessService(bucketService, auditService, cfg.Email.PublicURL, logger)
+
+	tagJobService := service.NewTagJobService(repos, bucketService, auditService, logger)
+
 	credentialService := service.NewCredentialService(
 		repos.Credentials,
-		cfg.EncryptionKey,
+		envelope,
+		auditService,
+		eventBus,
 		logger,
 	)
 
-	profileService := service.NewProfileService(repos.Users)
+	// Domain event subscribers; names are stored in the outbox and must not change
+	eventBus.Subscribe("log", service.LogEvents(logger))
+	eventBus.Subscribe("bucket_size", bucketService.HandleSizeEvent, service.SizeEventTypes...)
+	eventBus.Subscribe("webhooks", webhookService.HandleEvent, service.WebhookEventTypes...)
+
+	profileService := service.NewProfileService(repos.Users, repos.Sessions, emailService, passwordPolicy, auditService)
+
+	sessionService := service.NewSessionService(repos.Sessions, logger)
+
+	tokenService := service.NewPersonalAccessTokenService(
+		repos.Tokens,
+		repos.Users,
+		repos.Buckets,
+		auditService,
+		logger,
+	)
 
 	// Initialize HTTP handlers
-	authHandler := auth.NewHandler(authService, logger, cfg.CookieSecure, cfg.EnableDemoLogin)
-	bucketHandler := buckets.NewHandler(bucketService, cfg.EncryptionKey, logger)
+	authHandler := auth.NewHandler(authService, emailService, oidcOptions, logger, cfg.CookieSecure, cfg.EnableDemoLogin)
+	bucketHandler := buckets.NewHandler(bucketService, envelope, logger)
+	webhookHandler := webhooks.NewHandler(webhookService, logger)
+	quotaHandler := quotas.NewHandler(quotaService, logger)
+	usageHandler := usage.NewHandler(usageService, logger)
+	accessHandler := access.NewHandler(accessService, envelope, logger)
+	tagJobHandler := tagjobs.NewHandler(tagJobService, logger)
+	costHandler := costs.NewHandler(costService, logger)
 	credentialHandler := credentials.NewHandler(credentialService, logger)
-	profileHandler := profile.NewHandler(profileService, logger)
+	profileHandler := profile.NewHandler(profileService, twoFactorService, logger)
+	tokenHandler := tokens.NewHandler(tokenService, logger)
+	sessionHandler := sessions.NewHandler(sessionService, logger)
+	adminHandler := admin.NewHandler(settingsService, lockoutService, auditService, logger)
+	teamHandler := teams.NewHandler(teamService, logger)
+	inviteHandler := invites.NewHandler(inviteService, logger)
+	jwksHandler := jwks.NewHandler(tokenManager, logger)
 
 	// Setup Chi router
 	r := chi.NewRouter()
@@ -104,6 +365,7 @@ func runServe(cmd *cobra.Command, args []string) {
 	// Middleware stack
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.ClientInfo)
 	r.Use(chimiddleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(middleware.SecurityHeaders)
@@ -114,7 +376,7 @@ func runServe(cmd *cobra.Command, args []string) {
 		AllowedOrigins:   cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Retry-After"},
 		AllowCredentials: allowCredentials,
 		MaxAge:           300,
 	}))
@@ -127,59 +389,219 @@ func runServe(cmd *cobra.Command, args []string) {
 	r.Get("/health", healthHandler)
 	r.Get("/healthz", healthHandler)
 
+	// Public keys for verifying access tokens
+	r.Get("/.well-known/jwks.json", jwksHandler.Get)
+
 	// Public routes (no auth required)
 	r.Route("/api/v1/auth", func(r chi.Router) {
-		r.Post("/register", authHandler.Register)
-		r.Post("/login", authHandler.Login)
+		// Credential-checking endpoints are throttled per IP and per account
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RateLimitByIP(cfg.RateLimit.AuthPerIP))
+			r.Use(middleware.RateLimitByAccount(cfg.RateLimit.AuthPerAccount))
+			r.Post("/register", authHandler.Register)
+			r.Post("/login", authHandler.Login)
+			r.Post("/mfa/verify", authHandler.VerifyMFA)
+			r.Post("/password/forgot", authHandler.ForgotPassword)
+		})
+		// Emailed links carry their own single-use token, so they are only throttled per IP
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RateLimitByIP(cfg.RateLimit.AuthPerIP))
+			r.Post("/password/reset", authHandler.ResetPassword)
+			r.Post("/verify-email", authHandler.VerifyEmail)
+		})
 		r.Post("/demo", authHandler.DemoLogin)
 		r.Post("/refresh", authHandler.Refresh)
 		r.Post("/logout", authHandler.Logout)
+		r.Get("/providers", authHandler.Providers)
+		r.Get("/oidc/login", authHandler.OIDCLogin)
+		r.Get("/oidc/callback", authHandler.OIDCCallback)
 	})
 
 	// Protected routes (auth required)
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(middleware.Auth(authService))
+		r.Use(middleware.Auth(authService, tokenService))
 		r.Use(middleware.DemoReadOnly)
+		r.Use(middleware.PersonalAccessTokenReadOnly)
+
+		// Per-user rate limits, configurable per route group
+		profileRateLimit := middleware.RateLimitByUser(cfg.RateLimit.ForGroup("profile"))
 
 		// Auth endpoints (authenticated)
-		r.Get("/auth/me", authHandler.Me)
-
-		// Profile routes
-		r.Get("/profile", profileHandler.Get)
-		r.Put("/profile", profileHandler.Update)
-		r.Put("/profile/password", profileHandler.UpdatePassword)
-
-		// Bucket routes
-		r.Route("/buckets", func(r chi.Router) {
-			r.Get("/", bucketHandler.List)
-			r.Post("/", bucketHandler.Create)
-			r.Get("/{id}", bucketHandler.Get)
-			r.Put("/{id}", bucketHandler.Update)
-			r.Delete("/{id}", bucketHandler.Delete)
-			r.Post("/{id}/recalculate-size", bucketHandler.RecalculateSize)
-
-			// Object operations
-			r.Get("/{id}/objects", bucketHandler.ListObjects)
-			r.Get("/{id}/objects/search", bucketHandler.SearchObjects)
-			r.Post("/{id}/objects/upload", bucketHandler.UploadObject)
-			r.Get("/{id}/objects/download", bucketHandler.DownloadObject)
-			r.Post("/{id}/objects/presign", bucketHandler.PresignObject)
-			r.Get("/{id}/objects/metadata", bucketHandler.GetObjectMetadata)
-			r.Post("/{id}/objects/folders", bucketHandler.CreateFolder)
-			r.Post("/{id}/objects/delete", bucketHandler.DeleteObjects)
-			r.Post("/{id}/objects/rename", bucketHandler.RenameObject)
-			r.Post("/{id}/objects/copy", bucketHandler.CopyObject)
+		r.With(profileRateLimit).Get("/auth/me", authHandler.Me)
+
+		// Two-factor enrollment routes stay reachable while enrollment is enforced
+		r.Route("/profile/two-factor", func(r chi.Router) {
+			r.Use(profileRateLimit)
+			r.Use(middleware.RejectPersonalAccessTokens)
+			r.Get("/", profileHandler.GetTwoFactor)
+			r.Post("/setup", profileHandler.SetupTwoFactor)
+			r.Post("/enable", profileHandler.EnableTwoFactor)
+			r.Post("/disable", profileHandler.DisableTwoFactor)
+			r.Post("/recovery-codes", profileHandler.RegenerateRecoveryCodes)
 		})
 
-		// Credential routes
-		r.Route("/credentials", func(r chi.Router) {
-			r.Get("/", credentialHandler.List)
-			r.Post("/", credentialHandler.Create)
-			r.Get("/{id}", credentialHandler.Get)
-			r.Put("/{id}", credentialHandler.Update)
-			r.Delete("/{id}", credentialHandler.Delete)
-			r.Get("/{id}/buckets", credentialHandler.DiscoverBuckets)
-			r.Post("/{id}/test", credentialHandler.Test)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireTwoFactorEnrollment(twoFactorService))
+
+			// Profile routes
+			r.Group(func(r chi.Router) {
+				r.Use(profileRateLimit)
+				r.Get("/profile", profileHandler.Get)
+				r.Put("/profile", profileHandler.Update)
+				r.With(middleware.RejectPersonalAccessTokens).Post("/profile/email/verification", profileHandler.SendVerification)
+				r.With(middleware.RejectPersonalAccessTokens).Put("/profile/password", profileHandler.UpdatePassword)
+			})
+
+			// Personal access token routes (interactive sessions only)
+			r.Route("/tokens", func(r chi.Router) {
+				r.Use(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("tokens")))
+				r.Use(middleware.RejectPersonalAccessTokens)
+				r.Get("/", tokenHandler.List)
+				r.Post("/", tokenHandler.Create)
+				r.Delete("/{id}", tokenHandler.Revoke)
+			})
+
+			// Session routes (interactive sessions only)
+			r.Route("/sessions", func(r chi.Router) {
+				r.Use(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("sessions")))
+				r.Use(middleware.RejectPersonalAccessTokens)
+				r.Get("/", sessionHandler.List)
+				r.Post("/revoke-others", sessionHandler.RevokeOthers)
+				r.Delete("/{id}", sessionHandler.Revoke)
+			})
+
+			// Team routes
+			r.Route("/teams", func(r chi.Router) {
+				r.Use(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("teams")))
+				r.Use(middleware.RejectBucketScopedTokens)
+				r.Get("/", teamHandler.List)
+				r.Post("/", teamHandler.Create)
+				r.Get("/{id}", teamHandler.Get)
+				r.Get("/{id}/costs", costHandler.Team)
+				r.Delete("/{id}", teamHandler.Delete)
+				r.Delete("/{id}/members/{userId}", teamHandler.RemoveMember)
+			})
+
+			// Registration invite routes (interactive sessions only)
+			r.Route("/invites", func(r chi.Router) {
+				r.Use(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("invites")))
+				r.Use(middleware.RejectPersonalAccessTokens)
+				r.Get("/", inviteHandler.List)
+				r.Post("/", inviteHandler.Create)
+				r.Delete("/{id}", inviteHandler.Revoke)
+			})
+
+			// Bucket routes
+			r.Route("/buckets", func(r chi.Router) {
+				r.Use(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("buckets")))
+				r.Get("/", bucketHandler.List)
+				r.With(middleware.RejectBucketScopedTokens).Post("/", bucketHandler.Create)
+
+				r.Route("/{id}", func(r chi.Router) {
+					r.Use(middleware.RequireBucketScope)
+					r.Get("/", bucketHandler.Get)
+					r.Put("/", bucketHandler.Update)
+					r.Delete("/", bucketHandler.Delete)
+					r.Post("/recalculate-size", bucketHandler.RecalculateSize)
+
+					// Lifecycle rules
+					r.Get("/lifecycle", bucketHandler.GetLifecycle)
+					r.Put("/lifecycle", bucketHandler.PutLifecycle)
+					r.Delete("/lifecycle", bucketHandler.DeleteLifecycle)
+
+					// Object operations
+					r.Get("/objects", bucketHandler.ListObjects)
+					r.Get("/objects/search", bucketHandler.SearchObjects)
+					r.Post("/objects/upload", bucketHandler.UploadObject)
+					r.Get("/objects/download", bucketHandler.DownloadObject)
+					r.Post("/objects/presign", bucketHandler.PresignObject)
+					r.Get("/objects/metadata", bucketHandler.GetObjectMetadata)
+					r.Post("/objects/folders", bucketHandler.CreateFolder)
+					r.Post("/objects/delete", bucketHandler.DeleteObjects)
+					r.Post("/objects/rename", bucketHandler.RenameObject)
+					r.Post("/objects/copy", bucketHandler.CopyObject)
+
+					// Object tags, bulk tag jobs and tag searches
+					r.Get("/objects/tags", bucketHandler.GetObjectTags)
+					r.Put("/objects/tags", bucketHandler.PutObjectTags)
+					r.Delete("/objects/tags", bucketHandler.DeleteObjectTags)
+					r.Get("/objects/tags/jobs", tagJobHandler.List)
+					r.Post("/objects/tags/jobs", tagJobHandler.Create)
+					r.Get("/objects/tags/jobs/{jobId}", tagJobHandler.Get)
+					r.Delete("/objects/tags/jobs/{jobId}", tagJobHandler.Cancel)
+					r.Post("/objects/tags/searches", tagJobHandler.CreateSearch)
+					r.Get("/objects/tags/searches/{searchId}", tagJobHandler.GetSearch)
+
+					// Webhooks
+					r.Get("/webhooks", webhookHandler.List)
+					r.Post("/webhooks", webhookHandler.Create)
+					r.Get("/webhooks/{webhookId}", webhookHandler.Get)
+					r.Put("/webhooks/{webhookId}", webhookHandler.Update)
+					r.Delete("/webhooks/{webhookId}", webhookHandler.Delete)
+					r.Post("/webhooks/{webhookId}/ping", webhookHandler.Ping)
+					r.Get("/webhooks/{webhookId}/deliveries", webhookHandler.ListDeliveries)
+					r.Post("/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", webhookHandler.Redeliver)
+
+					// Usage history and growth alerts
+					r.Get("/usage/history", usageHandler.History)
+					r.Get("/usage/prefixes", usageHandler.Prefixes)
+					r.Get("/usage/tree", usageHandler.Tree)
+					r.Get("/usage/alert", usageHandler.GetAlert)
+					r.Put("/usage/alert", usageHandler.SetAlert)
+					r.Delete("/usage/alert", usageHandler.DeleteAlert)
+
+					// CORS, bucket policy and public access block
+					r.Get("/access", accessHandler.Get)
+					r.Get("/access/templates/{name}", accessHandler.Template)
+					r.Get("/cors", accessHandler.GetCors)
+					r.Put("/cors", accessHandler.PutCors)
+					r.Delete("/cors", accessHandler.DeleteCors)
+					r.Get("/policy", accessHandler.GetPolicy)
+					r.Put("/policy", accessHandler.PutPolicy)
+					r.Delete("/policy", accessHandler.DeletePolicy)
+					r.Get("/public-access-block", accessHandler.GetPublicAccessBlock)
+					r.Put("/public-access-block", accessHandler.PutPublicAccessBlock)
+					r.Delete("/public-access-block", accessHandler.DeletePublicAccessBlock)
+				})
+			})
+
+			// Storage quotas that apply to the caller
+			r.With(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("quotas")), middleware.RejectBucketScopedTokens).Get("/quotas", quotaHandler.List)
+
+			// Estimated monthly costs of the caller's buckets
+			r.With(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("costs")), middleware.RejectBucketScopedTokens).Get("/costs", costHandler.Get)
+
+			// Credential routes
+			r.Route("/credentials", func(r chi.Router) {
+				r.Use(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("credentials")))
+				r.Use(middleware.RejectBucketScopedTokens)
+				r.Get("/", credentialHandler.List)
+				r.Post("/", credentialHandler.Create)
+				r.Get("/{id}", credentialHandler.Get)
+				r.Put("/{id}", credentialHandler.Update)
+				r.Delete("/{id}", credentialHandler.Delete)
+				r.Get("/{id}/buckets", credentialHandler.DiscoverBuckets)
+				r.Post("/{id}/test", credentialHandler.Test)
+			})
+
+			// Admin routes
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(middleware.RateLimitByUser(cfg.RateLimit.ForGroup("admin")))
+				r.Use(middleware.RejectPersonalAccessTokens)
+				r.Use(middleware.RequireAdmin)
+				r.Get("/settings", adminHandler.GetSettings)
+				r.Put("/settings", adminHandler.UpdateSettings)
+				r.Get("/lockouts", adminHandler.ListLockouts)
+				r.Delete("/lockouts/{identifier}", adminHandler.Unlock)
+				r.Get("/audit", adminHandler.ListAudit)
+				r.Get("/audit/export", adminHandler.ExportAudit)
+				r.Get("/quotas", quotaHandler.AdminList)
+				r.Put("/quotas/{scope}/{subjectId}", quotaHandler.Set)
+				r.Delete("/quotas/{scope}/{subjectId}", quotaHandler.Delete)
+				r.Get("/users/{userId}/quotas", quotaHandler.AdminListForUser)
+				r.Get("/costs", costHandler.AdminGet)
+				r.Get("/costs/pricing", costHandler.Pricing)
+			})
 		})
 	})
 
@@ -191,6 +613,29 @@ func runServe(cmd *cobra.Command, args []string) {
 		WriteTimeout: cfg.WriteTimeout,
 	}
 
+	// Prune expired audit events in the background
+	backgroundCtx, stopBackground := context.WithCancel(ctx)
+	defer stopBackground()
+	go auditService.RunRetention(backgroundCtx, auditRetentionInterval)
+
+	// Send queued webhook deliveries in the background
+	go webhookService.Run(backgroundCtx, cfg.Webhook.PollInterval)
+
+	// Dispatch domain events from the outbox to their subscribers
+	go eventBus.Run(backgroundCtx, eventPollInterval)
+
+	// Expire quota reservations of writes that never finished
+	go quotaService.Run(backgroundCtx, quotaReservationInterval)
+
+	// Store the request counts cost estimates are based on
+	go requestCounter.Run(backgroundCtx, requestCountFlushInterval)
+
+	// Record bucket usage history, check growth alerts and compute queued usage breakdowns
+	go usageService.Run(backgroundCtx)
+
+	// Run queued bulk object tagging jobs
+	go tagJobService.Run(backgroundCtx)
+
 	// Start server in a goroutine
 	serverErrors := make(chan error, 1)
 	go func() {
@@ -11,7 +11,6 @@ import (
 	"bucketbird/backend/internal/logging"
 	"bucketbird/backend/internal/repository"
 	"bucketbird/backend/internal/service"
-	"bucketbird/backend/pkg/jwt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/spf13/cobra"
@@ -22,6 +21,7 @@ var (
 	createUserPassword  string
 	createUserFirstName string
 	createUserLastName  string
+	createUserAdmin     bool
 )
 
 var userCreateCmd = &cobra.Command{
@@ -38,6 +38,7 @@ func init() {
 	userCreateCmd.Flags().StringVarP(&createUserPassword, "password", "p", "", "Password (required)")
 	userCreateCmd.Flags().StringVar(&createUserFirstName, "first-name", "", "First name")
 	userCreateCmd.Flags().StringVar(&createUserLastName, "last-name", "", "Last name")
+	userCreateCmd.Flags().BoolVar(&createUserAdmin, "admin", false, "Grant administrator privileges")
 
 	userCreateCmd.MarkFlagRequired("email")
 	userCreateCmd.MarkFlagRequired("password")
@@ -66,12 +67,39 @@ func runUserCreate(cmd *cobra.Command, args []string) {
 	repos := repository.NewRepositories(pool)
 
 	// Initialize services
-	tokenManager := jwt.NewTokenManager(cfg.JWTSecret, cfg.AccessTokenTTL)
+	if err := configurePasswordHashing(cfg); err != nil {
+		logger.Error("invalid password hashing configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	passwordPolicy, err := newPasswordPolicy(cfg)
+	if err != nil {
+		logger.Error("invalid password policy configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	tokenManager, err := newTokenManager(cfg)
+	if err != nil {
+		logger.Error("invalid JWT configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
 	authService := service.NewAuthService(
 		repos.Users,
 		repos.Sessions,
 		tokenManager,
 		cfg.RefreshTokenTTL,
+		cfg.PasswordLoginEnabled,
+		// Admins create accounts regardless of the registration mode
+		service.RegistrationOpen,
+		passwordPolicy,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		service.NewAuditService(repos.Audit, cfg.AuditRetention, logger),
+		// Events wait in the outbox until a server dispatches them
+		service.NewEventBus(repos, logger),
 		logger,
 	)
 
@@ -82,9 +110,25 @@ func runUserCreate(cmd *cobra.Command, args []string) {
 		LastName:  strings.TrimSpace(createUserLastName),
 	})
 	if err != nil {
+		if printPasswordViolations(err) {
+			os.Exit(1)
+		}
 		logger.Error("failed to create user", slog.Any("error", err))
 		os.Exit(1)
 	}
 
+	// Accounts created by an operator do not need to confirm their address
+	if err := repos.Users.VerifyEmail(ctx, result.User.ID, result.User.Email); err != nil {
+		logger.Error("failed to mark email as verified", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	if createUserAdmin {
+		if err := repos.Users.SetAdmin(ctx, result.User.ID, true); err != nil {
+			logger.Error("failed to grant admin privileges", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
 	fmt.Printf("Created user %s (ID: %s)\n", result.User.Email, result.User.ID)
 }
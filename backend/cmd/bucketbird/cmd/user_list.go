@@ -41,7 +41,7 @@ func runUserList(cmd *cobra.Command, args []string) {
 
 	// Query all users
 	rows, err := pool.Query(ctx, `
-		SELECT id, email, first_name, last_name, created_at
+		SELECT id, email, first_name, last_name, is_admin, created_at
 		FROM users
 		ORDER BY created_at DESC
 	`)
@@ -53,7 +53,7 @@ func runUserList(cmd *cobra.Command, args []string) {
 
 	fmt.Println("\nUsers:")
 	fmt.Println("================================================================================")
-	fmt.Printf("%-38s %-30s %-20s %s\n", "ID", "Email", "Name", "Created At")
+	fmt.Printf("%-38s %-30s %-20s %-6s %s\n", "ID", "Email", "Name", "Admin", "Created At")
 	fmt.Println("--------------------------------------------------------------------------------")
 
 	count := 0
@@ -63,20 +63,26 @@ func runUserList(cmd *cobra.Command, args []string) {
 			email     string
 			firstName string
 			lastName  string
+			isAdmin   bool
 			createdAt time.Time
 		)
 
-		if err := rows.Scan(&id, &email, &firstName, &lastName, &createdAt); err != nil {
+		if err := rows.Scan(&id, &email, &firstName, &lastName, &isAdmin, &createdAt); err != nil {
 			logger.Error("failed to scan user row", slog.Any("error", err))
 			continue
 		}
 
 		name := fmt.Sprintf("%s %s", firstName, lastName)
+		admin := "no"
+		if isAdmin {
+			admin = "yes"
+		}
 		fmt.Printf(
-			"%-38s %-30s %-20s %s\n",
+			"%-38s %-30s %-20s %-6s %s\n",
 			id,
 			email,
 			name,
+			admin,
 			createdAt.UTC().Format("2006-01-02 15:04:05"),
 		)
 		count++
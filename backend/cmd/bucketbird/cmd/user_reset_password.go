@@ -9,6 +9,7 @@ import (
 	"bucketbird/backend/internal/config"
 	"bucketbird/backend/internal/logging"
 	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
 	"bucketbird/backend/pkg/crypto"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -23,7 +24,7 @@ var (
 var userResetPasswordCmd = &cobra.Command{
 	Use:   "reset-password",
 	Short: "Reset a user's password",
-	Long:  `Reset a user's password by email address.`,
+	Long:  `Reset a user's password by email address and sign them out of every session.`,
 	Run:   runUserResetPassword,
 }
 
@@ -31,7 +32,7 @@ func init() {
 	userCmd.AddCommand(userResetPasswordCmd)
 
 	userResetPasswordCmd.Flags().StringVarP(&resetPasswordEmail, "email", "e", "", "User email address (required)")
-	userResetPasswordCmd.Flags().StringVarP(&resetPasswordPassword, "password", "p", "", "New password (required, min 8 characters)")
+	userResetPasswordCmd.Flags().StringVarP(&resetPasswordPassword, "password", "p", "", "New password (required, must meet the password policy)")
 
 	userResetPasswordCmd.MarkFlagRequired("email")
 	userResetPasswordCmd.MarkFlagRequired("password")
@@ -43,11 +44,6 @@ func runUserResetPassword(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	if len(resetPasswordPassword) < 8 {
-		fmt.Fprintln(os.Stderr, "Error: Password must be at least 8 characters")
-		os.Exit(1)
-	}
-
 	// Load configuration
 	cfg := config.Load()
 	logger := logging.NewLogger(cfg.AppName, cfg.Env)
@@ -62,6 +58,17 @@ func runUserResetPassword(cmd *cobra.Command, args []string) {
 	}
 	defer pool.Close()
 
+	if err := configurePasswordHashing(cfg); err != nil {
+		logger.Error("invalid password hashing configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	passwordPolicy, err := newPasswordPolicy(cfg)
+	if err != nil {
+		logger.Error("invalid password policy configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
 	// Initialize repositories
 	repos := repository.NewRepositories(pool)
 
@@ -76,6 +83,16 @@ func runUserResetPassword(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Apply the same rules as self-service password changes
+	if err := passwordPolicy.Check(resetPasswordPassword, service.PasswordOwner{
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}); err != nil {
+		printPasswordViolations(err)
+		os.Exit(1)
+	}
+
 	// Hash the new password
 	passwordHash, err := crypto.HashPassword(resetPasswordPassword)
 	if err != nil {
@@ -89,5 +106,11 @@ func runUserResetPassword(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Sign the user out everywhere
+	if err := revokeUserSessions(ctx, repos, user.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to revoke sessions: %v\n", err)
+		os.Exit(1)
+	}
+
 	fmt.Printf("✓ Password successfully reset for user: %s (%s %s)\n", user.Email, user.FirstName, user.LastName)
 }
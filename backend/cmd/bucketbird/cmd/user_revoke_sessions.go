@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"bucketbird/backend/internal/config"
+	"bucketbird/backend/internal/logging"
+	"bucketbird/backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+var revokeSessionsEmail string
+
+var userRevokeSessionsCmd = &cobra.Command{
+	Use:   "revoke-sessions",
+	Short: "Sign a user out of every session",
+	Long: `Delete all of a user's sessions and revoke their outstanding access tokens.
+Running servers reject the revoked tokens on the next request.`,
+	Run: runUserRevokeSessions,
+}
+
+func init() {
+	userCmd.AddCommand(userRevokeSessionsCmd)
+
+	userRevokeSessionsCmd.Flags().StringVarP(&revokeSessionsEmail, "email", "e", "", "User email address (required)")
+	userRevokeSessionsCmd.MarkFlagRequired("email")
+}
+
+func runUserRevokeSessions(cmd *cobra.Command, args []string) {
+	cfg := config.Load()
+	logger := logging.NewLogger(cfg.AppName, cfg.Env)
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, cfg.DBDSN)
+	if err != nil {
+		logger.Error("failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	repos := repository.NewRepositories(pool)
+
+	user, err := repos.Users.GetByEmail(ctx, revokeSessionsEmail)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			fmt.Fprintf(os.Stderr, "User not found: %s\n", revokeSessionsEmail)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Failed to find user: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := revokeUserSessions(ctx, repos, user.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to revoke sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Revoked all sessions for user: %s\n", user.Email)
+}
+
+// revokeUserSessions deletes the user's sessions and bumps their token version,
+// so access tokens that were already issued are rejected as well
+func revokeUserSessions(ctx context.Context, repos *repository.Repositories, userID uuid.UUID) error {
+	if err := repos.Sessions.DeleteForUser(ctx, userID); err != nil {
+		return err
+	}
+	_, err := repos.Users.IncrementTokenVersion(ctx, userID)
+	return err
+}
@@ -0,0 +1,282 @@
+package access
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/service"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Policies are limited to 20 KB by the service; larger bodies are cut off
+// here so they still get its error
+const maxPolicyBody = 64 << 10
+
+type Handler struct {
+	accessService *service.AccessService
+	envelope      *crypto.Envelope
+	logger        *slog.Logger
+}
+
+func NewHandler(accessService *service.AccessService, envelope *crypto.Envelope, logger *slog.Logger) *Handler {
+	return &Handler{
+		accessService: accessService,
+		envelope:      envelope,
+		logger:        logger,
+	}
+}
+
+// Get returns the bucket's provider, its capabilities and the templates
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	access, err := h.accessService.Access(r.Context(), bucketID, userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to get bucket access settings")
+		return
+	}
+
+	h.respondJSON(w, access, http.StatusOK)
+}
+
+// Template renders a template for the bucket from the query parameters
+func (h *Handler) Template(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	params := map[string]string{}
+	for name, values := range r.URL.Query() {
+		params[name] = values[0]
+	}
+
+	template, err := h.accessService.RenderTemplate(r.Context(), bucketID, userID, chi.URLParam(r, "name"), params)
+	if err != nil {
+		h.handleError(w, err, "Failed to render template")
+		return
+	}
+
+	h.respondJSON(w, template, http.StatusOK)
+}
+
+func (h *Handler) GetCors(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	config, err := h.accessService.GetCors(r.Context(), bucketID, userID, h.envelope)
+	if err != nil {
+		h.handleError(w, err, "Failed to get CORS rules")
+		return
+	}
+
+	h.respondJSON(w, config, http.StatusOK)
+}
+
+// PutCors replaces the bucket's CORS rules, or only validates them with
+// dryRun=true
+func (h *Handler) PutCors(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.CorsConfiguration
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.accessService.PutCors(r.Context(), bucketID, userID, req, parseDryRun(r), h.envelope)
+	if err != nil {
+		h.handleError(w, err, "Failed to set CORS rules")
+		return
+	}
+
+	h.respondJSON(w, config, http.StatusOK)
+}
+
+func (h *Handler) DeleteCors(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.accessService.DeleteCors(r.Context(), bucketID, userID, h.envelope); err != nil {
+		h.handleError(w, err, "Failed to delete CORS rules")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	policy, err := h.accessService.GetPolicy(r.Context(), bucketID, userID, h.envelope)
+	if err != nil {
+		h.handleError(w, err, "Failed to get bucket policy")
+		return
+	}
+
+	h.respondJSON(w, policy, http.StatusOK)
+}
+
+// PutPolicy replaces the bucket's policy with the policy document in the
+// body, or only validates it with dryRun=true
+func (h *Handler) PutPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPolicyBody))
+	if err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.accessService.PutPolicy(r.Context(), bucketID, userID, body, parseDryRun(r), h.envelope)
+	if err != nil {
+		h.handleError(w, err, "Failed to set bucket policy")
+		return
+	}
+
+	h.respondJSON(w, policy, http.StatusOK)
+}
+
+func (h *Handler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.accessService.DeletePolicy(r.Context(), bucketID, userID, h.envelope); err != nil {
+		h.handleError(w, err, "Failed to delete bucket policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetPublicAccessBlock(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	config, err := h.accessService.GetPublicAccessBlock(r.Context(), bucketID, userID, h.envelope)
+	if err != nil {
+		h.handleError(w, err, "Failed to get public access block")
+		return
+	}
+
+	h.respondJSON(w, config, http.StatusOK)
+}
+
+// PutPublicAccessBlock replaces the bucket's public access block, or only
+// checks that the provider supports it with dryRun=true
+func (h *Handler) PutPublicAccessBlock(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.PublicAccessBlock
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.accessService.PutPublicAccessBlock(r.Context(), bucketID, userID, req, parseDryRun(r), h.envelope)
+	if err != nil {
+		h.handleError(w, err, "Failed to set public access block")
+		return
+	}
+
+	h.respondJSON(w, config, http.StatusOK)
+}
+
+func (h *Handler) DeletePublicAccessBlock(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.accessService.DeletePublicAccessBlock(r.Context(), bucketID, userID, h.envelope); err != nil {
+		h.handleError(w, err, "Failed to delete public access block")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseDryRun(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	return dryRun
+}
+
+func (h *Handler) parseBucket(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, bucketID, true
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCors), errors.Is(err, service.ErrInvalidBucketPolicy),
+		errors.Is(err, service.ErrInvalidPublicAccessBlock), errors.Is(err, service.ErrInvalidAccessTemplate):
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrFeatureUnsupported):
+		h.respondError(w, err.Error(), http.StatusNotImplemented)
+	case errors.Is(err, service.ErrBucketNotFound):
+		h.respondError(w, "Bucket not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrAccessTemplateNotFound):
+		h.respondError(w, "Template not found", http.StatusNotFound)
+	default:
+		h.logger.Error("bucket access request failed", slog.String("message", message), slog.Any("error", err))
+		h.respondError(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// ListAudit returns one page of audit events, newest first. Pass nextCursor
+// back as the before parameter to get the following page.
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.auditService.List(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAuditFilter) {
+			h.respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("failed to list audit events", slog.Any("error", err))
+		h.respondError(w, "Failed to list audit events", http.StatusInternalServerError)
+		return
+	}
+
+	records := make([]service.AuditRecord, len(page.Events))
+	for i, event := range page.Events {
+		records[i] = service.NewAuditRecord(event)
+	}
+
+	response := map[string]interface{}{"events": records}
+	if page.NextCursor != 0 {
+		response["nextCursor"] = strconv.FormatInt(page.NextCursor, 10)
+	}
+	h.respondJSON(w, response, http.StatusOK)
+}
+
+// ExportAudit streams every matching audit event as JSON lines or CSV
+func (h *Handler) ExportAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The export is not paginated; limit only caps its size
+	if r.URL.Query().Get("limit") == "" {
+		filter.Limit = 0
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.AuditFormatJSONL
+	}
+	contentType := "application/x-ndjson"
+	switch format {
+	case service.AuditFormatJSONL:
+	case service.AuditFormatCSV:
+		contentType = "text/csv"
+	default:
+		h.respondError(w, service.ErrInvalidAuditFormat.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Headers are already sent once rows are streamed, so later failures can only be logged
+	if err := h.auditService.Export(r.Context(), w, format, filter); err != nil {
+		h.logger.Error("failed to export audit events", slog.Any("error", err))
+	}
+}
+
+func parseAuditFilter(r *http.Request) (repository.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := repository.AuditFilter{
+		Action:  query.Get("action"),
+		Outcome: query.Get("outcome"),
+	}
+
+	if value := query.Get("actorId"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("Invalid actorId")
+		}
+		filter.ActorID = &id
+	}
+	if value := query.Get("bucketId"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("Invalid bucketId")
+		}
+		filter.BucketID = &id
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("Invalid since, expected an RFC 3339 timestamp")
+		}
+		filter.Since = &since
+	}
+	if value := query.Get("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("Invalid until, expected an RFC 3339 timestamp")
+		}
+		filter.Until = &until
+	}
+	if value := query.Get("before"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || before <= 0 {
+			return filter, errors.New("Invalid before cursor")
+		}
+		filter.BeforeID = before
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return filter, errors.New("Invalid limit")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"bucketbird/backend/internal/service"
+)
+
+type Handler struct {
+	settingsService *service.SettingsService
+	lockoutService  *service.LockoutService
+	auditService    *service.AuditService
+	logger          *slog.Logger
+}
+
+func NewHandler(settingsService *service.SettingsService, lockoutService *service.LockoutService, auditService *service.AuditService, logger *slog.Logger) *Handler {
+	return &Handler{
+		settingsService: settingsService,
+		lockoutService:  lockoutService,
+		auditService:    auditService,
+		logger:          logger,
+	}
+}
+
+type SettingsDTO struct {
+	RequireTwoFactor bool `json:"requireTwoFactor"`
+}
+
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.settingsService.Get(r.Context())
+	if err != nil {
+		h.logger.Error("failed to get instance settings", slog.Any("error", err))
+		h.respondError(w, "Failed to get settings", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"settings": SettingsDTO{
+		RequireTwoFactor: settings.RequireTwoFactor,
+	}}, http.StatusOK)
+}
+
+type UpdateSettingsRequest struct {
+	RequireTwoFactor *bool `json:"requireTwoFactor"`
+}
+
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req UpdateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.settingsService.Update(r.Context(), service.UpdateInstanceSettingsInput{
+		RequireTwoFactor: req.RequireTwoFactor,
+	})
+	entry := service.AuditEntry{Action: service.AuditAdminSettingsUpdate}
+	if req.RequireTwoFactor != nil {
+		entry.Details = map[string]string{"requireTwoFactor": strconv.FormatBool(*req.RequireTwoFactor)}
+	}
+	h.auditService.Record(r.Context(), entry, err)
+	if err != nil {
+		h.logger.Error("failed to update instance settings", slog.Any("error", err))
+		h.respondError(w, "Failed to update settings", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"settings": SettingsDTO{
+		RequireTwoFactor: settings.RequireTwoFactor,
+	}}, http.StatusOK)
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type LockoutDTO struct {
+	Identifier    string  `json:"identifier"`
+	FailedCount   int     `json:"failedCount"`
+	LastFailedAt  string  `json:"lastFailedAt"`
+	LastIPAddress string  `json:"lastIpAddress"`
+	LockedUntil   *string `json:"lockedUntil"`
+}
+
+func toLockoutDTO(attempt *repository.LoginAttempt) LockoutDTO {
+	dto := LockoutDTO{
+		Identifier:    attempt.Identifier,
+		FailedCount:   attempt.FailedCount,
+		LastFailedAt:  attempt.LastFailedAt.Format("2006-01-02T15:04:05Z07:00"),
+		LastIPAddress: attempt.LastIPAddress,
+	}
+	if attempt.LockedUntil != nil {
+		formatted := attempt.LockedUntil.Format("2006-01-02T15:04:05Z07:00")
+		dto.LockedUntil = &formatted
+	}
+	return dto
+}
+
+// ListLockouts returns accounts that are currently locked after failed logins
+func (h *Handler) ListLockouts(w http.ResponseWriter, r *http.Request) {
+	attempts, err := h.lockoutService.ListLocked(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list lockouts", slog.Any("error", err))
+		h.respondError(w, "Failed to list lockouts", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]LockoutDTO, len(attempts))
+	for i, attempt := range attempts {
+		dtos[i] = toLockoutDTO(attempt)
+	}
+
+	h.respondJSON(w, map[string]interface{}{"lockouts": dtos}, http.StatusOK)
+}
+
+// Unlock lifts the lockout for an account identifier (usually an email address)
+func (h *Handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	identifier, err := url.PathUnescape(chi.URLParam(r, "identifier"))
+	if err != nil || identifier == "" {
+		h.respondError(w, "Invalid account identifier", http.StatusBadRequest)
+		return
+	}
+
+	err = h.lockoutService.Unlock(r.Context(), identifier)
+	h.auditService.Record(r.Context(), service.AuditEntry{Action: service.AuditAdminUnlock, TargetID: identifier}, err)
+	if err != nil {
+		if errors.Is(err, service.ErrLockoutNotFound) {
+			h.respondError(w, "Lockout not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to unlock account", slog.Any("error", err))
+		h.respondError(w, "Failed to unlock account", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
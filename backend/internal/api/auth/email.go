@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"bucketbird/backend/internal/service"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ForgotPassword emails a password reset link. It responds the same way whether
+// or not the account exists.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if h.emailService == nil {
+		h.respondError(w, "Password reset by email is not configured", http.StatusNotFound)
+		return
+	}
+
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.emailService.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		h.logger.Error("failed to request password reset", slog.Any("error", err))
+		h.respondError(w, "Failed to request password reset", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ResetPassword sets a new password with a token from a reset email
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if h.emailService == nil {
+		h.respondError(w, "Password reset by email is not configured", http.StatusNotFound)
+		return
+	}
+
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.emailService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		if h.respondPasswordPolicy(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidEmailToken):
+			h.respondError(w, "Invalid or expired reset link", http.StatusBadRequest)
+			return
+		case errors.Is(err, service.ErrPasswordRequired):
+			h.respondError(w, "Password is required", http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("failed to reset password", slog.Any("error", err))
+		h.respondError(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// VerifyEmail confirms an email address with a token from a verification email
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if h.emailService == nil {
+		h.respondError(w, "Email verification is not configured", http.StatusNotFound)
+		return
+	}
+
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.emailService.VerifyEmail(r.Context(), req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidEmailToken):
+			h.respondError(w, "Invalid or expired verification link", http.StatusBadRequest)
+			return
+		case errors.Is(err, service.ErrEmailAlreadyInUse):
+			h.respondError(w, "Email already in use", http.StatusConflict)
+			return
+		}
+		h.logger.Error("failed to verify email", slog.Any("error", err))
+		h.respondError(w, "Failed to verify email", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"email": user.Email, "emailVerified": true}, http.StatusOK)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,14 +17,20 @@ const refreshTokenCookieName = "bb_refresh_token"
 
 type Handler struct {
 	authService     *service.AuthService
+	emailService    *service.EmailService
+	oidc            *OIDCOptions
 	logger          *slog.Logger
 	cookieSecure    bool
 	enableDemoLogin bool
 }
 
-func NewHandler(authService *service.AuthService, logger *slog.Logger, cookieSecure bool, enableDemoLogin bool) *Handler {
+// NewHandler creates the auth handler. emailService may be nil when email delivery is not
+// configured, and oidc may be nil when single sign-on is not configured.
+func NewHandler(authService *service.AuthService, emailService *service.EmailService, oidc *OIDCOptions, logger *slog.Logger, cookieSecure bool, enableDemoLogin bool) *Handler {
 	return &Handler{
 		authService:     authService,
+		emailService:    emailService,
+		oidc:            oidc,
 		logger:          logger,
 		cookieSecure:    cookieSecure,
 		enableDemoLogin: enableDemoLogin,
@@ -35,11 +42,28 @@ type RegisterRequest struct {
 	Password  string `json:"password"`
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
+	// InviteCode is required when registration is invite-only
+	InviteCode string `json:"inviteCode,omitempty"`
 }
 
 type AuthResponse struct {
 	User UserDTO       `json:"user"`
 	Auth AuthTokensDTO `json:"auth"`
+	// TwoFactorEnrollmentRequired tells the client to send the user to two-factor setup
+	TwoFactorEnrollmentRequired bool `json:"twoFactorEnrollmentRequired,omitempty"`
+}
+
+// MFAChallengeResponse is returned by login when a second factor is required
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+	MFAExpiry   int64  `json:"mfaExpiry"`
+}
+
+// EmailVerificationResponse is returned by register when the account must confirm its email first
+type EmailVerificationResponse struct {
+	User                      UserDTO `json:"user"`
+	EmailVerificationRequired bool    `json:"emailVerificationRequired"`
 }
 
 type AuthTokensDTO struct {
@@ -54,9 +78,18 @@ type UserDTO struct {
 	FirstName  string `json:"firstName"`
 	LastName   string `json:"lastName"`
 	IsReadonly bool   `json:"isReadonly"`
+	IsAdmin    bool   `json:"isAdmin"`
+	// TwoFactorEnabled reports whether TOTP two-factor authentication is enabled
+	TwoFactorEnabled bool `json:"twoFactorEnabled"`
+	EmailVerified    bool `json:"emailVerified"`
 }
 
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	if !h.authService.PasswordLoginEnabled() {
+		h.respondError(w, "Password login is disabled", http.StatusForbidden)
+		return
+	}
+
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.respondError(w, "Invalid request body", http.StatusBadRequest)
@@ -64,39 +97,73 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.authService.Register(r.Context(), service.RegisterInput{
-		Email:     req.Email,
-		Password:  req.Password,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
+		Email:      req.Email,
+		Password:   req.Password,
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		InviteCode: req.InviteCode,
 	})
 	if err != nil {
-		if errors.Is(err, service.ErrEmailAlreadyInUse) {
+		if h.respondPasswordPolicy(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrEmailAlreadyInUse):
 			h.respondError(w, "Email already in use", http.StatusConflict)
 			return
+		case errors.Is(err, service.ErrRegistrationClosed):
+			h.respondError(w, "Registration is closed", http.StatusForbidden)
+			return
+		case errors.Is(err, service.ErrInviteRequired):
+			h.respondError(w, "An invite code is required to register", http.StatusForbidden)
+			return
+		case errors.Is(err, service.ErrInvalidInvite):
+			h.respondError(w, "Invalid or expired invite code", http.StatusBadRequest)
+			return
+		case errors.Is(err, service.ErrInviteEmailMismatch):
+			h.respondError(w, "Invite code is for a different email address", http.StatusBadRequest)
+			return
 		}
 		h.logger.Error("register failed", slog.Any("error", err))
 		h.respondError(w, "Registration failed", http.StatusInternalServerError)
 		return
 	}
 
+	if result.EmailVerificationRequired {
+		h.respondJSON(w, EmailVerificationResponse{
+			User: UserDTO{
+				ID:        result.User.ID.String(),
+				Email:     result.User.Email,
+				FirstName: result.User.FirstName,
+				LastName:  result.User.LastName,
+			},
+			EmailVerificationRequired: true,
+		}, http.StatusAccepted)
+		return
+	}
+
 	h.setRefreshTokenCookie(w, result.RefreshToken, result.RefreshExpiry)
 	h.respondJSON(w, AuthResponse{
 		User: UserDTO{
-			ID:         result.User.ID.String(),
-			Email:      result.User.Email,
-			FirstName:  result.User.FirstName,
-			LastName:   result.User.LastName,
-			IsReadonly: result.User.IsDemo,
+			ID:               result.User.ID.String(),
+			Email:            result.User.Email,
+			FirstName:        result.User.FirstName,
+			LastName:         result.User.LastName,
+			IsReadonly:       result.User.IsDemo,
+			IsAdmin:          result.User.IsAdmin,
+			TwoFactorEnabled: service.TwoFactorEnabled(result.User),
+			EmailVerified:    result.User.EmailVerifiedAt != nil,
 		},
 		Auth: AuthTokensDTO{
 			AccessToken:   result.AccessToken,
 			AccessExpiry:  result.AccessExpiry.Unix(),
 			RefreshExpiry: result.RefreshExpiry.Unix(),
 		},
+		TwoFactorEnrollmentRequired: result.TwoFactorEnrollmentRequired,
 	}, http.StatusCreated)
 }
 
-type LoginRequest struct{
+type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
@@ -110,29 +177,108 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.authService.Login(r.Context(), req.Email, req.Password)
 	if err != nil {
+		if h.respondLocked(w, err) {
+			return
+		}
 		if errors.Is(err, service.ErrInvalidCredentials) {
 			h.respondError(w, "Invalid credentials", http.StatusUnauthorized)
 			return
 		}
+		if errors.Is(err, service.ErrPasswordLoginDisabled) {
+			h.respondError(w, "Password login is disabled", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			h.respondError(w, "Please verify your email address before signing in", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, service.ErrSSOAccountConflict) {
+			h.respondError(w, "An account with this email already exists", http.StatusConflict)
+			return
+		}
 		h.logger.Error("login failed", slog.Any("error", err))
 		h.respondError(w, "Login failed", http.StatusInternalServerError)
 		return
 	}
 
+	if result.MFARequired() {
+		h.respondJSON(w, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+			MFAExpiry:   result.MFAExpiry.Unix(),
+		}, http.StatusOK)
+		return
+	}
+
 	h.setRefreshTokenCookie(w, result.RefreshToken, result.RefreshExpiry)
 	h.respondJSON(w, AuthResponse{
 		User: UserDTO{
-			ID:         result.User.ID.String(),
-			Email:      result.User.Email,
-			FirstName:  result.User.FirstName,
-			LastName:   result.User.LastName,
-			IsReadonly: result.User.IsDemo,
+			ID:               result.User.ID.String(),
+			Email:            result.User.Email,
+			FirstName:        result.User.FirstName,
+			LastName:         result.User.LastName,
+			IsReadonly:       result.User.IsDemo,
+			IsAdmin:          result.User.IsAdmin,
+			TwoFactorEnabled: service.TwoFactorEnabled(result.User),
+			EmailVerified:    result.User.EmailVerifiedAt != nil,
 		},
 		Auth: AuthTokensDTO{
 			AccessToken:   result.AccessToken,
 			AccessExpiry:  result.AccessExpiry.Unix(),
 			RefreshExpiry: result.RefreshExpiry.Unix(),
 		},
+		TwoFactorEnrollmentRequired: result.TwoFactorEnrollmentRequired,
+	}, http.StatusOK)
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfaToken"`
+	Code     string `json:"code"`
+}
+
+func (h *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req VerifyMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.authService.VerifyMFA(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		if h.respondLocked(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidMFAToken) {
+			h.respondError(w, "Login session expired, please sign in again", http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTwoFactorCode) {
+			h.respondError(w, "Invalid verification code", http.StatusUnauthorized)
+			return
+		}
+		h.logger.Error("mfa verification failed", slog.Any("error", err))
+		h.respondError(w, "Verification failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.setRefreshTokenCookie(w, result.RefreshToken, result.RefreshExpiry)
+	h.respondJSON(w, AuthResponse{
+		User: UserDTO{
+			ID:               result.User.ID.String(),
+			Email:            result.User.Email,
+			FirstName:        result.User.FirstName,
+			LastName:         result.User.LastName,
+			IsReadonly:       result.User.IsDemo,
+			IsAdmin:          result.User.IsAdmin,
+			TwoFactorEnabled: service.TwoFactorEnabled(result.User),
+			EmailVerified:    result.User.EmailVerifiedAt != nil,
+		},
+		Auth: AuthTokensDTO{
+			AccessToken:   result.AccessToken,
+			AccessExpiry:  result.AccessExpiry.Unix(),
+			RefreshExpiry: result.RefreshExpiry.Unix(),
+		},
+		TwoFactorEnrollmentRequired: result.TwoFactorEnrollmentRequired,
 	}, http.StatusOK)
 }
 
@@ -167,17 +313,21 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	h.setRefreshTokenCookie(w, result.RefreshToken, result.RefreshExpiry)
 	h.respondJSON(w, AuthResponse{
 		User: UserDTO{
-			ID:         result.User.ID.String(),
-			Email:      result.User.Email,
-			FirstName:  result.User.FirstName,
-			LastName:   result.User.LastName,
-			IsReadonly: result.User.IsDemo,
+			ID:               result.User.ID.String(),
+			Email:            result.User.Email,
+			FirstName:        result.User.FirstName,
+			LastName:         result.User.LastName,
+			IsReadonly:       result.User.IsDemo,
+			IsAdmin:          result.User.IsAdmin,
+			TwoFactorEnabled: service.TwoFactorEnabled(result.User),
+			EmailVerified:    result.User.EmailVerifiedAt != nil,
 		},
 		Auth: AuthTokensDTO{
 			AccessToken:   result.AccessToken,
 			AccessExpiry:  result.AccessExpiry.Unix(),
 			RefreshExpiry: result.RefreshExpiry.Unix(),
 		},
+		TwoFactorEnrollmentRequired: result.TwoFactorEnrollmentRequired,
 	}, http.StatusOK)
 }
 
@@ -219,17 +369,21 @@ func (h *Handler) DemoLogin(w http.ResponseWriter, r *http.Request) {
 	h.setRefreshTokenCookie(w, result.RefreshToken, result.RefreshExpiry)
 	h.respondJSON(w, AuthResponse{
 		User: UserDTO{
-			ID:         result.User.ID.String(),
-			Email:      result.User.Email,
-			FirstName:  result.User.FirstName,
-			LastName:   result.User.LastName,
-			IsReadonly: result.User.IsDemo,
+			ID:               result.User.ID.String(),
+			Email:            result.User.Email,
+			FirstName:        result.User.FirstName,
+			LastName:         result.User.LastName,
+			IsReadonly:       result.User.IsDemo,
+			IsAdmin:          result.User.IsAdmin,
+			TwoFactorEnabled: service.TwoFactorEnabled(result.User),
+			EmailVerified:    result.User.EmailVerifiedAt != nil,
 		},
 		Auth: AuthTokensDTO{
 			AccessToken:   result.AccessToken,
 			AccessExpiry:  result.AccessExpiry.Unix(),
 			RefreshExpiry: result.RefreshExpiry.Unix(),
 		},
+		TwoFactorEnrollmentRequired: result.TwoFactorEnrollmentRequired,
 	}, http.StatusOK)
 }
 
@@ -241,11 +395,14 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.respondJSON(w, map[string]interface{}{"user": UserDTO{
-		ID:         user.ID.String(),
-		Email:      user.Email,
-		FirstName:  user.FirstName,
-		LastName:   user.LastName,
-		IsReadonly: user.IsDemo,
+		ID:               user.ID.String(),
+		Email:            user.Email,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		IsReadonly:       user.IsDemo,
+		IsAdmin:          user.IsAdmin,
+		TwoFactorEnabled: service.TwoFactorEnabled(user),
+		EmailVerified:    user.EmailVerifiedAt != nil,
 	}}, http.StatusOK)
 }
 
@@ -261,6 +418,30 @@ func (h *Handler) respondError(w http.ResponseWriter, message string, status int
 	h.respondJSON(w, map[string]string{"error": message}, status)
 }
 
+// respondPasswordPolicy answers with 400 and the failed rules if err is a password policy violation
+func (h *Handler) respondPasswordPolicy(w http.ResponseWriter, err error) bool {
+	var policyErr *service.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	h.respondJSON(w, map[string]interface{}{
+		"error":      "Password does not meet the requirements",
+		"violations": policyErr.Violations,
+	}, http.StatusBadRequest)
+	return true
+}
+
+// respondLocked answers with 429 and Retry-After if err is an account lockout
+func (h *Handler) respondLocked(w http.ResponseWriter, err error) bool {
+	var locked *service.AccountLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(locked.RetryAfter().Seconds())))
+	h.respondError(w, "Too many failed attempts, please try again later", http.StatusTooManyRequests)
+	return true
+}
+
 func (h *Handler) setRefreshTokenCookie(w http.ResponseWriter, token string, expires time.Time) {
 	if token == "" {
 		return
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bucketbird/backend/internal/service"
+	"bucketbird/backend/pkg/crypto"
+)
+
+const (
+	// oidcStateCookieName holds a hash of the login state, tying the callback
+	// to the browser that started the login
+	oidcStateCookieName = "bb_oidc_state"
+	oidcCookiePath      = "/api/v1/auth/oidc"
+)
+
+// OIDCOptions configures the single sign-on endpoints
+type OIDCOptions struct {
+	Service      *service.OIDCService
+	ProviderName string
+	// PostLoginRedirectURL is where the browser is sent after the callback. The refresh
+	// token is set as a cookie, so the frontend only needs to call /auth/refresh.
+	PostLoginRedirectURL string
+}
+
+type OIDCProviderDTO struct {
+	Enabled  bool   `json:"enabled"`
+	Name     string `json:"name,omitempty"`
+	LoginURL string `json:"loginUrl,omitempty"`
+}
+
+type ProvidersResponse struct {
+	PasswordLogin bool            `json:"passwordLogin"`
+	LDAP          bool            `json:"ldap"`
+	DemoLogin     bool            `json:"demoLogin"`
+	OIDC          OIDCProviderDTO `json:"oidc"`
+	// Registration is "open", "closed" or "invite"
+	Registration   string            `json:"registration"`
+	PasswordPolicy PasswordPolicyDTO `json:"passwordPolicy"`
+}
+
+// PasswordPolicyDTO lets the frontend show password requirements before submitting
+type PasswordPolicyDTO struct {
+	MinLength        int  `json:"minLength"`
+	MaxLength        int  `json:"maxLength,omitempty"`
+	RequireUppercase bool `json:"requireUppercase"`
+	RequireLowercase bool `json:"requireLowercase"`
+	RequireDigit     bool `json:"requireDigit"`
+	RequireSymbol    bool `json:"requireSymbol"`
+	BreachCheck      bool `json:"breachCheck"`
+}
+
+// Providers reports which login methods are available so the frontend can render them
+func (h *Handler) Providers(w http.ResponseWriter, r *http.Request) {
+	resp := ProvidersResponse{
+		PasswordLogin: h.authService.PasswordLoginEnabled(),
+		LDAP:          h.authService.LDAPEnabled(),
+		DemoLogin:     h.enableDemoLogin,
+		Registration:  string(h.authService.RegistrationMode()),
+	}
+	policy := h.authService.PasswordPolicy()
+	resp.PasswordPolicy = PasswordPolicyDTO{
+		MinLength:        policy.MinLength,
+		MaxLength:        policy.MaxLength,
+		RequireUppercase: policy.RequireUppercase,
+		RequireLowercase: policy.RequireLowercase,
+		RequireDigit:     policy.RequireDigit,
+		RequireSymbol:    policy.RequireSymbol,
+		BreachCheck:      policy.Breached != nil,
+	}
+	if h.oidc != nil {
+		resp.OIDC = OIDCProviderDTO{
+			Enabled:  true,
+			Name:     h.oidc.ProviderName,
+			LoginURL: "/api/v1/auth/oidc/login",
+		}
+	}
+
+	h.respondJSON(w, resp, http.StatusOK)
+}
+
+// OIDCLogin redirects the browser to the identity provider
+func (h *Handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		h.respondError(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	login, err := h.oidc.Service.BeginLogin(r.Context())
+	if err != nil {
+		h.logger.Error("failed to start oidc login", slog.Any("error", err))
+		h.respondError(w, "Failed to start single sign-on", http.StatusBadGateway)
+		return
+	}
+
+	// Lax, so the cookie is sent on the identity provider's top-level redirect back
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    crypto.HashOIDCState(login.State),
+		Path:     oidcCookiePath,
+		Expires:  login.ExpiresAt.UTC(),
+		MaxAge:   int(time.Until(login.ExpiresAt).Seconds()),
+		HttpOnly: true,
+		Secure:   h.cookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, login.URL, http.StatusFound)
+}
+
+// OIDCCallback completes the login after the identity provider redirects back
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		h.respondError(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	cookie, cookieErr := r.Cookie(oidcStateCookieName)
+	h.clearOIDCStateCookie(w)
+
+	if idpError := query.Get("error"); idpError != "" {
+		h.logger.Warn("identity provider returned an error",
+			slog.String("error", idpError),
+			slog.String("description", query.Get("error_description")),
+		)
+		h.redirectAfterOIDC(w, r, "Single sign-on was cancelled or rejected")
+		return
+	}
+
+	// A callback opened in another browser than the one that started the
+	// login would sign that browser into the initiator's account
+	state := query.Get("state")
+	if cookieErr != nil || state == "" ||
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(crypto.HashOIDCState(state))) != 1 {
+		h.logger.Warn("oidc callback state does not match this browser's login request")
+		h.redirectAfterOIDC(w, r, "Login request expired, please try again")
+		return
+	}
+
+	result, err := h.oidc.Service.CompleteLogin(r.Context(), state, query.Get("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidOIDCState):
+			h.redirectAfterOIDC(w, r, "Login request expired, please try again")
+		case errors.Is(err, service.ErrSSOUserNotProvisioned):
+			h.redirectAfterOIDC(w, r, "No account exists for this identity")
+		case errors.Is(err, service.ErrSSOAccountConflict):
+			h.redirectAfterOIDC(w, r, "An account with this email already exists")
+		default:
+			h.logger.Error("oidc login failed", slog.Any("error", err))
+			h.redirectAfterOIDC(w, r, "Single sign-on failed")
+		}
+		return
+	}
+
+	if result.MFARequired() {
+		h.redirectToOIDCMFA(w, r, result)
+		return
+	}
+
+	h.setRefreshTokenCookie(w, result.RefreshToken, result.RefreshExpiry)
+	h.redirectAfterOIDC(w, r, "")
+}
+
+// redirectToOIDCMFA hands the MFA challenge to the frontend, which completes
+// the login at /auth/mfa/verify. The token is put in the fragment so it is
+// not sent to servers or written to their logs.
+func (h *Handler) redirectToOIDCMFA(w http.ResponseWriter, r *http.Request, result *service.AuthResult) {
+	target := h.oidc.PostLoginRedirectURL
+	if u, err := url.Parse(target); err == nil {
+		fragment := url.Values{}
+		fragment.Set("mfaToken", result.MFAToken)
+		fragment.Set("mfaExpiry", strconv.FormatInt(result.MFAExpiry.Unix(), 10))
+		u.Fragment = fragment.Encode()
+		target = u.String()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (h *Handler) clearOIDCStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    "",
+		Path:     oidcCookiePath,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.cookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *Handler) redirectAfterOIDC(w http.ResponseWriter, r *http.Request, errorMessage string) {
+	target := h.oidc.PostLoginRedirectURL
+	if errorMessage != "" {
+		if u, err := url.Parse(target); err == nil {
+			q := u.Query()
+			q.Set("error", errorMessage)
+			u.RawQuery = q.Encode()
+			target = u.String()
+		}
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
@@ -10,6 +10,7 @@ import (
 
 	"bucketbird/backend/internal/middleware"
 	"bucketbird/backend/internal/service"
+	"bucketbird/backend/pkg/crypto"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -17,14 +18,14 @@ import (
 
 type Handler struct {
 	bucketService *service.BucketService
-	encryptionKey []byte
+	envelope      *crypto.Envelope
 	logger        *slog.Logger
 }
 
-func NewHandler(bucketService *service.BucketService, encryptionKey []byte, logger *slog.Logger) *Handler {
+func NewHandler(bucketService *service.BucketService, envelope *crypto.Envelope, logger *slog.Logger) *Handler {
 	return &Handler{
 		bucketService: bucketService,
-		encryptionKey: encryptionKey,
+		envelope:      envelope,
 		logger:        logger,
 	}
 }
@@ -36,6 +37,7 @@ type BucketDTO struct {
 	Description        *string `json:"description"`
 	Size               string  `json:"size"`
 	SizeBytes          int64   `json:"sizeBytes"`
+	ObjectCount        int64   `json:"objectCount"`
 	CredentialID       string  `json:"credentialId"`
 	CredentialName     string  `json:"credentialName"`
 	CredentialProvider string  `json:"credentialProvider"`
@@ -81,6 +83,17 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Bucket-scoped personal access tokens only see the buckets they were granted
+	if token, ok := middleware.GetPersonalAccessTokenFromContext(r.Context()); ok && service.TokenIsBucketScoped(token) {
+		allowed := buckets[:0]
+		for _, b := range buckets {
+			if service.TokenAllowsBucket(token, b.ID) {
+				allowed = append(allowed, b)
+			}
+		}
+		buckets = allowed
+	}
+
 	dtos := make([]BucketDTO, len(buckets))
 	for i, b := range buckets {
 		dtos[i] = BucketDTO{
@@ -90,6 +103,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 			Description:        b.Description,
 			Size:               formatByteSize(b.SizeBytes),
 			SizeBytes:          b.SizeBytes,
+			ObjectCount:        b.ObjectCount,
 			CredentialID:       b.CredentialID.String(),
 			CredentialName:     b.CredentialName,
 			CredentialProvider: b.CredentialProvider,
@@ -159,6 +173,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		Description:        bucket.Description,
 		Size:               formatByteSize(bucket.SizeBytes),
 		SizeBytes:          bucket.SizeBytes,
+		ObjectCount:        bucket.ObjectCount,
 		CredentialID:       bucket.CredentialID.String(),
 		CredentialName:     bucket.CredentialName,
 		CredentialProvider: bucket.CredentialProvider,
@@ -197,6 +212,7 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		Description:        bucket.Description,
 		Size:               formatByteSize(bucket.SizeBytes),
 		SizeBytes:          bucket.SizeBytes,
+		ObjectCount:        bucket.ObjectCount,
 		CredentialID:       bucket.CredentialID.String(),
 		CredentialName:     bucket.CredentialName,
 		CredentialProvider: bucket.CredentialProvider,
@@ -281,7 +297,7 @@ func (h *Handler) RecalculateSize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.bucketService.RecalculateBucketSize(r.Context(), bucketID, userID, h.encryptionKey); err != nil {
+	if err := h.bucketService.RecalculateBucketSize(r.Context(), bucketID, userID, h.envelope); err != nil {
 		if errors.Is(err, service.ErrBucketNotFound) {
 			h.respondError(w, "Bucket not found", http.StatusNotFound)
 			return
@@ -306,6 +322,7 @@ func (h *Handler) RecalculateSize(w http.ResponseWriter, r *http.Request) {
 		Description:        bucket.Description,
 		Size:               formatByteSize(bucket.SizeBytes),
 		SizeBytes:          bucket.SizeBytes,
+		ObjectCount:        bucket.ObjectCount,
 		CredentialID:       bucket.CredentialID.String(),
 		CredentialName:     bucket.CredentialName,
 		CredentialProvider: bucket.CredentialProvider,
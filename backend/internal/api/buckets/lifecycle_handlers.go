@@ -0,0 +1,116 @@
+package buckets
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) GetLifecycle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.bucketService.GetLifecycle(r.Context(), bucketID, userID, h.envelope)
+	if err != nil {
+		if h.respondLifecycleError(w, err) {
+			return
+		}
+		h.logger.Error("failed to get lifecycle rules", slog.Any("error", err))
+		h.respondError(w, "Failed to get lifecycle rules", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, config, http.StatusOK)
+}
+
+func (h *Handler) PutLifecycle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return
+	}
+
+	// Unknown fields are rejected so a misspelt action is not silently dropped
+	var req service.LifecycleConfiguration
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.bucketService.PutLifecycle(r.Context(), bucketID, userID, req, h.envelope)
+	if err != nil {
+		if h.respondLifecycleError(w, err) {
+			return
+		}
+		h.logger.Error("failed to set lifecycle rules", slog.Any("error", err))
+		h.respondError(w, "Failed to set lifecycle rules", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, config, http.StatusOK)
+}
+
+func (h *Handler) DeleteLifecycle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.bucketService.DeleteLifecycle(r.Context(), bucketID, userID, h.envelope); err != nil {
+		if h.respondLifecycleError(w, err) {
+			return
+		}
+		h.logger.Error("failed to delete lifecycle rules", slog.Any("error", err))
+		h.respondError(w, "Failed to delete lifecycle rules", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondLifecycleError answers the expected lifecycle errors; it returns
+// false if err is unexpected
+func (h *Handler) respondLifecycleError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrBucketNotFound):
+		h.respondError(w, "Bucket not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidLifecycle):
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrLifecycleUnsupported):
+		h.respondError(w, err.Error(), http.StatusNotImplemented)
+	default:
+		return false
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package buckets
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -33,9 +34,16 @@ func (h *Handler) ListObjects(w http.ResponseWriter, r *http.Request) {
 	}
 
 	prefix := r.URL.Query().Get("prefix")
+	tags, ok := h.parseTagFilters(w, r)
+	if !ok {
+		return
+	}
 
-	objects, err := h.bucketService.ListObjects(r.Context(), bucketID, userID, prefix, h.encryptionKey)
+	objects, err := h.bucketService.ListObjects(r.Context(), bucketID, userID, prefix, tags, h.envelope)
 	if err != nil {
+		if h.respondTagError(w, err) {
+			return
+		}
 		h.logger.Error("failed to list objects", slog.Any("error", err))
 		h.respondError(w, "Failed to list objects", http.StatusInternalServerError)
 		return
@@ -58,14 +66,28 @@ func (h *Handler) SearchObjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tags, ok := h.parseTagFilters(w, r)
+	if !ok {
+		return
+	}
+
 	query := r.URL.Query().Get("q")
 	if query == "" {
+		// Tag filters alone would look up every object in the bucket;
+		// those searches run in the background instead
+		if len(tags) > 0 {
+			h.respondError(w, "q is required with tag filters; start a tag search to search by tags alone", http.StatusBadRequest)
+			return
+		}
 		h.respondJSON(w, map[string]interface{}{"objects": []service.BucketObject{}}, http.StatusOK)
 		return
 	}
 
-	objects, err := h.bucketService.SearchObjects(r.Context(), bucketID, userID, query, h.encryptionKey)
+	objects, err := h.bucketService.SearchObjects(r.Context(), bucketID, userID, query, tags, h.envelope)
 	if err != nil {
+		if h.respondTagError(w, err) {
+			return
+		}
 		h.logger.Error("failed to search objects", slog.Any("error", err))
 		h.respondError(w, "Failed to search objects", http.StatusInternalServerError)
 		return
@@ -101,6 +123,7 @@ func (h *Handler) UploadObject(w http.ResponseWriter, r *http.Request) {
 	var key string
 	var file io.Reader
 	var contentType string
+	var tags map[string]string
 
 	// Read form parts
 	for {
@@ -122,6 +145,12 @@ func (h *Handler) UploadObject(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			key = string(keyBytes)
+		case "tags":
+			// A JSON object of tags, which must come before the file
+			if err := json.NewDecoder(part).Decode(&tags); err != nil {
+				h.respondError(w, "Invalid tags: "+err.Error(), http.StatusBadRequest)
+				return
+			}
 		case "file":
 			contentType = part.Header.Get("Content-Type")
 			if contentType == "" {
@@ -145,16 +174,25 @@ uploadFile:
 		return
 	}
 
-	if err := h.bucketService.UploadObject(r.Context(), bucketID, userID, key, file, contentType, h.encryptionKey); err != nil {
+	// The whole request bounds the file's size; it is -1 for chunked requests
+	quotaWarnings, err := h.bucketService.UploadObject(r.Context(), bucketID, userID, key, file, r.ContentLength, contentType, tags, h.envelope)
+	if err != nil {
+		if h.respondQuotaError(w, err) || h.respondTagError(w, err) {
+			return
+		}
 		h.logger.Error("failed to upload object", slog.Any("error", err))
 		h.respondError(w, fmt.Sprintf("Upload failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	h.respondJSON(w, map[string]interface{}{
+	response := map[string]interface{}{
 		"success": true,
 		"message": "File uploaded successfully",
-	}, http.StatusOK)
+	}
+	if len(quotaWarnings) > 0 {
+		response["quotaWarnings"] = quotaWarnings
+	}
+	h.respondJSON(w, response, http.StatusOK)
 }
 
 // DownloadObject downloads an object from a bucket
@@ -179,7 +217,7 @@ func (h *Handler) DownloadObject(w http.ResponseWriter, r *http.Request) {
 
 	// Check if it's a folder (ends with /)
 	if strings.HasSuffix(key, "/") {
-		reader, filename, err := h.bucketService.ZipFolder(r.Context(), bucketID, userID, key, h.encryptionKey)
+		reader, filename, err := h.bucketService.ZipFolder(r.Context(), bucketID, userID, key, h.envelope)
 		if err != nil {
 			h.logger.Error("failed to zip folder", slog.Any("error", err))
 			h.respondError(w, fmt.Sprintf("Failed to prepare folder download: %v", err), http.StatusInternalServerError)
@@ -197,7 +235,7 @@ func (h *Handler) DownloadObject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Regular file download
-	obj, err := h.bucketService.ProxyObject(r.Context(), bucketID, userID, key, h.encryptionKey)
+	obj, err := h.bucketService.ProxyObject(r.Context(), bucketID, userID, key, h.envelope)
 	if err != nil {
 		h.logger.Error("failed to get object", slog.Any("error", err))
 		h.respondError(w, fmt.Sprintf("Failed to fetch object: %v", err), http.StatusInternalServerError)
@@ -230,11 +268,18 @@ func (h *Handler) PresignObject(w http.ResponseWriter, r *http.Request) {
 		Method      string  `json:"method"`
 		Expires     *int64  `json:"expiresInSeconds"`
 		ContentType *string `json:"contentType"`
+		Size        *int64  `json:"size"`
+		// Tags are signed into PUT URLs; the upload must send the returned headers
+		Tags map[string]string `json:"tags"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.respondError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if req.Size != nil && *req.Size < 0 {
+		h.respondError(w, "size must not be negative", http.StatusBadRequest)
+		return
+	}
 
 	var expires time.Duration
 	if req.Expires != nil {
@@ -248,8 +293,13 @@ func (h *Handler) PresignObject(w http.ResponseWriter, r *http.Request) {
 		Method:      req.Method,
 		Expires:     expires,
 		ContentType: req.ContentType,
-	}, h.encryptionKey)
+		Size:        req.Size,
+		Tags:        req.Tags,
+	}, h.envelope)
 	if err != nil {
+		if h.respondQuotaError(w, err) || h.respondTagError(w, err) {
+			return
+		}
 		h.logger.Error("failed to presign object", slog.Any("error", err))
 		h.respondError(w, "Failed to presign object", http.StatusInternalServerError)
 		return
@@ -278,7 +328,7 @@ func (h *Handler) GetObjectMetadata(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	metadata, err := h.bucketService.GetObjectMetadata(r.Context(), bucketID, userID, key, h.encryptionKey)
+	metadata, err := h.bucketService.GetObjectMetadata(r.Context(), bucketID, userID, key, h.envelope)
 	if err != nil {
 		h.logger.Error("failed to get object metadata", slog.Any("error", err))
 		h.respondError(w, "Failed to get object metadata", http.StatusInternalServerError)
@@ -311,8 +361,11 @@ func (h *Handler) CreateFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.bucketService.CreateFolder(r.Context(), bucketID, userID, req.Name, req.Prefix, h.encryptionKey)
+	result, err := h.bucketService.CreateFolder(r.Context(), bucketID, userID, req.Name, req.Prefix, h.envelope)
 	if err != nil {
+		if h.respondQuotaError(w, err) {
+			return
+		}
 		h.logger.Error("failed to create folder", slog.Any("error", err))
 		h.respondError(w, "Failed to create folder", http.StatusInternalServerError)
 		return
@@ -343,7 +396,7 @@ func (h *Handler) DeleteObjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.bucketService.DeleteObjects(r.Context(), bucketID, userID, req.Keys, h.encryptionKey)
+	result, err := h.bucketService.DeleteObjects(r.Context(), bucketID, userID, req.Keys, h.envelope)
 	if err != nil {
 		h.logger.Error("failed to delete objects", slog.Any("error", err))
 		h.respondError(w, "Failed to delete objects", http.StatusInternalServerError)
@@ -376,7 +429,7 @@ func (h *Handler) RenameObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.bucketService.RenameObject(r.Context(), bucketID, userID, req.SourceKey, req.DestinationKey, h.encryptionKey)
+	result, err := h.bucketService.RenameObject(r.Context(), bucketID, userID, req.SourceKey, req.DestinationKey, h.envelope)
 	if err != nil {
 		h.logger.Error("failed to rename object", slog.Any("error", err))
 		h.respondError(w, "Failed to rename object", http.StatusInternalServerError)
@@ -409,8 +462,11 @@ func (h *Handler) CopyObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.bucketService.CopyObject(r.Context(), bucketID, userID, req.SourceKey, req.DestinationKey, h.encryptionKey)
+	result, err := h.bucketService.CopyObject(r.Context(), bucketID, userID, req.SourceKey, req.DestinationKey, h.envelope)
 	if err != nil {
+		if h.respondQuotaError(w, err) {
+			return
+		}
 		h.logger.Error("failed to copy object", slog.Any("error", err))
 		h.respondError(w, "Failed to copy object", http.StatusInternalServerError)
 		return
@@ -418,3 +474,17 @@ func (h *Handler) CopyObject(w http.ResponseWriter, r *http.Request) {
 
 	h.respondJSON(w, map[string]interface{}{"result": result}, http.StatusOK)
 }
+
+// respondQuotaError answers a write rejected by a storage quota; it returns
+// false if err is not a quota error
+func (h *Handler) respondQuotaError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrQuotaExceeded):
+		h.respondError(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, service.ErrQuotaSizeRequired):
+		h.respondError(w, err.Error(), http.StatusLengthRequired)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package buckets
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GetObjectTags returns the tags of the object named by the key query parameter
+func (h *Handler) GetObjectTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+	if strings.TrimSpace(key) == "" {
+		h.respondError(w, "key is required", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.bucketService.GetObjectTags(r.Context(), bucketID, userID, key, h.envelope)
+	if err != nil {
+		if h.respondTagError(w, err) {
+			return
+		}
+		h.logger.Error("failed to get object tags", slog.Any("error", err))
+		h.respondError(w, "Failed to get object tags", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"key": key, "tags": tags}, http.StatusOK)
+}
+
+// PutObjectTags replaces the tags of an object
+func (h *Handler) PutObjectTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Key  string            `json:"key"`
+		Tags map[string]string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Key) == "" {
+		h.respondError(w, "key is required", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.bucketService.PutObjectTags(r.Context(), bucketID, userID, req.Key, req.Tags, h.envelope)
+	if err != nil {
+		if h.respondTagError(w, err) {
+			return
+		}
+		h.logger.Error("failed to set object tags", slog.Any("error", err))
+		h.respondError(w, "Failed to set object tags", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"key": req.Key, "tags": tags}, http.StatusOK)
+}
+
+// DeleteObjectTags removes all tags of the object named by the key query parameter
+func (h *Handler) DeleteObjectTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+	if strings.TrimSpace(key) == "" {
+		h.respondError(w, "key is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.bucketService.DeleteObjectTags(r.Context(), bucketID, userID, key, h.envelope); err != nil {
+		if h.respondTagError(w, err) {
+			return
+		}
+		h.logger.Error("failed to delete object tags", slog.Any("error", err))
+		h.respondError(w, "Failed to delete object tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseTagFilters reads the repeatable tag query parameter, written as
+// key=value or key
+func (h *Handler) parseTagFilters(w http.ResponseWriter, r *http.Request) ([]service.TagFilter, bool) {
+	filters, err := service.ParseTagFilters(r.URL.Query()["tag"])
+	if err != nil {
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return filters, true
+}
+
+// respondTagError answers the expected object tag errors; it returns false
+// if err is unexpected
+func (h *Handler) respondTagError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrBucketNotFound):
+		h.respondError(w, "Bucket not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrObjectNotFound):
+		h.respondError(w, "Object not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidObjectTags), errors.Is(err, service.ErrInvalidTagFilter),
+		errors.Is(err, service.ErrTagFilterTooBroad):
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrFeatureUnsupported):
+		h.respondError(w, err.Error(), http.StatusNotImplemented)
+	case errors.Is(err, service.ErrDemoRestriction):
+		h.respondError(w, err.Error(), http.StatusForbidden)
+	default:
+		return false
+	}
+	return true
+}
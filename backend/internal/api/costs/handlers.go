@@ -0,0 +1,129 @@
+package costs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	costService *service.CostService
+	logger      *slog.Logger
+}
+
+func NewHandler(costService *service.CostService, logger *slog.Logger) *Handler {
+	return &Handler{
+		costService: costService,
+		logger:      logger,
+	}
+}
+
+// Get estimates the monthly cost of the caller's buckets
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	estimate, err := h.costService.ForUser(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to estimate costs")
+		return
+	}
+
+	h.respondJSON(w, estimate, http.StatusOK)
+}
+
+// Team estimates the monthly cost of the buckets of a team's members
+func (h *Handler) Team(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	teamID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	estimate, err := h.costService.ForTeam(r.Context(), teamID, userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to estimate team costs")
+		return
+	}
+
+	h.respondJSON(w, estimate, http.StatusOK)
+}
+
+// AdminGet estimates the monthly cost of every bucket, as JSON or, with
+// format=csv, as a CSV file with one row per bucket
+func (h *Handler) AdminGet(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		h.respondError(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	estimate, err := h.costService.All(r.Context())
+	if err != nil {
+		h.handleError(w, err, "Failed to estimate costs")
+		return
+	}
+
+	if format != "csv" {
+		h.respondJSON(w, estimate, http.StatusOK)
+		return
+	}
+
+	filename := fmt.Sprintf("costs-%s.csv", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if err := service.WriteCostCSV(w, estimate); err != nil {
+		h.logger.Error("failed to write cost export", slog.Any("error", err))
+	}
+}
+
+// Pricing returns the pricing table estimates are based on
+func (h *Handler) Pricing(w http.ResponseWriter, r *http.Request) {
+	table, err := h.costService.Pricing()
+	if err != nil {
+		h.handleError(w, err, "Failed to load pricing")
+		return
+	}
+
+	h.respondJSON(w, table, http.StatusOK)
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrTeamNotFound):
+		h.respondError(w, "Team not found", http.StatusNotFound)
+	default:
+		h.logger.Error("cost request failed", slog.String("message", message), slog.Any("error", err))
+		h.respondError(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
@@ -0,0 +1,185 @@
+package invites
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	inviteService *service.InviteService
+	logger        *slog.Logger
+}
+
+func NewHandler(inviteService *service.InviteService, logger *slog.Logger) *Handler {
+	return &Handler{
+		inviteService: inviteService,
+		logger:        logger,
+	}
+}
+
+type InviteDTO struct {
+	ID        string  `json:"id"`
+	Prefix    string  `json:"prefix"`
+	CreatedBy string  `json:"createdBy"`
+	Email     *string `json:"email"`
+	TeamID    *string `json:"teamId"`
+	MaxUses   int     `json:"maxUses"`
+	UseCount  int     `json:"useCount"`
+	Usable    bool    `json:"usable"`
+	ExpiresAt *string `json:"expiresAt"`
+	CreatedAt string  `json:"createdAt"`
+}
+
+func toDTO(invite *repository.Invite) InviteDTO {
+	dto := InviteDTO{
+		ID:        invite.ID.String(),
+		Prefix:    invite.CodePrefix,
+		CreatedBy: invite.CreatedBy.String(),
+		Email:     invite.Email,
+		MaxUses:   invite.MaxUses,
+		UseCount:  invite.UseCount,
+		Usable:    service.InviteUsable(invite),
+		CreatedAt: invite.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if invite.TeamID != nil {
+		teamID := invite.TeamID.String()
+		dto.TeamID = &teamID
+	}
+	if invite.ExpiresAt != nil {
+		formatted := invite.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.ExpiresAt = &formatted
+	}
+	return dto
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	invites, err := h.inviteService.List(r.Context(), user)
+	if err != nil {
+		h.logger.Error("failed to list invites", slog.Any("error", err))
+		h.respondError(w, "Failed to list invites", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]InviteDTO, len(invites))
+	for i, invite := range invites {
+		dtos[i] = toDTO(invite)
+	}
+
+	h.respondJSON(w, map[string]interface{}{"invites": dtos}, http.StatusOK)
+}
+
+type CreateInviteRequest struct {
+	Email     string     `json:"email"`
+	TeamID    *string    `json:"teamId"`
+	MaxUses   *int       `json:"maxUses"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	input := service.CreateInviteInput{
+		Email:     req.Email,
+		MaxUses:   1,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.MaxUses != nil {
+		input.MaxUses = *req.MaxUses
+	}
+	if req.TeamID != nil && *req.TeamID != "" {
+		teamID, err := uuid.Parse(*req.TeamID)
+		if err != nil {
+			h.respondError(w, "Invalid team ID", http.StatusBadRequest)
+			return
+		}
+		input.TeamID = &teamID
+	}
+
+	created, err := h.inviteService.Create(r.Context(), user, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInviteUses),
+			errors.Is(err, service.ErrInvalidInviteExpiry):
+			h.respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, service.ErrInviteForbidden):
+			h.respondError(w, "Only admins and team owners can create invites", http.StatusForbidden)
+			return
+		case errors.Is(err, service.ErrTeamNotFound):
+			h.respondError(w, "Team not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to create invite", slog.Any("error", err))
+		h.respondError(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{
+		"invite": toDTO(created.Invite),
+		"code":   created.Code,
+	}, http.StatusCreated)
+}
+
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	inviteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid invite ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.inviteService.Revoke(r.Context(), user, inviteID); err != nil {
+		if errors.Is(err, service.ErrInviteNotFound) {
+			h.respondError(w, "Invite not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to revoke invite", slog.Any("error", err))
+		h.respondError(w, "Failed to revoke invite", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
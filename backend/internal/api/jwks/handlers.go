@@ -0,0 +1,33 @@
+package jwks
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"bucketbird/backend/pkg/jwt"
+)
+
+// Handler publishes the public keys that verify BucketBird access tokens, so
+// other services can validate tokens without sharing a secret
+type Handler struct {
+	tokenManager *jwt.TokenManager
+	logger       *slog.Logger
+}
+
+func NewHandler(tokenManager *jwt.TokenManager, logger *slog.Logger) *Handler {
+	return &Handler{
+		tokenManager: tokenManager,
+		logger:       logger,
+	}
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// Verifiers may cache keys briefly; rollovers keep old keys listed longer than this
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(h.tokenManager.JWKS()); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
@@ -11,22 +11,38 @@ import (
 )
 
 type Handler struct {
-	profileService *service.ProfileService
-	logger         *slog.Logger
+	profileService   *service.ProfileService
+	twoFactorService *service.TwoFactorService
+	logger           *slog.Logger
 }
 
-func NewHandler(profileService *service.ProfileService, logger *slog.Logger) *Handler {
+func NewHandler(profileService *service.ProfileService, twoFactorService *service.TwoFactorService, logger *slog.Logger) *Handler {
 	return &Handler{
-		profileService: profileService,
-		logger:         logger,
+		profileService:   profileService,
+		twoFactorService: twoFactorService,
+		logger:           logger,
 	}
 }
 
 type ProfileDTO struct {
-	ID        string  `json:"id"`
-	FirstName string  `json:"firstName"`
-	LastName  string  `json:"lastName"`
-	Email     string  `json:"email"`
+	ID            string `json:"id"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	// PendingEmail is returned after an email change that awaits confirmation
+	PendingEmail string `json:"pendingEmail,omitempty"`
+}
+
+func toDTO(profile *service.ProfileData) ProfileDTO {
+	return ProfileDTO{
+		ID:            profile.ID.String(),
+		FirstName:     profile.FirstName,
+		LastName:      profile.LastName,
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified,
+		PendingEmail:  profile.PendingEmail,
+	}
 }
 
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
@@ -43,12 +59,7 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.respondJSON(w, map[string]interface{}{"profile": ProfileDTO{
-		ID:        profile.ID.String(),
-		FirstName: profile.FirstName,
-		LastName:  profile.LastName,
-		Email:     profile.Email,
-	}}, http.StatusOK)
+	h.respondJSON(w, map[string]interface{}{"profile": toDTO(profile)}, http.StatusOK)
 }
 
 type UpdateProfileRequest struct {
@@ -85,12 +96,32 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.respondJSON(w, map[string]interface{}{"profile": ProfileDTO{
-		ID:        profile.ID.String(),
-		FirstName: profile.FirstName,
-		LastName:  profile.LastName,
-		Email:     profile.Email,
-	}}, http.StatusOK)
+	h.respondJSON(w, map[string]interface{}{"profile": toDTO(profile)}, http.StatusOK)
+}
+
+// SendVerification emails a new verification link for the current address
+func (h *Handler) SendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.profileService.SendVerification(r.Context(), userID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailNotConfigured):
+			h.respondError(w, "Email delivery is not configured", http.StatusNotFound)
+			return
+		case errors.Is(err, service.ErrEmailAlreadyVerified):
+			h.respondError(w, "Email address is already verified", http.StatusConflict)
+			return
+		}
+		h.logger.Error("failed to send verification email", slog.Any("error", err))
+		h.respondError(w, "Failed to send verification email", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
 }
 
 type UpdatePasswordRequest struct {
@@ -111,11 +142,20 @@ func (h *Handler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.profileService.UpdatePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+	sessionID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	if err := h.profileService.UpdatePassword(r.Context(), userID, sessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		if h.respondPasswordPolicy(w, err) {
+			return
+		}
 		if errors.Is(err, service.ErrInvalidCredentials) {
 			h.respondError(w, "Current password is incorrect", http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, service.ErrNoLocalPassword) {
+			h.respondError(w, "This account signs in through single sign-on", http.StatusBadRequest)
+			return
+		}
 		h.logger.Error("failed to update password", slog.Any("error", err))
 		h.respondError(w, "Failed to update password", http.StatusInternalServerError)
 		return
@@ -135,3 +175,16 @@ func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status in
 func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
 	h.respondJSON(w, map[string]string{"error": message}, status)
 }
+
+// respondPasswordPolicy answers with 400 and the failed rules if err is a password policy violation
+func (h *Handler) respondPasswordPolicy(w http.ResponseWriter, err error) bool {
+	var policyErr *service.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	h.respondJSON(w, map[string]interface{}{
+		"error":      "Password does not meet the requirements",
+		"violations": policyErr.Violations,
+	}, http.StatusBadRequest)
+	return true
+}
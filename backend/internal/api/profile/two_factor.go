@@ -0,0 +1,163 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/service"
+)
+
+type TwoFactorStatusDTO struct {
+	Enabled                bool    `json:"enabled"`
+	EnabledAt              *string `json:"enabledAt"`
+	RecoveryCodesRemaining int64   `json:"recoveryCodesRemaining"`
+	Required               bool    `json:"required"`
+}
+
+func (h *Handler) GetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := h.twoFactorService.Status(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to get two-factor status", slog.Any("error", err))
+		h.respondError(w, "Failed to get two-factor status", http.StatusInternalServerError)
+		return
+	}
+
+	dto := TwoFactorStatusDTO{
+		Enabled:                status.Enabled,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+		Required:               status.Required,
+	}
+	if status.EnabledAt != nil {
+		formatted := status.EnabledAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.EnabledAt = &formatted
+	}
+
+	h.respondJSON(w, map[string]interface{}{"twoFactor": dto}, http.StatusOK)
+}
+
+func (h *Handler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	setup, err := h.twoFactorService.BeginSetup(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrTwoFactorAlreadyEnabled) {
+			h.respondError(w, "Two-factor authentication is already enabled", http.StatusConflict)
+			return
+		}
+		h.logger.Error("failed to start two-factor setup", slog.Any("error", err))
+		h.respondError(w, "Failed to start two-factor setup", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{
+		"secret":          setup.Secret,
+		"provisioningUri": setup.ProvisioningURI,
+	}, http.StatusOK)
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (h *Handler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(r.Context(), userID, req.Code)
+	if err != nil {
+		h.respondTwoFactorError(w, err, "failed to enable two-factor authentication")
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"recoveryCodes": codes}, http.StatusOK)
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), userID, req.Password, req.Code); err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			h.respondError(w, "Password is incorrect", http.StatusBadRequest)
+			return
+		}
+		h.respondTwoFactorError(w, err, "failed to disable two-factor authentication")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		h.respondTwoFactorError(w, err, "failed to regenerate recovery codes")
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"recoveryCodes": codes}, http.StatusOK)
+}
+
+func (h *Handler) respondTwoFactorError(w http.ResponseWriter, err error, logMessage string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		h.respondError(w, "Invalid verification code", http.StatusBadRequest)
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		h.respondError(w, "Two-factor authentication is already enabled", http.StatusConflict)
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		h.respondError(w, "Two-factor authentication is not enabled", http.StatusConflict)
+	case errors.Is(err, service.ErrTwoFactorNotSetUp):
+		h.respondError(w, "Start two-factor setup first", http.StatusConflict)
+	default:
+		h.logger.Error(logMessage, slog.Any("error", err))
+		h.respondError(w, "Two-factor request failed", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,201 @@
+package quotas
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	quotaService *service.QuotaService
+	logger       *slog.Logger
+}
+
+func NewHandler(quotaService *service.QuotaService, logger *slog.Logger) *Handler {
+	return &Handler{
+		quotaService: quotaService,
+		logger:       logger,
+	}
+}
+
+type QuotaDTO struct {
+	ID          string `json:"id"`
+	Scope       string `json:"scope"`
+	SubjectID   string `json:"subjectId"`
+	SubjectName string `json:"subjectName"`
+	MaxBytes    *int64 `json:"maxBytes"`
+	MaxObjects  *int64 `json:"maxObjects"`
+	WarnPercent int    `json:"warnPercent"`
+	// Used values are as of the last bucket size recalculation; reserved
+	// values are held for writes that are in flight or not yet counted
+	UsedBytes       int64  `json:"usedBytes"`
+	UsedObjects     int64  `json:"usedObjects"`
+	ReservedBytes   int64  `json:"reservedBytes"`
+	ReservedObjects int64  `json:"reservedObjects"`
+	Warning         bool   `json:"warning"`
+	Exceeded        bool   `json:"exceeded"`
+	CreatedAt       string `json:"createdAt"`
+	UpdatedAt       string `json:"updatedAt"`
+}
+
+func toDTO(status *service.QuotaStatus) QuotaDTO {
+	return QuotaDTO{
+		ID:              status.Quota.ID.String(),
+		Scope:           status.Quota.Scope,
+		SubjectID:       status.Quota.SubjectID.String(),
+		SubjectName:     status.Quota.SubjectName,
+		MaxBytes:        status.Quota.MaxBytes,
+		MaxObjects:      status.Quota.MaxObjects,
+		WarnPercent:     status.Quota.WarnPercent,
+		UsedBytes:       status.Usage.Bytes,
+		UsedObjects:     status.Usage.Objects,
+		ReservedBytes:   status.Usage.ReservedBytes,
+		ReservedObjects: status.Usage.ReservedObjects,
+		Warning:         status.Warning,
+		Exceeded:        status.Exceeded,
+		CreatedAt:       status.Quota.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:       status.Quota.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func toDTOs(statuses []*service.QuotaStatus) []QuotaDTO {
+	dtos := make([]QuotaDTO, len(statuses))
+	for i, status := range statuses {
+		dtos[i] = toDTO(status)
+	}
+	return dtos
+}
+
+// List returns the quotas that apply to the caller, with their usage
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	statuses, err := h.quotaService.ListForUser(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to list quotas")
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"quotas": toDTOs(statuses)}, http.StatusOK)
+}
+
+// AdminList returns every quota with its usage
+func (h *Handler) AdminList(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.quotaService.List(r.Context())
+	if err != nil {
+		h.handleError(w, err, "Failed to list quotas")
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"quotas": toDTOs(statuses)}, http.StatusOK)
+}
+
+// AdminListForUser returns the quotas that apply to a user, as the user sees them
+func (h *Handler) AdminListForUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		h.respondError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	statuses, err := h.quotaService.ListForUser(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to list quotas")
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"quotas": toDTOs(statuses)}, http.StatusOK)
+}
+
+type SetQuotaRequest struct {
+	// MaxBytes and MaxObjects are omitted or null for no limit
+	MaxBytes    *int64 `json:"maxBytes"`
+	MaxObjects  *int64 `json:"maxObjects"`
+	WarnPercent int    `json:"warnPercent"`
+}
+
+// Set creates or replaces the quota of the user, team or bucket in the path
+func (h *Handler) Set(w http.ResponseWriter, r *http.Request) {
+	scope, subjectID, ok := h.parseSubject(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetQuotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.quotaService.Set(r.Context(), service.SetQuotaInput{
+		Scope:       scope,
+		SubjectID:   subjectID,
+		MaxBytes:    req.MaxBytes,
+		MaxObjects:  req.MaxObjects,
+		WarnPercent: req.WarnPercent,
+	})
+	if err != nil {
+		h.handleError(w, err, "Failed to set quota")
+		return
+	}
+
+	h.respondJSON(w, toDTO(status), http.StatusOK)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	scope, subjectID, ok := h.parseSubject(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.quotaService.Delete(r.Context(), scope, subjectID); err != nil {
+		h.handleError(w, err, "Failed to delete quota")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) parseSubject(w http.ResponseWriter, r *http.Request) (string, uuid.UUID, bool) {
+	subjectID, err := uuid.Parse(chi.URLParam(r, "subjectId"))
+	if err != nil {
+		h.respondError(w, "Invalid subject ID", http.StatusBadRequest)
+		return "", uuid.Nil, false
+	}
+	return chi.URLParam(r, "scope"), subjectID, true
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidQuota):
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrQuotaNotFound):
+		h.respondError(w, "Quota not found", http.StatusNotFound)
+	default:
+		h.logger.Error("quota request failed", slog.String("message", message), slog.Any("error", err))
+		h.respondError(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
@@ -0,0 +1,129 @@
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	sessionService *service.SessionService
+	logger         *slog.Logger
+}
+
+func NewHandler(sessionService *service.SessionService, logger *slog.Logger) *Handler {
+	return &Handler{
+		sessionService: sessionService,
+		logger:         logger,
+	}
+}
+
+type SessionDTO struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"userAgent"`
+	IPAddress  string `json:"ipAddress"`
+	Current    bool   `json:"current"`
+	CreatedAt  string `json:"createdAt"`
+	LastUsedAt string `json:"lastUsedAt"`
+	ExpiresAt  string `json:"expiresAt"`
+}
+
+func toDTO(session *repository.Session, currentSessionID uuid.UUID) SessionDTO {
+	return SessionDTO{
+		ID:         session.ID.String(),
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		Current:    session.ID == currentSessionID,
+		CreatedAt:  session.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		LastUsedAt: session.LastUsedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ExpiresAt:  session.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	currentSessionID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	sessions, err := h.sessionService.List(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list sessions", slog.Any("error", err))
+		h.respondError(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]SessionDTO, len(sessions))
+	for i, s := range sessions {
+		dtos[i] = toDTO(s, currentSessionID)
+	}
+
+	h.respondJSON(w, map[string]interface{}{"sessions": dtos}, http.StatusOK)
+}
+
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.sessionService.Revoke(r.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			h.respondError(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to revoke session", slog.Any("error", err))
+		h.respondError(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOthers signs out every session except the one making the request
+func (h *Handler) RevokeOthers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	currentSessionID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	revoked, err := h.sessionService.RevokeOthers(r.Context(), userID, currentSessionID)
+	if err != nil {
+		h.logger.Error("failed to revoke sessions", slog.Any("error", err))
+		h.respondError(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"revoked": revoked}, http.StatusOK)
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
@@ -0,0 +1,334 @@
+package tagjobs
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	tagJobService *service.TagJobService
+	logger        *slog.Logger
+}
+
+func NewHandler(tagJobService *service.TagJobService, logger *slog.Logger) *Handler {
+	return &Handler{
+		tagJobService: tagJobService,
+		logger:        logger,
+	}
+}
+
+type TagJobDTO struct {
+	ID          string                     `json:"id"`
+	Prefix      *string                    `json:"prefix,omitempty"`
+	KeyCount    *int                       `json:"keyCount,omitempty"`
+	SetTags     map[string]string          `json:"setTags"`
+	RemoveTags  []string                   `json:"removeTags"`
+	ReplaceTags bool                       `json:"replaceTags"`
+	Status      string                     `json:"status"`
+	Total       *int64                     `json:"total"`
+	Processed   int64                      `json:"processed"`
+	Failed      int64                      `json:"failed"`
+	Failures    []repository.TagJobFailure `json:"failures"`
+	Error       *string                    `json:"error"`
+	CreatedAt   string                     `json:"createdAt"`
+	StartedAt   *string                    `json:"startedAt"`
+	CompletedAt *string                    `json:"completedAt"`
+}
+
+func toTagJobDTO(job *repository.TagJob) TagJobDTO {
+	dto := TagJobDTO{
+		ID:          job.ID.String(),
+		Prefix:      job.Prefix,
+		SetTags:     job.SetTags,
+		RemoveTags:  job.RemoveTags,
+		ReplaceTags: job.ReplaceTags,
+		Status:      job.Status,
+		Total:       job.Total,
+		Processed:   job.Processed,
+		Failed:      job.Failed,
+		Failures:    job.Failures,
+		CreatedAt:   job.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if job.Prefix == nil {
+		count := len(job.Keys)
+		dto.KeyCount = &count
+	}
+	if dto.SetTags == nil {
+		dto.SetTags = map[string]string{}
+	}
+	if dto.RemoveTags == nil {
+		dto.RemoveTags = []string{}
+	}
+	if dto.Failures == nil {
+		dto.Failures = []repository.TagJobFailure{}
+	}
+	if job.Error != "" {
+		dto.Error = &job.Error
+	}
+	if job.StartedAt != nil {
+		formatted := job.StartedAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.StartedAt = &formatted
+	}
+	if job.CompletedAt != nil {
+		formatted := job.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.CompletedAt = &formatted
+	}
+	return dto
+}
+
+// Create queues a job that tags a list of keys or everything under a prefix
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Keys        []string          `json:"keys"`
+		Prefix      *string           `json:"prefix"`
+		SetTags     map[string]string `json:"setTags"`
+		RemoveTags  []string          `json:"removeTags"`
+		ReplaceTags bool              `json:"replaceTags"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.tagJobService.Create(r.Context(), service.CreateTagJobInput{
+		BucketID:    bucketID,
+		UserID:      userID,
+		Keys:        req.Keys,
+		Prefix:      req.Prefix,
+		SetTags:     req.SetTags,
+		RemoveTags:  req.RemoveTags,
+		ReplaceTags: req.ReplaceTags,
+	})
+	if err != nil {
+		h.handleError(w, err, "Failed to create tag job")
+		return
+	}
+
+	h.respondJSON(w, toTagJobDTO(job), http.StatusAccepted)
+}
+
+// List returns the bucket's recent tag jobs, newest first
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.tagJobService.List(r.Context(), bucketID, userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to list tag jobs")
+		return
+	}
+
+	dtos := make([]TagJobDTO, len(jobs))
+	for i, job := range jobs {
+		dtos[i] = toTagJobDTO(job)
+	}
+
+	h.respondJSON(w, map[string]interface{}{"jobs": dtos}, http.StatusOK)
+}
+
+// Get returns a tag job with its progress
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, jobID, ok := h.parseJob(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.tagJobService.Get(r.Context(), bucketID, userID, jobID)
+	if err != nil {
+		h.handleError(w, err, "Failed to get tag job")
+		return
+	}
+
+	h.respondJSON(w, toTagJobDTO(job), http.StatusOK)
+}
+
+// Cancel stops a pending or running tag job
+func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, jobID, ok := h.parseJob(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.tagJobService.Cancel(r.Context(), bucketID, userID, jobID); err != nil {
+		h.handleError(w, err, "Failed to cancel tag job")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type TagSearchDTO struct {
+	ID          string                      `json:"id"`
+	Prefix      string                      `json:"prefix"`
+	Tags        []string                    `json:"tags"`
+	Status      string                      `json:"status"`
+	Scanned     int64                       `json:"scanned"`
+	Matches     []repository.TagSearchMatch `json:"matches"`
+	Truncated   bool                        `json:"truncated"`
+	Error       *string                     `json:"error"`
+	CreatedAt   string                      `json:"createdAt"`
+	StartedAt   *string                     `json:"startedAt"`
+	CompletedAt *string                     `json:"completedAt"`
+}
+
+func toTagSearchDTO(search *repository.TagSearch) TagSearchDTO {
+	dto := TagSearchDTO{
+		ID:        search.ID.String(),
+		Prefix:    search.Prefix,
+		Tags:      search.Filters,
+		Status:    search.Status,
+		Scanned:   search.Scanned,
+		Matches:   search.Matches,
+		Truncated: search.Truncated,
+		CreatedAt: search.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if dto.Matches == nil {
+		dto.Matches = []repository.TagSearchMatch{}
+	}
+	if search.Error != "" {
+		dto.Error = &search.Error
+	}
+	if search.StartedAt != nil {
+		formatted := search.StartedAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.StartedAt = &formatted
+	}
+	if search.CompletedAt != nil {
+		formatted := search.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.CompletedAt = &formatted
+	}
+	return dto
+}
+
+// CreateSearch queues a search for the objects under a prefix having tags
+func (h *Handler) CreateSearch(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Prefix string   `json:"prefix"`
+		Tags   []string `json:"tags"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	search, err := h.tagJobService.CreateSearch(r.Context(), bucketID, userID, req.Prefix, req.Tags)
+	if err != nil {
+		h.handleError(w, err, "Failed to create tag search")
+		return
+	}
+
+	h.respondJSON(w, toTagSearchDTO(search), http.StatusAccepted)
+}
+
+// GetSearch returns a tag search with its progress, and its matches once it
+// has completed
+func (h *Handler) GetSearch(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	searchID, err := uuid.Parse(chi.URLParam(r, "searchId"))
+	if err != nil {
+		h.respondError(w, "Invalid search ID", http.StatusBadRequest)
+		return
+	}
+
+	search, err := h.tagJobService.GetSearch(r.Context(), bucketID, userID, searchID)
+	if err != nil {
+		h.handleError(w, err, "Failed to get tag search")
+		return
+	}
+
+	h.respondJSON(w, toTagSearchDTO(search), http.StatusOK)
+}
+
+func (h *Handler) parseBucket(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, bucketID, true
+}
+
+func (h *Handler) parseJob(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobId"))
+	if err != nil {
+		h.respondError(w, "Invalid job ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, bucketID, jobID, true
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTagJob), errors.Is(err, service.ErrInvalidObjectTags),
+		errors.Is(err, service.ErrInvalidTagFilter):
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrFeatureUnsupported):
+		h.respondError(w, err.Error(), http.StatusNotImplemented)
+	case errors.Is(err, service.ErrDemoRestriction):
+		h.respondError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrBucketNotFound):
+		h.respondError(w, "Bucket not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrTagJobNotFound):
+		h.respondError(w, "Tag job not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrTagSearchNotFound):
+		h.respondError(w, "Tag search not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrTagJobFinished):
+		h.respondError(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Error("tag job request failed", slog.String("message", message), slog.Any("error", err))
+		h.respondError(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
@@ -0,0 +1,227 @@
+package teams
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	teamService *service.TeamService
+	logger      *slog.Logger
+}
+
+func NewHandler(teamService *service.TeamService, logger *slog.Logger) *Handler {
+	return &Handler{
+		teamService: teamService,
+		logger:      logger,
+	}
+}
+
+type TeamDTO struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type TeamMemberDTO struct {
+	UserID    string `json:"userId"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Role      string `json:"role"`
+	JoinedAt  string `json:"joinedAt"`
+}
+
+func toDTO(team *repository.Team, role string) TeamDTO {
+	return TeamDTO{
+		ID:        team.ID.String(),
+		Name:      team.Name,
+		Role:      role,
+		CreatedAt: team.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func toMemberDTO(member *repository.TeamMember) TeamMemberDTO {
+	return TeamMemberDTO{
+		UserID:    member.UserID.String(),
+		Email:     member.Email,
+		FirstName: member.FirstName,
+		LastName:  member.LastName,
+		Role:      member.Role,
+		JoinedAt:  member.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	memberships, err := h.teamService.List(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list teams", slog.Any("error", err))
+		h.respondError(w, "Failed to list teams", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]TeamDTO, len(memberships))
+	for i, m := range memberships {
+		dtos[i] = toDTO(&m.Team, m.Role)
+	}
+
+	h.respondJSON(w, map[string]interface{}{"teams": dtos}, http.StatusOK)
+}
+
+type CreateTeamRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.teamService.Create(r.Context(), userID, req.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTeamName) {
+			h.respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("failed to create team", slog.Any("error", err))
+		h.respondError(w, "Failed to create team", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{"team": toDTO(team, service.TeamRoleOwner)}, http.StatusCreated)
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	teamID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	details, err := h.teamService.Get(r.Context(), teamID, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			h.respondError(w, "Team not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to get team", slog.Any("error", err))
+		h.respondError(w, "Failed to get team", http.StatusInternalServerError)
+		return
+	}
+
+	members := make([]TeamMemberDTO, len(details.Members))
+	for i, m := range details.Members {
+		members[i] = toMemberDTO(m)
+	}
+
+	h.respondJSON(w, map[string]interface{}{
+		"team":    toDTO(details.Team, details.Role),
+		"members": members,
+	}, http.StatusOK)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	teamID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.teamService.Delete(r.Context(), teamID, userID); err != nil {
+		h.respondTeamError(w, err, "Failed to delete team")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveMember removes a member from the team; members may remove themselves to leave
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	teamID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	memberID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		h.respondError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.teamService.RemoveMember(r.Context(), teamID, userID, memberID); err != nil {
+		h.respondTeamError(w, err, "Failed to remove team member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) respondTeamError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrTeamNotFound):
+		h.respondError(w, "Team not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrTeamMemberNotFound):
+		h.respondError(w, "Team member not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrNotTeamOwner):
+		h.respondError(w, "Only team owners can do this", http.StatusForbidden)
+	case errors.Is(err, service.ErrLastTeamOwner):
+		h.respondError(w, "A team must keep at least one owner", http.StatusConflict)
+	default:
+		h.logger.Error(fallback, slog.Any("error", err))
+		h.respondError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
@@ -0,0 +1,183 @@
+package tokens
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	tokenService *service.PersonalAccessTokenService
+	logger       *slog.Logger
+}
+
+func NewHandler(tokenService *service.PersonalAccessTokenService, logger *slog.Logger) *Handler {
+	return &Handler{
+		tokenService: tokenService,
+		logger:       logger,
+	}
+}
+
+type PersonalAccessTokenDTO struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scope      string   `json:"scope"`
+	BucketIDs  []string `json:"bucketIds"`
+	ExpiresAt  *string  `json:"expiresAt"`
+	LastUsedAt *string  `json:"lastUsedAt"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+func toDTO(token *repository.PersonalAccessToken) PersonalAccessTokenDTO {
+	bucketIDs := make([]string, len(token.BucketIDs))
+	for i, id := range token.BucketIDs {
+		bucketIDs[i] = id.String()
+	}
+
+	dto := PersonalAccessTokenDTO{
+		ID:        token.ID.String(),
+		Name:      token.Name,
+		Prefix:    token.TokenPrefix,
+		Scope:     token.Scope,
+		BucketIDs: bucketIDs,
+		CreatedAt: token.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if token.ExpiresAt != nil {
+		formatted := token.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.ExpiresAt = &formatted
+	}
+	if token.LastUsedAt != nil {
+		formatted := token.LastUsedAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.LastUsedAt = &formatted
+	}
+	return dto
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.tokenService.List(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list personal access tokens", slog.Any("error", err))
+		h.respondError(w, "Failed to list tokens", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]PersonalAccessTokenDTO, len(tokens))
+	for i, t := range tokens {
+		dtos[i] = toDTO(t)
+	}
+
+	h.respondJSON(w, map[string]interface{}{"tokens": dtos}, http.StatusOK)
+}
+
+type CreateTokenRequest struct {
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	BucketIDs []string   `json:"bucketIds"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	bucketIDs := make([]uuid.UUID, 0, len(req.BucketIDs))
+	for _, raw := range req.BucketIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+			return
+		}
+		bucketIDs = append(bucketIDs, id)
+	}
+
+	created, err := h.tokenService.Create(r.Context(), service.CreatePersonalAccessTokenInput{
+		UserID:    userID,
+		Name:      req.Name,
+		Scope:     req.Scope,
+		BucketIDs: bucketIDs,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTokenName),
+			errors.Is(err, service.ErrInvalidTokenScope),
+			errors.Is(err, service.ErrInvalidTokenExpiry):
+			h.respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, service.ErrBucketNotFound):
+			h.respondError(w, "Bucket not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to create personal access token", slog.Any("error", err))
+		h.respondError(w, "Failed to create token", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{
+		"token":  toDTO(created.Token),
+		"secret": created.Secret,
+	}, http.StatusCreated)
+}
+
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenService.Revoke(r.Context(), tokenID, userID); err != nil {
+		if errors.Is(err, service.ErrPersonalAccessTokenNotFound) {
+			h.respondError(w, "Token not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to revoke personal access token", slog.Any("error", err))
+		h.respondError(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
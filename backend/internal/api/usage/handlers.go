@@ -0,0 +1,315 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	usageService *service.UsageService
+	logger       *slog.Logger
+}
+
+func NewHandler(usageService *service.UsageService, logger *slog.Logger) *Handler {
+	return &Handler{
+		usageService: usageService,
+		logger:       logger,
+	}
+}
+
+type UsagePointDTO struct {
+	PeriodStart   string `json:"periodStart"`
+	CapturedAt    string `json:"capturedAt"`
+	SizeBytes     int64  `json:"sizeBytes"`
+	ObjectCount   int64  `json:"objectCount"`
+	GrowthBytes   int64  `json:"growthBytes"`
+	GrowthObjects int64  `json:"growthObjects"`
+}
+
+type PrefixGrowthDTO struct {
+	Prefix        string `json:"prefix"`
+	CapturedAt    string `json:"capturedAt"`
+	SizeBytes     int64  `json:"sizeBytes"`
+	ObjectCount   int64  `json:"objectCount"`
+	GrowthBytes   int64  `json:"growthBytes"`
+	GrowthObjects int64  `json:"growthObjects"`
+}
+
+type UsageAlertDTO struct {
+	MaxDailyGrowthBytes   *int64  `json:"maxDailyGrowthBytes"`
+	MaxDailyGrowthObjects *int64  `json:"maxDailyGrowthObjects"`
+	LastTriggeredAt       *string `json:"lastTriggeredAt"`
+	CreatedAt             string  `json:"createdAt"`
+	UpdatedAt             string  `json:"updatedAt"`
+}
+
+func toAlertDTO(alert *repository.UsageAlert) UsageAlertDTO {
+	dto := UsageAlertDTO{
+		MaxDailyGrowthBytes:   alert.MaxDailyGrowthBytes,
+		MaxDailyGrowthObjects: alert.MaxDailyGrowthObjects,
+		CreatedAt:             alert.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:             alert.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if alert.LastTriggeredAt != nil {
+		formatted := alert.LastTriggeredAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.LastTriggeredAt = &formatted
+	}
+	return dto
+}
+
+// History returns the bucket's usage rolled up by day or week
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+	days, ok := h.parseCount(w, r, "days")
+	if !ok {
+		return
+	}
+
+	history, err := h.usageService.History(r.Context(), service.UsageHistoryInput{
+		BucketID: bucketID,
+		UserID:   userID,
+		Prefix:   r.URL.Query().Get("prefix"),
+		Period:   r.URL.Query().Get("period"),
+		Days:     days,
+	})
+	if err != nil {
+		h.handleError(w, err, "Failed to get usage history")
+		return
+	}
+
+	points := make([]UsagePointDTO, len(history.Points))
+	for i, point := range history.Points {
+		points[i] = UsagePointDTO{
+			PeriodStart:   point.PeriodStart.Format("2006-01-02T15:04:05Z07:00"),
+			CapturedAt:    point.CapturedAt.Format("2006-01-02T15:04:05Z07:00"),
+			SizeBytes:     point.SizeBytes,
+			ObjectCount:   point.ObjectCount,
+			GrowthBytes:   point.GrowthBytes,
+			GrowthObjects: point.GrowthObjects,
+		}
+	}
+
+	h.respondJSON(w, map[string]interface{}{
+		"prefix":              history.Prefix,
+		"period":              history.Period,
+		"points":              points,
+		"growthBytesPerDay":   history.GrowthBytesPerDay,
+		"growthObjectsPerDay": history.GrowthObjectsPerDay,
+	}, http.StatusOK)
+}
+
+// Prefixes returns the bucket's largest top-level prefixes with their recent growth
+func (h *Handler) Prefixes(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+	days, ok := h.parseCount(w, r, "days")
+	if !ok {
+		return
+	}
+
+	prefixes, err := h.usageService.Prefixes(r.Context(), bucketID, userID, days)
+	if err != nil {
+		h.handleError(w, err, "Failed to get prefix usage")
+		return
+	}
+
+	dtos := make([]PrefixGrowthDTO, len(prefixes))
+	for i, prefix := range prefixes {
+		dtos[i] = PrefixGrowthDTO{
+			Prefix:        prefix.Prefix,
+			CapturedAt:    prefix.CapturedAt.Format("2006-01-02T15:04:05Z07:00"),
+			SizeBytes:     prefix.SizeBytes,
+			ObjectCount:   prefix.ObjectCount,
+			GrowthBytes:   prefix.GrowthBytes,
+			GrowthObjects: prefix.GrowthObjects,
+		}
+	}
+
+	h.respondJSON(w, map[string]interface{}{"prefixes": dtos}, http.StatusOK)
+}
+
+// Tree returns the disk usage breakdown of the bucket or a prefix. While a
+// large bucket is being scanned in the background it answers 202 Accepted,
+// with the previous result if there is one; clients poll until it is ready.
+func (h *Handler) Tree(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	depth, ok := h.parseCount(w, r, "depth")
+	if !ok {
+		return
+	}
+	top, ok := h.parseCount(w, r, "top")
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	refresh, _ := strconv.ParseBool(query.Get("refresh"))
+
+	report, err := h.usageService.Tree(r.Context(), service.UsageTreeInput{
+		BucketID: bucketID,
+		UserID:   userID,
+		Prefix:   query.Get("prefix"),
+		Depth:    depth,
+		Top:      top,
+		Refresh:  refresh,
+	})
+	if err != nil {
+		h.handleError(w, err, "Failed to get usage breakdown")
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      report.Status,
+		"requestedAt": report.RequestedAt.Format("2006-01-02T15:04:05Z07:00"),
+		"completedAt": nil,
+		"tree":        report.Tree,
+	}
+	if report.Error != "" {
+		response["error"] = report.Error
+	}
+	if report.CompletedAt != nil {
+		response["completedAt"] = report.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	status := http.StatusOK
+	if report.Status == repository.UsageReportPending || report.Status == repository.UsageReportRunning {
+		status = http.StatusAccepted
+	}
+	h.respondJSON(w, response, status)
+}
+
+func (h *Handler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	alert, err := h.usageService.GetAlert(r.Context(), bucketID, userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to get usage alert")
+		return
+	}
+
+	h.respondJSON(w, toAlertDTO(alert), http.StatusOK)
+}
+
+type SetAlertRequest struct {
+	// Either limit may be omitted or null, but not both
+	MaxDailyGrowthBytes   *int64 `json:"maxDailyGrowthBytes"`
+	MaxDailyGrowthObjects *int64 `json:"maxDailyGrowthObjects"`
+}
+
+// SetAlert creates or replaces the bucket's growth alert
+func (h *Handler) SetAlert(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	alert, err := h.usageService.SetAlert(r.Context(), service.SetUsageAlertInput{
+		BucketID:              bucketID,
+		UserID:                userID,
+		MaxDailyGrowthBytes:   req.MaxDailyGrowthBytes,
+		MaxDailyGrowthObjects: req.MaxDailyGrowthObjects,
+	})
+	if err != nil {
+		h.handleError(w, err, "Failed to set usage alert")
+		return
+	}
+
+	h.respondJSON(w, toAlertDTO(alert), http.StatusOK)
+}
+
+func (h *Handler) DeleteAlert(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.usageService.DeleteAlert(r.Context(), bucketID, userID); err != nil {
+		h.handleError(w, err, "Failed to delete usage alert")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) parseBucket(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, bucketID, true
+}
+
+// parseCount reads an optional positive query parameter; 0 means the default
+func (h *Handler) parseCount(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, true
+	}
+	count, err := strconv.Atoi(raw)
+	if err != nil || count < 1 {
+		h.respondError(w, "Invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return count, true
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidUsageQuery), errors.Is(err, service.ErrInvalidUsageAlert):
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrBucketNotFound):
+		h.respondError(w, "Bucket not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrUsageAlertNotFound):
+		h.respondError(w, "Usage alert not found", http.StatusNotFound)
+	default:
+		h.logger.Error("usage request failed", slog.String("message", message), slog.Any("error", err))
+		h.respondError(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
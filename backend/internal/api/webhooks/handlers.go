@@ -0,0 +1,358 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"bucketbird/backend/internal/middleware"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	webhookService *service.WebhookService
+	logger         *slog.Logger
+}
+
+func NewHandler(webhookService *service.WebhookService, logger *slog.Logger) *Handler {
+	return &Handler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+type WebhookDTO struct {
+	ID        string   `json:"id"`
+	BucketID  string   `json:"bucketId"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	KeyPrefix string   `json:"keyPrefix"`
+	KeySuffix string   `json:"keySuffix"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"createdAt"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+func toDTO(webhook *repository.Webhook) WebhookDTO {
+	dto := WebhookDTO{
+		ID:        webhook.ID.String(),
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		KeyPrefix: webhook.KeyPrefix,
+		KeySuffix: webhook.KeySuffix,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: webhook.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if webhook.BucketID != nil {
+		dto.BucketID = webhook.BucketID.String()
+	}
+	return dto
+}
+
+type DeliveryDTO struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhookId"`
+	EventID        string          `json:"eventId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *string         `json:"nextAttemptAt"`
+	LastAttemptAt  *string         `json:"lastAttemptAt"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	ResponseBody   string          `json:"responseBody,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	RedeliveryOf   *string         `json:"redeliveryOf"`
+	CreatedAt      string          `json:"createdAt"`
+	CompletedAt    *string         `json:"completedAt"`
+}
+
+func toDeliveryDTO(delivery *repository.WebhookDelivery) DeliveryDTO {
+	dto := DeliveryDTO{
+		ID:             delivery.ID.String(),
+		WebhookID:      delivery.WebhookID.String(),
+		EventID:        delivery.EventID.String(),
+		Event:          delivery.Event,
+		Payload:        json.RawMessage(delivery.Payload),
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		Error:          delivery.Error,
+		CreatedAt:      delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if delivery.Status == repository.WebhookDeliveryPending {
+		formatted := delivery.NextAttemptAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.NextAttemptAt = &formatted
+	}
+	if delivery.LastAttemptAt != nil {
+		formatted := delivery.LastAttemptAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.LastAttemptAt = &formatted
+	}
+	if delivery.RedeliveryOf != nil {
+		id := delivery.RedeliveryOf.String()
+		dto.RedeliveryOf = &id
+	}
+	if delivery.CompletedAt != nil {
+		formatted := delivery.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+		dto.CompletedAt = &formatted
+	}
+	return dto
+}
+
+type WebhookRequest struct {
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	KeyPrefix string   `json:"keyPrefix"`
+	KeySuffix string   `json:"keySuffix"`
+	// Active defaults to true when omitted on update
+	Active *bool `json:"active"`
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookService.List(r.Context(), bucketID, userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to list webhooks")
+		return
+	}
+
+	dtos := make([]WebhookDTO, len(webhooks))
+	for i, webhook := range webhooks {
+		dtos[i] = toDTO(webhook)
+	}
+
+	h.respondJSON(w, map[string]interface{}{"webhooks": dtos}, http.StatusOK)
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, secret, err := h.webhookService.Create(r.Context(), service.CreateWebhookInput{
+		BucketID:  bucketID,
+		UserID:    userID,
+		URL:       req.URL,
+		Events:    req.Events,
+		KeyPrefix: req.KeyPrefix,
+		KeySuffix: req.KeySuffix,
+	})
+	if err != nil {
+		h.handleError(w, err, "Failed to create webhook")
+		return
+	}
+
+	h.respondJSON(w, map[string]interface{}{
+		"webhook": toDTO(webhook),
+		"secret":  secret,
+	}, http.StatusCreated)
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, webhookID, ok := h.parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Get(r.Context(), webhookID, bucketID, userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to get webhook")
+		return
+	}
+
+	h.respondJSON(w, toDTO(webhook), http.StatusOK)
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, webhookID, ok := h.parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	webhook, err := h.webhookService.Update(r.Context(), service.UpdateWebhookInput{
+		ID:        webhookID,
+		BucketID:  bucketID,
+		UserID:    userID,
+		URL:       req.URL,
+		Events:    req.Events,
+		KeyPrefix: req.KeyPrefix,
+		KeySuffix: req.KeySuffix,
+		Active:    active,
+	})
+	if err != nil {
+		h.handleError(w, err, "Failed to update webhook")
+		return
+	}
+
+	h.respondJSON(w, toDTO(webhook), http.StatusOK)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, webhookID, ok := h.parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(r.Context(), webhookID, bucketID, userID); err != nil {
+		h.handleError(w, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Ping sends a test event and returns the logged delivery, whether or not the receiver accepted it
+func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, webhookID, ok := h.parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Ping(r.Context(), webhookID, bucketID, userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to ping webhook")
+		return
+	}
+
+	h.respondJSON(w, toDeliveryDTO(delivery), http.StatusOK)
+}
+
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, webhookID, ok := h.parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			h.respondError(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), webhookID, bucketID, userID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		h.handleError(w, err, "Failed to list deliveries")
+		return
+	}
+
+	dtos := make([]DeliveryDTO, len(deliveries))
+	for i, delivery := range deliveries {
+		dtos[i] = toDeliveryDTO(delivery)
+	}
+
+	h.respondJSON(w, map[string]interface{}{"deliveries": dtos}, http.StatusOK)
+}
+
+func (h *Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	userID, bucketID, webhookID, ok := h.parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		h.respondError(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), deliveryID, webhookID, bucketID, userID)
+	if err != nil {
+		h.handleError(w, err, "Failed to redeliver")
+		return
+	}
+
+	h.respondJSON(w, toDeliveryDTO(delivery), http.StatusAccepted)
+}
+
+func (h *Handler) parseBucket(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.respondError(w, "Unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, "Invalid bucket ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, bucketID, true
+}
+
+func (h *Handler) parseWebhook(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	userID, bucketID, ok := h.parseBucket(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		h.respondError(w, "Invalid webhook ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, bucketID, webhookID, true
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhook):
+		h.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrBucketNotFound):
+		h.respondError(w, "Bucket not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrWebhookNotFound):
+		h.respondError(w, "Webhook not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		h.respondError(w, "Delivery not found", http.StatusNotFound)
+	default:
+		h.logger.Error("webhook request failed", slog.String("message", message), slog.Any("error", err))
+		h.respondError(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, message string, status int) {
+	h.respondJSON(w, map[string]string{"error": message}, status)
+}
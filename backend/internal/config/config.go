@@ -1,33 +1,254 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type Config struct {
-	AppName           string
-	Env               string
-	HTTPPort          string
-	ReadTimeout       time.Duration
-	WriteTimeout      time.Duration
-	AllowedOrigins    []string
-	DBDSN             string
-	S3Endpoint        string
-	S3Region          string
-	S3AccessKey       string
-	S3SecretKey       string
-	S3UseSSL          bool
-	JWTSecret         string
-	EncryptionKey     []byte
-	AccessTokenTTL    time.Duration
-	RefreshTokenTTL   time.Duration
-	CookieSecure      bool
-	AllowRegistration bool
-	EnableDemoLogin   bool
+	AppName         string
+	Env             string
+	HTTPPort        string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	AllowedOrigins  []string
+	DBDSN           string
+	S3Endpoint      string
+	S3Region        string
+	S3AccessKey     string
+	S3SecretKey     string
+	S3UseSSL        bool
+	JWTSecret       string
+	EncryptionKey   []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	CookieSecure    bool
+	EnableDemoLogin bool
+
+	// SessionCacheTTL is how long session lookups for access token checks are
+	// cached; sessions revoked on another instance are honoured within it. 0 disables the cache.
+	SessionCacheTTL time.Duration
+
+	// EncryptionKeyID identifies EncryptionKey in ciphertexts
+	EncryptionKeyID string
+	// PreviousEncryptionKeys are decrypt-only keys kept until `bucketbird keys rotate` has run
+	PreviousEncryptionKeys map[string][]byte
+	// KeyProvider wraps per-record data keys: "local" uses the encryption keys
+	// above, "vault" uses Vault Transit and needs no local key
+	KeyProvider string
+	Vault       VaultConfig
+
+	// JWTAlgorithm is "HS256" (signed with JWTSecret), "EdDSA" or "RS256"
+	JWTAlgorithm string
+	// JWTPrivateKey is the PEM signing key for EdDSA and RS256
+	JWTPrivateKey string
+	// JWTKeyID overrides the kid derived from JWTPrivateKey
+	JWTKeyID string
+	// JWTVerificationKeys are extra public keys accepted during a key rollover
+	JWTVerificationKeys []JWTVerificationKey
+	JWTIssuer           string
+	JWTAudience         string
+
+	// RegistrationMode is "open", "closed" or "invite"
+	RegistrationMode string
+
+	// PasswordLoginEnabled controls whether users can sign in with email and password
+	PasswordLoginEnabled bool
+	// TOTPIssuer is the account issuer shown in authenticator apps
+	TOTPIssuer string
+	OIDC       OIDCConfig
+	LDAP       LDAPConfig
+	RateLimit  RateLimitConfig
+	Lockout    LockoutConfig
+	Email      EmailConfig
+
+	PasswordHashing PasswordHashingConfig
+	PasswordPolicy  PasswordPolicyConfig
+
+	// AuditRetention is how long audit events are kept; 0 keeps them forever
+	AuditRetention time.Duration
+
+	Webhook WebhookConfig
+	Usage   UsageConfig
+
+	// PricingFile is a YAML or JSON pricing table used for cost estimates
+	// instead of the built-in prices; it is reloaded when it changes
+	PricingFile string
+}
+
+// JWTVerificationKey is a PEM key read from BB_JWT_VERIFICATION_KEYS. An empty ID means the derived kid.
+type JWTVerificationKey struct {
+	ID  string
+	PEM []byte
+}
+
+// VaultConfig configures the HashiCorp Vault Transit key provider.
+type VaultConfig struct {
+	Address      string
+	Token        string
+	TransitMount string
+	TransitKey   string
+	// Namespace is only used by Vault Enterprise
+	Namespace string
+}
+
+// EmailConfig configures outbound email for address verification and password resets.
+type EmailConfig struct {
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	// SMTPTLS is "starttls", "tls" or "none"
+	SMTPTLS string
+	From    string
+	// PublicURL is the frontend base URL used for links in emails
+	PublicURL string
+	// RequireVerification blocks password logins until the email address is verified
+	RequireVerification bool
+	VerificationTTL     time.Duration
+	PasswordResetTTL    time.Duration
+}
+
+// Enabled reports whether an SMTP server is configured.
+func (c EmailConfig) Enabled() bool {
+	return c.SMTPHost != ""
+}
+
+// RateLimit allows Requests per Window. A zero limit disables throttling.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Enabled reports whether the limit throttles anything.
+func (l RateLimit) Enabled() bool {
+	return l.Requests > 0 && l.Window > 0
+}
+
+// RateLimitConfig configures request throttling for authentication and API routes.
+type RateLimitConfig struct {
+	// AuthPerIP limits login, registration and MFA attempts from a single IP address
+	AuthPerIP RateLimit
+	// AuthPerAccount limits login and registration attempts for a single email address
+	AuthPerAccount RateLimit
+	// APIPerUser is the default limit for authenticated API routes, per user
+	APIPerUser RateLimit
+	// APIGroups overrides APIPerUser for individual route groups such as "buckets"
+	APIGroups map[string]RateLimit
+}
+
+// ForGroup returns the per-user limit for an API route group.
+func (c RateLimitConfig) ForGroup(group string) RateLimit {
+	if limit, ok := c.APIGroups[group]; ok {
+		return limit
+	}
+	return c.APIPerUser
+}
+
+// LockoutConfig configures temporary account lockout after repeated failed logins.
+type LockoutConfig struct {
+	// Threshold is the number of consecutive failures before an account is locked; 0 disables lockout
+	Threshold int
+	// BaseDuration is the first lockout; each further failure doubles it up to MaxDuration
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+	// ResetAfter forgets failures once no attempt has failed for this long
+	ResetAfter time.Duration
+}
+
+// WebhookConfig configures delivery of outbound webhooks
+type WebhookConfig struct {
+	// MaxAttempts is how often a delivery is tried before it is marked failed
+	MaxAttempts int
+	Timeout     time.Duration
+	// PollInterval is how often the delivery queue is checked
+	PollInterval time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback, private and link-local addresses
+	AllowPrivateNetworks bool
+	// DeliveryRetention is how long finished deliveries are kept; 0 keeps them forever
+	DeliveryRetention time.Duration
+}
+
+// UsageConfig configures the bucket usage history
+type UsageConfig struct {
+	// SnapshotInterval is how often each bucket's usage is recorded; 0 disables the history
+	SnapshotInterval time.Duration
+	// HistoryRetention is how long usage snapshots are kept; 0 keeps them forever
+	HistoryRetention time.Duration
+}
+
+// PasswordHashingConfig sets the argon2id cost for new password hashes. Stored
+// hashes keep their own parameters and are upgraded on the user's next login.
+type PasswordHashingConfig struct {
+	Time int
+	// MemoryKiB is the memory cost in KiB
+	MemoryKiB int
+	Threads   int
+}
+
+// PasswordPolicyConfig sets the rules for new passwords
+type PasswordPolicyConfig struct {
+	MinLength int
+	// MaxLength is the longest password accepted; 0 means no limit
+	MaxLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	BannedWords      []string
+	// BreachFilter is the path of a breached password filter built with
+	// `bucketbird passwords build-filter`; empty disables the check
+	BreachFilter string
+}
+
+// OIDCConfig configures single sign-on through an OpenID Connect identity provider.
+type OIDCConfig struct {
+	IssuerURL            string
+	ClientID             string
+	ClientSecret         string
+	RedirectURL          string
+	PostLoginRedirectURL string
+	ProviderName         string
+	Scopes               []string
+	AdminClaim           string
+	AdminValues          []string
+	AutoCreateUsers      bool
+	LinkByEmail          bool
+}
+
+// Enabled reports whether OIDC login is configured.
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// LDAPConfig configures password authentication against an LDAP or Active Directory server.
+type LDAPConfig struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	UserDNTemplate     string
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string
+	EmailAttribute     string
+	FirstNameAttribute string
+	LastNameAttribute  string
+	GroupAttribute     string
+	GroupBaseDN        string
+	GroupFilter        string
+	AdminGroups        []string
+	LinkByEmail        bool
+}
+
+// Enabled reports whether LDAP login is configured.
+func (c LDAPConfig) Enabled() bool {
+	return c.URL != ""
 }
 
 const (
@@ -40,6 +261,32 @@ const (
 	defaultEncryptionKey   = "bucketbird-dev-key-32-bytes-long!!" // Must be exactly 32 bytes for AES-256
 	defaultAccessTokenTTL  = 15 * time.Minute
 	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+	defaultSessionCacheTTL = 10 * time.Second
+	defaultArgon2Time      = 1
+	defaultArgon2MemoryKiB = 64 * 1024
+	defaultArgon2Threads   = 4
+	defaultEncryptionKeyID = "k1"
+	defaultKeyProvider     = "local"
+	defaultJWTAlgorithm    = "HS256"
+	defaultJWTIssuer       = "bucketbird"
+	defaultJWTAudience     = "bucketbird-api"
+
+	defaultPasswordMinLength   = 8
+	defaultPasswordMaxLength   = 256
+	defaultPasswordBannedWords = "bucketbird"
+
+	defaultAuditRetention = 365 * 24 * time.Hour
+
+	defaultWebhookMaxAttempts       = 8
+	defaultWebhookTimeout           = 10 * time.Second
+	defaultWebhookPollInterval      = 5 * time.Second
+	defaultWebhookDeliveryRetention = 30 * 24 * time.Hour
+
+	defaultUsageSnapshotInterval = time.Hour
+	defaultUsageHistoryRetention = 90 * 24 * time.Hour
+
+	defaultVaultTransitMount = "transit"
+	defaultVaultTransitKey   = "bucketbird"
 
 	defaultDBHost     = "postgres"
 	defaultDBPort     = "5432"
@@ -52,28 +299,160 @@ const (
 	defaultS3AccessKey = "minioadmin"
 	defaultS3SecretKey = "minioadmin"
 	defaultS3UseSSL    = false
+
+	defaultTOTPIssuer = "BucketBird"
+
+	defaultRateLimitAuthPerIP      = "30/1m"
+	defaultRateLimitAuthPerAccount = "10/1m"
+	defaultRateLimitAPIPerUser     = "600/1m"
+
+	defaultSMTPPort              = 587
+	defaultSMTPTLS               = "starttls"
+	defaultEmailFrom             = "BucketBird <no-reply@localhost>"
+	defaultPublicURL             = "http://localhost:3000"
+	defaultEmailVerificationTTL  = 24 * time.Hour
+	defaultPasswordResetTokenTTL = time.Hour
+
+	defaultLockoutThreshold    = 5
+	defaultLockoutBaseDuration = time.Minute
+	defaultLockoutMaxDuration  = time.Hour
+	defaultLockoutResetAfter   = 24 * time.Hour
+
+	defaultOIDCScopes               = "openid,email,profile"
+	defaultOIDCProviderName         = "Single Sign-On"
+	defaultOIDCPostLoginRedirectURL = "/"
+
+	defaultLDAPUserFilter         = "(|(uid={username})(mail={username}))"
+	defaultLDAPEmailAttribute     = "mail"
+	defaultLDAPFirstNameAttribute = "givenName"
+	defaultLDAPLastNameAttribute  = "sn"
+	defaultLDAPGroupAttribute     = "memberOf"
+	defaultLDAPGroupFilter        = "(member={dn})"
 )
 
 func Load() Config {
-	encKey := getEnv("BB_ENCRYPTION_KEY", defaultEncryptionKey)
-	if len(encKey) != 32 {
-		panic("BB_ENCRYPTION_KEY must be exactly 32 bytes for AES-256")
-	}
+	keyProvider := strings.ToLower(getEnv("BB_KEY_PROVIDER", defaultKeyProvider))
 
 	cfg := Config{
-		AppName:           getEnv("BB_APP_NAME", defaultAppName),
-		Env:               getEnv("BB_ENV", defaultEnv),
-		HTTPPort:          getEnv("BB_HTTP_PORT", defaultHTTPPort),
-		ReadTimeout:       getDurationEnv("BB_HTTP_READ_TIMEOUT", defaultReadTimeout),
-		WriteTimeout:      getDurationEnv("BB_HTTP_WRITE_TIMEOUT", defaultWriteTimeout),
-		AllowedOrigins:    []string{"*"},
-		JWTSecret:         getEnv("BB_JWT_SECRET", defaultJWTSecret),
-		EncryptionKey:     []byte(encKey),
-		AccessTokenTTL:    getDurationEnv("BB_ACCESS_TOKEN_TTL", defaultAccessTokenTTL),
-		RefreshTokenTTL:   getDurationEnv("BB_REFRESH_TOKEN_TTL", defaultRefreshTokenTTL),
-		CookieSecure:      getBoolEnv("BB_COOKIE_SECURE", false),
-		AllowRegistration: getBoolEnv("BB_ALLOW_REGISTRATION", true),
-		EnableDemoLogin:   getBoolEnv("BB_ENABLE_DEMO_LOGIN", false),
+		AppName:         getEnv("BB_APP_NAME", defaultAppName),
+		Env:             getEnv("BB_ENV", defaultEnv),
+		HTTPPort:        getEnv("BB_HTTP_PORT", defaultHTTPPort),
+		ReadTimeout:     getDurationEnv("BB_HTTP_READ_TIMEOUT", defaultReadTimeout),
+		WriteTimeout:    getDurationEnv("BB_HTTP_WRITE_TIMEOUT", defaultWriteTimeout),
+		AllowedOrigins:  []string{"*"},
+		JWTSecret:       getEnv("BB_JWT_SECRET", defaultJWTSecret),
+		EncryptionKey:   getEncryptionKey(keyProvider),
+		AccessTokenTTL:  getDurationEnv("BB_ACCESS_TOKEN_TTL", defaultAccessTokenTTL),
+		RefreshTokenTTL: getDurationEnv("BB_REFRESH_TOKEN_TTL", defaultRefreshTokenTTL),
+		SessionCacheTTL: getDurationEnv("BB_SESSION_CACHE_TTL", defaultSessionCacheTTL),
+		CookieSecure:    getBoolEnv("BB_COOKIE_SECURE", false),
+		EnableDemoLogin: getBoolEnv("BB_ENABLE_DEMO_LOGIN", false),
+
+		EncryptionKeyID:        strings.TrimSpace(getEnv("BB_ENCRYPTION_KEY_ID", defaultEncryptionKeyID)),
+		PreviousEncryptionKeys: parseEncryptionKeys("BB_ENCRYPTION_PREVIOUS_KEYS", os.Getenv("BB_ENCRYPTION_PREVIOUS_KEYS")),
+		KeyProvider:            keyProvider,
+		Vault: VaultConfig{
+			Address:      strings.TrimRight(strings.TrimSpace(os.Getenv("BB_VAULT_ADDR")), "/"),
+			Token:        getSecretEnv("BB_VAULT_TOKEN"),
+			TransitMount: getEnv("BB_VAULT_TRANSIT_MOUNT", defaultVaultTransitMount),
+			TransitKey:   getEnv("BB_VAULT_TRANSIT_KEY", defaultVaultTransitKey),
+			Namespace:    strings.TrimSpace(os.Getenv("BB_VAULT_NAMESPACE")),
+		},
+
+		JWTAlgorithm:        normalizeJWTAlgorithm(getEnv("BB_JWT_ALGORITHM", defaultJWTAlgorithm)),
+		JWTPrivateKey:       getSecretEnv("BB_JWT_PRIVATE_KEY"),
+		JWTKeyID:            strings.TrimSpace(os.Getenv("BB_JWT_KEY_ID")),
+		JWTVerificationKeys: parseJWTVerificationKeys("BB_JWT_VERIFICATION_KEYS", os.Getenv("BB_JWT_VERIFICATION_KEYS")),
+		JWTIssuer:           getEnv("BB_JWT_ISSUER", defaultJWTIssuer),
+		JWTAudience:         getEnv("BB_JWT_AUDIENCE", defaultJWTAudience),
+
+		RegistrationMode: getRegistrationMode(),
+
+		PasswordLoginEnabled: getBoolEnv("BB_PASSWORD_LOGIN_ENABLED", true),
+		TOTPIssuer:           getEnv("BB_TOTP_ISSUER", defaultTOTPIssuer),
+		OIDC: OIDCConfig{
+			IssuerURL:            strings.TrimRight(strings.TrimSpace(os.Getenv("BB_OIDC_ISSUER_URL")), "/"),
+			ClientID:             strings.TrimSpace(os.Getenv("BB_OIDC_CLIENT_ID")),
+			ClientSecret:         os.Getenv("BB_OIDC_CLIENT_SECRET"),
+			RedirectURL:          strings.TrimSpace(os.Getenv("BB_OIDC_REDIRECT_URL")),
+			PostLoginRedirectURL: getEnv("BB_OIDC_POST_LOGIN_REDIRECT_URL", defaultOIDCPostLoginRedirectURL),
+			ProviderName:         getEnv("BB_OIDC_PROVIDER_NAME", defaultOIDCProviderName),
+			Scopes:               splitList(getEnv("BB_OIDC_SCOPES", defaultOIDCScopes)),
+			AdminClaim:           strings.TrimSpace(os.Getenv("BB_OIDC_ADMIN_CLAIM")),
+			AdminValues:          splitList(os.Getenv("BB_OIDC_ADMIN_VALUES")),
+			AutoCreateUsers:      getBoolEnv("BB_OIDC_AUTO_CREATE_USERS", true),
+			LinkByEmail:          getBoolEnv("BB_OIDC_LINK_BY_EMAIL", true),
+		},
+		LDAP: LDAPConfig{
+			URL:                strings.TrimSpace(os.Getenv("BB_LDAP_URL")),
+			StartTLS:           getBoolEnv("BB_LDAP_START_TLS", false),
+			InsecureSkipVerify: getBoolEnv("BB_LDAP_INSECURE_SKIP_VERIFY", false),
+			UserDNTemplate:     strings.TrimSpace(os.Getenv("BB_LDAP_USER_DN_TEMPLATE")),
+			BindDN:             strings.TrimSpace(os.Getenv("BB_LDAP_BIND_DN")),
+			BindPassword:       os.Getenv("BB_LDAP_BIND_PASSWORD"),
+			BaseDN:             strings.TrimSpace(os.Getenv("BB_LDAP_BASE_DN")),
+			UserFilter:         getEnv("BB_LDAP_USER_FILTER", defaultLDAPUserFilter),
+			EmailAttribute:     getEnv("BB_LDAP_EMAIL_ATTRIBUTE", defaultLDAPEmailAttribute),
+			FirstNameAttribute: getEnv("BB_LDAP_FIRST_NAME_ATTRIBUTE", defaultLDAPFirstNameAttribute),
+			LastNameAttribute:  getEnv("BB_LDAP_LAST_NAME_ATTRIBUTE", defaultLDAPLastNameAttribute),
+			GroupAttribute:     getEnv("BB_LDAP_GROUP_ATTRIBUTE", defaultLDAPGroupAttribute),
+			GroupBaseDN:        strings.TrimSpace(os.Getenv("BB_LDAP_GROUP_BASE_DN")),
+			GroupFilter:        getEnv("BB_LDAP_GROUP_FILTER", defaultLDAPGroupFilter),
+			AdminGroups:        splitList(os.Getenv("BB_LDAP_ADMIN_GROUPS")),
+			LinkByEmail:        getBoolEnv("BB_LDAP_LINK_BY_EMAIL", false),
+		},
+		RateLimit: RateLimitConfig{
+			AuthPerIP:      getRateLimitEnv("BB_RATE_LIMIT_AUTH_IP", defaultRateLimitAuthPerIP),
+			AuthPerAccount: getRateLimitEnv("BB_RATE_LIMIT_AUTH_ACCOUNT", defaultRateLimitAuthPerAccount),
+			APIPerUser:     getRateLimitEnv("BB_RATE_LIMIT_API", defaultRateLimitAPIPerUser),
+			APIGroups:      parseRateLimitGroups("BB_RATE_LIMIT_API_GROUPS", os.Getenv("BB_RATE_LIMIT_API_GROUPS")),
+		},
+		PasswordHashing: PasswordHashingConfig{
+			Time:      getIntEnv("BB_ARGON2_TIME", defaultArgon2Time),
+			MemoryKiB: getIntEnv("BB_ARGON2_MEMORY", defaultArgon2MemoryKiB),
+			Threads:   getIntEnv("BB_ARGON2_THREADS", defaultArgon2Threads),
+		},
+		PasswordPolicy: PasswordPolicyConfig{
+			MinLength:        getIntEnv("BB_PASSWORD_MIN_LENGTH", defaultPasswordMinLength),
+			MaxLength:        getIntEnv("BB_PASSWORD_MAX_LENGTH", defaultPasswordMaxLength),
+			RequireUppercase: getBoolEnv("BB_PASSWORD_REQUIRE_UPPERCASE", false),
+			RequireLowercase: getBoolEnv("BB_PASSWORD_REQUIRE_LOWERCASE", false),
+			RequireDigit:     getBoolEnv("BB_PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:    getBoolEnv("BB_PASSWORD_REQUIRE_SYMBOL", false),
+			BannedWords:      splitList(getEnv("BB_PASSWORD_BANNED_WORDS", defaultPasswordBannedWords)),
+			BreachFilter:     strings.TrimSpace(os.Getenv("BB_PASSWORD_BREACH_FILTER")),
+		},
+		AuditRetention: getDurationEnv("BB_AUDIT_RETENTION", defaultAuditRetention),
+		Webhook: WebhookConfig{
+			MaxAttempts:          getIntEnv("BB_WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts),
+			Timeout:              getDurationEnv("BB_WEBHOOK_TIMEOUT", defaultWebhookTimeout),
+			PollInterval:         getDurationEnv("BB_WEBHOOK_POLL_INTERVAL", defaultWebhookPollInterval),
+			AllowPrivateNetworks: getBoolEnv("BB_WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+			DeliveryRetention:    getDurationEnv("BB_WEBHOOK_DELIVERY_RETENTION", defaultWebhookDeliveryRetention),
+		},
+		Usage: UsageConfig{
+			SnapshotInterval: getDurationEnv("BB_USAGE_SNAPSHOT_INTERVAL", defaultUsageSnapshotInterval),
+			HistoryRetention: getDurationEnv("BB_USAGE_HISTORY_RETENTION", defaultUsageHistoryRetention),
+		},
+		PricingFile: strings.TrimSpace(os.Getenv("BB_PRICING_FILE")),
+		Lockout: LockoutConfig{
+			Threshold:    getIntEnv("BB_LOGIN_LOCKOUT_THRESHOLD", defaultLockoutThreshold),
+			BaseDuration: getDurationEnv("BB_LOGIN_LOCKOUT_DURATION", defaultLockoutBaseDuration),
+			MaxDuration:  getDurationEnv("BB_LOGIN_LOCKOUT_MAX_DURATION", defaultLockoutMaxDuration),
+			ResetAfter:   getDurationEnv("BB_LOGIN_LOCKOUT_RESET_AFTER", defaultLockoutResetAfter),
+		},
+		Email: EmailConfig{
+			SMTPHost:            strings.TrimSpace(os.Getenv("BB_SMTP_HOST")),
+			SMTPPort:            getIntEnv("BB_SMTP_PORT", defaultSMTPPort),
+			SMTPUsername:        os.Getenv("BB_SMTP_USERNAME"),
+			SMTPPassword:        os.Getenv("BB_SMTP_PASSWORD"),
+			SMTPTLS:             strings.ToLower(getEnv("BB_SMTP_TLS", defaultSMTPTLS)),
+			From:                getEnv("BB_EMAIL_FROM", defaultEmailFrom),
+			PublicURL:           strings.TrimRight(getEnv("BB_PUBLIC_URL", defaultPublicURL), "/"),
+			RequireVerification: getBoolEnv("BB_REQUIRE_EMAIL_VERIFICATION", false),
+			VerificationTTL:     getDurationEnv("BB_EMAIL_VERIFICATION_TTL", defaultEmailVerificationTTL),
+			PasswordResetTTL:    getDurationEnv("BB_PASSWORD_RESET_TTL", defaultPasswordResetTokenTTL),
+		},
 	}
 
 	if origins := strings.TrimSpace(os.Getenv("BB_ALLOWED_ORIGINS")); origins != "" {
@@ -122,6 +501,184 @@ func getDurationEnv(key string, fallback time.Duration) time.Duration {
 	return fallback
 }
 
+func getIntEnv(key string, fallback int) int {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// getRateLimitEnv reads a limit written as "<requests>/<window>", e.g. "20/1m".
+// "off" or "0" disables the limit.
+func getRateLimitEnv(key, fallback string) RateLimit {
+	limit, err := parseRateLimit(getEnv(key, fallback))
+	if err != nil {
+		panic(fmt.Sprintf("%s: %v", key, err))
+	}
+	return limit
+}
+
+// parseRateLimitGroups reads comma separated "<group>=<requests>/<window>" entries.
+func parseRateLimitGroups(key, value string) map[string]RateLimit {
+	groups := make(map[string]RateLimit)
+	for _, entry := range splitList(value) {
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			panic(fmt.Sprintf("%s: expected <group>=<requests>/<window>, got %q", key, entry))
+		}
+		limit, err := parseRateLimit(spec)
+		if err != nil {
+			panic(fmt.Sprintf("%s: group %s: %v", key, strings.TrimSpace(name), err))
+		}
+		groups[strings.ToLower(strings.TrimSpace(name))] = limit
+	}
+	return groups
+}
+
+// parseEncryptionKeys parses "<id>=<key>" entries. Keys are 32 raw bytes, or
+// "base64:<encoded>" for keys that contain separators.
+func parseEncryptionKeys(key, value string) map[string][]byte {
+	keys := make(map[string][]byte)
+	for _, entry := range splitList(value) {
+		id, raw, ok := strings.Cut(entry, "=")
+		if !ok {
+			panic(fmt.Sprintf("%s: expected <id>=<key>, got an entry without '='", key))
+		}
+		id = strings.TrimSpace(id)
+		secret, err := decodeEncryptionKey(raw)
+		if err != nil {
+			panic(fmt.Sprintf("%s: key %s is not valid base64", key, id))
+		}
+		if len(secret) != 32 {
+			panic(fmt.Sprintf("%s: key %s must be exactly 32 bytes for AES-256", key, id))
+		}
+		if _, dup := keys[id]; dup {
+			panic(fmt.Sprintf("%s: key %s is listed twice", key, id))
+		}
+		keys[id] = secret
+	}
+	return keys
+}
+
+// decodeEncryptionKey accepts a raw key or "base64:<encoded>".
+func decodeEncryptionKey(raw string) ([]byte, error) {
+	if encoded, isBase64 := strings.CutPrefix(raw, "base64:"); isBase64 {
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+	return []byte(raw), nil
+}
+
+// getEncryptionKey reads the active key from the file named by
+// BB_ENCRYPTION_KEY_FILE, or from BB_ENCRYPTION_KEY. Only the local key
+// provider needs one; with Vault it just decrypts values from before the switch.
+func getEncryptionKey(keyProvider string) []byte {
+	if path := strings.TrimSpace(os.Getenv("BB_ENCRYPTION_KEY_FILE")); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			panic(fmt.Sprintf("BB_ENCRYPTION_KEY_FILE: %v", err))
+		}
+		key, err := decodeEncryptionKey(strings.TrimRight(string(data), "\r\n"))
+		if err != nil {
+			panic("BB_ENCRYPTION_KEY_FILE: key is not valid base64")
+		}
+		return key
+	}
+	if keyProvider == "local" {
+		return []byte(getEnv("BB_ENCRYPTION_KEY", defaultEncryptionKey))
+	}
+	if value := os.Getenv("BB_ENCRYPTION_KEY"); value != "" {
+		return []byte(value)
+	}
+	return nil
+}
+
+// getSecretEnv reads key, or the contents of the file named by key+"_FILE".
+func getSecretEnv(key string) string {
+	if path := strings.TrimSpace(os.Getenv(key + "_FILE")); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			panic(fmt.Sprintf("%s_FILE: %v", key, err))
+		}
+		return strings.TrimSpace(string(data))
+	}
+	return os.Getenv(key)
+}
+
+// normalizeJWTAlgorithm accepts algorithm names in any case.
+func normalizeJWTAlgorithm(value string) string {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "HS256":
+		return "HS256"
+	case "RS256":
+		return "RS256"
+	case "EDDSA":
+		return "EdDSA"
+	default:
+		return value
+	}
+}
+
+// parseJWTVerificationKeys parses a comma-separated list of PEM file paths,
+// each optionally prefixed with "<kid>=".
+func parseJWTVerificationKeys(key, value string) []JWTVerificationKey {
+	var keys []JWTVerificationKey
+	for _, entry := range splitList(value) {
+		id, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			id, path = "", entry
+		}
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			panic(fmt.Sprintf("%s: %v", key, err))
+		}
+		keys = append(keys, JWTVerificationKey{ID: strings.TrimSpace(id), PEM: data})
+	}
+	return keys
+}
+
+// HasJWTSecret reports whether BB_JWT_SECRET was set to a non-default value.
+func (cfg Config) HasJWTSecret() bool {
+	return cfg.JWTSecret != "" && cfg.JWTSecret != defaultJWTSecret
+}
+
+// HasLocalKey reports whether a local encryption key is configured.
+func (cfg Config) HasLocalKey() bool {
+	return len(cfg.EncryptionKey) > 0
+}
+
+// EncryptionKeys returns the active and previous encryption keys by ID.
+func (cfg Config) EncryptionKeys() map[string][]byte {
+	keys := make(map[string][]byte, len(cfg.PreviousEncryptionKeys)+1)
+	for id, key := range cfg.PreviousEncryptionKeys {
+		keys[id] = key
+	}
+	keys[cfg.EncryptionKeyID] = cfg.EncryptionKey
+	return keys
+}
+
+func parseRateLimit(value string) (RateLimit, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "off" || value == "0" {
+		return RateLimit{}, nil
+	}
+
+	requests, window, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("expected <requests>/<window>, got %q", value)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 0 {
+		return RateLimit{}, fmt.Errorf("invalid request count %q", requests)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid window %q", window)
+	}
+	return RateLimit{Requests: n, Window: d}, nil
+}
+
 func getBoolEnv(key string, fallback bool) bool {
 	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
 	switch value {
@@ -148,6 +705,17 @@ func splitAndTrim(value string) []string {
 	return cleaned
 }
 
+// splitList splits a comma separated value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, part := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
 func containsWildcardOrigin(origins []string) bool {
 	for _, origin := range origins {
 		if origin == "*" {
@@ -163,19 +731,89 @@ func (cfg Config) HasWildcardOrigin() bool {
 }
 
 func validateSecurity(cfg *Config) {
-	if len(cfg.JWTSecret) < 32 {
-		panic("BB_JWT_SECRET must be set to a string with at least 32 characters")
+	if cfg.PasswordPolicy.MinLength < 8 {
+		panic("BB_PASSWORD_MIN_LENGTH must be at least 8")
 	}
-	if cfg.JWTSecret == defaultJWTSecret {
-		panic("BB_JWT_SECRET defaults to an insecure value; please override it in the environment")
+	if cfg.PasswordPolicy.MaxLength != 0 && cfg.PasswordPolicy.MaxLength < cfg.PasswordPolicy.MinLength {
+		panic("BB_PASSWORD_MAX_LENGTH must be 0 or at least BB_PASSWORD_MIN_LENGTH")
+	}
+	switch cfg.JWTAlgorithm {
+	case "HS256":
+		if len(cfg.JWTSecret) < 32 {
+			panic("BB_JWT_SECRET must be set to a string with at least 32 characters")
+		}
+		if cfg.JWTSecret == defaultJWTSecret {
+			panic("BB_JWT_SECRET defaults to an insecure value; please override it in the environment")
+		}
+	case "EdDSA", "RS256":
+		if strings.TrimSpace(cfg.JWTPrivateKey) == "" {
+			panic("BB_JWT_PRIVATE_KEY or BB_JWT_PRIVATE_KEY_FILE must be set when BB_JWT_ALGORITHM is " + cfg.JWTAlgorithm)
+		}
+		// A leftover secret keeps verifying HS256 tokens issued before the switch
+		if cfg.HasJWTSecret() && len(cfg.JWTSecret) < 32 {
+			panic("BB_JWT_SECRET must be set to a string with at least 32 characters")
+		}
+	default:
+		panic("BB_JWT_ALGORITHM must be one of HS256, EdDSA or RS256")
 	}
-	if len(cfg.EncryptionKey) != 32 {
-		panic("BB_ENCRYPTION_KEY must be exactly 32 bytes for AES-256")
+	switch cfg.KeyProvider {
+	case "local":
+	case "vault":
+		if cfg.Vault.Address == "" || cfg.Vault.Token == "" {
+			panic("BB_VAULT_ADDR and BB_VAULT_TOKEN must be set when BB_KEY_PROVIDER=vault")
+		}
+		if !cfg.HasLocalKey() && len(cfg.PreviousEncryptionKeys) > 0 {
+			panic("BB_ENCRYPTION_PREVIOUS_KEYS requires BB_ENCRYPTION_KEY to be set")
+		}
+	default:
+		panic("BB_KEY_PROVIDER must be local or vault")
 	}
-	if string(cfg.EncryptionKey) == defaultEncryptionKey {
-		panic("BB_ENCRYPTION_KEY defaults to an insecure value; please override it in the environment")
+	if cfg.KeyProvider == "local" || cfg.HasLocalKey() {
+		if len(cfg.EncryptionKey) != 32 {
+			panic("BB_ENCRYPTION_KEY must be exactly 32 bytes for AES-256")
+		}
+		if string(cfg.EncryptionKey) == defaultEncryptionKey {
+			panic("BB_ENCRYPTION_KEY defaults to an insecure value; please override it in the environment")
+		}
+		if _, ok := cfg.PreviousEncryptionKeys[cfg.EncryptionKeyID]; ok {
+			panic("BB_ENCRYPTION_PREVIOUS_KEYS must not reuse BB_ENCRYPTION_KEY_ID")
+		}
 	}
 	if containsWildcardOrigin(cfg.AllowedOrigins) && strings.EqualFold(cfg.Env, "production") {
 		panic("BB_ALLOWED_ORIGINS cannot contain '*' when BB_ENV=production")
 	}
+	if cfg.OIDC.Enabled() {
+		if cfg.OIDC.ClientID == "" {
+			panic("BB_OIDC_CLIENT_ID must be set when BB_OIDC_ISSUER_URL is configured")
+		}
+		if cfg.OIDC.RedirectURL == "" {
+			panic("BB_OIDC_REDIRECT_URL must be set when BB_OIDC_ISSUER_URL is configured")
+		}
+	}
+	if cfg.LDAP.Enabled() && cfg.LDAP.UserDNTemplate == "" && cfg.LDAP.BaseDN == "" {
+		panic("BB_LDAP_BASE_DN or BB_LDAP_USER_DN_TEMPLATE must be set when BB_LDAP_URL is configured")
+	}
+	if !cfg.PasswordLoginEnabled && !cfg.OIDC.Enabled() && !cfg.LDAP.Enabled() {
+		panic("BB_PASSWORD_LOGIN_ENABLED=false requires single sign-on or LDAP to be configured")
+	}
+	if cfg.Email.RequireVerification && !cfg.Email.Enabled() {
+		panic("BB_REQUIRE_EMAIL_VERIFICATION=true requires BB_SMTP_HOST to be configured")
+	}
+	switch cfg.RegistrationMode {
+	case "open", "closed", "invite":
+	default:
+		panic("BB_REGISTRATION_MODE must be one of open, closed or invite")
+	}
+}
+
+// getRegistrationMode reads BB_REGISTRATION_MODE, falling back to the older
+// BB_ALLOW_REGISTRATION switch when it is not set
+func getRegistrationMode() string {
+	if mode := strings.TrimSpace(os.Getenv("BB_REGISTRATION_MODE")); mode != "" {
+		return strings.ToLower(mode)
+	}
+	if getBoolEnv("BB_ALLOW_REGISTRATION", true) {
+		return "open"
+	}
+	return "closed"
 }
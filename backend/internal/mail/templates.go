@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template names
+const (
+	TemplateVerifyEmail   = "verify_email"
+	TemplateEmailChange   = "email_change"
+	TemplatePasswordReset = "password_reset"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Templates renders messages from the embedded templates. Each message has a
+// <name>.txt.tmpl template defining "subject" and "body", and an optional
+// <name>.html.tmpl template for the HTML part.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func LoadTemplates() (*Templates, error) {
+	t := &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	files, err := fs.Glob(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		base := path.Base(file)
+		switch {
+		case strings.HasSuffix(base, ".txt.tmpl"):
+			tmpl, err := texttemplate.ParseFS(templateFS, file)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", base, err)
+			}
+			t.text[strings.TrimSuffix(base, ".txt.tmpl")] = tmpl
+		case strings.HasSuffix(base, ".html.tmpl"):
+			tmpl, err := htmltemplate.ParseFS(templateFS, file)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", base, err)
+			}
+			t.html[strings.TrimSuffix(base, ".html.tmpl")] = tmpl
+		}
+	}
+	return t, nil
+}
+
+// Render builds a message for the recipient from the named template
+func (t *Templates) Render(name, to string, data any) (Message, error) {
+	tmpl, ok := t.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown mail template %q", name)
+	}
+
+	var subject, text bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("render %s subject: %w", name, err)
+	}
+	if err := tmpl.ExecuteTemplate(&text, "body", data); err != nil {
+		return Message{}, fmt.Errorf("render %s body: %w", name, err)
+	}
+
+	msg := Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}
+
+	if html, ok := t.html[name]; ok {
+		var body bytes.Buffer
+		if err := html.Execute(&body, data); err != nil {
+			return Message{}, fmt.Errorf("render %s html: %w", name, err)
+		}
+		msg.HTML = body.String()
+	}
+
+	return msg, nil
+}
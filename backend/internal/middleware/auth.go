@@ -8,15 +8,20 @@ import (
 	"bucketbird/backend/internal/repository"
 	"bucketbird/backend/internal/service"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 type contextKey string
 
-const UserContextKey contextKey = "user"
+const (
+	UserContextKey                contextKey = "user"
+	PersonalAccessTokenContextKey contextKey = "personal_access_token"
+	SessionIDContextKey           contextKey = "session_id"
+)
 
-// Auth middleware extracts and validates JWT token, adds user to context
-func Auth(authService *service.AuthService) func(http.Handler) http.Handler {
+// Auth middleware extracts and validates the bearer token (JWT or personal access token), adds user to context
+func Auth(authService *service.AuthService, tokenService *service.PersonalAccessTokenService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract token from Authorization header
@@ -42,16 +47,34 @@ func Auth(authService *service.AuthService) func(http.Handler) http.Handler {
 
 			token := parts[1]
 
+			// Personal access tokens are opaque and looked up by hash
+			if service.IsPersonalAccessToken(token) {
+				user, pat, err := tokenService.Validate(r.Context(), token)
+				if err != nil {
+					http.Error(w, `{"error":"Invalid token"}`, http.StatusUnauthorized)
+					w.Header().Set("Content-Type", "application/json")
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserContextKey, user)
+				ctx = context.WithValue(ctx, PersonalAccessTokenContextKey, pat)
+				ctx = service.WithAuditActor(ctx, service.AuditActor{UserID: user.ID, Email: user.Email, TokenID: &pat.ID})
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			// Validate token
-			user, err := authService.ValidateAccessToken(r.Context(), token)
+			user, sessionID, err := authService.ValidateAccessToken(r.Context(), token)
 			if err != nil {
 				http.Error(w, `{"error":"Invalid token"}`, http.StatusUnauthorized)
 				w.Header().Set("Content-Type", "application/json")
 				return
 			}
 
-			// Add user to context
+			// Add user and session to context
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			ctx = context.WithValue(ctx, SessionIDContextKey, sessionID)
+			ctx = service.WithAuditActor(ctx, service.AuditActor{UserID: user.ID, Email: user.Email})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -72,6 +95,19 @@ func GetUserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	return user.ID, true
 }
 
+// GetPersonalAccessTokenFromContext returns the personal access token used to authenticate the request, if any
+func GetPersonalAccessTokenFromContext(ctx context.Context) (*repository.PersonalAccessToken, bool) {
+	token, ok := ctx.Value(PersonalAccessTokenContextKey).(*repository.PersonalAccessToken)
+	return token, ok
+}
+
+// GetSessionIDFromContext returns the session behind the request's access token.
+// It is not set for requests authenticated with a personal access token.
+func GetSessionIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	sessionID, ok := ctx.Value(SessionIDContextKey).(uuid.UUID)
+	return sessionID, ok
+}
+
 // DemoReadOnly middleware blocks write operations for demo users
 func DemoReadOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -87,3 +123,98 @@ func DemoReadOnly(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// PersonalAccessTokenReadOnly middleware blocks write operations for read-only personal access tokens
+func PersonalAccessTokenReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := GetPersonalAccessTokenFromContext(r.Context())
+		if ok && service.TokenIsReadOnly(token) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+				w.Header().Set("Content-Type", "application/json")
+				http.Error(w, `{"error":"This token only has read access"}`, http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireBucketScope middleware blocks bucket-scoped personal access tokens from buckets outside their scope.
+// It must be mounted on a route with an {id} bucket parameter.
+func RequireBucketScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := GetPersonalAccessTokenFromContext(r.Context())
+		if ok && service.TokenIsBucketScoped(token) {
+			bucketID, err := uuid.Parse(chi.URLParam(r, "id"))
+			if err != nil || !service.TokenAllowsBucket(token, bucketID) {
+				w.Header().Set("Content-Type", "application/json")
+				http.Error(w, `{"error":"This token does not have access to this bucket"}`, http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RejectBucketScopedTokens middleware blocks bucket-scoped personal access tokens from routes
+// that are not tied to a single bucket (credentials, bucket creation, ...)
+func RejectBucketScopedTokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := GetPersonalAccessTokenFromContext(r.Context())
+		if ok && service.TokenIsBucketScoped(token) {
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, `{"error":"This token is restricted to specific buckets"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RejectPersonalAccessTokens middleware restricts a route to interactive (JWT) sessions
+func RejectPersonalAccessTokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GetPersonalAccessTokenFromContext(r.Context()); ok {
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, `{"error":"This action is not available with a personal access token"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin middleware restricts a route to administrators
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := GetUserFromContext(r.Context())
+		if !ok || !user.IsAdmin {
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, `{"error":"Administrator privileges required"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireTwoFactorEnrollment middleware blocks users who must enroll in two-factor
+// authentication before using the API. Routes needed for enrollment must not use it.
+func RequireTwoFactorEnrollment(twoFactorService *service.TwoFactorService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r.Context())
+			if ok {
+				required, err := twoFactorService.EnrollmentRequired(r.Context(), user)
+				if err != nil {
+					w.Header().Set("Content-Type", "application/json")
+					http.Error(w, `{"error":"Internal server error"}`, http.StatusInternalServerError)
+					return
+				}
+				if required {
+					w.Header().Set("Content-Type", "application/json")
+					http.Error(w, `{"error":"Two-factor authentication must be enabled for this account","code":"two_factor_enrollment_required"}`, http.StatusForbidden)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
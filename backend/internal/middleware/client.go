@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"bucketbird/backend/internal/service"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// User agents are stored with sessions; cap their length
+const maxUserAgentLength = 512
+
+// ClientInfo middleware records the client IP and user agent in the request context.
+// It must run after chi's RequestID and RealIP middleware so proxied requests report the original client.
+func ClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+
+		userAgent := r.UserAgent()
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+
+		ctx := service.WithClientInfo(r.Context(), service.ClientInfo{
+			IPAddress: ip,
+			UserAgent: userAgent,
+			RequestID: chimiddleware.GetReqID(r.Context()),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
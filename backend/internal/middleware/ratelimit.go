@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"bucketbird/backend/internal/config"
+
+	"github.com/go-chi/httprate"
+)
+
+// Login and registration bodies are small; larger bodies are not inspected
+const maxRateLimitBodySize = 64 << 10
+
+// RateLimitByIP limits requests per client IP. It relies on chi's RealIP middleware
+// for requests that pass through a reverse proxy.
+func RateLimitByIP(limit config.RateLimit) func(http.Handler) http.Handler {
+	return rateLimit(limit, httprate.KeyByIP)
+}
+
+// RateLimitByAccount limits requests per email address found in the JSON request body,
+// so that one account cannot be attacked from many addresses. Requests without an
+// email address are not limited.
+func RateLimitByAccount(limit config.RateLimit) func(http.Handler) http.Handler {
+	if !limit.Enabled() {
+		return passthrough
+	}
+	limiter := newRateLimiter(limit)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			email := emailFromBody(r)
+			if email != "" && limiter.RespondOnLimit(w, r, "account:"+email) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitByUser limits requests per authenticated user and must run after Auth.
+// Each call creates an independent limiter, so every route group gets its own budget.
+func RateLimitByUser(limit config.RateLimit) func(http.Handler) http.Handler {
+	return rateLimit(limit, func(r *http.Request) (string, error) {
+		if userID, ok := GetUserIDFromContext(r.Context()); ok {
+			return "user:" + userID.String(), nil
+		}
+		return httprate.KeyByIP(r)
+	})
+}
+
+func rateLimit(limit config.RateLimit, keyFunc httprate.KeyFunc) func(http.Handler) http.Handler {
+	if !limit.Enabled() {
+		return passthrough
+	}
+	return newRateLimiter(limit, httprate.WithKeyFuncs(keyFunc)).Handler
+}
+
+func newRateLimiter(limit config.RateLimit, options ...httprate.Option) *httprate.RateLimiter {
+	options = append(options, httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+		// httprate has already set Retry-After
+		w.Header().Set("Content-Type", "application/json")
+		http.Error(w, `{"error":"Too many requests, please try again later"}`, http.StatusTooManyRequests)
+	}))
+	return httprate.NewRateLimiter(limit.Requests, limit.Window, options...)
+}
+
+func passthrough(next http.Handler) http.Handler {
+	return next
+}
+
+// emailFromBody reads the email field of a JSON body and restores the body for the handler
+func emailFromBody(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	original := r.Body
+	body, err := io.ReadAll(io.LimitReader(original, maxRateLimitBodySize+1))
+	// Hand the handler everything, including any part not read here
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), original), original}
+	if err != nil || len(body) > maxRateLimitBodySize {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ToLower(payload.Email))
+}
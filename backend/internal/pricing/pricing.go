@@ -0,0 +1,224 @@
+// Package pricing holds the storage, request and egress prices of
+// S3-compatible providers, used to estimate what buckets cost per month.
+package pricing
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultRegion holds the prices of regions a provider does not list
+	DefaultRegion = "default"
+	// DefaultStorageClass is charged for storage classes without a price
+	DefaultStorageClass = "STANDARD"
+	// BytesPerGB is the size prices per GB are charged for
+	BytesPerGB = 1 << 30
+)
+
+var ErrInvalidTable = errors.New("invalid pricing table")
+
+//go:embed default.yaml
+var defaultTable []byte
+
+type Table struct {
+	Currency  string     `yaml:"currency" json:"currency"`
+	Providers []Provider `yaml:"providers" json:"providers"`
+}
+
+// Provider is matched against the free-text provider of a credential by its
+// name or one of its aliases, ignoring case
+type Provider struct {
+	Name    string            `yaml:"name" json:"name"`
+	Aliases []string          `yaml:"aliases" json:"aliases"`
+	Regions map[string]Prices `yaml:"regions" json:"regions"`
+}
+
+type Prices struct {
+	// Price per GB-month by storage class
+	Storage map[string]float64 `yaml:"storage" json:"storage"`
+	// Price per 1000 requests of each kind
+	Requests RequestPrices `yaml:"requests" json:"requests"`
+	// Price per GB downloaded
+	Egress float64 `yaml:"egress" json:"egress"`
+}
+
+type RequestPrices struct {
+	Write float64 `yaml:"write" json:"write"`
+	List  float64 `yaml:"list" json:"list"`
+	Read  float64 `yaml:"read" json:"read"`
+}
+
+// StoragePrice returns the price per GB-month of a storage class
+func (p Prices) StoragePrice(class string) float64 {
+	if price, ok := p.Storage[strings.ToUpper(class)]; ok {
+		return price
+	}
+	return p.Storage[DefaultStorageClass]
+}
+
+// Default returns the prices shipped with BucketBird
+func Default() (*Table, error) {
+	return Parse(defaultTable)
+}
+
+// Parse reads a pricing table from YAML or JSON
+func Parse(data []byte) (*Table, error) {
+	var table Table
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTable, err)
+	}
+	if err := table.validate(); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+func (t *Table) validate() error {
+	if strings.TrimSpace(t.Currency) == "" {
+		return fmt.Errorf("%w: currency is required", ErrInvalidTable)
+	}
+	names := map[string]string{}
+	for i := range t.Providers {
+		provider := &t.Providers[i]
+		if strings.TrimSpace(provider.Name) == "" {
+			return fmt.Errorf("%w: provider %d has no name", ErrInvalidTable, i+1)
+		}
+		for _, name := range append([]string{provider.Name}, provider.Aliases...) {
+			key := normalize(name)
+			if other, ok := names[key]; ok {
+				return fmt.Errorf("%w: %q is used by both %s and %s", ErrInvalidTable, name, other, provider.Name)
+			}
+			names[key] = provider.Name
+		}
+		if len(provider.Regions) == 0 {
+			return fmt.Errorf("%w: %s has no regions", ErrInvalidTable, provider.Name)
+		}
+
+		// Region and storage class names are matched case-insensitively
+		regions := make(map[string]Prices, len(provider.Regions))
+		for region, prices := range provider.Regions {
+			if _, ok := prices.Storage[DefaultStorageClass]; !ok {
+				return fmt.Errorf("%w: %s %s has no %s storage price", ErrInvalidTable, provider.Name, region, DefaultStorageClass)
+			}
+			storage := make(map[string]float64, len(prices.Storage))
+			for class, price := range prices.Storage {
+				if !validPrice(price) {
+					return fmt.Errorf("%w: %s %s has an invalid %s storage price", ErrInvalidTable, provider.Name, region, class)
+				}
+				storage[strings.ToUpper(class)] = price
+			}
+			prices.Storage = storage
+			if !validPrice(prices.Requests.Write) || !validPrice(prices.Requests.List) ||
+				!validPrice(prices.Requests.Read) || !validPrice(prices.Egress) {
+				return fmt.Errorf("%w: %s %s has an invalid price", ErrInvalidTable, provider.Name, region)
+			}
+			regions[strings.ToLower(region)] = prices
+		}
+		provider.Regions = regions
+	}
+	return nil
+}
+
+func validPrice(price float64) bool {
+	return price >= 0 && !math.IsInf(price, 0) && !math.IsNaN(price)
+}
+
+func normalize(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// Lookup finds the prices for a provider and region, falling back to the
+// provider's default region. It returns nil for unknown providers, such as
+// self-hosted MinIO.
+func (t *Table) Lookup(provider, region string) (*Provider, *Prices) {
+	key := normalize(provider)
+	for i := range t.Providers {
+		p := &t.Providers[i]
+		match := normalize(p.Name) == key
+		for _, alias := range p.Aliases {
+			match = match || normalize(alias) == key
+		}
+		if !match {
+			continue
+		}
+		if prices, ok := p.Regions[strings.ToLower(strings.TrimSpace(region))]; ok {
+			return p, &prices
+		}
+		if prices, ok := p.Regions[DefaultRegion]; ok {
+			return p, &prices
+		}
+		return p, nil
+	}
+	return nil, nil
+}
+
+// Source serves the pricing table from a file, reloading it whenever the
+// file changes so prices can be edited without a restart. Without a file it
+// serves the default table.
+type Source struct {
+	path string
+
+	mu      sync.Mutex
+	table   *Table
+	modTime time.Time
+	size    int64
+}
+
+// NewSource loads the pricing table from path, or the default table if path
+// is empty
+func NewSource(path string) (*Source, error) {
+	s := &Source{path: path}
+	if path == "" {
+		table, err := Default()
+		if err != nil {
+			return nil, err
+		}
+		s.table = table
+		return s, nil
+	}
+	if _, err := s.Table(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Table returns the current pricing table. If the file changed but can no
+// longer be loaded, the last good table is returned along with the error.
+func (s *Source) Table() (*Table, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" {
+		return s.table, nil
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return s.table, fmt.Errorf("stat pricing file: %w", err)
+	}
+	if s.table != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.table, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return s.table, fmt.Errorf("read pricing file: %w", err)
+	}
+	table, err := Parse(data)
+	if err != nil {
+		// Remember the broken file so the error is not reported on every call
+		s.modTime, s.size = info.ModTime(), info.Size()
+		return s.table, err
+	}
+	s.table, s.modTime, s.size = table, info.ModTime(), info.Size()
+	return s.table, nil
+}
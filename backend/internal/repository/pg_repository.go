@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"bucketbird/backend/internal/repository/sqlc"
@@ -32,22 +34,133 @@ func pgtypeToTime(t pgtype.Timestamptz) time.Time {
 	return t.Time
 }
 
+func timePtrToPgtype(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+func pgtypeToTimePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	value := t.Time
+	return &value
+}
+
+func uuidPtrToPgtype(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return uuidToPgtype(*id)
+}
+
+func pgtypeToUUIDPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	value := pgtypeToUUID(id)
+	return &value
+}
+
+func uuidsToPgtype(ids []uuid.UUID) []pgtype.UUID {
+	result := make([]pgtype.UUID, len(ids))
+	for i, id := range ids {
+		result[i] = uuidToPgtype(id)
+	}
+	return result
+}
+
+func pgtypeToUUIDs(ids []pgtype.UUID) []uuid.UUID {
+	result := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		result[i] = pgtypeToUUID(id)
+	}
+	return result
+}
+
 // Repositories holds all repository implementations
 type Repositories struct {
 	Users       UserRepository
 	Sessions    SessionRepository
 	Credentials CredentialRepository
 	Buckets     BucketRepository
+	Tokens      PersonalAccessTokenRepository
+	Identities  UserIdentityRepository
+	OIDC        OIDCLoginRequestRepository
+	Recovery    RecoveryCodeRepository
+	Settings    SettingsRepository
+	Logins      LoginAttemptRepository
+	Teams       TeamRepository
+	Invites     InviteRepository
+	EmailTokens EmailTokenRepository
+	Audit       AuditRepository
+	Webhooks    WebhookRepository
+	Deliveries  WebhookDeliveryRepository
+	Outbox      OutboxRepository
+	Quotas      QuotaRepository
+	Usage       UsageRepository
+	Costs       CostRepository
+	TagJobs     TagJobRepository
+	TagSearches TagSearchRepository
+
+	pool *pgxpool.Pool
 }
 
 func NewRepositories(pool *pgxpool.Pool) *Repositories {
-	q := sqlc.New(pool)
+	repos := newRepositories(sqlc.New(pool), pool)
+	repos.pool = pool
+	return repos
+}
+
+// newRepositories binds the repositories to q. pool is nil when q runs in a
+// transaction already.
+func newRepositories(q *sqlc.Queries, pool *pgxpool.Pool) *Repositories {
 	return &Repositories{
 		Users:       &pgUserRepository{q: q},
-		Sessions:    &pgSessionRepository{q: q},
+		Sessions:    &pgSessionRepository{q: q, pool: pool},
 		Credentials: &pgCredentialRepository{q: q},
 		Buckets:     &pgBucketRepository{q: q},
+		Tokens:      &pgPersonalAccessTokenRepository{q: q},
+		Identities:  &pgUserIdentityRepository{q: q},
+		OIDC:        &pgOIDCLoginRequestRepository{q: q},
+		Recovery:    &pgRecoveryCodeRepository{q: q},
+		Settings:    &pgSettingsRepository{q: q},
+		Logins:      &pgLoginAttemptRepository{q: q},
+		Teams:       &pgTeamRepository{q: q},
+		Invites:     &pgInviteRepository{q: q},
+		EmailTokens: &pgEmailTokenRepository{q: q},
+		Audit:       &pgAuditRepository{q: q},
+		Webhooks:    &pgWebhookRepository{q: q},
+		Deliveries:  &pgWebhookDeliveryRepository{q: q},
+		Outbox:      &pgOutboxRepository{q: q},
+		Quotas:      &pgQuotaRepository{q: q},
+		Usage:       &pgUsageRepository{q: q},
+		Costs:       &pgCostRepository{q: q},
+		TagJobs:     &pgTagJobRepository{q: q},
+		TagSearches: &pgTagSearchRepository{q: q},
+	}
+}
+
+// InTx runs fn with repositories bound to a single transaction. The transaction
+// is committed if fn returns nil and rolled back otherwise.
+func (r *Repositories) InTx(ctx context.Context, fn func(tx *Repositories) error) error {
+	if r.pool == nil {
+		return errors.New("repositories are already bound to a transaction")
 	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Rolling back after a successful commit is a no-op
+	defer tx.Rollback(ctx)
+
+	if err := fn(newRepositories(sqlc.New(tx), nil)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // ========== UserRepository implementation ==========
@@ -56,6 +169,25 @@ type pgUserRepository struct {
 	q *sqlc.Queries
 }
 
+func toUser(user sqlc.User) *User {
+	return &User{
+		ID:              pgtypeToUUID(user.ID),
+		Email:           user.Email,
+		PasswordHash:    user.PasswordHash,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		IsDemo:          user.IsDemo,
+		IsAdmin:         user.IsAdmin,
+		TOTPSecret:      user.TotpSecret,
+		TOTPEnabledAt:   pgtypeToTimePtr(user.TotpEnabledAt),
+		TOTPLastStep:    user.TotpLastStep,
+		EmailVerifiedAt: pgtypeToTimePtr(user.EmailVerifiedAt),
+		TokenVersion:    user.TokenVersion,
+		CreatedAt:       pgtypeToTime(user.CreatedAt),
+		UpdatedAt:       pgtypeToTime(user.UpdatedAt),
+	}
+}
+
 func (r *pgUserRepository) Create(ctx context.Context, email, passwordHash, firstName, lastName string) (*User, error) {
 	user, err := r.q.InsertUser(ctx, sqlc.InsertUserParams{
 		ID:           uuidToPgtype(uuid.New()),
@@ -67,16 +199,7 @@ func (r *pgUserRepository) Create(ctx context.Context, email, passwordHash, firs
 	if err != nil {
 		return nil, err
 	}
-	return &User{
-		ID:           pgtypeToUUID(user.ID),
-		Email:        user.Email,
-		PasswordHash: user.PasswordHash,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		IsDemo:       user.IsDemo,
-		CreatedAt:    pgtypeToTime(user.CreatedAt),
-		UpdatedAt:    pgtypeToTime(user.UpdatedAt),
-	}, nil
+	return toUser(user), nil
 }
 
 func (r *pgUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
@@ -87,16 +210,7 @@ func (r *pgUserRepository) GetByEmail(ctx context.Context, email string) (*User,
 		}
 		return nil, err
 	}
-	return &User{
-		ID:           pgtypeToUUID(user.ID),
-		Email:        user.Email,
-		PasswordHash: user.PasswordHash,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		IsDemo:       user.IsDemo,
-		CreatedAt:    pgtypeToTime(user.CreatedAt),
-		UpdatedAt:    pgtypeToTime(user.UpdatedAt),
-	}, nil
+	return toUser(user), nil
 }
 
 func (r *pgUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*User, error) {
@@ -107,16 +221,7 @@ func (r *pgUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*User, er
 		}
 		return nil, err
 	}
-	return &User{
-		ID:           pgtypeToUUID(user.ID),
-		Email:        user.Email,
-		PasswordHash: user.PasswordHash,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		IsDemo:       user.IsDemo,
-		CreatedAt:    pgtypeToTime(user.CreatedAt),
-		UpdatedAt:    pgtypeToTime(user.UpdatedAt),
-	}, nil
+	return toUser(user), nil
 }
 
 func (r *pgUserRepository) Update(ctx context.Context, id uuid.UUID, email, firstName, lastName string) error {
@@ -135,6 +240,76 @@ func (r *pgUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, pas
 	})
 }
 
+func (r *pgUserRepository) SetAdmin(ctx context.Context, id uuid.UUID, isAdmin bool) error {
+	return r.q.UpdateUserAdmin(ctx, sqlc.UpdateUserAdminParams{
+		ID:      uuidToPgtype(id),
+		IsAdmin: isAdmin,
+	})
+}
+
+func (r *pgUserRepository) SetTOTPSecret(ctx context.Context, id uuid.UUID, encryptedSecret string) error {
+	return r.q.SetUserTOTPSecret(ctx, sqlc.SetUserTOTPSecretParams{
+		ID:         uuidToPgtype(id),
+		TotpSecret: &encryptedSecret,
+	})
+}
+
+func (r *pgUserRepository) EnableTOTP(ctx context.Context, id uuid.UUID) error {
+	return r.q.EnableUserTOTP(ctx, uuidToPgtype(id))
+}
+
+func (r *pgUserRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	return r.q.DisableUserTOTP(ctx, uuidToPgtype(id))
+}
+
+func (r *pgUserRepository) UpdateTOTPLastStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	rows, err := r.q.UpdateUserTOTPLastStep(ctx, sqlc.UpdateUserTOTPLastStepParams{
+		ID:           uuidToPgtype(id),
+		TotpLastStep: step,
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *pgUserRepository) ListWithTOTPSecretForUpdate(ctx context.Context) ([]*User, error) {
+	users, err := r.q.ListUsersWithTOTPSecretForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*User, len(users))
+	for i, u := range users {
+		result[i] = toUser(u)
+	}
+	return result, nil
+}
+
+func (r *pgUserRepository) UpdateTOTPSecretCiphertext(ctx context.Context, id uuid.UUID, encryptedSecret string) error {
+	return r.q.UpdateUserTOTPSecretCiphertext(ctx, sqlc.UpdateUserTOTPSecretCiphertextParams{
+		ID:         uuidToPgtype(id),
+		TotpSecret: &encryptedSecret,
+	})
+}
+
+func (r *pgUserRepository) VerifyEmail(ctx context.Context, id uuid.UUID, email string) error {
+	return r.q.VerifyUserEmail(ctx, sqlc.VerifyUserEmailParams{
+		ID:    uuidToPgtype(id),
+		Email: email,
+	})
+}
+
+func (r *pgUserRepository) IncrementTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	version, err := r.q.IncrementUserTokenVersion(ctx, uuidToPgtype(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
 func (r *pgUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.q.DeleteUser(ctx, uuidToPgtype(id))
 }
@@ -143,26 +318,60 @@ func (r *pgUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 type pgSessionRepository struct {
 	q *sqlc.Queries
+	// pool starts the transactions of changes spanning several statements; it
+	// is nil when the repository is bound to a transaction already
+	pool *pgxpool.Pool
+}
+
+// inTx runs fn with the repository bound to a transaction, the one it is
+// already bound to or a new one
+func (r *pgSessionRepository) inTx(ctx context.Context, fn func(tx *pgSessionRepository) error) error {
+	if r.pool == nil {
+		return fn(r)
+	}
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		return fn(&pgSessionRepository{q: r.q.WithTx(tx)})
+	})
+}
+
+func toSession(session sqlc.Session) *Session {
+	return &Session{
+		ID:               pgtypeToUUID(session.ID),
+		UserID:           pgtypeToUUID(session.UserID),
+		RefreshTokenHash: session.RefreshTokenHash,
+		UserAgent:        session.UserAgent,
+		IPAddress:        session.IpAddress,
+		ExpiresAt:        pgtypeToTime(session.ExpiresAt),
+		LastUsedAt:       pgtypeToTime(session.LastUsedAt),
+		CreatedAt:        pgtypeToTime(session.CreatedAt),
+		UpdatedAt:        pgtypeToTime(session.UpdatedAt),
+	}
 }
 
-func (r *pgSessionRepository) Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*Session, error) {
+func (r *pgSessionRepository) Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time, userAgent, ipAddress string) (*Session, error) {
 	session, err := r.q.CreateSession(ctx, sqlc.CreateSessionParams{
 		ID:               uuidToPgtype(uuid.New()),
 		UserID:           uuidToPgtype(userID),
 		RefreshTokenHash: tokenHash,
 		ExpiresAt:        timeToPgtype(expiresAt),
+		UserAgent:        userAgent,
+		IpAddress:        ipAddress,
 	})
 	if err != nil {
 		return nil, err
 	}
-	return &Session{
-		ID:               pgtypeToUUID(session.ID),
-		UserID:           pgtypeToUUID(session.UserID),
-		RefreshTokenHash: session.RefreshTokenHash,
-		ExpiresAt:        pgtypeToTime(session.ExpiresAt),
-		CreatedAt:        pgtypeToTime(session.CreatedAt),
-		UpdatedAt:        pgtypeToTime(session.UpdatedAt),
-	}, nil
+	return toSession(session), nil
+}
+
+func (r *pgSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*Session, error) {
+	session, err := r.q.GetSession(ctx, uuidToPgtype(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toSession(session), nil
 }
 
 func (r *pgSessionRepository) GetByHash(ctx context.Context, hash string) (*Session, error) {
@@ -173,30 +382,141 @@ func (r *pgSessionRepository) GetByHash(ctx context.Context, hash string) (*Sess
 		}
 		return nil, err
 	}
-	return &Session{
-		ID:               pgtypeToUUID(session.ID),
-		UserID:           pgtypeToUUID(session.UserID),
-		RefreshTokenHash: session.RefreshTokenHash,
-		ExpiresAt:        pgtypeToTime(session.ExpiresAt),
-		CreatedAt:        pgtypeToTime(session.CreatedAt),
-		UpdatedAt:        pgtypeToTime(session.UpdatedAt),
-	}, nil
+	return toSession(session), nil
 }
 
-func (r *pgSessionRepository) UpdateToken(ctx context.Context, sessionID uuid.UUID, tokenHash string, expiresAt time.Time) error {
-	return r.q.UpdateSessionToken(ctx, sqlc.UpdateSessionTokenParams{
-		ID:               uuidToPgtype(sessionID),
-		RefreshTokenHash: tokenHash,
-		ExpiresAt:        timeToPgtype(expiresAt),
+func (r *pgSessionRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]*Session, error) {
+	rows, err := r.q.ListSessionsForUser(ctx, uuidToPgtype(userID))
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = toSession(row)
+	}
+	return sessions, nil
+}
+
+func (r *pgSessionRepository) Rotate(ctx context.Context, session *Session, tokenHash string, expiresAt time.Time, userAgent, ipAddress string) (bool, error) {
+	// The old token is retired in the same transaction. Otherwise a failure
+	// in between would leave it unknown, and a replay of it would not be
+	// detected as reuse.
+	var rotated bool
+	err := r.inTx(ctx, func(tx *pgSessionRepository) error {
+		rows, err := tx.q.RotateSessionToken(ctx, sqlc.RotateSessionTokenParams{
+			NewTokenHash:     tokenHash,
+			ExpiresAt:        timeToPgtype(expiresAt),
+			UserAgent:        userAgent,
+			IpAddress:        ipAddress,
+			ID:               uuidToPgtype(session.ID),
+			CurrentTokenHash: session.RefreshTokenHash,
+		})
+		if err != nil || rows == 0 {
+			return err
+		}
+		rotated = true
+		return tx.retire(ctx, session, RefreshTokenRotated)
 	})
+	if err != nil {
+		return false, err
+	}
+	return rotated, nil
+}
+
+func (r *pgSessionRepository) GetRetired(ctx context.Context, hash string) (*RetiredRefreshToken, error) {
+	token, err := r.q.GetRetiredRefreshToken(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &RetiredRefreshToken{
+		TokenHash: token.TokenHash,
+		SessionID: pgtypeToUUID(token.SessionID),
+		UserID:    pgtypeToUUID(token.UserID),
+		Reason:    token.Reason,
+		ExpiresAt: pgtypeToTime(token.ExpiresAt),
+		RetiredAt: pgtypeToTime(token.RetiredAt),
+	}, nil
+}
+
+func (r *pgSessionRepository) DeleteExpiredRetired(ctx context.Context) error {
+	return r.q.DeleteExpiredRetiredRefreshTokens(ctx)
 }
 
 func (r *pgSessionRepository) DeleteByHash(ctx context.Context, hash string) error {
-	return r.q.DeleteSessionByHash(ctx, hash)
+	_, err := r.deleteAndRetire(ctx, func(q *sqlc.Queries) ([]sqlc.Session, error) {
+		return q.DeleteSessionByHash(ctx, hash)
+	})
+	return err
+}
+
+func (r *pgSessionRepository) DeleteFamily(ctx context.Context, id uuid.UUID) error {
+	_, err := r.deleteAndRetire(ctx, func(q *sqlc.Queries) ([]sqlc.Session, error) {
+		return q.DeleteSession(ctx, uuidToPgtype(id))
+	})
+	return err
+}
+
+func (r *pgSessionRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	count, err := r.deleteAndRetire(ctx, func(q *sqlc.Queries) ([]sqlc.Session, error) {
+		return q.DeleteSessionForUser(ctx, sqlc.DeleteSessionForUserParams{
+			ID:     uuidToPgtype(id),
+			UserID: uuidToPgtype(userID),
+		})
+	})
+	return count > 0, err
+}
+
+func (r *pgSessionRepository) DeleteOthers(ctx context.Context, userID, keepID uuid.UUID) (int64, error) {
+	return r.deleteAndRetire(ctx, func(q *sqlc.Queries) ([]sqlc.Session, error) {
+		return q.DeleteOtherSessionsForUser(ctx, sqlc.DeleteOtherSessionsForUserParams{
+			UserID: uuidToPgtype(userID),
+			ID:     uuidToPgtype(keepID),
+		})
+	})
 }
 
 func (r *pgSessionRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
-	return r.q.DeleteSessionsForUser(ctx, uuidToPgtype(userID))
+	_, err := r.deleteAndRetire(ctx, func(q *sqlc.Queries) ([]sqlc.Session, error) {
+		return q.DeleteSessionsForUser(ctx, uuidToPgtype(userID))
+	})
+	return err
+}
+
+// deleteAndRetire deletes sessions with del and records their current refresh
+// tokens as revoked, in one transaction. A token deleted but not retired
+// would not be recognized as reused if it is replayed.
+func (r *pgSessionRepository) deleteAndRetire(ctx context.Context, del func(q *sqlc.Queries) ([]sqlc.Session, error)) (int64, error) {
+	var count int64
+	err := r.inTx(ctx, func(tx *pgSessionRepository) error {
+		deleted, err := del(tx.q)
+		if err != nil {
+			return err
+		}
+		for _, row := range deleted {
+			if err := tx.retire(ctx, toSession(row), RefreshTokenRevoked); err != nil {
+				return err
+			}
+		}
+		count = int64(len(deleted))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *pgSessionRepository) retire(ctx context.Context, session *Session, reason string) error {
+	return r.q.RetireRefreshToken(ctx, sqlc.RetireRefreshTokenParams{
+		TokenHash: session.RefreshTokenHash,
+		SessionID: uuidToPgtype(session.ID),
+		UserID:    uuidToPgtype(session.UserID),
+		Reason:    reason,
+		ExpiresAt: timeToPgtype(session.ExpiresAt),
+	})
 }
 
 // ========== CredentialRepository implementation ==========
@@ -215,6 +535,7 @@ func (r *pgCredentialRepository) Create(ctx context.Context, cred *Credential) (
 		Endpoint:           cred.Endpoint,
 		EncryptedAccessKey: cred.EncryptedAccessKey,
 		EncryptedSecretKey: cred.EncryptedSecretKey,
+		EncryptedDataKey:   cred.EncryptedDataKey,
 		UseSsl:             cred.UseSSL,
 		Status:             cred.Status,
 		Logo:               cred.Logo,
@@ -244,6 +565,7 @@ func (r *pgCredentialRepository) List(ctx context.Context, userID uuid.UUID) ([]
 			Endpoint:           c.Endpoint,
 			EncryptedAccessKey: c.EncryptedAccessKey,
 			EncryptedSecretKey: c.EncryptedSecretKey,
+			EncryptedDataKey:   c.EncryptedDataKey,
 			UseSSL:             c.UseSsl,
 			Status:             c.Status,
 			Logo:               c.Logo,
@@ -274,6 +596,7 @@ func (r *pgCredentialRepository) Get(ctx context.Context, id, userID uuid.UUID)
 		Endpoint:           cred.Endpoint,
 		EncryptedAccessKey: cred.EncryptedAccessKey,
 		EncryptedSecretKey: cred.EncryptedSecretKey,
+		EncryptedDataKey:   cred.EncryptedDataKey,
 		UseSSL:             cred.UseSsl,
 		Status:             cred.Status,
 		Logo:               cred.Logo,
@@ -292,6 +615,7 @@ func (r *pgCredentialRepository) Update(ctx context.Context, cred *Credential) e
 		Endpoint:           cred.Endpoint,
 		EncryptedAccessKey: cred.EncryptedAccessKey,
 		EncryptedSecretKey: cred.EncryptedSecretKey,
+		EncryptedDataKey:   cred.EncryptedDataKey,
 		UseSsl:             cred.UseSSL,
 		Status:             cred.Status,
 		Logo:               cred.Logo,
@@ -306,6 +630,42 @@ func (r *pgCredentialRepository) Delete(ctx context.Context, id, userID uuid.UUI
 	})
 }
 
+func (r *pgCredentialRepository) ListAllForUpdate(ctx context.Context) ([]*Credential, error) {
+	creds, err := r.q.ListAllCredentialsForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Credential, len(creds))
+	for i, c := range creds {
+		result[i] = &Credential{
+			ID:                 pgtypeToUUID(c.ID),
+			UserID:             pgtypeToUUID(c.UserID),
+			Name:               c.Name,
+			Provider:           c.Provider,
+			Region:             c.Region,
+			Endpoint:           c.Endpoint,
+			EncryptedAccessKey: c.EncryptedAccessKey,
+			EncryptedSecretKey: c.EncryptedSecretKey,
+			EncryptedDataKey:   c.EncryptedDataKey,
+			UseSSL:             c.UseSsl,
+			Status:             c.Status,
+			Logo:               c.Logo,
+			CreatedAt:          pgtypeToTime(c.CreatedAt),
+			UpdatedAt:          pgtypeToTime(c.UpdatedAt),
+		}
+	}
+	return result, nil
+}
+
+func (r *pgCredentialRepository) UpdateSecrets(ctx context.Context, id uuid.UUID, encryptedAccessKey, encryptedSecretKey string, encryptedDataKey *string) error {
+	return r.q.UpdateCredentialSecrets(ctx, sqlc.UpdateCredentialSecretsParams{
+		ID:                 uuidToPgtype(id),
+		EncryptedAccessKey: encryptedAccessKey,
+		EncryptedSecretKey: encryptedSecretKey,
+		EncryptedDataKey:   encryptedDataKey,
+	})
+}
+
 // ========== BucketRepository implementation ==========
 
 type pgBucketRepository struct {
@@ -326,6 +686,8 @@ func (r *pgBucketRepository) Create(ctx context.Context, bucket *Bucket) (*Bucke
 	}
 	bucket.ID = pgtypeToUUID(created.ID)
 	bucket.SizeBytes = created.SizeBytes
+	bucket.ObjectCount = created.ObjectCount
+	bucket.SizeUpdatedAt = pgtypeToTimePtr(created.SizeUpdatedAt)
 	bucket.CreatedAt = pgtypeToTime(created.CreatedAt)
 	bucket.UpdatedAt = pgtypeToTime(created.UpdatedAt)
 	return bucket, nil
@@ -340,15 +702,17 @@ func (r *pgBucketRepository) List(ctx context.Context, userID uuid.UUID) ([]*Buc
 	for i, b := range buckets {
 		result[i] = &BucketWithCredential{
 			Bucket: Bucket{
-				ID:           pgtypeToUUID(b.Bucket.ID),
-				UserID:       pgtypeToUUID(b.Bucket.UserID),
-				CredentialID: pgtypeToUUID(b.Bucket.CredentialID),
-				Name:         b.Bucket.Name,
-				Region:       b.Bucket.Region,
-				Description:  b.Bucket.Description,
-				SizeBytes:    b.Bucket.SizeBytes,
-				CreatedAt:    pgtypeToTime(b.Bucket.CreatedAt),
-				UpdatedAt:    pgtypeToTime(b.Bucket.UpdatedAt),
+				ID:            pgtypeToUUID(b.Bucket.ID),
+				UserID:        pgtypeToUUID(b.Bucket.UserID),
+				CredentialID:  pgtypeToUUID(b.Bucket.CredentialID),
+				Name:          b.Bucket.Name,
+				Region:        b.Bucket.Region,
+				Description:   b.Bucket.Description,
+				SizeBytes:     b.Bucket.SizeBytes,
+				ObjectCount:   b.Bucket.ObjectCount,
+				SizeUpdatedAt: pgtypeToTimePtr(b.Bucket.SizeUpdatedAt),
+				CreatedAt:     pgtypeToTime(b.Bucket.CreatedAt),
+				UpdatedAt:     pgtypeToTime(b.Bucket.UpdatedAt),
 			},
 			CredentialName:     b.CredentialName,
 			CredentialProvider: b.CredentialProvider,
@@ -370,21 +734,46 @@ func (r *pgBucketRepository) Get(ctx context.Context, id, userID uuid.UUID) (*Bu
 	}
 	return &BucketWithCredential{
 		Bucket: Bucket{
-			ID:           pgtypeToUUID(b.Bucket.ID),
-			UserID:       pgtypeToUUID(b.Bucket.UserID),
-			CredentialID: pgtypeToUUID(b.Bucket.CredentialID),
-			Name:         b.Bucket.Name,
-			Region:       b.Bucket.Region,
-			Description:  b.Bucket.Description,
-			SizeBytes:    b.Bucket.SizeBytes,
-			CreatedAt:    pgtypeToTime(b.Bucket.CreatedAt),
-			UpdatedAt:    pgtypeToTime(b.Bucket.UpdatedAt),
+			ID:            pgtypeToUUID(b.Bucket.ID),
+			UserID:        pgtypeToUUID(b.Bucket.UserID),
+			CredentialID:  pgtypeToUUID(b.Bucket.CredentialID),
+			Name:          b.Bucket.Name,
+			Region:        b.Bucket.Region,
+			Description:   b.Bucket.Description,
+			SizeBytes:     b.Bucket.SizeBytes,
+			ObjectCount:   b.Bucket.ObjectCount,
+			SizeUpdatedAt: pgtypeToTimePtr(b.Bucket.SizeUpdatedAt),
+			CreatedAt:     pgtypeToTime(b.Bucket.CreatedAt),
+			UpdatedAt:     pgtypeToTime(b.Bucket.UpdatedAt),
 		},
 		CredentialName:     b.CredentialName,
 		CredentialProvider: b.CredentialProvider,
 	}, nil
 }
 
+func (r *pgBucketRepository) GetByID(ctx context.Context, id uuid.UUID) (*Bucket, error) {
+	b, err := r.q.GetBucketByID(ctx, uuidToPgtype(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &Bucket{
+		ID:            pgtypeToUUID(b.ID),
+		UserID:        pgtypeToUUID(b.UserID),
+		CredentialID:  pgtypeToUUID(b.CredentialID),
+		Name:          b.Name,
+		Region:        b.Region,
+		Description:   b.Description,
+		SizeBytes:     b.SizeBytes,
+		ObjectCount:   b.ObjectCount,
+		SizeUpdatedAt: pgtypeToTimePtr(b.SizeUpdatedAt),
+		CreatedAt:     pgtypeToTime(b.CreatedAt),
+		UpdatedAt:     pgtypeToTime(b.UpdatedAt),
+	}, nil
+}
+
 func (r *pgBucketRepository) GetByName(ctx context.Context, userID uuid.UUID, name string) (*BucketWithCredential, error) {
 	b, err := r.q.GetBucketByName(ctx, sqlc.GetBucketByNameParams{
 		UserID: uuidToPgtype(userID),
@@ -398,15 +787,17 @@ func (r *pgBucketRepository) GetByName(ctx context.Context, userID uuid.UUID, na
 	}
 	return &BucketWithCredential{
 		Bucket: Bucket{
-			ID:           pgtypeToUUID(b.Bucket.ID),
-			UserID:       pgtypeToUUID(b.Bucket.UserID),
-			CredentialID: pgtypeToUUID(b.Bucket.CredentialID),
-			Name:         b.Bucket.Name,
-			Region:       b.Bucket.Region,
-			Description:  b.Bucket.Description,
-			SizeBytes:    b.Bucket.SizeBytes,
-			CreatedAt:    pgtypeToTime(b.Bucket.CreatedAt),
-			UpdatedAt:    pgtypeToTime(b.Bucket.UpdatedAt),
+			ID:            pgtypeToUUID(b.Bucket.ID),
+			UserID:        pgtypeToUUID(b.Bucket.UserID),
+			CredentialID:  pgtypeToUUID(b.Bucket.CredentialID),
+			Name:          b.Bucket.Name,
+			Region:        b.Bucket.Region,
+			Description:   b.Bucket.Description,
+			SizeBytes:     b.Bucket.SizeBytes,
+			ObjectCount:   b.Bucket.ObjectCount,
+			SizeUpdatedAt: pgtypeToTimePtr(b.Bucket.SizeUpdatedAt),
+			CreatedAt:     pgtypeToTime(b.Bucket.CreatedAt),
+			UpdatedAt:     pgtypeToTime(b.Bucket.UpdatedAt),
 		},
 		CredentialName:     b.CredentialName,
 		CredentialProvider: b.CredentialProvider,
@@ -421,10 +812,11 @@ func (r *pgBucketRepository) Update(ctx context.Context, id, userID uuid.UUID, d
 	})
 }
 
-func (r *pgBucketRepository) UpdateSize(ctx context.Context, id uuid.UUID, sizeBytes int64) error {
-	return r.q.UpdateBucketSize(ctx, sqlc.UpdateBucketSizeParams{
-		ID:        uuidToPgtype(id),
-		SizeBytes: sizeBytes,
+func (r *pgBucketRepository) UpdateUsage(ctx context.Context, id uuid.UUID, sizeBytes, objectCount int64) error {
+	return r.q.UpdateBucketUsage(ctx, sqlc.UpdateBucketUsageParams{
+		ID:          uuidToPgtype(id),
+		SizeBytes:   sizeBytes,
+		ObjectCount: objectCount,
 	})
 }
 
@@ -435,10 +827,1901 @@ func (r *pgBucketRepository) Delete(ctx context.Context, id, userID uuid.UUID) e
 	})
 }
 
+// ========== PersonalAccessTokenRepository implementation ==========
+
+type pgPersonalAccessTokenRepository struct {
+	q *sqlc.Queries
+}
+
+func toPersonalAccessToken(t sqlc.PersonalAccessToken) *PersonalAccessToken {
+	return &PersonalAccessToken{
+		ID:          pgtypeToUUID(t.ID),
+		UserID:      pgtypeToUUID(t.UserID),
+		Name:        t.Name,
+		TokenHash:   t.TokenHash,
+		TokenPrefix: t.TokenPrefix,
+		Scope:       t.Scope,
+		BucketIDs:   pgtypeToUUIDs(t.BucketIds),
+		ExpiresAt:   pgtypeToTimePtr(t.ExpiresAt),
+		LastUsedAt:  pgtypeToTimePtr(t.LastUsedAt),
+		CreatedAt:   pgtypeToTime(t.CreatedAt),
+		UpdatedAt:   pgtypeToTime(t.UpdatedAt),
+	}
+}
+
+func (r *pgPersonalAccessTokenRepository) Create(ctx context.Context, token *PersonalAccessToken) (*PersonalAccessToken, error) {
+	created, err := r.q.CreatePersonalAccessToken(ctx, sqlc.CreatePersonalAccessTokenParams{
+		ID:          uuidToPgtype(uuid.New()),
+		UserID:      uuidToPgtype(token.UserID),
+		Name:        token.Name,
+		TokenHash:   token.TokenHash,
+		TokenPrefix: token.TokenPrefix,
+		Scope:       token.Scope,
+		BucketIds:   uuidsToPgtype(token.BucketIDs),
+		ExpiresAt:   timePtrToPgtype(token.ExpiresAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toPersonalAccessToken(created), nil
+}
+
+func (r *pgPersonalAccessTokenRepository) List(ctx context.Context, userID uuid.UUID) ([]*PersonalAccessToken, error) {
+	tokens, err := r.q.ListPersonalAccessTokens(ctx, uuidToPgtype(userID))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*PersonalAccessToken, len(tokens))
+	for i, t := range tokens {
+		result[i] = toPersonalAccessToken(t)
+	}
+	return result, nil
+}
+
+func (r *pgPersonalAccessTokenRepository) Get(ctx context.Context, id, userID uuid.UUID) (*PersonalAccessToken, error) {
+	token, err := r.q.GetPersonalAccessToken(ctx, sqlc.GetPersonalAccessTokenParams{
+		ID:     uuidToPgtype(id),
+		UserID: uuidToPgtype(userID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toPersonalAccessToken(token), nil
+}
+
+func (r *pgPersonalAccessTokenRepository) GetByHash(ctx context.Context, hash string) (*PersonalAccessToken, error) {
+	token, err := r.q.GetPersonalAccessTokenByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toPersonalAccessToken(token), nil
+}
+
+func (r *pgPersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id uuid.UUID) error {
+	return r.q.TouchPersonalAccessToken(ctx, uuidToPgtype(id))
+}
+
+func (r *pgPersonalAccessTokenRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	return r.q.DeletePersonalAccessToken(ctx, sqlc.DeletePersonalAccessTokenParams{
+		ID:     uuidToPgtype(id),
+		UserID: uuidToPgtype(userID),
+	})
+}
+
 // Verify interface compliance
+// ========== UserIdentityRepository implementation ==========
+
+type pgUserIdentityRepository struct {
+	q *sqlc.Queries
+}
+
+func toUserIdentity(identity sqlc.UserIdentity) *UserIdentity {
+	return &UserIdentity{
+		ID:        pgtypeToUUID(identity.ID),
+		UserID:    pgtypeToUUID(identity.UserID),
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: pgtypeToTime(identity.CreatedAt),
+		UpdatedAt: pgtypeToTime(identity.UpdatedAt),
+	}
+}
+
+func (r *pgUserIdentityRepository) Create(ctx context.Context, userID uuid.UUID, provider, subject, email string) (*UserIdentity, error) {
+	identity, err := r.q.CreateUserIdentity(ctx, sqlc.CreateUserIdentityParams{
+		ID:       uuidToPgtype(uuid.New()),
+		UserID:   uuidToPgtype(userID),
+		Provider: provider,
+		Subject:  subject,
+		Email:    email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toUserIdentity(identity), nil
+}
+
+func (r *pgUserIdentityRepository) Get(ctx context.Context, provider, subject string) (*UserIdentity, error) {
+	identity, err := r.q.GetUserIdentity(ctx, sqlc.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  subject,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toUserIdentity(identity), nil
+}
+
+func (r *pgUserIdentityRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email string) error {
+	return r.q.UpdateUserIdentityEmail(ctx, sqlc.UpdateUserIdentityEmailParams{
+		ID:    uuidToPgtype(id),
+		Email: email,
+	})
+}
+
+// ========== OIDCLoginRequestRepository implementation ==========
+
+type pgOIDCLoginRequestRepository struct {
+	q *sqlc.Queries
+}
+
+func toOIDCLoginRequest(req sqlc.OidcLoginRequest) *OIDCLoginRequest {
+	return &OIDCLoginRequest{
+		ID:           pgtypeToUUID(req.ID),
+		State:        req.State,
+		CodeVerifier: req.CodeVerifier,
+		Nonce:        req.Nonce,
+		ExpiresAt:    pgtypeToTime(req.ExpiresAt),
+		CreatedAt:    pgtypeToTime(req.CreatedAt),
+	}
+}
+
+func (r *pgOIDCLoginRequestRepository) Create(ctx context.Context, state, codeVerifier, nonce string, expiresAt time.Time) (*OIDCLoginRequest, error) {
+	req, err := r.q.CreateOIDCLoginRequest(ctx, sqlc.CreateOIDCLoginRequestParams{
+		ID:           uuidToPgtype(uuid.New()),
+		State:        state,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiresAt:    timeToPgtype(expiresAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toOIDCLoginRequest(req), nil
+}
+
+func (r *pgOIDCLoginRequestRepository) Consume(ctx context.Context, state string) (*OIDCLoginRequest, error) {
+	req, err := r.q.ConsumeOIDCLoginRequest(ctx, state)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toOIDCLoginRequest(req), nil
+}
+
+func (r *pgOIDCLoginRequestRepository) DeleteExpired(ctx context.Context) error {
+	return r.q.DeleteExpiredOIDCLoginRequests(ctx)
+}
+
+// ========== RecoveryCodeRepository implementation ==========
+
+type pgRecoveryCodeRepository struct {
+	q *sqlc.Queries
+}
+
+func (r *pgRecoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	if err := r.q.DeleteRecoveryCodes(ctx, uuidToPgtype(userID)); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if err := r.q.CreateRecoveryCode(ctx, sqlc.CreateRecoveryCodeParams{
+			ID:       uuidToPgtype(uuid.New()),
+			UserID:   uuidToPgtype(userID),
+			CodeHash: hash,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *pgRecoveryCodeRepository) Use(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	rows, err := r.q.UseRecoveryCode(ctx, sqlc.UseRecoveryCodeParams{
+		UserID:   uuidToPgtype(userID),
+		CodeHash: codeHash,
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *pgRecoveryCodeRepository) CountUnused(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return r.q.CountUnusedRecoveryCodes(ctx, uuidToPgtype(userID))
+}
+
+func (r *pgRecoveryCodeRepository) DeleteAll(ctx context.Context, userID uuid.UUID) error {
+	return r.q.DeleteRecoveryCodes(ctx, uuidToPgtype(userID))
+}
+
+// ========== SettingsRepository implementation ==========
+
+type pgSettingsRepository struct {
+	q *sqlc.Queries
+}
+
+func (r *pgSettingsRepository) Get(ctx context.Context, key string) ([]byte, error) {
+	setting, err := r.q.GetInstanceSetting(ctx, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return setting.Value, nil
+}
+
+func (r *pgSettingsRepository) Set(ctx context.Context, key string, value []byte) error {
+	return r.q.UpsertInstanceSetting(ctx, sqlc.UpsertInstanceSettingParams{
+		Key:   key,
+		Value: value,
+	})
+}
+
+// ========== LoginAttemptRepository implementation ==========
+
+type pgLoginAttemptRepository struct {
+	q *sqlc.Queries
+}
+
+func toLoginAttempt(attempt sqlc.LoginAttempt) *LoginAttempt {
+	return &LoginAttempt{
+		Identifier:    attempt.Identifier,
+		FailedCount:   int(attempt.FailedCount),
+		LastFailedAt:  pgtypeToTime(attempt.LastFailedAt),
+		LastIPAddress: attempt.LastIpAddress,
+		LockedUntil:   pgtypeToTimePtr(attempt.LockedUntil),
+		CreatedAt:     pgtypeToTime(attempt.CreatedAt),
+	}
+}
+
+func (r *pgLoginAttemptRepository) Get(ctx context.Context, identifier string) (*LoginAttempt, error) {
+	attempt, err := r.q.GetLoginAttempt(ctx, identifier)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toLoginAttempt(attempt), nil
+}
+
+func (r *pgLoginAttemptRepository) RecordFailure(ctx context.Context, identifier, ipAddress string, failedAt, resetBefore time.Time) (*LoginAttempt, error) {
+	row, err := r.q.RecordLoginFailure(ctx, sqlc.RecordLoginFailureParams{
+		Identifier:  identifier,
+		FailedAt:    timeToPgtype(failedAt),
+		IpAddress:   ipAddress,
+		ResetBefore: timeToPgtype(resetBefore),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toLoginAttempt(row), nil
+}
+
+func (r *pgLoginAttemptRepository) Lock(ctx context.Context, identifier string, until time.Time) error {
+	return r.q.LockLoginAttempt(ctx, sqlc.LockLoginAttemptParams{
+		Identifier:  identifier,
+		LockedUntil: timeToPgtype(until),
+	})
+}
+
+func (r *pgLoginAttemptRepository) ListLocked(ctx context.Context) ([]*LoginAttempt, error) {
+	rows, err := r.q.ListLockedLoginAttempts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	attempts := make([]*LoginAttempt, len(rows))
+	for i, row := range rows {
+		attempts[i] = toLoginAttempt(row)
+	}
+	return attempts, nil
+}
+
+func (r *pgLoginAttemptRepository) Delete(ctx context.Context, identifier string) (bool, error) {
+	deleted, err := r.q.DeleteLoginAttempt(ctx, identifier)
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
+func (r *pgLoginAttemptRepository) DeleteStale(ctx context.Context, before time.Time) error {
+	return r.q.DeleteStaleLoginAttempts(ctx, timeToPgtype(before))
+}
+
+// ========== TeamRepository implementation ==========
+
+type pgTeamRepository struct {
+	q *sqlc.Queries
+}
+
+func toTeam(team sqlc.Team) *Team {
+	return &Team{
+		ID:        pgtypeToUUID(team.ID),
+		Name:      team.Name,
+		CreatedBy: pgtypeToUUIDPtr(team.CreatedBy),
+		CreatedAt: pgtypeToTime(team.CreatedAt),
+		UpdatedAt: pgtypeToTime(team.UpdatedAt),
+	}
+}
+
+func (r *pgTeamRepository) Create(ctx context.Context, name string, createdBy uuid.UUID) (*Team, error) {
+	team, err := r.q.CreateTeam(ctx, sqlc.CreateTeamParams{
+		ID:        uuidToPgtype(uuid.New()),
+		Name:      name,
+		CreatedBy: uuidToPgtype(createdBy),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toTeam(team), nil
+}
+
+func (r *pgTeamRepository) Get(ctx context.Context, id uuid.UUID) (*Team, error) {
+	team, err := r.q.GetTeam(ctx, uuidToPgtype(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toTeam(team), nil
+}
+
+func (r *pgTeamRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]*TeamMembership, error) {
+	rows, err := r.q.ListTeamsForUser(ctx, uuidToPgtype(userID))
+	if err != nil {
+		return nil, err
+	}
+	teams := make([]*TeamMembership, len(rows))
+	for i, row := range rows {
+		teams[i] = &TeamMembership{
+			Team: Team{
+				ID:        pgtypeToUUID(row.ID),
+				Name:      row.Name,
+				CreatedBy: pgtypeToUUIDPtr(row.CreatedBy),
+				CreatedAt: pgtypeToTime(row.CreatedAt),
+				UpdatedAt: pgtypeToTime(row.UpdatedAt),
+			},
+			Role: row.Role,
+		}
+	}
+	return teams, nil
+}
+
+func (r *pgTeamRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.q.DeleteTeam(ctx, uuidToPgtype(id))
+}
+
+func (r *pgTeamRepository) AddMember(ctx context.Context, teamID, userID uuid.UUID, role string) error {
+	return r.q.AddTeamMember(ctx, sqlc.AddTeamMemberParams{
+		TeamID: uuidToPgtype(teamID),
+		UserID: uuidToPgtype(userID),
+		Role:   role,
+	})
+}
+
+func (r *pgTeamRepository) GetMember(ctx context.Context, teamID, userID uuid.UUID) (*TeamMember, error) {
+	member, err := r.q.GetTeamMember(ctx, sqlc.GetTeamMemberParams{
+		TeamID: uuidToPgtype(teamID),
+		UserID: uuidToPgtype(userID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &TeamMember{
+		TeamID:    pgtypeToUUID(member.TeamID),
+		UserID:    pgtypeToUUID(member.UserID),
+		Role:      member.Role,
+		CreatedAt: pgtypeToTime(member.CreatedAt),
+	}, nil
+}
+
+func (r *pgTeamRepository) ListMembers(ctx context.Context, teamID uuid.UUID) ([]*TeamMember, error) {
+	rows, err := r.q.ListTeamMembers(ctx, uuidToPgtype(teamID))
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*TeamMember, len(rows))
+	for i, row := range rows {
+		members[i] = &TeamMember{
+			TeamID:    pgtypeToUUID(row.TeamID),
+			UserID:    pgtypeToUUID(row.UserID),
+			Role:      row.Role,
+			Email:     row.Email,
+			FirstName: row.FirstName,
+			LastName:  row.LastName,
+			CreatedAt: pgtypeToTime(row.CreatedAt),
+		}
+	}
+	return members, nil
+}
+
+func (r *pgTeamRepository) RemoveMember(ctx context.Context, teamID, userID uuid.UUID) (bool, error) {
+	removed, err := r.q.RemoveTeamMember(ctx, sqlc.RemoveTeamMemberParams{
+		TeamID: uuidToPgtype(teamID),
+		UserID: uuidToPgtype(userID),
+	})
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+// ========== InviteRepository implementation ==========
+
+type pgInviteRepository struct {
+	q *sqlc.Queries
+}
+
+func toInvite(invite sqlc.Invite) *Invite {
+	return &Invite{
+		ID:         pgtypeToUUID(invite.ID),
+		CodeHash:   invite.CodeHash,
+		CodePrefix: invite.CodePrefix,
+		CreatedBy:  pgtypeToUUID(invite.CreatedBy),
+		Email:      invite.Email,
+		TeamID:     pgtypeToUUIDPtr(invite.TeamID),
+		MaxUses:    int(invite.MaxUses),
+		UseCount:   int(invite.UseCount),
+		ExpiresAt:  pgtypeToTimePtr(invite.ExpiresAt),
+		CreatedAt:  pgtypeToTime(invite.CreatedAt),
+	}
+}
+
+func (r *pgInviteRepository) Create(ctx context.Context, invite *Invite) (*Invite, error) {
+	created, err := r.q.CreateInvite(ctx, sqlc.CreateInviteParams{
+		ID:         uuidToPgtype(uuid.New()),
+		CodeHash:   invite.CodeHash,
+		CodePrefix: invite.CodePrefix,
+		CreatedBy:  uuidToPgtype(invite.CreatedBy),
+		Email:      invite.Email,
+		TeamID:     uuidPtrToPgtype(invite.TeamID),
+		MaxUses:    int32(invite.MaxUses),
+		ExpiresAt:  timePtrToPgtype(invite.ExpiresAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toInvite(created), nil
+}
+
+func (r *pgInviteRepository) Get(ctx context.Context, id uuid.UUID) (*Invite, error) {
+	invite, err := r.q.GetInvite(ctx, uuidToPgtype(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toInvite(invite), nil
+}
+
+func (r *pgInviteRepository) GetByHash(ctx context.Context, hash string) (*Invite, error) {
+	invite, err := r.q.GetInviteByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toInvite(invite), nil
+}
+
+func (r *pgInviteRepository) List(ctx context.Context) ([]*Invite, error) {
+	rows, err := r.q.ListInvites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toInvites(rows), nil
+}
+
+func (r *pgInviteRepository) ListByCreator(ctx context.Context, userID uuid.UUID) ([]*Invite, error) {
+	rows, err := r.q.ListInvitesByCreator(ctx, uuidToPgtype(userID))
+	if err != nil {
+		return nil, err
+	}
+	return toInvites(rows), nil
+}
+
+func toInvites(rows []sqlc.Invite) []*Invite {
+	invites := make([]*Invite, len(rows))
+	for i, row := range rows {
+		invites[i] = toInvite(row)
+	}
+	return invites
+}
+
+func (r *pgInviteRepository) Redeem(ctx context.Context, id uuid.UUID) (bool, error) {
+	redeemed, err := r.q.RedeemInvite(ctx, uuidToPgtype(id))
+	if err != nil {
+		return false, err
+	}
+	return redeemed > 0, nil
+}
+
+func (r *pgInviteRepository) Release(ctx context.Context, id uuid.UUID) error {
+	return r.q.ReleaseInvite(ctx, uuidToPgtype(id))
+}
+
+func (r *pgInviteRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.q.DeleteInvite(ctx, uuidToPgtype(id))
+}
+
+// ========== EmailTokenRepository implementation ==========
+
+type pgEmailTokenRepository struct {
+	q *sqlc.Queries
+}
+
+func toEmailToken(token sqlc.EmailToken) *EmailToken {
+	return &EmailToken{
+		ID:        pgtypeToUUID(token.ID),
+		UserID:    pgtypeToUUID(token.UserID),
+		Purpose:   token.Purpose,
+		Email:     token.Email,
+		ExpiresAt: pgtypeToTime(token.ExpiresAt),
+		CreatedAt: pgtypeToTime(token.CreatedAt),
+	}
+}
+
+func (r *pgEmailTokenRepository) Create(ctx context.Context, userID uuid.UUID, purpose, tokenHash, email string, expiresAt time.Time) (*EmailToken, error) {
+	token, err := r.q.CreateEmailToken(ctx, sqlc.CreateEmailTokenParams{
+		ID:        uuidToPgtype(uuid.New()),
+		UserID:    uuidToPgtype(userID),
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		Email:     email,
+		ExpiresAt: timeToPgtype(expiresAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEmailToken(token), nil
+}
+
+func (r *pgEmailTokenRepository) Get(ctx context.Context, tokenHash, purpose string) (*EmailToken, error) {
+	token, err := r.q.GetEmailToken(ctx, sqlc.GetEmailTokenParams{
+		TokenHash: tokenHash,
+		Purpose:   purpose,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toEmailToken(token), nil
+}
+
+func (r *pgEmailTokenRepository) Consume(ctx context.Context, tokenHash, purpose string) (*EmailToken, error) {
+	token, err := r.q.ConsumeEmailToken(ctx, sqlc.ConsumeEmailTokenParams{
+		TokenHash: tokenHash,
+		Purpose:   purpose,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toEmailToken(token), nil
+}
+
+func (r *pgEmailTokenRepository) DeleteForUser(ctx context.Context, userID uuid.UUID, purpose string) error {
+	return r.q.DeleteEmailTokensForUser(ctx, sqlc.DeleteEmailTokensForUserParams{
+		UserID:  uuidToPgtype(userID),
+		Purpose: purpose,
+	})
+}
+
+func (r *pgEmailTokenRepository) DeleteExpired(ctx context.Context) error {
+	return r.q.DeleteExpiredEmailTokens(ctx)
+}
+
+// ========== AuditRepository implementation ==========
+
+type pgAuditRepository struct {
+	q *sqlc.Queries
+}
+
+// optionalString stores empty strings as NULL
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func toAuditEvent(event sqlc.AuditEvent) *AuditEvent {
+	details := map[string]string{}
+	// Details are written by Create, so a decode error means a hand-edited row; show it without details
+	_ = json.Unmarshal(event.Details, &details)
+	return &AuditEvent{
+		ID:         event.ID,
+		OccurredAt: pgtypeToTime(event.OccurredAt),
+		ActorID:    pgtypeToUUIDPtr(event.ActorID),
+		ActorEmail: stringValue(event.ActorEmail),
+		TokenID:    pgtypeToUUIDPtr(event.TokenID),
+		IPAddress:  stringValue(event.IpAddress),
+		UserAgent:  stringValue(event.UserAgent),
+		RequestID:  stringValue(event.RequestID),
+		Action:     event.Action,
+		BucketID:   pgtypeToUUIDPtr(event.BucketID),
+		BucketName: stringValue(event.BucketName),
+		ObjectKey:  stringValue(event.ObjectKey),
+		TargetID:   stringValue(event.TargetID),
+		Details:    details,
+		Outcome:    event.Outcome,
+		Error:      stringValue(event.Error),
+	}
+}
+
+func (r *pgAuditRepository) Create(ctx context.Context, event *AuditEvent) (*AuditEvent, error) {
+	details := event.Details
+	if details == nil {
+		details = map[string]string{}
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.q.CreateAuditEvent(ctx, sqlc.CreateAuditEventParams{
+		ActorID:    uuidPtrToPgtype(event.ActorID),
+		ActorEmail: optionalString(event.ActorEmail),
+		TokenID:    uuidPtrToPgtype(event.TokenID),
+		IpAddress:  optionalString(event.IPAddress),
+		UserAgent:  optionalString(event.UserAgent),
+		RequestID:  optionalString(event.RequestID),
+		Action:     event.Action,
+		BucketID:   uuidPtrToPgtype(event.BucketID),
+		BucketName: optionalString(event.BucketName),
+		ObjectKey:  optionalString(event.ObjectKey),
+		TargetID:   optionalString(event.TargetID),
+		Details:    encoded,
+		Outcome:    event.Outcome,
+		Error:      optionalString(event.Error),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAuditEvent(created), nil
+}
+
+func (r *pgAuditRepository) List(ctx context.Context, filter AuditFilter) ([]*AuditEvent, error) {
+	params := sqlc.ListAuditEventsParams{
+		ActorID:  uuidPtrToPgtype(filter.ActorID),
+		Action:   optionalString(filter.Action),
+		BucketID: uuidPtrToPgtype(filter.BucketID),
+		Outcome:  optionalString(filter.Outcome),
+		Since:    timePtrToPgtype(filter.Since),
+		Until:    timePtrToPgtype(filter.Until),
+		MaxRows:  int32(filter.Limit),
+	}
+	if filter.BeforeID > 0 {
+		params.BeforeID = &filter.BeforeID
+	}
+
+	rows, err := r.q.ListAuditEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*AuditEvent, len(rows))
+	for i, row := range rows {
+		events[i] = toAuditEvent(row)
+	}
+	return events, nil
+}
+
+func (r *pgAuditRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.q.DeleteAuditEventsBefore(ctx, timeToPgtype(before))
+}
+
+// ========== WebhookRepository implementation ==========
+
+type pgWebhookRepository struct {
+	q *sqlc.Queries
+}
+
+func toWebhook(webhook sqlc.Webhook) *Webhook {
+	return &Webhook{
+		ID:        pgtypeToUUID(webhook.ID),
+		BucketID:  pgtypeToUUIDPtr(webhook.BucketID),
+		URL:       webhook.Url,
+		Events:    webhook.Events,
+		KeyPrefix: webhook.KeyPrefix,
+		KeySuffix: webhook.KeySuffix,
+		Secret:    webhook.Secret,
+		Active:    webhook.Active,
+		CreatedAt: pgtypeToTime(webhook.CreatedAt),
+		UpdatedAt: pgtypeToTime(webhook.UpdatedAt),
+	}
+}
+
+func toWebhooks(rows []sqlc.Webhook) []*Webhook {
+	webhooks := make([]*Webhook, len(rows))
+	for i, row := range rows {
+		webhooks[i] = toWebhook(row)
+	}
+	return webhooks
+}
+
+func (r *pgWebhookRepository) Create(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	created, err := r.q.CreateWebhook(ctx, sqlc.CreateWebhookParams{
+		ID:        uuidToPgtype(uuid.New()),
+		BucketID:  uuidPtrToPgtype(webhook.BucketID),
+		Url:       webhook.URL,
+		Events:    webhook.Events,
+		KeyPrefix: webhook.KeyPrefix,
+		KeySuffix: webhook.KeySuffix,
+		Secret:    webhook.Secret,
+		Active:    webhook.Active,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toWebhook(created), nil
+}
+
+func (r *pgWebhookRepository) Get(ctx context.Context, id, bucketID uuid.UUID) (*Webhook, error) {
+	webhook, err := r.q.GetWebhook(ctx, sqlc.GetWebhookParams{
+		ID:       uuidToPgtype(id),
+		BucketID: uuidToPgtype(bucketID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toWebhook(webhook), nil
+}
+
+func (r *pgWebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	webhook, err := r.q.GetWebhookByID(ctx, uuidToPgtype(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toWebhook(webhook), nil
+}
+
+func (r *pgWebhookRepository) List(ctx context.Context, bucketID uuid.UUID) ([]*Webhook, error) {
+	rows, err := r.q.ListWebhooks(ctx, uuidToPgtype(bucketID))
+	if err != nil {
+		return nil, err
+	}
+	return toWebhooks(rows), nil
+}
+
+func (r *pgWebhookRepository) ListForEvent(ctx context.Context, bucketID uuid.UUID, event string) ([]*Webhook, error) {
+	rows, err := r.q.ListWebhooksForEvent(ctx, sqlc.ListWebhooksForEventParams{
+		BucketID: uuidToPgtype(bucketID),
+		Event:    event,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toWebhooks(rows), nil
+}
+
+func (r *pgWebhookRepository) Update(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	updated, err := r.q.UpdateWebhook(ctx, sqlc.UpdateWebhookParams{
+		ID:        uuidToPgtype(webhook.ID),
+		BucketID:  uuidPtrToPgtype(webhook.BucketID),
+		Url:       webhook.URL,
+		Events:    webhook.Events,
+		KeyPrefix: webhook.KeyPrefix,
+		KeySuffix: webhook.KeySuffix,
+		Active:    webhook.Active,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toWebhook(updated), nil
+}
+
+func (r *pgWebhookRepository) Delete(ctx context.Context, id, bucketID uuid.UUID) error {
+	return r.q.DeleteWebhook(ctx, sqlc.DeleteWebhookParams{
+		ID:       uuidToPgtype(id),
+		BucketID: uuidToPgtype(bucketID),
+	})
+}
+
+func (r *pgWebhookRepository) DeleteOrphaned(ctx context.Context) (int64, error) {
+	return r.q.DeleteOrphanedWebhooks(ctx)
+}
+
+func (r *pgWebhookRepository) ListAllForUpdate(ctx context.Context) ([]*Webhook, error) {
+	rows, err := r.q.ListWebhooksForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhooks(rows), nil
+}
+
+func (r *pgWebhookRepository) UpdateSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	return r.q.UpdateWebhookSecret(ctx, sqlc.UpdateWebhookSecretParams{
+		ID:     uuidToPgtype(id),
+		Secret: secret,
+	})
+}
+
+// ========== WebhookDeliveryRepository implementation ==========
+
+type pgWebhookDeliveryRepository struct {
+	q *sqlc.Queries
+}
+
+func toWebhookDelivery(delivery sqlc.WebhookDelivery) *WebhookDelivery {
+	result := &WebhookDelivery{
+		ID:            pgtypeToUUID(delivery.ID),
+		WebhookID:     pgtypeToUUID(delivery.WebhookID),
+		EventID:       pgtypeToUUID(delivery.EventID),
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        delivery.Status,
+		Attempts:      int(delivery.Attempts),
+		NextAttemptAt: pgtypeToTime(delivery.NextAttemptAt),
+		LastAttemptAt: pgtypeToTimePtr(delivery.LastAttemptAt),
+		ResponseBody:  stringValue(delivery.ResponseBody),
+		Error:         stringValue(delivery.Error),
+		RedeliveryOf:  pgtypeToUUIDPtr(delivery.RedeliveryOf),
+		CreatedAt:     pgtypeToTime(delivery.CreatedAt),
+		CompletedAt:   pgtypeToTimePtr(delivery.CompletedAt),
+	}
+	if delivery.ResponseStatus != nil {
+		result.ResponseStatus = int(*delivery.ResponseStatus)
+	}
+	return result
+}
+
+func toWebhookDeliveries(rows []sqlc.WebhookDelivery) []*WebhookDelivery {
+	deliveries := make([]*WebhookDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = toWebhookDelivery(row)
+	}
+	return deliveries
+}
+
+func (r *pgWebhookDeliveryRepository) Create(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error) {
+	nextAttemptAt := delivery.NextAttemptAt
+	if nextAttemptAt.IsZero() {
+		nextAttemptAt = time.Now()
+	}
+	created, err := r.q.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+		ID:            uuidToPgtype(uuid.New()),
+		WebhookID:     uuidToPgtype(delivery.WebhookID),
+		EventID:       uuidToPgtype(delivery.EventID),
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		RedeliveryOf:  uuidPtrToPgtype(delivery.RedeliveryOf),
+		NextAttemptAt: timeToPgtype(nextAttemptAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookDelivery(created), nil
+}
+
+func (r *pgWebhookDeliveryRepository) Get(ctx context.Context, id, webhookID uuid.UUID) (*WebhookDelivery, error) {
+	delivery, err := r.q.GetWebhookDelivery(ctx, sqlc.GetWebhookDeliveryParams{
+		ID:        uuidToPgtype(id),
+		WebhookID: uuidToPgtype(webhookID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toWebhookDelivery(delivery), nil
+}
+
+func (r *pgWebhookDeliveryRepository) List(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]*WebhookDelivery, error) {
+	rows, err := r.q.ListWebhookDeliveries(ctx, sqlc.ListWebhookDeliveriesParams{
+		WebhookID: uuidToPgtype(webhookID),
+		Status:    optionalString(status),
+		MaxRows:   int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookDeliveries(rows), nil
+}
+
+func (r *pgWebhookDeliveryRepository) Enqueue(ctx context.Context, delivery *WebhookDelivery) error {
+	return r.q.EnqueueWebhookDelivery(ctx, sqlc.EnqueueWebhookDeliveryParams{
+		ID:        uuidToPgtype(uuid.New()),
+		WebhookID: uuidToPgtype(delivery.WebhookID),
+		EventID:   uuidToPgtype(delivery.EventID),
+		Event:     delivery.Event,
+		Payload:   delivery.Payload,
+	})
+}
+
+func (r *pgWebhookDeliveryRepository) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*WebhookDelivery, error) {
+	rows, err := r.q.ClaimWebhookDeliveries(ctx, sqlc.ClaimWebhookDeliveriesParams{
+		LeaseUntil: timeToPgtype(leaseUntil),
+		MaxRows:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookDeliveries(rows), nil
+}
+
+func (r *pgWebhookDeliveryRepository) RecordAttempt(ctx context.Context, attempt WebhookAttempt) error {
+	params := sqlc.RecordWebhookAttemptParams{
+		ID:            uuidToPgtype(attempt.DeliveryID),
+		Status:        attempt.Status,
+		NextAttemptAt: timeToPgtype(attempt.NextAttemptAt),
+		ResponseBody:  optionalString(attempt.ResponseBody),
+		Error:         optionalString(attempt.Error),
+	}
+	if attempt.ResponseStatus != 0 {
+		status := int32(attempt.ResponseStatus)
+		params.ResponseStatus = &status
+	}
+	return r.q.RecordWebhookAttempt(ctx, params)
+}
+
+func (r *pgWebhookDeliveryRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.q.DeleteWebhookDeliveriesBefore(ctx, timeToPgtype(before))
+}
+
+// ========== OutboxRepository implementation ==========
+
+type pgOutboxRepository struct {
+	q *sqlc.Queries
+}
+
+func toOutboxEvent(event sqlc.OutboxEvent) *OutboxEvent {
+	return &OutboxEvent{
+		ID:            pgtypeToUUID(event.ID),
+		Type:          event.Type,
+		BucketID:      pgtypeToUUIDPtr(event.BucketID),
+		Payload:       event.Payload,
+		OccurredAt:    pgtypeToTime(event.OccurredAt),
+		Status:        event.Status,
+		Attempts:      int(event.Attempts),
+		NextAttemptAt: pgtypeToTime(event.NextAttemptAt),
+		LastError:     stringValue(event.LastError),
+		DispatchedAt:  pgtypeToTimePtr(event.DispatchedAt),
+	}
+}
+
+func (r *pgOutboxRepository) Create(ctx context.Context, event *OutboxEvent) error {
+	return r.q.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
+		ID:         uuidToPgtype(event.ID),
+		Type:       event.Type,
+		BucketID:   uuidPtrToPgtype(event.BucketID),
+		Payload:    event.Payload,
+		OccurredAt: timeToPgtype(event.OccurredAt),
+	})
+}
+
+func (r *pgOutboxRepository) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*OutboxEvent, error) {
+	rows, err := r.q.ClaimOutboxEvents(ctx, sqlc.ClaimOutboxEventsParams{
+		LeaseUntil: timeToPgtype(leaseUntil),
+		MaxRows:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*OutboxEvent, len(rows))
+	for i, row := range rows {
+		events[i] = toOutboxEvent(row)
+	}
+	return events, nil
+}
+
+func (r *pgOutboxRepository) MarkDispatched(ctx context.Context, id uuid.UUID) error {
+	return r.q.MarkOutboxEventDispatched(ctx, uuidToPgtype(id))
+}
+
+func (r *pgOutboxRepository) RecordFailure(ctx context.Context, id uuid.UUID, status string, nextAttemptAt time.Time, lastError string) error {
+	return r.q.RecordOutboxEventFailure(ctx, sqlc.RecordOutboxEventFailureParams{
+		ID:            uuidToPgtype(id),
+		Status:        status,
+		NextAttemptAt: timeToPgtype(nextAttemptAt),
+		LastError:     optionalString(lastError),
+	})
+}
+
+func (r *pgOutboxRepository) HandledBy(ctx context.Context, id uuid.UUID) ([]string, error) {
+	return r.q.ListOutboxEventHandlers(ctx, uuidToPgtype(id))
+}
+
+func (r *pgOutboxRepository) MarkHandled(ctx context.Context, id uuid.UUID, subscriber string) error {
+	return r.q.CreateOutboxEventHandler(ctx, sqlc.CreateOutboxEventHandlerParams{
+		EventID:    uuidToPgtype(id),
+		Subscriber: subscriber,
+	})
+}
+
+func (r *pgOutboxRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.q.DeleteOutboxEventsBefore(ctx, timeToPgtype(before))
+}
+
+// ========== QuotaRepository implementation ==========
+
+type pgQuotaRepository struct {
+	q *sqlc.Queries
+}
+
+func toQuota(quota sqlc.Quota, subjectName string) *Quota {
+	return &Quota{
+		ID:          pgtypeToUUID(quota.ID),
+		Scope:       quota.Scope,
+		SubjectID:   pgtypeToUUID(quota.SubjectID),
+		SubjectName: subjectName,
+		MaxBytes:    quota.MaxBytes,
+		MaxObjects:  quota.MaxObjects,
+		WarnPercent: int(quota.WarnPercent),
+		CreatedAt:   pgtypeToTime(quota.CreatedAt),
+		UpdatedAt:   pgtypeToTime(quota.UpdatedAt),
+	}
+}
+
+func toQuotaReservation(reservation sqlc.QuotaReservation) *QuotaReservation {
+	return &QuotaReservation{
+		ID:          pgtypeToUUID(reservation.ID),
+		UserID:      pgtypeToUUID(reservation.UserID),
+		BucketID:    pgtypeToUUID(reservation.BucketID),
+		Bytes:       reservation.Bytes,
+		Objects:     reservation.Objects,
+		ExpiresAt:   pgtypeToTime(reservation.ExpiresAt),
+		CommittedAt: pgtypeToTimePtr(reservation.CommittedAt),
+		CreatedAt:   pgtypeToTime(reservation.CreatedAt),
+	}
+}
+
+func (r *pgQuotaRepository) Upsert(ctx context.Context, quota *Quota) (*Quota, error) {
+	saved, err := r.q.UpsertQuota(ctx, sqlc.UpsertQuotaParams{
+		ID:          uuidToPgtype(uuid.New()),
+		Scope:       quota.Scope,
+		SubjectID:   uuidToPgtype(quota.SubjectID),
+		MaxBytes:    quota.MaxBytes,
+		MaxObjects:  quota.MaxObjects,
+		WarnPercent: int32(quota.WarnPercent),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toQuota(saved, quota.SubjectName), nil
+}
+
+func (r *pgQuotaRepository) List(ctx context.Context) ([]*Quota, error) {
+	rows, err := r.q.ListQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quotas := make([]*Quota, len(rows))
+	for i, row := range rows {
+		quotas[i] = toQuota(row.Quota, row.SubjectName)
+	}
+	return quotas, nil
+}
+
+func (r *pgQuotaRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]*Quota, error) {
+	rows, err := r.q.ListQuotasForUser(ctx, uuidToPgtype(userID))
+	if err != nil {
+		return nil, err
+	}
+	quotas := make([]*Quota, len(rows))
+	for i, row := range rows {
+		quotas[i] = toQuota(row.Quota, row.SubjectName)
+	}
+	return quotas, nil
+}
+
+func (r *pgQuotaRepository) LockForWrite(ctx context.Context, userID, bucketID uuid.UUID) ([]*Quota, error) {
+	rows, err := r.q.LockQuotasForWrite(ctx, sqlc.LockQuotasForWriteParams{
+		UserID:   uuidToPgtype(userID),
+		BucketID: uuidToPgtype(bucketID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	quotas := make([]*Quota, len(rows))
+	for i, row := range rows {
+		quotas[i] = toQuota(row, "")
+	}
+	return quotas, nil
+}
+
+func (r *pgQuotaRepository) Delete(ctx context.Context, scope string, subjectID uuid.UUID) (bool, error) {
+	deleted, err := r.q.DeleteQuota(ctx, sqlc.DeleteQuotaParams{
+		Scope:     scope,
+		SubjectID: uuidToPgtype(subjectID),
+	})
+	return deleted > 0, err
+}
+
+func (r *pgQuotaRepository) DeleteOrphaned(ctx context.Context) (int64, error) {
+	return r.q.DeleteOrphanedQuotas(ctx)
+}
+
+func (r *pgQuotaRepository) Usage(ctx context.Context, scope string, subjectID uuid.UUID) (*QuotaUsage, error) {
+	id := uuidToPgtype(subjectID)
+	switch scope {
+	case QuotaScopeUser:
+		stored, err := r.q.GetUserStorageUsage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		reserved, err := r.q.GetUserReservedStorage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &QuotaUsage{Bytes: stored.Bytes, Objects: stored.Objects, ReservedBytes: reserved.Bytes, ReservedObjects: reserved.Objects}, nil
+	case QuotaScopeTeam:
+		stored, err := r.q.GetTeamStorageUsage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		reserved, err := r.q.GetTeamReservedStorage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &QuotaUsage{Bytes: stored.Bytes, Objects: stored.Objects, ReservedBytes: reserved.Bytes, ReservedObjects: reserved.Objects}, nil
+	case QuotaScopeBucket:
+		stored, err := r.q.GetBucketStorageUsage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		reserved, err := r.q.GetBucketReservedStorage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &QuotaUsage{Bytes: stored.Bytes, Objects: stored.Objects, ReservedBytes: reserved.Bytes, ReservedObjects: reserved.Objects}, nil
+	default:
+		return nil, fmt.Errorf("unknown quota scope %q", scope)
+	}
+}
+
+func (r *pgQuotaRepository) CreateReservation(ctx context.Context, reservation *QuotaReservation) (*QuotaReservation, error) {
+	created, err := r.q.CreateQuotaReservation(ctx, sqlc.CreateQuotaReservationParams{
+		ID:        uuidToPgtype(uuid.New()),
+		UserID:    uuidToPgtype(reservation.UserID),
+		BucketID:  uuidToPgtype(reservation.BucketID),
+		Bytes:     reservation.Bytes,
+		Objects:   reservation.Objects,
+		ExpiresAt: timeToPgtype(reservation.ExpiresAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toQuotaReservation(created), nil
+}
+
+func (r *pgQuotaRepository) CommitReservation(ctx context.Context, id uuid.UUID, committedAt, expiresAt time.Time) error {
+	return r.q.CommitQuotaReservation(ctx, sqlc.CommitQuotaReservationParams{
+		ID:          uuidToPgtype(id),
+		CommittedAt: timeToPgtype(committedAt),
+		ExpiresAt:   timeToPgtype(expiresAt),
+	})
+}
+
+func (r *pgQuotaRepository) DeleteReservation(ctx context.Context, id uuid.UUID) error {
+	return r.q.DeleteQuotaReservation(ctx, uuidToPgtype(id))
+}
+
+func (r *pgQuotaRepository) DeleteCommittedReservations(ctx context.Context, bucketID uuid.UUID, before time.Time) error {
+	return r.q.DeleteCommittedQuotaReservations(ctx, sqlc.DeleteCommittedQuotaReservationsParams{
+		BucketID:    uuidToPgtype(bucketID),
+		CommittedAt: timeToPgtype(before),
+	})
+}
+
+func (r *pgQuotaRepository) DeleteExpiredReservations(ctx context.Context) ([]*QuotaReservation, error) {
+	rows, err := r.q.DeleteExpiredQuotaReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reservations := make([]*QuotaReservation, len(rows))
+	for i, row := range rows {
+		reservations[i] = toQuotaReservation(row)
+	}
+	return reservations, nil
+}
+
+// ========== UsageRepository implementation ==========
+
+type pgUsageRepository struct {
+	q *sqlc.Queries
+}
+
+func toUsageSnapshot(snapshot sqlc.UsageSnapshot) *UsageSnapshot {
+	return &UsageSnapshot{
+		ID:          snapshot.ID,
+		BucketID:    pgtypeToUUID(snapshot.BucketID),
+		Prefix:      snapshot.Prefix,
+		SizeBytes:   snapshot.SizeBytes,
+		ObjectCount: snapshot.ObjectCount,
+		CapturedAt:  pgtypeToTime(snapshot.CapturedAt),
+	}
+}
+
+func toUsageAlert(alert sqlc.UsageAlert) *UsageAlert {
+	return &UsageAlert{
+		ID:                    pgtypeToUUID(alert.ID),
+		BucketID:              pgtypeToUUID(alert.BucketID),
+		MaxDailyGrowthBytes:   alert.MaxDailyGrowthBytes,
+		MaxDailyGrowthObjects: alert.MaxDailyGrowthObjects,
+		LastTriggeredAt:       pgtypeToTimePtr(alert.LastTriggeredAt),
+		CreatedAt:             pgtypeToTime(alert.CreatedAt),
+		UpdatedAt:             pgtypeToTime(alert.UpdatedAt),
+	}
+}
+
+func (r *pgUsageRepository) ClaimBucketsForSnapshot(ctx context.Context, dueBefore time.Time, limit int) ([]*Bucket, error) {
+	rows, err := r.q.ClaimBucketsForUsageSnapshot(ctx, sqlc.ClaimBucketsForUsageSnapshotParams{
+		DueBefore: timeToPgtype(dueBefore),
+		MaxRows:   int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([]*Bucket, len(rows))
+	for i, b := range rows {
+		buckets[i] = &Bucket{
+			ID:            pgtypeToUUID(b.ID),
+			UserID:        pgtypeToUUID(b.UserID),
+			CredentialID:  pgtypeToUUID(b.CredentialID),
+			Name:          b.Name,
+			Region:        b.Region,
+			Description:   b.Description,
+			SizeBytes:     b.SizeBytes,
+			ObjectCount:   b.ObjectCount,
+			SizeUpdatedAt: pgtypeToTimePtr(b.SizeUpdatedAt),
+			CreatedAt:     pgtypeToTime(b.CreatedAt),
+			UpdatedAt:     pgtypeToTime(b.UpdatedAt),
+		}
+	}
+	return buckets, nil
+}
+
+func (r *pgUsageRepository) CreateSnapshot(ctx context.Context, snapshot *UsageSnapshot) error {
+	return r.q.CreateUsageSnapshot(ctx, sqlc.CreateUsageSnapshotParams{
+		BucketID:    uuidToPgtype(snapshot.BucketID),
+		Prefix:      snapshot.Prefix,
+		SizeBytes:   snapshot.SizeBytes,
+		ObjectCount: snapshot.ObjectCount,
+		CapturedAt:  timeToPgtype(snapshot.CapturedAt),
+	})
+}
+
+func (r *pgUsageRepository) ListSnapshots(ctx context.Context, bucketID uuid.UUID, prefix *string, since time.Time) ([]*UsageSnapshot, error) {
+	rows, err := r.q.ListUsageSnapshots(ctx, sqlc.ListUsageSnapshotsParams{
+		BucketID: uuidToPgtype(bucketID),
+		Prefix:   prefix,
+		Since:    timeToPgtype(since),
+	})
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*UsageSnapshot, len(rows))
+	for i, row := range rows {
+		snapshots[i] = toUsageSnapshot(row)
+	}
+	return snapshots, nil
+}
+
+func (r *pgUsageRepository) GetBaseline(ctx context.Context, bucketID uuid.UUID, prefix string, at time.Time) (*UsageSnapshot, error) {
+	snapshot, err := r.q.GetLatestUsageSnapshotBefore(ctx, sqlc.GetLatestUsageSnapshotBeforeParams{
+		BucketID:   uuidToPgtype(bucketID),
+		Prefix:     prefix,
+		CapturedAt: timeToPgtype(at),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		snapshot, err = r.q.GetFirstUsageSnapshot(ctx, sqlc.GetFirstUsageSnapshotParams{
+			BucketID: uuidToPgtype(bucketID),
+			Prefix:   prefix,
+		})
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toUsageSnapshot(snapshot), nil
+}
+
+func (r *pgUsageRepository) DeleteSnapshotsBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.q.DeleteUsageSnapshotsBefore(ctx, timeToPgtype(before))
+}
+
+func (r *pgUsageRepository) GetAlert(ctx context.Context, bucketID uuid.UUID) (*UsageAlert, error) {
+	alert, err := r.q.GetUsageAlert(ctx, uuidToPgtype(bucketID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toUsageAlert(alert), nil
+}
+
+func (r *pgUsageRepository) UpsertAlert(ctx context.Context, alert *UsageAlert) (*UsageAlert, error) {
+	saved, err := r.q.UpsertUsageAlert(ctx, sqlc.UpsertUsageAlertParams{
+		ID:                    uuidToPgtype(uuid.New()),
+		BucketID:              uuidToPgtype(alert.BucketID),
+		MaxDailyGrowthBytes:   alert.MaxDailyGrowthBytes,
+		MaxDailyGrowthObjects: alert.MaxDailyGrowthObjects,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toUsageAlert(saved), nil
+}
+
+func (r *pgUsageRepository) DeleteAlert(ctx context.Context, bucketID uuid.UUID) (bool, error) {
+	deleted, err := r.q.DeleteUsageAlert(ctx, uuidToPgtype(bucketID))
+	return deleted > 0, err
+}
+
+func (r *pgUsageRepository) MarkAlertTriggered(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.q.MarkUsageAlertTriggered(ctx, sqlc.MarkUsageAlertTriggeredParams{
+		ID:              uuidToPgtype(id),
+		LastTriggeredAt: timeToPgtype(at),
+	})
+}
+
+func toUsageReport(report sqlc.UsageReport) *UsageReport {
+	result := &UsageReport{
+		ID:          pgtypeToUUID(report.ID),
+		BucketID:    pgtypeToUUID(report.BucketID),
+		Prefix:      report.Prefix,
+		Depth:       int(report.Depth),
+		Status:      report.Status,
+		Result:      report.Result,
+		RequestedAt: pgtypeToTime(report.RequestedAt),
+		StartedAt:   pgtypeToTimePtr(report.StartedAt),
+		CompletedAt: pgtypeToTimePtr(report.CompletedAt),
+	}
+	if report.Error != nil {
+		result.Error = *report.Error
+	}
+	return result
+}
+
+func (r *pgUsageRepository) GetReport(ctx context.Context, bucketID uuid.UUID, prefix string, depth int) (*UsageReport, error) {
+	report, err := r.q.GetUsageReport(ctx, sqlc.GetUsageReportParams{
+		BucketID: uuidToPgtype(bucketID),
+		Prefix:   prefix,
+		Depth:    int32(depth),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toUsageReport(report), nil
+}
+
+func (r *pgUsageRepository) RequestReport(ctx context.Context, bucketID uuid.UUID, prefix string, depth int) (*UsageReport, error) {
+	report, err := r.q.RequestUsageReport(ctx, sqlc.RequestUsageReportParams{
+		ID:       uuidToPgtype(uuid.New()),
+		BucketID: uuidToPgtype(bucketID),
+		Prefix:   prefix,
+		Depth:    int32(depth),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Already pending or running
+		return r.GetReport(ctx, bucketID, prefix, depth)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toUsageReport(report), nil
+}
+
+func (r *pgUsageRepository) SaveReport(ctx context.Context, report *UsageReport) (*UsageReport, error) {
+	params := sqlc.SaveUsageReportParams{
+		ID:       uuidToPgtype(uuid.New()),
+		BucketID: uuidToPgtype(report.BucketID),
+		Prefix:   report.Prefix,
+		Depth:    int32(report.Depth),
+		Result:   report.Result,
+	}
+	if report.StartedAt != nil {
+		params.StartedAt = timeToPgtype(*report.StartedAt)
+	}
+	saved, err := r.q.SaveUsageReport(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return toUsageReport(saved), nil
+}
+
+func (r *pgUsageRepository) ClaimReports(ctx context.Context, staleBefore time.Time, limit int) ([]*UsageReport, error) {
+	rows, err := r.q.ClaimUsageReports(ctx, sqlc.ClaimUsageReportsParams{
+		StaleBefore: timeToPgtype(staleBefore),
+		MaxRows:     int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]*UsageReport, len(rows))
+	for i, row := range rows {
+		reports[i] = toUsageReport(row)
+	}
+	return reports, nil
+}
+
+func (r *pgUsageRepository) CompleteReport(ctx context.Context, id uuid.UUID, result []byte) error {
+	return r.q.CompleteUsageReport(ctx, sqlc.CompleteUsageReportParams{
+		ID:     uuidToPgtype(id),
+		Result: result,
+	})
+}
+
+func (r *pgUsageRepository) FailReport(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.q.FailUsageReport(ctx, sqlc.FailUsageReportParams{
+		ID:    uuidToPgtype(id),
+		Error: &reason,
+	})
+}
+
+func (r *pgUsageRepository) DeleteReportsBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.q.DeleteUsageReportsBefore(ctx, timeToPgtype(before))
+}
+
+// ========== CostRepository implementation ==========
+
+type pgCostRepository struct {
+	q *sqlc.Queries
+}
+
+// dateToPgtype returns the UTC day of t
+func dateToPgtype(t time.Time) pgtype.Date {
+	year, month, day := t.UTC().Date()
+	return pgtype.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Valid: true}
+}
+
+func (r *pgCostRepository) AddRequestCounts(ctx context.Context, counts *RequestCounts) error {
+	return r.q.AddBucketRequestCounts(ctx, sqlc.AddBucketRequestCountsParams{
+		BucketID:      uuidToPgtype(counts.BucketID),
+		Day:           dateToPgtype(counts.Day),
+		WriteRequests: counts.WriteRequests,
+		ListRequests:  counts.ListRequests,
+		ReadRequests:  counts.ReadRequests,
+		EgressBytes:   counts.EgressBytes,
+	})
+}
+
+func (r *pgCostRepository) ListRequestTotals(ctx context.Context, since time.Time) ([]*RequestCounts, error) {
+	rows, err := r.q.ListBucketRequestTotals(ctx, dateToPgtype(since))
+	if err != nil {
+		return nil, err
+	}
+	totals := make([]*RequestCounts, len(rows))
+	for i, row := range rows {
+		totals[i] = &RequestCounts{
+			BucketID:      pgtypeToUUID(row.BucketID),
+			Day:           dateToPgtype(since).Time,
+			WriteRequests: row.WriteRequests,
+			ListRequests:  row.ListRequests,
+			ReadRequests:  row.ReadRequests,
+			EgressBytes:   row.EgressBytes,
+		}
+	}
+	return totals, nil
+}
+
+func (r *pgCostRepository) DeleteRequestCountsBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.q.DeleteBucketRequestCountsBefore(ctx, dateToPgtype(before))
+}
+
+func (r *pgCostRepository) ListBuckets(ctx context.Context, userID, teamID *uuid.UUID) ([]*CostBucket, error) {
+	rows, err := r.q.ListBucketsForCosts(ctx, sqlc.ListBucketsForCostsParams{
+		UserID: uuidPtrToPgtype(userID),
+		TeamID: uuidPtrToPgtype(teamID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([]*CostBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = &CostBucket{
+			Bucket: Bucket{
+				ID:            pgtypeToUUID(row.Bucket.ID),
+				UserID:        pgtypeToUUID(row.Bucket.UserID),
+				CredentialID:  pgtypeToUUID(row.Bucket.CredentialID),
+				Name:          row.Bucket.Name,
+				Region:        row.Bucket.Region,
+				Description:   row.Bucket.Description,
+				SizeBytes:     row.Bucket.SizeBytes,
+				ObjectCount:   row.Bucket.ObjectCount,
+				SizeUpdatedAt: pgtypeToTimePtr(row.Bucket.SizeUpdatedAt),
+				CreatedAt:     pgtypeToTime(row.Bucket.CreatedAt),
+				UpdatedAt:     pgtypeToTime(row.Bucket.UpdatedAt),
+			},
+			CredentialName:     row.CredentialName,
+			CredentialProvider: row.CredentialProvider,
+			CredentialRegion:   row.CredentialRegion,
+			OwnerEmail:         row.OwnerEmail,
+		}
+	}
+	return buckets, nil
+}
+
+func (r *pgCostRepository) ListTeamMembers(ctx context.Context) ([]*TeamAssignment, error) {
+	rows, err := r.q.ListAllTeamMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*TeamAssignment, len(rows))
+	for i, row := range rows {
+		members[i] = &TeamAssignment{
+			TeamID:   pgtypeToUUID(row.TeamID),
+			TeamName: row.TeamName,
+			UserID:   pgtypeToUUID(row.UserID),
+		}
+	}
+	return members, nil
+}
+
+func (r *pgCostRepository) ListLatestRootReports(ctx context.Context) (map[uuid.UUID][]byte, error) {
+	rows, err := r.q.ListRootUsageReports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Rows are ordered by completion, so the newest report of a bucket wins
+	reports := make(map[uuid.UUID][]byte, len(rows))
+	for _, row := range rows {
+		reports[pgtypeToUUID(row.BucketID)] = row.Result
+	}
+	return reports, nil
+}
+
+// ========== TagJobRepository implementation ==========
+
+type pgTagJobRepository struct {
+	q *sqlc.Queries
+}
+
+func toTagJob(job sqlc.TagJob) *TagJob {
+	result := &TagJob{
+		ID:          pgtypeToUUID(job.ID),
+		BucketID:    pgtypeToUUID(job.BucketID),
+		UserID:      pgtypeToUUID(job.UserID),
+		Prefix:      job.Prefix,
+		ReplaceTags: job.ReplaceTags,
+		Status:      job.Status,
+		Total:       job.Total,
+		Processed:   job.Processed,
+		Failed:      job.Failed,
+		Error:       stringValue(job.Error),
+		CreatedAt:   pgtypeToTime(job.CreatedAt),
+		StartedAt:   pgtypeToTimePtr(job.StartedAt),
+		CompletedAt: pgtypeToTimePtr(job.CompletedAt),
+	}
+	// The JSON columns are written by this repository, so decode errors only
+	// come from hand-edited rows; those fields are then left empty
+	if job.Keys != nil {
+		_ = json.Unmarshal(job.Keys, &result.Keys)
+	}
+	_ = json.Unmarshal(job.SetTags, &result.SetTags)
+	_ = json.Unmarshal(job.RemoveTags, &result.RemoveTags)
+	if job.Failures != nil {
+		_ = json.Unmarshal(job.Failures, &result.Failures)
+	}
+	return result
+}
+
+func (r *pgTagJobRepository) Create(ctx context.Context, job *TagJob) (*TagJob, error) {
+	var keys []byte
+	if job.Prefix == nil {
+		encoded, err := json.Marshal(job.Keys)
+		if err != nil {
+			return nil, err
+		}
+		keys = encoded
+	}
+	setTags := job.SetTags
+	if setTags == nil {
+		setTags = map[string]string{}
+	}
+	encodedSet, err := json.Marshal(setTags)
+	if err != nil {
+		return nil, err
+	}
+	removeTags := job.RemoveTags
+	if removeTags == nil {
+		removeTags = []string{}
+	}
+	encodedRemove, err := json.Marshal(removeTags)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.q.CreateTagJob(ctx, sqlc.CreateTagJobParams{
+		ID:          uuidToPgtype(uuid.New()),
+		BucketID:    uuidToPgtype(job.BucketID),
+		UserID:      uuidToPgtype(job.UserID),
+		Keys:        keys,
+		Prefix:      job.Prefix,
+		SetTags:     encodedSet,
+		RemoveTags:  encodedRemove,
+		ReplaceTags: job.ReplaceTags,
+		Total:       job.Total,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toTagJob(created), nil
+}
+
+func (r *pgTagJobRepository) Get(ctx context.Context, id, bucketID uuid.UUID) (*TagJob, error) {
+	job, err := r.q.GetTagJob(ctx, sqlc.GetTagJobParams{
+		ID:       uuidToPgtype(id),
+		BucketID: uuidToPgtype(bucketID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toTagJob(job), nil
+}
+
+func (r *pgTagJobRepository) List(ctx context.Context, bucketID uuid.UUID, limit int) ([]*TagJob, error) {
+	rows, err := r.q.ListTagJobs(ctx, sqlc.ListTagJobsParams{
+		BucketID: uuidToPgtype(bucketID),
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*TagJob, len(rows))
+	for i, row := range rows {
+		jobs[i] = toTagJob(row)
+	}
+	return jobs, nil
+}
+
+func (r *pgTagJobRepository) Claim(ctx context.Context, staleBefore time.Time, limit int) ([]*TagJob, error) {
+	rows, err := r.q.ClaimTagJobs(ctx, sqlc.ClaimTagJobsParams{
+		StaleBefore: timeToPgtype(staleBefore),
+		MaxRows:     int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*TagJob, len(rows))
+	for i, row := range rows {
+		jobs[i] = toTagJob(row)
+	}
+	return jobs, nil
+}
+
+func encodeTagJobFailures(failures []TagJobFailure) ([]byte, error) {
+	if len(failures) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(failures)
+}
+
+func (r *pgTagJobRepository) UpdateProgress(ctx context.Context, job *TagJob) (bool, error) {
+	failures, err := encodeTagJobFailures(job.Failures)
+	if err != nil {
+		return false, err
+	}
+	updated, err := r.q.UpdateTagJobProgress(ctx, sqlc.UpdateTagJobProgressParams{
+		ID:        uuidToPgtype(job.ID),
+		Processed: job.Processed,
+		Failed:    job.Failed,
+		Failures:  failures,
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+func (r *pgTagJobRepository) Finish(ctx context.Context, job *TagJob) error {
+	failures, err := encodeTagJobFailures(job.Failures)
+	if err != nil {
+		return err
+	}
+	_, err = r.q.FinishTagJob(ctx, sqlc.FinishTagJobParams{
+		ID:        uuidToPgtype(job.ID),
+		Status:    job.Status,
+		Processed: job.Processed,
+		Failed:    job.Failed,
+		Failures:  failures,
+		Error:     optionalString(job.Error),
+	})
+	return err
+}
+
+func (r *pgTagJobRepository) Cancel(ctx context.Context, id, bucketID uuid.UUID) (bool, error) {
+	canceled, err := r.q.CancelTagJob(ctx, sqlc.CancelTagJobParams{
+		ID:       uuidToPgtype(id),
+		BucketID: uuidToPgtype(bucketID),
+	})
+	if err != nil {
+		return false, err
+	}
+	return canceled > 0, nil
+}
+
+func (r *pgTagJobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.q.DeleteTagJobsBefore(ctx, timeToPgtype(before))
+}
+
+// ========== TagSearchRepository implementation ==========
+
+type pgTagSearchRepository struct {
+	q *sqlc.Queries
+}
+
+func toTagSearch(search sqlc.TagSearch) *TagSearch {
+	result := &TagSearch{
+		ID:          pgtypeToUUID(search.ID),
+		BucketID:    pgtypeToUUID(search.BucketID),
+		UserID:      pgtypeToUUID(search.UserID),
+		Prefix:      search.Prefix,
+		Status:      search.Status,
+		Scanned:     search.Scanned,
+		Truncated:   search.Truncated,
+		Error:       stringValue(search.Error),
+		CreatedAt:   pgtypeToTime(search.CreatedAt),
+		StartedAt:   pgtypeToTimePtr(search.StartedAt),
+		CompletedAt: pgtypeToTimePtr(search.CompletedAt),
+	}
+	// As with tag jobs, only hand-edited rows fail to decode
+	_ = json.Unmarshal(search.Filters, &result.Filters)
+	if search.Matches != nil {
+		_ = json.Unmarshal(search.Matches, &result.Matches)
+	}
+	return result
+}
+
+func (r *pgTagSearchRepository) Create(ctx context.Context, search *TagSearch) (*TagSearch, error) {
+	filters, err := json.Marshal(search.Filters)
+	if err != nil {
+		return nil, err
+	}
+	created, err := r.q.CreateTagSearch(ctx, sqlc.CreateTagSearchParams{
+		ID:       uuidToPgtype(uuid.New()),
+		BucketID: uuidToPgtype(search.BucketID),
+		UserID:   uuidToPgtype(search.UserID),
+		Prefix:   search.Prefix,
+		Filters:  filters,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toTagSearch(created), nil
+}
+
+func (r *pgTagSearchRepository) Get(ctx context.Context, id, bucketID uuid.UUID) (*TagSearch, error) {
+	search, err := r.q.GetTagSearch(ctx, sqlc.GetTagSearchParams{
+		ID:       uuidToPgtype(id),
+		BucketID: uuidToPgtype(bucketID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return toTagSearch(search), nil
+}
+
+func (r *pgTagSearchRepository) Claim(ctx context.Context, staleBefore time.Time, limit int) ([]*TagSearch, error) {
+	rows, err := r.q.ClaimTagSearches(ctx, sqlc.ClaimTagSearchesParams{
+		StaleBefore: timeToPgtype(staleBefore),
+		MaxRows:     int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	searches := make([]*TagSearch, len(rows))
+	for i, row := range rows {
+		searches[i] = toTagSearch(row)
+	}
+	return searches, nil
+}
+
+func (r *pgTagSearchRepository) UpdateProgress(ctx context.Context, search *TagSearch) error {
+	_, err := r.q.UpdateTagSearchProgress(ctx, sqlc.UpdateTagSearchProgressParams{
+		ID:      uuidToPgtype(search.ID),
+		Scanned: search.Scanned,
+	})
+	return err
+}
+
+func (r *pgTagSearchRepository) Finish(ctx context.Context, search *TagSearch) error {
+	matches := []TagSearchMatch{}
+	if search.Matches != nil {
+		matches = search.Matches
+	}
+	encoded, err := json.Marshal(matches)
+	if err != nil {
+		return err
+	}
+	_, err = r.q.FinishTagSearch(ctx, sqlc.FinishTagSearchParams{
+		ID:        uuidToPgtype(search.ID),
+		Status:    search.Status,
+		Scanned:   search.Scanned,
+		Matches:   encoded,
+		Truncated: search.Truncated,
+		Error:     optionalString(search.Error),
+	})
+	return err
+}
+
+func (r *pgTagSearchRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.q.DeleteTagSearchesBefore(ctx, timeToPgtype(before))
+}
+
 var (
-	_ UserRepository       = (*pgUserRepository)(nil)
-	_ SessionRepository    = (*pgSessionRepository)(nil)
-	_ CredentialRepository = (*pgCredentialRepository)(nil)
-	_ BucketRepository     = (*pgBucketRepository)(nil)
+	_ UserRepository                = (*pgUserRepository)(nil)
+	_ SessionRepository             = (*pgSessionRepository)(nil)
+	_ CredentialRepository          = (*pgCredentialRepository)(nil)
+	_ BucketRepository              = (*pgBucketRepository)(nil)
+	_ PersonalAccessTokenRepository = (*pgPersonalAccessTokenRepository)(nil)
+	_ UserIdentityRepository        = (*pgUserIdentityRepository)(nil)
+	_ OIDCLoginRequestRepository    = (*pgOIDCLoginRequestRepository)(nil)
+	_ RecoveryCodeRepository        = (*pgRecoveryCodeRepository)(nil)
+	_ SettingsRepository            = (*pgSettingsRepository)(nil)
+	_ LoginAttemptRepository        = (*pgLoginAttemptRepository)(nil)
+	_ TeamRepository                = (*pgTeamRepository)(nil)
+	_ InviteRepository              = (*pgInviteRepository)(nil)
+	_ EmailTokenRepository          = (*pgEmailTokenRepository)(nil)
+	_ AuditRepository               = (*pgAuditRepository)(nil)
+	_ WebhookRepository             = (*pgWebhookRepository)(nil)
+	_ WebhookDeliveryRepository     = (*pgWebhookDeliveryRepository)(nil)
+	_ OutboxRepository              = (*pgOutboxRepository)(nil)
+	_ QuotaRepository               = (*pgQuotaRepository)(nil)
+	_ UsageRepository               = (*pgUsageRepository)(nil)
+	_ CostRepository                = (*pgCostRepository)(nil)
+	_ TagJobRepository              = (*pgTagJobRepository)(nil)
+	_ TagSearchRepository           = (*pgTagSearchRepository)(nil)
 )
@@ -14,15 +14,45 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
 	Update(ctx context.Context, id uuid.UUID, email, firstName, lastName string) error
 	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	SetAdmin(ctx context.Context, id uuid.UUID, isAdmin bool) error
+	SetTOTPSecret(ctx context.Context, id uuid.UUID, encryptedSecret string) error
+	EnableTOTP(ctx context.Context, id uuid.UUID) error
+	DisableTOTP(ctx context.Context, id uuid.UUID) error
+	// UpdateTOTPLastStep records the last accepted TOTP time step; it returns false if the step was already used
+	UpdateTOTPLastStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
+	// ListWithTOTPSecretForUpdate returns users with a TOTP secret, locking the rows when called in a transaction
+	ListWithTOTPSecretForUpdate(ctx context.Context) ([]*User, error)
+	// UpdateTOTPSecretCiphertext replaces the encrypted TOTP secret without changing enrollment state
+	UpdateTOTPSecretCiphertext(ctx context.Context, id uuid.UUID, encryptedSecret string) error
+	// VerifyEmail sets the user's email address and marks it as verified
+	VerifyEmail(ctx context.Context, id uuid.UUID, email string) error
+	// IncrementTokenVersion invalidates all of the user's access tokens and returns the new version
+	IncrementTokenVersion(ctx context.Context, id uuid.UUID) (int32, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
-// SessionRepository defines operations for session management
+// SessionRepository defines operations for session management.
+// Each session is a refresh token family: tokens that are rotated out or revoked
+// are retired so that presenting them again can be detected.
 type SessionRepository interface {
-	Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*Session, error)
+	Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time, userAgent, ipAddress string) (*Session, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*Session, error)
 	GetByHash(ctx context.Context, hash string) (*Session, error)
-	UpdateToken(ctx context.Context, sessionID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	// ListForUser returns the user's unexpired sessions, most recently used first
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]*Session, error)
+	// Rotate replaces the session's refresh token and retires the previous one. It returns
+	// false if the session's token was already rotated by a concurrent request.
+	Rotate(ctx context.Context, session *Session, tokenHash string, expiresAt time.Time, userAgent, ipAddress string) (bool, error)
+	// GetRetired looks up a refresh token that was rotated or revoked
+	GetRetired(ctx context.Context, hash string) (*RetiredRefreshToken, error)
+	DeleteExpiredRetired(ctx context.Context) error
 	DeleteByHash(ctx context.Context, hash string) error
+	// DeleteFamily revokes a session regardless of its owner
+	DeleteFamily(ctx context.Context, id uuid.UUID) error
+	// Delete removes one of the user's sessions; it returns false if no such session exists
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+	// DeleteOthers removes all of the user's sessions except keepID and returns how many were removed
+	DeleteOthers(ctx context.Context, userID, keepID uuid.UUID) (int64, error)
 	DeleteForUser(ctx context.Context, userID uuid.UUID) error
 }
 
@@ -33,6 +63,10 @@ type CredentialRepository interface {
 	Get(ctx context.Context, id, userID uuid.UUID) (*Credential, error)
 	Update(ctx context.Context, cred *Credential) error
 	Delete(ctx context.Context, id, userID uuid.UUID) error
+	// ListAllForUpdate returns every user's credentials, locking the rows when called in a transaction
+	ListAllForUpdate(ctx context.Context) ([]*Credential, error)
+	// UpdateSecrets replaces the encrypted keys and wrapped data key without touching other fields
+	UpdateSecrets(ctx context.Context, id uuid.UUID, encryptedAccessKey, encryptedSecretKey string, encryptedDataKey *string) error
 }
 
 // BucketRepository defines operations for bucket management
@@ -40,20 +74,304 @@ type BucketRepository interface {
 	Create(ctx context.Context, bucket *Bucket) (*Bucket, error)
 	List(ctx context.Context, userID uuid.UUID) ([]*BucketWithCredential, error)
 	Get(ctx context.Context, id, userID uuid.UUID) (*BucketWithCredential, error)
+	// GetByID looks a bucket up regardless of its owner
+	GetByID(ctx context.Context, id uuid.UUID) (*Bucket, error)
 	GetByName(ctx context.Context, userID uuid.UUID, name string) (*BucketWithCredential, error)
 	Update(ctx context.Context, id, userID uuid.UUID, description *string) error
-	UpdateSize(ctx context.Context, id uuid.UUID, sizeBytes int64) error
+	// UpdateUsage stores the bucket's recalculated size and object count
+	UpdateUsage(ctx context.Context, id uuid.UUID, sizeBytes, objectCount int64) error
 	Delete(ctx context.Context, id, userID uuid.UUID) error
 }
 
+// PersonalAccessTokenRepository defines operations for personal access token management
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *PersonalAccessToken) (*PersonalAccessToken, error)
+	List(ctx context.Context, userID uuid.UUID) ([]*PersonalAccessToken, error)
+	Get(ctx context.Context, id, userID uuid.UUID) (*PersonalAccessToken, error)
+	GetByHash(ctx context.Context, hash string) (*PersonalAccessToken, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// UserIdentityRepository defines operations for identities from external identity providers
+type UserIdentityRepository interface {
+	Create(ctx context.Context, userID uuid.UUID, provider, subject, email string) (*UserIdentity, error)
+	Get(ctx context.Context, provider, subject string) (*UserIdentity, error)
+	UpdateEmail(ctx context.Context, id uuid.UUID, email string) error
+}
+
+// OIDCLoginRequestRepository defines operations for in-flight OIDC authorization requests
+type OIDCLoginRequestRepository interface {
+	Create(ctx context.Context, state, codeVerifier, nonce string, expiresAt time.Time) (*OIDCLoginRequest, error)
+	Consume(ctx context.Context, state string) (*OIDCLoginRequest, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// RecoveryCodeRepository defines operations for two-factor recovery codes
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	// Use marks an unused code as used; it returns false if no such code exists
+	Use(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	CountUnused(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteAll(ctx context.Context, userID uuid.UUID) error
+}
+
+// SettingsRepository defines operations for instance-wide settings stored as JSON
+type SettingsRepository interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// LoginAttemptRepository tracks failed logins per account identifier
+type LoginAttemptRepository interface {
+	Get(ctx context.Context, identifier string) (*LoginAttempt, error)
+	// RecordFailure counts a failed login in a single statement, so concurrent
+	// failures are all counted. Failures before resetBefore are
+	// forgotten first, along with an expired lock.
+	RecordFailure(ctx context.Context, identifier, ipAddress string, failedAt, resetBefore time.Time) (*LoginAttempt, error)
+	// Lock locks the identifier until the given time, unless it is already
+	// locked for longer
+	Lock(ctx context.Context, identifier string, until time.Time) error
+	ListLocked(ctx context.Context) ([]*LoginAttempt, error)
+	// Delete clears the failures for an identifier; it returns false if none were recorded
+	Delete(ctx context.Context, identifier string) (bool, error)
+	// DeleteStale removes unlocked entries whose last failure is older than before
+	DeleteStale(ctx context.Context, before time.Time) error
+}
+
+// TeamRepository defines operations for teams and their members
+type TeamRepository interface {
+	Create(ctx context.Context, name string, createdBy uuid.UUID) (*Team, error)
+	Get(ctx context.Context, id uuid.UUID) (*Team, error)
+	// ListForUser returns the teams the user belongs to, with the user's role
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]*TeamMembership, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// AddMember adds a user to a team; existing memberships are left unchanged
+	AddMember(ctx context.Context, teamID, userID uuid.UUID, role string) error
+	GetMember(ctx context.Context, teamID, userID uuid.UUID) (*TeamMember, error)
+	ListMembers(ctx context.Context, teamID uuid.UUID) ([]*TeamMember, error)
+	// RemoveMember returns false if the user was not a member
+	RemoveMember(ctx context.Context, teamID, userID uuid.UUID) (bool, error)
+}
+
+// InviteRepository defines operations for registration invites
+type InviteRepository interface {
+	Create(ctx context.Context, invite *Invite) (*Invite, error)
+	Get(ctx context.Context, id uuid.UUID) (*Invite, error)
+	GetByHash(ctx context.Context, hash string) (*Invite, error)
+	List(ctx context.Context) ([]*Invite, error)
+	ListByCreator(ctx context.Context, userID uuid.UUID) ([]*Invite, error)
+	// Redeem counts one use; it returns false if the invite is used up or expired
+	Redeem(ctx context.Context, id uuid.UUID) (bool, error)
+	// Release gives back a use after a failed registration
+	Release(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// EmailTokenRepository defines operations for single-use tokens sent by email
+type EmailTokenRepository interface {
+	Create(ctx context.Context, userID uuid.UUID, purpose, tokenHash, email string, expiresAt time.Time) (*EmailToken, error)
+	// Get returns the token without using it up
+	Get(ctx context.Context, tokenHash, purpose string) (*EmailToken, error)
+	// Consume deletes and returns the token so it can only be used once
+	Consume(ctx context.Context, tokenHash, purpose string) (*EmailToken, error)
+	DeleteForUser(ctx context.Context, userID uuid.UUID, purpose string) error
+	DeleteExpired(ctx context.Context) error
+}
+
+// AuditRepository appends to and reads the audit log. Entries are never
+// modified, only deleted in bulk once they fall out of the retention period.
+type AuditRepository interface {
+	Create(ctx context.Context, event *AuditEvent) (*AuditEvent, error)
+	// List returns matching events newest first
+	List(ctx context.Context, filter AuditFilter) ([]*AuditEvent, error)
+	// DeleteBefore removes events older than before and returns how many were removed
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookRepository manages outbound webhook subscriptions
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	Get(ctx context.Context, id, bucketID uuid.UUID) (*Webhook, error)
+	// GetByID also finds webhooks whose bucket was deleted
+	GetByID(ctx context.Context, id uuid.UUID) (*Webhook, error)
+	List(ctx context.Context, bucketID uuid.UUID) ([]*Webhook, error)
+	// ListForEvent returns the bucket's active webhooks subscribed to event
+	ListForEvent(ctx context.Context, bucketID uuid.UUID, event string) ([]*Webhook, error)
+	Update(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	Delete(ctx context.Context, id, bucketID uuid.UUID) error
+	// DeleteOrphaned removes webhooks of deleted buckets once nothing is pending for them
+	DeleteOrphaned(ctx context.Context) (int64, error)
+	// ListAllForUpdate locks every webhook, for key rotation
+	ListAllForUpdate(ctx context.Context) ([]*Webhook, error)
+	UpdateSecret(ctx context.Context, id uuid.UUID, secret string) error
+}
+
+// WebhookDeliveryRepository is the queue of outgoing webhook deliveries and
+// the log of finished ones
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error)
+	Get(ctx context.Context, id, webhookID uuid.UUID) (*WebhookDelivery, error)
+	// List returns the webhook's deliveries newest first; an empty status matches all
+	List(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]*WebhookDelivery, error)
+	// Enqueue queues a delivery unless the webhook already has one for the event
+	Enqueue(ctx context.Context, delivery *WebhookDelivery) error
+	// Claim returns up to limit due deliveries and hides them from other
+	// workers until leaseUntil
+	Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, attempt WebhookAttempt) error
+	// DeleteFinishedBefore removes finished deliveries created before before
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OutboxRepository stores domain events until every subscriber has handled them
+type OutboxRepository interface {
+	Create(ctx context.Context, event *OutboxEvent) error
+	// Claim returns up to limit due events, oldest first, and hides them from
+	// other workers until leaseUntil
+	Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*OutboxEvent, error)
+	MarkDispatched(ctx context.Context, id uuid.UUID) error
+	// RecordFailure counts a failed dispatch and either schedules the next one
+	// or, with status OutboxFailed, gives up
+	RecordFailure(ctx context.Context, id uuid.UUID, status string, nextAttemptAt time.Time, lastError string) error
+	// HandledBy returns the subscribers that have already handled the event
+	HandledBy(ctx context.Context, id uuid.UUID) ([]string, error)
+	MarkHandled(ctx context.Context, id uuid.UUID, subscriber string) error
+	// DeleteFinishedBefore removes dispatched and failed events that occurred before before
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// QuotaRepository manages storage quotas and the space reserved against them
+// by writes in flight
+type QuotaRepository interface {
+	// Upsert creates the quota for its scope and subject or replaces its limits
+	Upsert(ctx context.Context, quota *Quota) (*Quota, error)
+	List(ctx context.Context) ([]*Quota, error)
+	// ListForUser returns the quotas that apply to the user: their own, their
+	// teams' and those of buckets they own
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]*Quota, error)
+	// LockForWrite returns the quotas a write by the user into the bucket counts
+	// against, locking them until the transaction ends
+	LockForWrite(ctx context.Context, userID, bucketID uuid.UUID) ([]*Quota, error)
+	// Delete returns false if no such quota exists
+	Delete(ctx context.Context, scope string, subjectID uuid.UUID) (bool, error)
+	// DeleteOrphaned removes quotas whose user, team or bucket no longer exists
+	DeleteOrphaned(ctx context.Context) (int64, error)
+	// Usage returns the stored usage of a quota subject and the space reserved against it
+	Usage(ctx context.Context, scope string, subjectID uuid.UUID) (*QuotaUsage, error)
+	CreateReservation(ctx context.Context, reservation *QuotaReservation) (*QuotaReservation, error)
+	// CommitReservation marks the write as done; the reservation is kept until
+	// the bucket usage is recalculated or expiresAt passes
+	CommitReservation(ctx context.Context, id uuid.UUID, committedAt, expiresAt time.Time) error
+	DeleteReservation(ctx context.Context, id uuid.UUID) error
+	// DeleteCommittedReservations removes the bucket's reservations committed at or before before
+	DeleteCommittedReservations(ctx context.Context, bucketID uuid.UUID, before time.Time) error
+	// DeleteExpiredReservations removes lapsed reservations and returns them
+	DeleteExpiredReservations(ctx context.Context) ([]*QuotaReservation, error)
+}
+
+// UsageRepository stores the bucket usage history and growth alerts
+type UsageRepository interface {
+	// ClaimBucketsForSnapshot returns up to limit buckets whose usage was last
+	// captured at or before dueBefore, marking them as captured now
+	ClaimBucketsForSnapshot(ctx context.Context, dueBefore time.Time, limit int) ([]*Bucket, error)
+	CreateSnapshot(ctx context.Context, snapshot *UsageSnapshot) error
+	// ListSnapshots returns the bucket's snapshots since the given time, oldest
+	// first; a nil prefix returns every prefix
+	ListSnapshots(ctx context.Context, bucketID uuid.UUID, prefix *string, since time.Time) ([]*UsageSnapshot, error)
+	// GetBaseline returns the newest snapshot of the prefix captured at or
+	// before at, or the oldest one if all are newer
+	GetBaseline(ctx context.Context, bucketID uuid.UUID, prefix string, at time.Time) (*UsageSnapshot, error)
+	DeleteSnapshotsBefore(ctx context.Context, before time.Time) (int64, error)
+	GetAlert(ctx context.Context, bucketID uuid.UUID) (*UsageAlert, error)
+	UpsertAlert(ctx context.Context, alert *UsageAlert) (*UsageAlert, error)
+	// DeleteAlert returns false if the bucket has no alert
+	DeleteAlert(ctx context.Context, bucketID uuid.UUID) (bool, error)
+	MarkAlertTriggered(ctx context.Context, id uuid.UUID, at time.Time) error
+	GetReport(ctx context.Context, bucketID uuid.UUID, prefix string, depth int) (*UsageReport, error)
+	// RequestReport queues the report for the background worker. A report that
+	// is already pending or running is left alone and returned as it is.
+	RequestReport(ctx context.Context, bucketID uuid.UUID, prefix string, depth int) (*UsageReport, error)
+	// SaveReport stores a report computed outside of the worker as ready
+	SaveReport(ctx context.Context, report *UsageReport) (*UsageReport, error)
+	// ClaimReports marks up to limit pending reports, and running ones started
+	// at or before staleBefore, as running and returns them
+	ClaimReports(ctx context.Context, staleBefore time.Time, limit int) ([]*UsageReport, error)
+	CompleteReport(ctx context.Context, id uuid.UUID, result []byte) error
+	FailReport(ctx context.Context, id uuid.UUID, reason string) error
+	// DeleteReportsBefore removes finished reports last requested before the given time
+	DeleteReportsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// CostRepository stores the requests observed per bucket and reads the data
+// cost estimates are built from
+type CostRepository interface {
+	// AddRequestCounts adds to the bucket's counts for the UTC day of counts.Day
+	AddRequestCounts(ctx context.Context, counts *RequestCounts) error
+	// ListRequestTotals sums each bucket's counts from the UTC day of since on
+	ListRequestTotals(ctx context.Context, since time.Time) ([]*RequestCounts, error)
+	DeleteRequestCountsBefore(ctx context.Context, before time.Time) (int64, error)
+	// ListBuckets returns buckets with their credential and owner. A nil user
+	// or team matches every bucket; a team matches the buckets of its members.
+	ListBuckets(ctx context.Context, userID, teamID *uuid.UUID) ([]*CostBucket, error)
+	// ListTeamMembers returns every team membership with the team's name
+	ListTeamMembers(ctx context.Context) ([]*TeamAssignment, error)
+	// ListLatestRootReports returns the newest finished whole-bucket usage
+	// report result of each bucket that has one
+	ListLatestRootReports(ctx context.Context) (map[uuid.UUID][]byte, error)
+}
+
+// TagJobRepository stores bulk object tagging jobs for the background worker
+type TagJobRepository interface {
+	Create(ctx context.Context, job *TagJob) (*TagJob, error)
+	Get(ctx context.Context, id, bucketID uuid.UUID) (*TagJob, error)
+	// List returns the bucket's newest jobs first
+	List(ctx context.Context, bucketID uuid.UUID, limit int) ([]*TagJob, error)
+	// Claim marks up to limit pending jobs, and running ones whose last
+	// heartbeat was at or before staleBefore, as running and returns them
+	Claim(ctx context.Context, staleBefore time.Time, limit int) ([]*TagJob, error)
+	// UpdateProgress stores a running job's counts as a heartbeat. It returns
+	// false once the job is no longer running, e.g. after it was canceled.
+	UpdateProgress(ctx context.Context, job *TagJob) (bool, error)
+	// Finish stores the final status, counts and error of a running job
+	Finish(ctx context.Context, job *TagJob) error
+	// Cancel stops a pending or running job; it returns false if the job has finished
+	Cancel(ctx context.Context, id, bucketID uuid.UUID) (bool, error)
+	// DeleteFinishedBefore removes jobs that finished before the given time
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TagSearchRepository stores background searches for objects by tag
+type TagSearchRepository interface {
+	Create(ctx context.Context, search *TagSearch) (*TagSearch, error)
+	Get(ctx context.Context, id, bucketID uuid.UUID) (*TagSearch, error)
+	// Claim marks up to limit pending searches, and running ones whose last
+	// heartbeat was at or before staleBefore, as running and returns them
+	Claim(ctx context.Context, staleBefore time.Time, limit int) ([]*TagSearch, error)
+	// UpdateProgress stores a running search's scanned count as a heartbeat
+	UpdateProgress(ctx context.Context, search *TagSearch) error
+	// Finish stores the final status, matches and error of a running search
+	Finish(ctx context.Context, search *TagSearch) error
+	// DeleteBefore removes searches created before the given time
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
 // Domain models (converted from pgtype to standard types)
 type User struct {
-	ID           uuid.UUID
-	Email        string
-	PasswordHash string
-	FirstName    string
-	LastName     string
-	IsDemo       bool
+	ID            uuid.UUID
+	Email         string
+	PasswordHash  string
+	FirstName     string
+	LastName      string
+	IsDemo        bool
+	IsAdmin       bool
+	TOTPSecret    *string // encrypted
+	TOTPEnabledAt *time.Time
+	TOTPLastStep  int64
+	// EmailVerifiedAt is nil until the user confirms their address
+	EmailVerifiedAt *time.Time
+	// TokenVersion must match the version in access tokens; bumping it revokes them all
+	TokenVersion int32
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -62,11 +380,29 @@ type Session struct {
 	ID               uuid.UUID
 	UserID           uuid.UUID
 	RefreshTokenHash string
+	UserAgent        string
+	IPAddress        string
 	ExpiresAt        time.Time
+	LastUsedAt       time.Time
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 }
 
+// Reasons a refresh token was retired
+const (
+	RefreshTokenRotated = "rotated"
+	RefreshTokenRevoked = "revoked"
+)
+
+type RetiredRefreshToken struct {
+	TokenHash string
+	SessionID uuid.UUID
+	UserID    uuid.UUID
+	Reason    string
+	ExpiresAt time.Time
+	RetiredAt time.Time
+}
+
 type Credential struct {
 	ID                 uuid.UUID
 	UserID             uuid.UUID
@@ -76,11 +412,14 @@ type Credential struct {
 	Endpoint           string
 	EncryptedAccessKey string
 	EncryptedSecretKey string
-	UseSSL             bool
-	Status             string
-	Logo               *string
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	// EncryptedDataKey is the wrapped data key for the two keys above; nil
+	// for credentials encrypted directly with the keyring
+	EncryptedDataKey *string
+	UseSSL           bool
+	Status           string
+	Logo             *string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 type Bucket struct {
@@ -91,8 +430,12 @@ type Bucket struct {
 	Region       string
 	Description  *string
 	SizeBytes    int64
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ObjectCount  int64
+	// SizeUpdatedAt is when SizeBytes and ObjectCount were last recalculated;
+	// nil if they never were
+	SizeUpdatedAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 type BucketWithCredential struct {
@@ -100,3 +443,395 @@ type BucketWithCredential struct {
 	CredentialName     string
 	CredentialProvider string
 }
+
+type PersonalAccessToken struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Name        string
+	TokenHash   string
+	TokenPrefix string
+	Scope       string
+	BucketIDs   []uuid.UUID
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type OIDCLoginRequest struct {
+	ID           uuid.UUID
+	State        string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+type LoginAttempt struct {
+	Identifier    string
+	FailedCount   int
+	LastFailedAt  time.Time
+	LastIPAddress string
+	LockedUntil   *time.Time
+	CreatedAt     time.Time
+}
+
+type Team struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedBy *uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TeamMembership is a team as seen by one of its members
+type TeamMembership struct {
+	Team
+	Role string
+}
+
+type TeamMember struct {
+	TeamID    uuid.UUID
+	UserID    uuid.UUID
+	Role      string
+	Email     string
+	FirstName string
+	LastName  string
+	CreatedAt time.Time
+}
+
+type Invite struct {
+	ID         uuid.UUID
+	CodeHash   string
+	CodePrefix string
+	CreatedBy  uuid.UUID
+	// Email, when set, restricts the invite to that address
+	Email  *string
+	TeamID *uuid.UUID
+	// MaxUses of 0 means unlimited
+	MaxUses   int
+	UseCount  int
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
+
+// Email token purposes
+const (
+	EmailTokenVerifyEmail   = "verify_email"
+	EmailTokenPasswordReset = "password_reset"
+)
+
+type EmailToken struct {
+	ID      uuid.UUID
+	UserID  uuid.UUID
+	Purpose string
+	// Email is the address the token was sent to
+	Email     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Audit event outcomes
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+type AuditEvent struct {
+	ID         int64
+	OccurredAt time.Time
+	ActorID    *uuid.UUID
+	ActorEmail string
+	// TokenID is set when the actor used a personal access token
+	TokenID    *uuid.UUID
+	IPAddress  string
+	UserAgent  string
+	RequestID  string
+	Action     string
+	BucketID   *uuid.UUID
+	BucketName string
+	ObjectKey  string
+	TargetID   string
+	Details    map[string]string
+	Outcome    string
+	Error      string
+}
+
+// AuditFilter selects audit events; zero fields match everything
+type AuditFilter struct {
+	ActorID  *uuid.UUID
+	Action   string
+	BucketID *uuid.UUID
+	Outcome  string
+	Since    *time.Time
+	Until    *time.Time
+	// BeforeID returns only events older than this ID, for paging
+	BeforeID int64
+	Limit    int
+}
+
+type Webhook struct {
+	ID uuid.UUID
+	// BucketID is nil once the bucket was deleted
+	BucketID  *uuid.UUID
+	URL       string
+	Events    []string
+	KeyPrefix string
+	KeySuffix string
+	Secret    string // encrypted
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookDelivery struct {
+	ID        uuid.UUID
+	WebhookID uuid.UUID
+	// EventID is shared by redeliveries of the same event
+	EventID       uuid.UUID
+	Event         string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastAttemptAt *time.Time
+	// ResponseStatus is 0 when the last attempt got no response
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	RedeliveryOf   *uuid.UUID
+	CreatedAt      time.Time
+	CompletedAt    *time.Time
+}
+
+// WebhookAttempt is the result of sending a delivery once
+type WebhookAttempt struct {
+	DeliveryID uuid.UUID
+	// Status stays pending while retries remain
+	Status         string
+	NextAttemptAt  time.Time
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+}
+
+// Outbox event statuses
+const (
+	OutboxPending    = "pending"
+	OutboxDispatched = "dispatched"
+	OutboxFailed     = "failed"
+)
+
+// OutboxEvent is a domain event waiting in, or dispatched from, the outbox
+type OutboxEvent struct {
+	ID   uuid.UUID
+	Type string
+	// BucketID is the bucket the event concerns, if any
+	BucketID      *uuid.UUID
+	Payload       []byte
+	OccurredAt    time.Time
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	DispatchedAt  *time.Time
+}
+
+// Quota scopes
+const (
+	QuotaScopeUser   = "user"
+	QuotaScopeTeam   = "team"
+	QuotaScopeBucket = "bucket"
+)
+
+type Quota struct {
+	ID        uuid.UUID
+	Scope     string
+	SubjectID uuid.UUID
+	// SubjectName is the user's email, team name or bucket name; it is only
+	// filled in by List and ListForUser
+	SubjectName string
+	// MaxBytes and MaxObjects are nil when unlimited
+	MaxBytes    *int64
+	MaxObjects  *int64
+	WarnPercent int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// QuotaUsage is what a quota subject stores, as of the last bucket size
+// recalculation, and what is reserved for writes on top of that
+type QuotaUsage struct {
+	Bytes           int64
+	Objects         int64
+	ReservedBytes   int64
+	ReservedObjects int64
+}
+
+type QuotaReservation struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	BucketID uuid.UUID
+	Bytes    int64
+	Objects  int64
+	// ExpiresAt is when the reservation stops counting
+	ExpiresAt   time.Time
+	CommittedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// UsageSnapshot is the usage of a bucket, or of one of its top-level
+// prefixes, at a point in time. Prefix is empty for the whole bucket.
+type UsageSnapshot struct {
+	ID          int64
+	BucketID    uuid.UUID
+	Prefix      string
+	SizeBytes   int64
+	ObjectCount int64
+	CapturedAt  time.Time
+}
+
+type UsageAlert struct {
+	ID       uuid.UUID
+	BucketID uuid.UUID
+	// MaxDailyGrowthBytes and MaxDailyGrowthObjects are nil when not checked
+	MaxDailyGrowthBytes   *int64
+	MaxDailyGrowthObjects *int64
+	LastTriggeredAt       *time.Time
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// Usage report statuses
+const (
+	UsageReportPending = "pending"
+	UsageReportRunning = "running"
+	UsageReportReady   = "ready"
+	UsageReportFailed  = "failed"
+)
+
+// RequestCounts are the requests BucketBird sent to a bucket and the bytes it downloaded
+type RequestCounts struct {
+	BucketID      uuid.UUID
+	Day           time.Time
+	WriteRequests int64
+	ListRequests  int64
+	ReadRequests  int64
+	EgressBytes   int64
+}
+
+// CostBucket is a bucket with what its cost estimate needs to know about its
+// credential and owner
+type CostBucket struct {
+	Bucket
+	CredentialName     string
+	CredentialProvider string
+	CredentialRegion   string
+	OwnerEmail         string
+}
+
+type TeamAssignment struct {
+	TeamID   uuid.UUID
+	TeamName string
+	UserID   uuid.UUID
+}
+
+// UsageReport is a cached disk usage breakdown of a bucket or prefix
+type UsageReport struct {
+	ID       uuid.UUID
+	BucketID uuid.UUID
+	Prefix   string
+	Depth    int
+	Status   string
+	// Result is the JSON encoded breakdown; a refresh keeps the previous one until it finishes
+	Result      []byte
+	Error       string
+	RequestedAt time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// Tag job statuses
+const (
+	TagJobPending   = "pending"
+	TagJobRunning   = "running"
+	TagJobCompleted = "completed"
+	TagJobFailed    = "failed"
+	TagJobCanceled  = "canceled"
+)
+
+// TagJob is a bulk object tagging job. It names either Keys or a Prefix.
+type TagJob struct {
+	ID       uuid.UUID
+	BucketID uuid.UUID
+	UserID   uuid.UUID
+	Keys     []string
+	Prefix   *string
+	// SetTags are added or overwritten, RemoveTags are deleted, and
+	// ReplaceTags drops every other tag
+	SetTags     map[string]string
+	RemoveTags  []string
+	ReplaceTags bool
+	Status      string
+	// Total is known up front for key lists only
+	Total     *int64
+	Processed int64
+	Failed    int64
+	Failures  []TagJobFailure
+	Error     string
+	CreatedAt time.Time
+	StartedAt *time.Time
+	// CompletedAt is set once the job has completed, failed or been canceled
+	CompletedAt *time.Time
+}
+
+// TagJobFailure is an object a tag job could not tag
+type TagJobFailure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// TagSearch is a background search for the objects under a prefix having
+// tags. Its status is one of the tag job statuses other than canceled.
+type TagSearch struct {
+	ID       uuid.UUID
+	BucketID uuid.UUID
+	UserID   uuid.UUID
+	Prefix   string
+	// Filters are written as key=value or key
+	Filters []string
+	Status  string
+	Scanned int64
+	Matches []TagSearchMatch
+	// Truncated is set when the search stopped at its match limit
+	Truncated   bool
+	Error       string
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// TagSearchMatch is an object found by a tag search
+type TagSearchMatch struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	LastModified time.Time         `json:"lastModified"`
+	Tags         map[string]string `json:"tags"`
+}
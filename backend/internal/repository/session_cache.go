@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// cachedSessionRepository remembers GetByID results for a short time, since
+// every request authenticated with an access token looks up its session.
+// Sessions revoked through this repository are evicted at once; other API
+// instances see the revocation when their entry expires.
+type cachedSessionRepository struct {
+	SessionRepository
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]cachedSession
+}
+
+type cachedSession struct {
+	// session is nil if the session did not exist
+	session  *Session
+	cachedAt time.Time
+}
+
+// NewCachedSessionRepository wraps sessions with an in-process GetByID cache.
+// A ttl of zero disables caching.
+func NewCachedSessionRepository(sessions SessionRepository, ttl time.Duration) SessionRepository {
+	if ttl <= 0 {
+		return sessions
+	}
+	return &cachedSessionRepository{
+		SessionRepository: sessions,
+		ttl:               ttl,
+		entries:           make(map[uuid.UUID]cachedSession),
+	}
+}
+
+func (r *cachedSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*Session, error) {
+	r.mu.Lock()
+	entry, ok := r.entries[id]
+	r.mu.Unlock()
+	if ok && time.Since(entry.cachedAt) < r.ttl {
+		if entry.session == nil {
+			return nil, ErrNotFound
+		}
+		session := *entry.session
+		return &session, nil
+	}
+
+	session, err := r.SessionRepository.GetByID(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.pruneLocked()
+	r.entries[id] = cachedSession{session: session, cachedAt: time.Now()}
+	r.mu.Unlock()
+
+	if session == nil {
+		return nil, ErrNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *cachedSessionRepository) Rotate(ctx context.Context, session *Session, tokenHash string, expiresAt time.Time, userAgent, ipAddress string) (bool, error) {
+	defer r.evict(func(id uuid.UUID, _ *Session) bool { return id == session.ID })
+	return r.SessionRepository.Rotate(ctx, session, tokenHash, expiresAt, userAgent, ipAddress)
+}
+
+func (r *cachedSessionRepository) DeleteByHash(ctx context.Context, hash string) error {
+	session, err := r.SessionRepository.GetByHash(ctx, hash)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := r.SessionRepository.DeleteByHash(ctx, hash); err != nil {
+		return err
+	}
+	if session != nil {
+		r.evict(func(id uuid.UUID, _ *Session) bool { return id == session.ID })
+	}
+	return nil
+}
+
+func (r *cachedSessionRepository) DeleteFamily(ctx context.Context, id uuid.UUID) error {
+	defer r.evict(func(cachedID uuid.UUID, _ *Session) bool { return cachedID == id })
+	return r.SessionRepository.DeleteFamily(ctx, id)
+}
+
+func (r *cachedSessionRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	defer r.evict(func(cachedID uuid.UUID, _ *Session) bool { return cachedID == id })
+	return r.SessionRepository.Delete(ctx, id, userID)
+}
+
+func (r *cachedSessionRepository) DeleteOthers(ctx context.Context, userID, keepID uuid.UUID) (int64, error) {
+	defer r.evict(func(id uuid.UUID, s *Session) bool { return id != keepID && (s == nil || s.UserID == userID) })
+	return r.SessionRepository.DeleteOthers(ctx, userID, keepID)
+}
+
+func (r *cachedSessionRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	defer r.evict(func(_ uuid.UUID, s *Session) bool { return s == nil || s.UserID == userID })
+	return r.SessionRepository.DeleteForUser(ctx, userID)
+}
+
+// evict drops matching entries. Entries for missing sessions are cheap to
+// reload, so callers may drop them along with the ones they target.
+func (r *cachedSessionRepository) evict(match func(id uuid.UUID, session *Session) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, entry := range r.entries {
+		if match(id, entry.session) {
+			delete(r.entries, id)
+		}
+	}
+}
+
+// pruneLocked drops expired entries once the cache grows large
+func (r *cachedSessionRepository) pruneLocked() {
+	if len(r.entries) < 10000 {
+		return
+	}
+	for id, entry := range r.entries {
+		if time.Since(entry.cachedAt) >= r.ttl {
+			delete(r.entries, id)
+		}
+	}
+}
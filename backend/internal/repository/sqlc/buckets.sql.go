@@ -27,7 +27,7 @@ func (q *Queries) DeleteBucket(ctx context.Context, arg DeleteBucketParams) erro
 
 const getBucket = `-- name: GetBucket :one
 SELECT
-    b.id, b.user_id, b.credential_id, b.name, b.region, b.description, b.size_bytes, b.created_at, b.updated_at,
+    b.id, b.user_id, b.credential_id, b.name, b.region, b.description, b.size_bytes, b.created_at, b.updated_at, b.object_count, b.usage_snapshot_at, b.size_updated_at,
     c.name as credential_name,
     c.provider as credential_provider
 FROM buckets b
@@ -59,15 +59,42 @@ func (q *Queries) GetBucket(ctx context.Context, arg GetBucketParams) (GetBucket
 		&i.Bucket.SizeBytes,
 		&i.Bucket.CreatedAt,
 		&i.Bucket.UpdatedAt,
+		&i.Bucket.ObjectCount,
+		&i.Bucket.UsageSnapshotAt,
+		&i.Bucket.SizeUpdatedAt,
 		&i.CredentialName,
 		&i.CredentialProvider,
 	)
 	return i, err
 }
 
+const getBucketByID = `-- name: GetBucketByID :one
+SELECT id, user_id, credential_id, name, region, description, size_bytes, created_at, updated_at, object_count, usage_snapshot_at, size_updated_at FROM buckets WHERE id = $1
+`
+
+func (q *Queries) GetBucketByID(ctx context.Context, id pgtype.UUID) (Bucket, error) {
+	row := q.db.QueryRow(ctx, getBucketByID, id)
+	var i Bucket
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.Name,
+		&i.Region,
+		&i.Description,
+		&i.SizeBytes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ObjectCount,
+		&i.UsageSnapshotAt,
+		&i.SizeUpdatedAt,
+	)
+	return i, err
+}
+
 const getBucketByName = `-- name: GetBucketByName :one
 SELECT
-    b.id, b.user_id, b.credential_id, b.name, b.region, b.description, b.size_bytes, b.created_at, b.updated_at,
+    b.id, b.user_id, b.credential_id, b.name, b.region, b.description, b.size_bytes, b.created_at, b.updated_at, b.object_count, b.usage_snapshot_at, b.size_updated_at,
     c.name as credential_name,
     c.provider as credential_provider
 FROM buckets b
@@ -99,6 +126,9 @@ func (q *Queries) GetBucketByName(ctx context.Context, arg GetBucketByNameParams
 		&i.Bucket.SizeBytes,
 		&i.Bucket.CreatedAt,
 		&i.Bucket.UpdatedAt,
+		&i.Bucket.ObjectCount,
+		&i.Bucket.UsageSnapshotAt,
+		&i.Bucket.SizeUpdatedAt,
 		&i.CredentialName,
 		&i.CredentialProvider,
 	)
@@ -108,7 +138,7 @@ func (q *Queries) GetBucketByName(ctx context.Context, arg GetBucketByNameParams
 const insertBucket = `-- name: InsertBucket :one
 INSERT INTO buckets (id, user_id, credential_id, name, region, description)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, user_id, credential_id, name, region, description, size_bytes, created_at, updated_at
+RETURNING id, user_id, credential_id, name, region, description, size_bytes, created_at, updated_at, object_count, usage_snapshot_at, size_updated_at
 `
 
 type InsertBucketParams struct {
@@ -140,13 +170,16 @@ func (q *Queries) InsertBucket(ctx context.Context, arg InsertBucketParams) (Buc
 		&i.SizeBytes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ObjectCount,
+		&i.UsageSnapshotAt,
+		&i.SizeUpdatedAt,
 	)
 	return i, err
 }
 
 const listBuckets = `-- name: ListBuckets :many
 SELECT
-    b.id, b.user_id, b.credential_id, b.name, b.region, b.description, b.size_bytes, b.created_at, b.updated_at,
+    b.id, b.user_id, b.credential_id, b.name, b.region, b.description, b.size_bytes, b.created_at, b.updated_at, b.object_count, b.usage_snapshot_at, b.size_updated_at,
     c.name as credential_name,
     c.provider as credential_provider
 FROM buckets b
@@ -180,6 +213,9 @@ func (q *Queries) ListBuckets(ctx context.Context, userID pgtype.UUID) ([]ListBu
 			&i.Bucket.SizeBytes,
 			&i.Bucket.CreatedAt,
 			&i.Bucket.UpdatedAt,
+			&i.Bucket.ObjectCount,
+			&i.Bucket.UsageSnapshotAt,
+			&i.Bucket.SizeUpdatedAt,
 			&i.CredentialName,
 			&i.CredentialProvider,
 		); err != nil {
@@ -210,18 +246,19 @@ func (q *Queries) UpdateBucket(ctx context.Context, arg UpdateBucketParams) erro
 	return err
 }
 
-const updateBucketSize = `-- name: UpdateBucketSize :exec
+const updateBucketUsage = `-- name: UpdateBucketUsage :exec
 UPDATE buckets
-SET size_bytes = $2, updated_at = NOW()
+SET size_bytes = $2, object_count = $3, size_updated_at = NOW(), updated_at = NOW()
 WHERE id = $1
 `
 
-type UpdateBucketSizeParams struct {
-	ID        pgtype.UUID `json:"id"`
-	SizeBytes int64       `json:"size_bytes"`
+type UpdateBucketUsageParams struct {
+	ID          pgtype.UUID `json:"id"`
+	SizeBytes   int64       `json:"size_bytes"`
+	ObjectCount int64       `json:"object_count"`
 }
 
-func (q *Queries) UpdateBucketSize(ctx context.Context, arg UpdateBucketSizeParams) error {
-	_, err := q.db.Exec(ctx, updateBucketSize, arg.ID, arg.SizeBytes)
+func (q *Queries) UpdateBucketUsage(ctx context.Context, arg UpdateBucketUsageParams) error {
+	_, err := q.db.Exec(ctx, updateBucketUsage, arg.ID, arg.SizeBytes, arg.ObjectCount)
 	return err
 }
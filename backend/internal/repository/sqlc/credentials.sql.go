@@ -15,10 +15,10 @@ const createCredential = `-- name: CreateCredential :one
 INSERT INTO credentials (
     id, user_id, name, provider, region, endpoint,
     encrypted_access_key, encrypted_secret_key,
-    use_ssl, status, logo
+    use_ssl, status, logo, encrypted_data_key
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-RETURNING id, user_id, name, provider, region, endpoint, encrypted_access_key, encrypted_secret_key, use_ssl, status, logo, created_at, updated_at
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, user_id, name, provider, region, endpoint, encrypted_access_key, encrypted_secret_key, use_ssl, status, logo, created_at, updated_at, encrypted_data_key
 `
 
 type CreateCredentialParams struct {
@@ -33,6 +33,7 @@ type CreateCredentialParams struct {
 	UseSsl             bool        `json:"use_ssl"`
 	Status             string      `json:"status"`
 	Logo               *string     `json:"logo"`
+	EncryptedDataKey   *string     `json:"encrypted_data_key"`
 }
 
 func (q *Queries) CreateCredential(ctx context.Context, arg CreateCredentialParams) (Credential, error) {
@@ -48,6 +49,7 @@ func (q *Queries) CreateCredential(ctx context.Context, arg CreateCredentialPara
 		arg.UseSsl,
 		arg.Status,
 		arg.Logo,
+		arg.EncryptedDataKey,
 	)
 	var i Credential
 	err := row.Scan(
@@ -64,6 +66,7 @@ func (q *Queries) CreateCredential(ctx context.Context, arg CreateCredentialPara
 		&i.Logo,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EncryptedDataKey,
 	)
 	return i, err
 }
@@ -83,7 +86,7 @@ func (q *Queries) DeleteCredential(ctx context.Context, arg DeleteCredentialPara
 }
 
 const getCredential = `-- name: GetCredential :one
-SELECT id, user_id, name, provider, region, endpoint, encrypted_access_key, encrypted_secret_key, use_ssl, status, logo, created_at, updated_at FROM credentials
+SELECT id, user_id, name, provider, region, endpoint, encrypted_access_key, encrypted_secret_key, use_ssl, status, logo, created_at, updated_at, encrypted_data_key FROM credentials
 WHERE id = $1 AND user_id = $2
 `
 
@@ -109,12 +112,54 @@ func (q *Queries) GetCredential(ctx context.Context, arg GetCredentialParams) (C
 		&i.Logo,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EncryptedDataKey,
 	)
 	return i, err
 }
 
+const listAllCredentialsForUpdate = `-- name: ListAllCredentialsForUpdate :many
+SELECT id, user_id, name, provider, region, endpoint, encrypted_access_key, encrypted_secret_key, use_ssl, status, logo, created_at, updated_at, encrypted_data_key FROM credentials
+ORDER BY created_at
+FOR UPDATE
+`
+
+func (q *Queries) ListAllCredentialsForUpdate(ctx context.Context) ([]Credential, error) {
+	rows, err := q.db.Query(ctx, listAllCredentialsForUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Credential{}
+	for rows.Next() {
+		var i Credential
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Provider,
+			&i.Region,
+			&i.Endpoint,
+			&i.EncryptedAccessKey,
+			&i.EncryptedSecretKey,
+			&i.UseSsl,
+			&i.Status,
+			&i.Logo,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EncryptedDataKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listCredentials = `-- name: ListCredentials :many
-SELECT id, user_id, name, provider, region, endpoint, encrypted_access_key, encrypted_secret_key, use_ssl, status, logo, created_at, updated_at FROM credentials
+SELECT id, user_id, name, provider, region, endpoint, encrypted_access_key, encrypted_secret_key, use_ssl, status, logo, created_at, updated_at, encrypted_data_key FROM credentials
 WHERE user_id = $1
 ORDER BY created_at DESC
 `
@@ -142,6 +187,7 @@ func (q *Queries) ListCredentials(ctx context.Context, userID pgtype.UUID) ([]Cr
 			&i.Logo,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EncryptedDataKey,
 		); err != nil {
 			return nil, err
 		}
@@ -157,7 +203,8 @@ const updateCredential = `-- name: UpdateCredential :exec
 UPDATE credentials
 SET name = $3, provider = $4, region = $5, endpoint = $6,
     encrypted_access_key = $7, encrypted_secret_key = $8,
-    use_ssl = $9, status = $10, logo = $11, updated_at = NOW()
+    use_ssl = $9, status = $10, logo = $11, encrypted_data_key = $12,
+    updated_at = NOW()
 WHERE id = $1 AND user_id = $2
 `
 
@@ -173,6 +220,7 @@ type UpdateCredentialParams struct {
 	UseSsl             bool        `json:"use_ssl"`
 	Status             string      `json:"status"`
 	Logo               *string     `json:"logo"`
+	EncryptedDataKey   *string     `json:"encrypted_data_key"`
 }
 
 func (q *Queries) UpdateCredential(ctx context.Context, arg UpdateCredentialParams) error {
@@ -188,6 +236,30 @@ func (q *Queries) UpdateCredential(ctx context.Context, arg UpdateCredentialPara
 		arg.UseSsl,
 		arg.Status,
 		arg.Logo,
+		arg.EncryptedDataKey,
+	)
+	return err
+}
+
+const updateCredentialSecrets = `-- name: UpdateCredentialSecrets :exec
+UPDATE credentials
+SET encrypted_access_key = $2, encrypted_secret_key = $3, encrypted_data_key = $4
+WHERE id = $1
+`
+
+type UpdateCredentialSecretsParams struct {
+	ID                 pgtype.UUID `json:"id"`
+	EncryptedAccessKey string      `json:"encrypted_access_key"`
+	EncryptedSecretKey string      `json:"encrypted_secret_key"`
+	EncryptedDataKey   *string     `json:"encrypted_data_key"`
+}
+
+func (q *Queries) UpdateCredentialSecrets(ctx context.Context, arg UpdateCredentialSecretsParams) error {
+	_, err := q.db.Exec(ctx, updateCredentialSecrets,
+		arg.ID,
+		arg.EncryptedAccessKey,
+		arg.EncryptedSecretKey,
+		arg.EncryptedDataKey,
 	)
 	return err
 }
@@ -8,16 +8,47 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuditEvent struct {
+	ID         int64              `json:"id"`
+	OccurredAt pgtype.Timestamptz `json:"occurred_at"`
+	ActorID    pgtype.UUID        `json:"actor_id"`
+	ActorEmail *string            `json:"actor_email"`
+	TokenID    pgtype.UUID        `json:"token_id"`
+	IpAddress  *string            `json:"ip_address"`
+	UserAgent  *string            `json:"user_agent"`
+	RequestID  *string            `json:"request_id"`
+	Action     string             `json:"action"`
+	BucketID   pgtype.UUID        `json:"bucket_id"`
+	BucketName *string            `json:"bucket_name"`
+	ObjectKey  *string            `json:"object_key"`
+	TargetID   *string            `json:"target_id"`
+	Details    []byte             `json:"details"`
+	Outcome    string             `json:"outcome"`
+	Error      *string            `json:"error"`
+}
+
 type Bucket struct {
-	ID           pgtype.UUID        `json:"id"`
-	UserID       pgtype.UUID        `json:"user_id"`
-	CredentialID pgtype.UUID        `json:"credential_id"`
-	Name         string             `json:"name"`
-	Region       string             `json:"region"`
-	Description  *string            `json:"description"`
-	SizeBytes    int64              `json:"size_bytes"`
-	CreatedAt    pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	ID              pgtype.UUID        `json:"id"`
+	UserID          pgtype.UUID        `json:"user_id"`
+	CredentialID    pgtype.UUID        `json:"credential_id"`
+	Name            string             `json:"name"`
+	Region          string             `json:"region"`
+	Description     *string            `json:"description"`
+	SizeBytes       int64              `json:"size_bytes"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	ObjectCount     int64              `json:"object_count"`
+	UsageSnapshotAt pgtype.Timestamptz `json:"usage_snapshot_at"`
+	SizeUpdatedAt   pgtype.Timestamptz `json:"size_updated_at"`
+}
+
+type BucketRequestCount struct {
+	BucketID      pgtype.UUID `json:"bucket_id"`
+	Day           pgtype.Date `json:"day"`
+	WriteRequests int64       `json:"write_requests"`
+	ListRequests  int64       `json:"list_requests"`
+	ReadRequests  int64       `json:"read_requests"`
+	EgressBytes   int64       `json:"egress_bytes"`
 }
 
 type Credential struct {
@@ -34,6 +65,87 @@ type Credential struct {
 	Logo               *string            `json:"logo"`
 	CreatedAt          pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	EncryptedDataKey   *string            `json:"encrypted_data_key"`
+}
+
+type EmailToken struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Purpose   string             `json:"purpose"`
+	TokenHash string             `json:"token_hash"`
+	Email     string             `json:"email"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type InstanceSetting struct {
+	Key       string             `json:"key"`
+	Value     []byte             `json:"value"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Invite struct {
+	ID         pgtype.UUID        `json:"id"`
+	CodeHash   string             `json:"code_hash"`
+	CodePrefix string             `json:"code_prefix"`
+	CreatedBy  pgtype.UUID        `json:"created_by"`
+	Email      *string            `json:"email"`
+	TeamID     pgtype.UUID        `json:"team_id"`
+	MaxUses    int32              `json:"max_uses"`
+	UseCount   int32              `json:"use_count"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	Identifier    string             `json:"identifier"`
+	FailedCount   int32              `json:"failed_count"`
+	LastFailedAt  pgtype.Timestamptz `json:"last_failed_at"`
+	LastIpAddress string             `json:"last_ip_address"`
+	LockedUntil   pgtype.Timestamptz `json:"locked_until"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type OidcLoginRequest struct {
+	ID           pgtype.UUID        `json:"id"`
+	State        string             `json:"state"`
+	CodeVerifier string             `json:"code_verifier"`
+	Nonce        string             `json:"nonce"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type OutboxEvent struct {
+	ID            pgtype.UUID        `json:"id"`
+	Type          string             `json:"type"`
+	BucketID      pgtype.UUID        `json:"bucket_id"`
+	Payload       []byte             `json:"payload"`
+	OccurredAt    pgtype.Timestamptz `json:"occurred_at"`
+	Status        string             `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LastError     *string            `json:"last_error"`
+	DispatchedAt  pgtype.Timestamptz `json:"dispatched_at"`
+}
+
+type OutboxEventHandler struct {
+	EventID    pgtype.UUID        `json:"event_id"`
+	Subscriber string             `json:"subscriber"`
+	HandledAt  pgtype.Timestamptz `json:"handled_at"`
+}
+
+type PersonalAccessToken struct {
+	ID          pgtype.UUID        `json:"id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	Name        string             `json:"name"`
+	TokenHash   string             `json:"token_hash"`
+	TokenPrefix string             `json:"token_prefix"`
+	Scope       string             `json:"scope"`
+	BucketIds   []pgtype.UUID      `json:"bucket_ids"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
 
 type Profile struct {
@@ -49,6 +161,45 @@ type Profile struct {
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
+type Quota struct {
+	ID          pgtype.UUID        `json:"id"`
+	Scope       string             `json:"scope"`
+	SubjectID   pgtype.UUID        `json:"subject_id"`
+	MaxBytes    *int64             `json:"max_bytes"`
+	MaxObjects  *int64             `json:"max_objects"`
+	WarnPercent int32              `json:"warn_percent"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type QuotaReservation struct {
+	ID          pgtype.UUID        `json:"id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	BucketID    pgtype.UUID        `json:"bucket_id"`
+	Bytes       int64              `json:"bytes"`
+	Objects     int64              `json:"objects"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	CommittedAt pgtype.Timestamptz `json:"committed_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type RetiredRefreshToken struct {
+	TokenHash string             `json:"token_hash"`
+	SessionID pgtype.UUID        `json:"session_id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Reason    string             `json:"reason"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	RetiredAt pgtype.Timestamptz `json:"retired_at"`
+}
+
 type Session struct {
 	ID               pgtype.UUID        `json:"id"`
 	UserID           pgtype.UUID        `json:"user_id"`
@@ -56,15 +207,150 @@ type Session struct {
 	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
 	CreatedAt        pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	UserAgent        string             `json:"user_agent"`
+	IpAddress        string             `json:"ip_address"`
+	LastUsedAt       pgtype.Timestamptz `json:"last_used_at"`
+}
+
+type TagJob struct {
+	ID          pgtype.UUID        `json:"id"`
+	BucketID    pgtype.UUID        `json:"bucket_id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	Keys        []byte             `json:"keys"`
+	Prefix      *string            `json:"prefix"`
+	SetTags     []byte             `json:"set_tags"`
+	RemoveTags  []byte             `json:"remove_tags"`
+	ReplaceTags bool               `json:"replace_tags"`
+	Status      string             `json:"status"`
+	Total       *int64             `json:"total"`
+	Processed   int64              `json:"processed"`
+	Failed      int64              `json:"failed"`
+	Failures    []byte             `json:"failures"`
+	Error       *string            `json:"error"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	HeartbeatAt pgtype.Timestamptz `json:"heartbeat_at"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+}
+
+type TagSearch struct {
+	ID          pgtype.UUID        `json:"id"`
+	BucketID    pgtype.UUID        `json:"bucket_id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	Prefix      string             `json:"prefix"`
+	Filters     []byte             `json:"filters"`
+	Status      string             `json:"status"`
+	Scanned     int64              `json:"scanned"`
+	Matches     []byte             `json:"matches"`
+	Truncated   bool               `json:"truncated"`
+	Error       *string            `json:"error"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	HeartbeatAt pgtype.Timestamptz `json:"heartbeat_at"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+}
+
+type Team struct {
+	ID        pgtype.UUID        `json:"id"`
+	Name      string             `json:"name"`
+	CreatedBy pgtype.UUID        `json:"created_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type TeamMember struct {
+	TeamID    pgtype.UUID        `json:"team_id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Role      string             `json:"role"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type UsageAlert struct {
+	ID                    pgtype.UUID        `json:"id"`
+	BucketID              pgtype.UUID        `json:"bucket_id"`
+	MaxDailyGrowthBytes   *int64             `json:"max_daily_growth_bytes"`
+	MaxDailyGrowthObjects *int64             `json:"max_daily_growth_objects"`
+	LastTriggeredAt       pgtype.Timestamptz `json:"last_triggered_at"`
+	CreatedAt             pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt             pgtype.Timestamptz `json:"updated_at"`
+}
+
+type UsageReport struct {
+	ID          pgtype.UUID        `json:"id"`
+	BucketID    pgtype.UUID        `json:"bucket_id"`
+	Prefix      string             `json:"prefix"`
+	Depth       int32              `json:"depth"`
+	Status      string             `json:"status"`
+	Result      []byte             `json:"result"`
+	Error       *string            `json:"error"`
+	RequestedAt pgtype.Timestamptz `json:"requested_at"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+}
+
+type UsageSnapshot struct {
+	ID          int64              `json:"id"`
+	BucketID    pgtype.UUID        `json:"bucket_id"`
+	Prefix      string             `json:"prefix"`
+	SizeBytes   int64              `json:"size_bytes"`
+	ObjectCount int64              `json:"object_count"`
+	CapturedAt  pgtype.Timestamptz `json:"captured_at"`
 }
 
 type User struct {
-	ID           pgtype.UUID        `json:"id"`
-	Email        string             `json:"email"`
-	PasswordHash string             `json:"password_hash"`
-	FirstName    string             `json:"first_name"`
-	LastName     string             `json:"last_name"`
-	CreatedAt    pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
-	IsDemo       bool               `json:"is_demo"`
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	PasswordHash    string             `json:"password_hash"`
+	FirstName       string             `json:"first_name"`
+	LastName        string             `json:"last_name"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	IsDemo          bool               `json:"is_demo"`
+	IsAdmin         bool               `json:"is_admin"`
+	TotpSecret      *string            `json:"totp_secret"`
+	TotpEnabledAt   pgtype.Timestamptz `json:"totp_enabled_at"`
+	TotpLastStep    int64              `json:"totp_last_step"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	TokenVersion    int32              `json:"token_version"`
+}
+
+type UserIdentity struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Provider  string             `json:"provider"`
+	Subject   string             `json:"subject"`
+	Email     string             `json:"email"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Webhook struct {
+	ID        pgtype.UUID        `json:"id"`
+	BucketID  pgtype.UUID        `json:"bucket_id"`
+	Url       string             `json:"url"`
+	Events    []string           `json:"events"`
+	KeyPrefix string             `json:"key_prefix"`
+	KeySuffix string             `json:"key_suffix"`
+	Secret    string             `json:"secret"`
+	Active    bool               `json:"active"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             pgtype.UUID        `json:"id"`
+	WebhookID      pgtype.UUID        `json:"webhook_id"`
+	EventID        pgtype.UUID        `json:"event_id"`
+	Event          string             `json:"event"`
+	Payload        []byte             `json:"payload"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz `json:"next_attempt_at"`
+	LastAttemptAt  pgtype.Timestamptz `json:"last_attempt_at"`
+	ResponseStatus *int32             `json:"response_status"`
+	ResponseBody   *string            `json:"response_body"`
+	Error          *string            `json:"error"`
+	RedeliveryOf   pgtype.UUID        `json:"redelivery_of"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	CompletedAt    pgtype.Timestamptz `json:"completed_at"`
 }
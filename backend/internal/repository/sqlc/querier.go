@@ -11,32 +11,178 @@ import (
 )
 
 type Querier interface {
+	AddBucketRequestCounts(ctx context.Context, arg AddBucketRequestCountsParams) error
+	AddTeamMember(ctx context.Context, arg AddTeamMemberParams) error
+	CancelTagJob(ctx context.Context, arg CancelTagJobParams) (int64, error)
+	ClaimBucketsForUsageSnapshot(ctx context.Context, arg ClaimBucketsForUsageSnapshotParams) ([]Bucket, error)
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error)
+	ClaimTagJobs(ctx context.Context, arg ClaimTagJobsParams) ([]TagJob, error)
+	ClaimTagSearches(ctx context.Context, arg ClaimTagSearchesParams) ([]TagSearch, error)
+	ClaimUsageReports(ctx context.Context, arg ClaimUsageReportsParams) ([]UsageReport, error)
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	CommitQuotaReservation(ctx context.Context, arg CommitQuotaReservationParams) error
+	CompleteUsageReport(ctx context.Context, arg CompleteUsageReportParams) error
+	ConsumeEmailToken(ctx context.Context, arg ConsumeEmailTokenParams) (EmailToken, error)
+	ConsumeOIDCLoginRequest(ctx context.Context, state string) (OidcLoginRequest, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID pgtype.UUID) (int64, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
 	CreateCredential(ctx context.Context, arg CreateCredentialParams) (Credential, error)
+	CreateEmailToken(ctx context.Context, arg CreateEmailTokenParams) (EmailToken, error)
+	CreateInvite(ctx context.Context, arg CreateInviteParams) (Invite, error)
+	CreateOIDCLoginRequest(ctx context.Context, arg CreateOIDCLoginRequestParams) (OidcLoginRequest, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error
+	CreateOutboxEventHandler(ctx context.Context, arg CreateOutboxEventHandlerParams) error
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	CreateQuotaReservation(ctx context.Context, arg CreateQuotaReservationParams) (QuotaReservation, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateTagJob(ctx context.Context, arg CreateTagJobParams) (TagJob, error)
+	CreateTagSearch(ctx context.Context, arg CreateTagSearchParams) (TagSearch, error)
+	CreateTeam(ctx context.Context, arg CreateTeamParams) (Team, error)
+	CreateUsageSnapshot(ctx context.Context, arg CreateUsageSnapshotParams) error
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	DeleteAuditEventsBefore(ctx context.Context, occurredAt pgtype.Timestamptz) (int64, error)
 	DeleteBucket(ctx context.Context, arg DeleteBucketParams) error
+	DeleteBucketRequestCountsBefore(ctx context.Context, day pgtype.Date) (int64, error)
+	DeleteCommittedQuotaReservations(ctx context.Context, arg DeleteCommittedQuotaReservationsParams) error
 	DeleteCredential(ctx context.Context, arg DeleteCredentialParams) error
-	DeleteSessionByHash(ctx context.Context, refreshTokenHash string) error
-	DeleteSessionsForUser(ctx context.Context, userID pgtype.UUID) error
+	DeleteEmailTokensForUser(ctx context.Context, arg DeleteEmailTokensForUserParams) error
+	DeleteExpiredEmailTokens(ctx context.Context) error
+	DeleteExpiredOIDCLoginRequests(ctx context.Context) error
+	DeleteExpiredQuotaReservations(ctx context.Context) ([]QuotaReservation, error)
+	DeleteExpiredRetiredRefreshTokens(ctx context.Context) error
+	DeleteInvite(ctx context.Context, id pgtype.UUID) error
+	DeleteLoginAttempt(ctx context.Context, identifier string) (int64, error)
+	DeleteOrphanedQuotas(ctx context.Context) (int64, error)
+	DeleteOrphanedWebhooks(ctx context.Context) (int64, error)
+	DeleteOtherSessionsForUser(ctx context.Context, arg DeleteOtherSessionsForUserParams) ([]Session, error)
+	DeleteOutboxEventsBefore(ctx context.Context, occurredAt pgtype.Timestamptz) (int64, error)
+	DeletePersonalAccessToken(ctx context.Context, arg DeletePersonalAccessTokenParams) error
+	DeleteQuota(ctx context.Context, arg DeleteQuotaParams) (int64, error)
+	DeleteQuotaReservation(ctx context.Context, id pgtype.UUID) error
+	DeleteRecoveryCodes(ctx context.Context, userID pgtype.UUID) error
+	DeleteSession(ctx context.Context, id pgtype.UUID) ([]Session, error)
+	DeleteSessionByHash(ctx context.Context, refreshTokenHash string) ([]Session, error)
+	DeleteSessionForUser(ctx context.Context, arg DeleteSessionForUserParams) ([]Session, error)
+	DeleteSessionsForUser(ctx context.Context, userID pgtype.UUID) ([]Session, error)
+	DeleteStaleLoginAttempts(ctx context.Context, lastFailedAt pgtype.Timestamptz) error
+	DeleteTagJobsBefore(ctx context.Context, completedAt pgtype.Timestamptz) (int64, error)
+	DeleteTagSearchesBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	DeleteTeam(ctx context.Context, id pgtype.UUID) error
+	DeleteUsageAlert(ctx context.Context, bucketID pgtype.UUID) (int64, error)
+	DeleteUsageReportsBefore(ctx context.Context, requestedAt pgtype.Timestamptz) (int64, error)
+	DeleteUsageSnapshotsBefore(ctx context.Context, capturedAt pgtype.Timestamptz) (int64, error)
 	DeleteUser(ctx context.Context, id pgtype.UUID) error
+	DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) error
+	DeleteWebhookDeliveriesBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	DisableUserTOTP(ctx context.Context, id pgtype.UUID) error
+	EnableUserTOTP(ctx context.Context, id pgtype.UUID) error
+	EnqueueWebhookDelivery(ctx context.Context, arg EnqueueWebhookDeliveryParams) error
+	FailUsageReport(ctx context.Context, arg FailUsageReportParams) error
+	FinishTagJob(ctx context.Context, arg FinishTagJobParams) (int64, error)
+	FinishTagSearch(ctx context.Context, arg FinishTagSearchParams) (int64, error)
 	GetBucket(ctx context.Context, arg GetBucketParams) (GetBucketRow, error)
+	GetBucketByID(ctx context.Context, id pgtype.UUID) (Bucket, error)
 	GetBucketByName(ctx context.Context, arg GetBucketByNameParams) (GetBucketByNameRow, error)
+	GetBucketReservedStorage(ctx context.Context, bucketID pgtype.UUID) (GetBucketReservedStorageRow, error)
+	GetBucketStorageUsage(ctx context.Context, id pgtype.UUID) (GetBucketStorageUsageRow, error)
 	GetCredential(ctx context.Context, arg GetCredentialParams) (Credential, error)
+	GetEmailToken(ctx context.Context, arg GetEmailTokenParams) (EmailToken, error)
+	GetFirstUsageSnapshot(ctx context.Context, arg GetFirstUsageSnapshotParams) (UsageSnapshot, error)
+	GetInstanceSetting(ctx context.Context, key string) (InstanceSetting, error)
+	GetInvite(ctx context.Context, id pgtype.UUID) (Invite, error)
+	GetInviteByHash(ctx context.Context, codeHash string) (Invite, error)
+	GetLatestUsageSnapshotBefore(ctx context.Context, arg GetLatestUsageSnapshotBeforeParams) (UsageSnapshot, error)
+	GetLoginAttempt(ctx context.Context, identifier string) (LoginAttempt, error)
+	GetPersonalAccessToken(ctx context.Context, arg GetPersonalAccessTokenParams) (PersonalAccessToken, error)
+	GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (PersonalAccessToken, error)
 	GetProfileByID(ctx context.Context, id pgtype.UUID) (Profile, error)
 	GetProfileByUserID(ctx context.Context, userID pgtype.UUID) (Profile, error)
+	GetRetiredRefreshToken(ctx context.Context, tokenHash string) (RetiredRefreshToken, error)
+	GetSession(ctx context.Context, id pgtype.UUID) (Session, error)
 	GetSessionByHash(ctx context.Context, refreshTokenHash string) (Session, error)
+	GetTagJob(ctx context.Context, arg GetTagJobParams) (TagJob, error)
+	GetTagSearch(ctx context.Context, arg GetTagSearchParams) (TagSearch, error)
+	GetTeam(ctx context.Context, id pgtype.UUID) (Team, error)
+	GetTeamMember(ctx context.Context, arg GetTeamMemberParams) (TeamMember, error)
+	GetTeamReservedStorage(ctx context.Context, teamID pgtype.UUID) (GetTeamReservedStorageRow, error)
+	GetTeamStorageUsage(ctx context.Context, teamID pgtype.UUID) (GetTeamStorageUsageRow, error)
+	GetUsageAlert(ctx context.Context, bucketID pgtype.UUID) (UsageAlert, error)
+	GetUsageReport(ctx context.Context, arg GetUsageReportParams) (UsageReport, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetUserReservedStorage(ctx context.Context, userID pgtype.UUID) (GetUserReservedStorageRow, error)
+	GetUserStorageUsage(ctx context.Context, userID pgtype.UUID) (GetUserStorageUsageRow, error)
+	GetWebhook(ctx context.Context, arg GetWebhookParams) (Webhook, error)
+	GetWebhookByID(ctx context.Context, id pgtype.UUID) (Webhook, error)
+	GetWebhookDelivery(ctx context.Context, arg GetWebhookDeliveryParams) (WebhookDelivery, error)
+	IncrementUserTokenVersion(ctx context.Context, id pgtype.UUID) (int32, error)
 	InsertBucket(ctx context.Context, arg InsertBucketParams) (Bucket, error)
 	InsertUser(ctx context.Context, arg InsertUserParams) (User, error)
+	ListAllCredentialsForUpdate(ctx context.Context) ([]Credential, error)
+	ListAllTeamMembers(ctx context.Context) ([]ListAllTeamMembersRow, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListBucketRequestTotals(ctx context.Context, since pgtype.Date) ([]ListBucketRequestTotalsRow, error)
 	ListBuckets(ctx context.Context, userID pgtype.UUID) ([]ListBucketsRow, error)
+	ListBucketsForCosts(ctx context.Context, arg ListBucketsForCostsParams) ([]ListBucketsForCostsRow, error)
 	ListCredentials(ctx context.Context, userID pgtype.UUID) ([]Credential, error)
+	ListInvites(ctx context.Context) ([]Invite, error)
+	ListInvitesByCreator(ctx context.Context, createdBy pgtype.UUID) ([]Invite, error)
+	ListLockedLoginAttempts(ctx context.Context) ([]LoginAttempt, error)
+	ListOutboxEventHandlers(ctx context.Context, eventID pgtype.UUID) ([]string, error)
+	ListPersonalAccessTokens(ctx context.Context, userID pgtype.UUID) ([]PersonalAccessToken, error)
+	ListQuotas(ctx context.Context) ([]ListQuotasRow, error)
+	ListQuotasForUser(ctx context.Context, userID pgtype.UUID) ([]ListQuotasForUserRow, error)
+	ListRootUsageReports(ctx context.Context) ([]ListRootUsageReportsRow, error)
+	ListSessionsForUser(ctx context.Context, userID pgtype.UUID) ([]Session, error)
+	ListTagJobs(ctx context.Context, arg ListTagJobsParams) ([]TagJob, error)
+	ListTeamMembers(ctx context.Context, teamID pgtype.UUID) ([]ListTeamMembersRow, error)
+	ListTeamsForUser(ctx context.Context, userID pgtype.UUID) ([]ListTeamsForUserRow, error)
+	ListUsageSnapshots(ctx context.Context, arg ListUsageSnapshotsParams) ([]UsageSnapshot, error)
+	ListUsersWithTOTPSecretForUpdate(ctx context.Context) ([]User, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context, bucketID pgtype.UUID) ([]Webhook, error)
+	ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error)
+	ListWebhooksForUpdate(ctx context.Context) ([]Webhook, error)
+	LockLoginAttempt(ctx context.Context, arg LockLoginAttemptParams) error
+	LockQuotasForWrite(ctx context.Context, arg LockQuotasForWriteParams) ([]Quota, error)
+	MarkOutboxEventDispatched(ctx context.Context, id pgtype.UUID) error
+	MarkUsageAlertTriggered(ctx context.Context, arg MarkUsageAlertTriggeredParams) error
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginAttempt, error)
+	RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error
+	RedeemInvite(ctx context.Context, id pgtype.UUID) (int64, error)
+	ReleaseInvite(ctx context.Context, id pgtype.UUID) error
+	RemoveTeamMember(ctx context.Context, arg RemoveTeamMemberParams) (int64, error)
+	RequestUsageReport(ctx context.Context, arg RequestUsageReportParams) (UsageReport, error)
+	RetireRefreshToken(ctx context.Context, arg RetireRefreshTokenParams) error
+	RotateSessionToken(ctx context.Context, arg RotateSessionTokenParams) (int64, error)
+	SaveUsageReport(ctx context.Context, arg SaveUsageReportParams) (UsageReport, error)
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) error
+	TouchPersonalAccessToken(ctx context.Context, id pgtype.UUID) error
 	UpdateBucket(ctx context.Context, arg UpdateBucketParams) error
-	UpdateBucketSize(ctx context.Context, arg UpdateBucketSizeParams) error
+	UpdateBucketUsage(ctx context.Context, arg UpdateBucketUsageParams) error
 	UpdateCredential(ctx context.Context, arg UpdateCredentialParams) error
-	UpdateSessionToken(ctx context.Context, arg UpdateSessionTokenParams) error
+	UpdateCredentialSecrets(ctx context.Context, arg UpdateCredentialSecretsParams) error
+	UpdateTagJobProgress(ctx context.Context, arg UpdateTagJobProgressParams) (int64, error)
+	UpdateTagSearchProgress(ctx context.Context, arg UpdateTagSearchProgressParams) (int64, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) error
+	UpdateUserAdmin(ctx context.Context, arg UpdateUserAdminParams) error
+	UpdateUserIdentityEmail(ctx context.Context, arg UpdateUserIdentityEmailParams) error
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserTOTPLastStep(ctx context.Context, arg UpdateUserTOTPLastStepParams) (int64, error)
+	UpdateUserTOTPSecretCiphertext(ctx context.Context, arg UpdateUserTOTPSecretCiphertextParams) error
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error)
+	UpdateWebhookSecret(ctx context.Context, arg UpdateWebhookSecretParams) error
+	UpsertInstanceSetting(ctx context.Context, arg UpsertInstanceSettingParams) error
 	UpsertProfile(ctx context.Context, arg UpsertProfileParams) error
+	UpsertQuota(ctx context.Context, arg UpsertQuotaParams) (Quota, error)
+	UpsertUsageAlert(ctx context.Context, arg UpsertUsageAlertParams) (UsageAlert, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) error
 }
 
 var _ Querier = (*Queries)(nil)
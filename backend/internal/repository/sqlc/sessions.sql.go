@@ -12,9 +12,9 @@ import (
 )
 
 const createSession = `-- name: CreateSession :one
-INSERT INTO sessions (id, user_id, refresh_token_hash, expires_at)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, refresh_token_hash, expires_at, created_at, updated_at
+INSERT INTO sessions (id, user_id, refresh_token_hash, expires_at, user_agent, ip_address)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at
 `
 
 type CreateSessionParams struct {
@@ -22,6 +22,8 @@ type CreateSessionParams struct {
 	UserID           pgtype.UUID        `json:"user_id"`
 	RefreshTokenHash string             `json:"refresh_token_hash"`
 	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	UserAgent        string             `json:"user_agent"`
+	IpAddress        string             `json:"ip_address"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -30,6 +32,8 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.UserID,
 		arg.RefreshTokenHash,
 		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.IpAddress,
 	)
 	var i Session
 	err := row.Scan(
@@ -39,30 +43,221 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
 
-const deleteSessionByHash = `-- name: DeleteSessionByHash :exec
+const deleteOtherSessionsForUser = `-- name: DeleteOtherSessionsForUser :many
+DELETE FROM sessions WHERE user_id = $1 AND id <> $2
+RETURNING id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at
+`
+
+type DeleteOtherSessionsForUserParams struct {
+	UserID pgtype.UUID `json:"user_id"`
+	ID     pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) DeleteOtherSessionsForUser(ctx context.Context, arg DeleteOtherSessionsForUserParams) ([]Session, error) {
+	rows, err := q.db.Query(ctx, deleteOtherSessionsForUser, arg.UserID, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshTokenHash,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteSession = `-- name: DeleteSession :many
+DELETE FROM sessions WHERE id = $1
+RETURNING id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, id pgtype.UUID) ([]Session, error) {
+	rows, err := q.db.Query(ctx, deleteSession, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshTokenHash,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteSessionByHash = `-- name: DeleteSessionByHash :many
 DELETE FROM sessions WHERE refresh_token_hash = $1
+RETURNING id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at
 `
 
-func (q *Queries) DeleteSessionByHash(ctx context.Context, refreshTokenHash string) error {
-	_, err := q.db.Exec(ctx, deleteSessionByHash, refreshTokenHash)
-	return err
+func (q *Queries) DeleteSessionByHash(ctx context.Context, refreshTokenHash string) ([]Session, error) {
+	rows, err := q.db.Query(ctx, deleteSessionByHash, refreshTokenHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshTokenHash,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const deleteSessionsForUser = `-- name: DeleteSessionsForUser :exec
+const deleteSessionForUser = `-- name: DeleteSessionForUser :many
+DELETE FROM sessions WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at
+`
+
+type DeleteSessionForUserParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteSessionForUser(ctx context.Context, arg DeleteSessionForUserParams) ([]Session, error) {
+	rows, err := q.db.Query(ctx, deleteSessionForUser, arg.ID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshTokenHash,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteSessionsForUser = `-- name: DeleteSessionsForUser :many
 DELETE FROM sessions WHERE user_id = $1
+RETURNING id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at
+`
+
+func (q *Queries) DeleteSessionsForUser(ctx context.Context, userID pgtype.UUID) ([]Session, error) {
+	rows, err := q.db.Query(ctx, deleteSessionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshTokenHash,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSession = `-- name: GetSession :one
+SELECT id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at FROM sessions WHERE id = $1
 `
 
-func (q *Queries) DeleteSessionsForUser(ctx context.Context, userID pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteSessionsForUser, userID)
-	return err
+func (q *Queries) GetSession(ctx context.Context, id pgtype.UUID) (Session, error) {
+	row := q.db.QueryRow(ctx, getSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+	)
+	return i, err
 }
 
 const getSessionByHash = `-- name: GetSessionByHash :one
-SELECT id, user_id, refresh_token_hash, expires_at, created_at, updated_at FROM sessions WHERE refresh_token_hash = $1
+SELECT id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at FROM sessions WHERE refresh_token_hash = $1
 `
 
 func (q *Queries) GetSessionByHash(ctx context.Context, refreshTokenHash string) (Session, error) {
@@ -75,23 +270,80 @@ func (q *Queries) GetSessionByHash(ctx context.Context, refreshTokenHash string)
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
 
-const updateSessionToken = `-- name: UpdateSessionToken :exec
+const listSessionsForUser = `-- name: ListSessionsForUser :many
+SELECT id, user_id, refresh_token_hash, expires_at, created_at, updated_at, user_agent, ip_address, last_used_at FROM sessions
+WHERE user_id = $1 AND expires_at > NOW()
+ORDER BY last_used_at DESC
+`
+
+func (q *Queries) ListSessionsForUser(ctx context.Context, userID pgtype.UUID) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listSessionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshTokenHash,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rotateSessionToken = `-- name: RotateSessionToken :execrows
 UPDATE sessions
-SET refresh_token_hash = $2, expires_at = $3, updated_at = NOW()
-WHERE id = $1
+SET refresh_token_hash = $1,
+    expires_at = $2,
+    user_agent = $3,
+    ip_address = $4,
+    last_used_at = NOW(),
+    updated_at = NOW()
+WHERE id = $5 AND refresh_token_hash = $6
 `
 
-type UpdateSessionTokenParams struct {
-	ID               pgtype.UUID        `json:"id"`
-	RefreshTokenHash string             `json:"refresh_token_hash"`
+type RotateSessionTokenParams struct {
+	NewTokenHash     string             `json:"new_token_hash"`
 	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	UserAgent        string             `json:"user_agent"`
+	IpAddress        string             `json:"ip_address"`
+	ID               pgtype.UUID        `json:"id"`
+	CurrentTokenHash string             `json:"current_token_hash"`
 }
 
-func (q *Queries) UpdateSessionToken(ctx context.Context, arg UpdateSessionTokenParams) error {
-	_, err := q.db.Exec(ctx, updateSessionToken, arg.ID, arg.RefreshTokenHash, arg.ExpiresAt)
-	return err
+func (q *Queries) RotateSessionToken(ctx context.Context, arg RotateSessionTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rotateSessionToken,
+		arg.NewTokenHash,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.ID,
+		arg.CurrentTokenHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
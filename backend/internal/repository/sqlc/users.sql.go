@@ -20,8 +20,30 @@ func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const disableUserTOTP = `-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, disableUserTOTP, id)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, enableUserTOTP, id)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, first_name, last_name, created_at, updated_at, is_demo FROM users WHERE email = $1
+SELECT id, email, password_hash, first_name, last_name, created_at, updated_at, is_demo, is_admin, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, token_version FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -36,12 +58,18 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsDemo,
+		&i.IsAdmin,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash, first_name, last_name, created_at, updated_at, is_demo FROM users WHERE id = $1
+SELECT id, email, password_hash, first_name, last_name, created_at, updated_at, is_demo, is_admin, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, token_version FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error) {
@@ -56,14 +84,34 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsDemo,
+		&i.IsAdmin,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
+const incrementUserTokenVersion = `-- name: IncrementUserTokenVersion :one
+UPDATE users
+SET token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING token_version
+`
+
+func (q *Queries) IncrementUserTokenVersion(ctx context.Context, id pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementUserTokenVersion, id)
+	var tokenVersion int32
+	err := row.Scan(&tokenVersion)
+	return tokenVersion, err
+}
+
 const insertUser = `-- name: InsertUser :one
 INSERT INTO users (id, email, password_hash, first_name, last_name)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, email, password_hash, first_name, last_name, created_at, updated_at, is_demo
+RETURNING id, email, password_hash, first_name, last_name, created_at, updated_at, is_demo, is_admin, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, token_version
 `
 
 type InsertUserParams struct {
@@ -92,10 +140,74 @@ func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsDemo,
+		&i.IsAdmin,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
+const listUsersWithTOTPSecretForUpdate = `-- name: ListUsersWithTOTPSecretForUpdate :many
+SELECT id, email, password_hash, first_name, last_name, created_at, updated_at, is_demo, is_admin, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, token_version FROM users
+WHERE totp_secret IS NOT NULL
+ORDER BY created_at
+FOR UPDATE
+`
+
+func (q *Queries) ListUsersWithTOTPSecretForUpdate(ctx context.Context) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersWithTOTPSecretForUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.FirstName,
+			&i.LastName,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsDemo,
+			&i.IsAdmin,
+			&i.TotpSecret,
+			&i.TotpEnabledAt,
+			&i.TotpLastStep,
+			&i.EmailVerifiedAt,
+			&i.TokenVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserTOTPSecretParams struct {
+	ID         pgtype.UUID `json:"id"`
+	TotpSecret *string     `json:"totp_secret"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) error {
+	_, err := q.db.Exec(ctx, setUserTOTPSecret, arg.ID, arg.TotpSecret)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :exec
 UPDATE users
 SET email = $2, first_name = $3, last_name = $4, updated_at = NOW()
@@ -119,6 +231,22 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
 	return err
 }
 
+const updateUserAdmin = `-- name: UpdateUserAdmin :exec
+UPDATE users
+SET is_admin = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserAdminParams struct {
+	ID      pgtype.UUID `json:"id"`
+	IsAdmin bool        `json:"is_admin"`
+}
+
+func (q *Queries) UpdateUserAdmin(ctx context.Context, arg UpdateUserAdminParams) error {
+	_, err := q.db.Exec(ctx, updateUserAdmin, arg.ID, arg.IsAdmin)
+	return err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
 SET password_hash = $2, updated_at = NOW()
@@ -134,3 +262,54 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
 	return err
 }
+
+const updateUserTOTPLastStep = `-- name: UpdateUserTOTPLastStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1 AND totp_last_step < $2
+`
+
+type UpdateUserTOTPLastStepParams struct {
+	ID           pgtype.UUID `json:"id"`
+	TotpLastStep int64       `json:"totp_last_step"`
+}
+
+func (q *Queries) UpdateUserTOTPLastStep(ctx context.Context, arg UpdateUserTOTPLastStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateUserTOTPLastStep, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUserTOTPSecretCiphertext = `-- name: UpdateUserTOTPSecretCiphertext :exec
+UPDATE users
+SET totp_secret = $2
+WHERE id = $1
+`
+
+type UpdateUserTOTPSecretCiphertextParams struct {
+	ID         pgtype.UUID `json:"id"`
+	TotpSecret *string     `json:"totp_secret"`
+}
+
+func (q *Queries) UpdateUserTOTPSecretCiphertext(ctx context.Context, arg UpdateUserTOTPSecretCiphertextParams) error {
+	_, err := q.db.Exec(ctx, updateUserTOTPSecretCiphertext, arg.ID, arg.TotpSecret)
+	return err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :exec
+UPDATE users
+SET email = $2, email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+type VerifyUserEmailParams struct {
+	ID    pgtype.UUID `json:"id"`
+	Email string      `json:"email"`
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) error {
+	_, err := q.db.Exec(ctx, verifyUserEmail, arg.ID, arg.Email)
+	return err
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions
+const (
+	AuditAuthRegister          = "auth.register"
+	AuditAuthLogin             = "auth.login"
+	AuditAuthMFAVerify         = "auth.mfa_verify"
+	AuditAuthOIDCLogin         = "auth.oidc_login"
+	AuditAuthDemoLogin         = "auth.demo_login"
+	AuditAuthPasswordReset     = "auth.password_reset"
+	AuditAuth2FAEnable         = "auth.2fa_enable"
+	AuditAuth2FADisable        = "auth.2fa_disable"
+	AuditAuth2FARecoveryCodes  = "auth.2fa_recovery_codes"
+	AuditAuthLogout            = "auth.logout"
+	AuditAuthRefreshTokenReuse = "auth.refresh_token_reuse"
+
+	AuditProfileUpdate           = "profile.update"
+	AuditProfilePasswordChange   = "profile.password_change"
+	AuditProfileVerificationSent = "profile.verification_sent"
+
+	AuditTokenCreate = "pat.create"
+	AuditTokenRevoke = "pat.revoke"
+
+	AuditCredentialCreate = "credential.create"
+	AuditCredentialUpdate = "credential.update"
+	AuditCredentialDelete = "credential.delete"
+
+	AuditBucketCreate                  = "bucket.create"
+	AuditBucketUpdate                  = "bucket.update"
+	AuditBucketDelete                  = "bucket.delete"
+	AuditBucketRecalculateSize         = "bucket.recalculate_size"
+	AuditBucketLifecycleSet            = "bucket.lifecycle_set"
+	AuditBucketLifecycleDelete         = "bucket.lifecycle_delete"
+	AuditBucketCorsSet                 = "bucket.cors_set"
+	AuditBucketCorsDelete              = "bucket.cors_delete"
+	AuditBucketPolicySet               = "bucket.policy_set"
+	AuditBucketPolicyDelete            = "bucket.policy_delete"
+	AuditBucketPublicAccessBlockSet    = "bucket.public_access_block_set"
+	AuditBucketPublicAccessBlockDelete = "bucket.public_access_block_delete"
+
+	AuditObjectUpload   = "object.upload"
+	AuditObjectDownload = "object.download"
+	AuditObjectPresign  = "object.presign"
+	AuditObjectDelete   = "object.delete"
+	AuditObjectRename   = "object.rename"
+	AuditObjectCopy     = "object.copy"
+	AuditFolderCreate   = "folder.create"
+	AuditFolderDownload = "folder.download"
+	AuditObjectTag      = "object.tag"
+	AuditObjectUntag    = "object.untag"
+	AuditTagJobCreate   = "tag_job.create"
+	AuditTagJobCancel   = "tag_job.cancel"
+
+	AuditWebhookCreate    = "webhook.create"
+	AuditWebhookUpdate    = "webhook.update"
+	AuditWebhookDelete    = "webhook.delete"
+	AuditWebhookPing      = "webhook.ping"
+	AuditWebhookRedeliver = "webhook.redeliver"
+
+	AuditQuotaSet    = "quota.set"
+	AuditQuotaDelete = "quota.delete"
+
+	AuditUsageAlertSet    = "usage_alert.set"
+	AuditUsageAlertDelete = "usage_alert.delete"
+
+	AuditAdminSettingsUpdate = "admin.settings_update"
+	AuditAdminUnlock         = "admin.unlock"
+)
+
+// Audit export formats
+const (
+	AuditFormatJSONL = "jsonl"
+	AuditFormatCSV   = "csv"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	// Long error messages, e.g. from S3, are cut to keep rows small
+	maxAuditErrorLength = 1000
+	auditWriteTimeout   = 5 * time.Second
+)
+
+var (
+	ErrInvalidAuditFilter = errors.New("invalid audit filter")
+	ErrInvalidAuditFormat = errors.New("audit export format must be jsonl or csv")
+)
+
+// AuditActor is the authenticated caller of a request
+type AuditActor struct {
+	UserID uuid.UUID
+	Email  string
+	// TokenID is set when the request used a personal access token
+	TokenID *uuid.UUID
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a context naming the authenticated caller for audit entries
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func auditActorFromContext(ctx context.Context) (AuditActor, bool) {
+	actor, ok := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor, ok
+}
+
+// AuditEntry describes an action to record. The client IP, user agent and
+// request ID are taken from the context.
+type AuditEntry struct {
+	Action string
+	// ActorID and ActorEmail default to the authenticated caller; set them for
+	// unauthenticated actions such as logins
+	ActorID    uuid.UUID
+	ActorEmail string
+	BucketID   uuid.UUID
+	BucketName string
+	ObjectKey  string
+	// TargetID identifies a target that is not a bucket or object, such as a credential
+	TargetID string
+	Details  map[string]string
+}
+
+// AuditService records user and admin actions in an append-only log
+type AuditService struct {
+	events repository.AuditRepository
+	// retention is how long events are kept; 0 keeps them forever
+	retention time.Duration
+	logger    *slog.Logger
+}
+
+func NewAuditService(events repository.AuditRepository, retention time.Duration, logger *slog.Logger) *AuditService {
+	return &AuditService{
+		events:    events,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Record appends an entry for an action whose result was err; nil means it
+// succeeded. Write failures are logged rather than returned, so auditing never
+// fails the action itself. A nil service records nothing.
+func (s *AuditService) Record(ctx context.Context, entry AuditEntry, err error) {
+	if s == nil {
+		return
+	}
+
+	event := &repository.AuditEvent{
+		ActorEmail: entry.ActorEmail,
+		Action:     entry.Action,
+		BucketName: entry.BucketName,
+		ObjectKey:  entry.ObjectKey,
+		TargetID:   entry.TargetID,
+		Details:    entry.Details,
+		Outcome:    repository.AuditOutcomeSuccess,
+	}
+	if entry.ActorID != uuid.Nil {
+		event.ActorID = &entry.ActorID
+	}
+	if actor, ok := auditActorFromContext(ctx); ok && (event.ActorID == nil || *event.ActorID == actor.UserID) {
+		event.ActorID = &actor.UserID
+		event.TokenID = actor.TokenID
+		if event.ActorEmail == "" {
+			event.ActorEmail = actor.Email
+		}
+	}
+	if entry.BucketID != uuid.Nil {
+		event.BucketID = &entry.BucketID
+	}
+	if err != nil {
+		event.Outcome = repository.AuditOutcomeFailure
+		event.Error = err.Error()
+		if len(event.Error) > maxAuditErrorLength {
+			event.Error = event.Error[:maxAuditErrorLength]
+		}
+	}
+
+	client := ClientInfoFromContext(ctx)
+	event.IPAddress = client.IPAddress
+	event.UserAgent = client.UserAgent
+	event.RequestID = client.RequestID
+
+	// The entry is written even if the client has gone away
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+	defer cancel()
+	if _, err := s.events.Create(writeCtx, event); err != nil {
+		s.logger.Error("failed to write audit event",
+			slog.String("action", entry.Action),
+			slog.String("request_id", client.RequestID),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// AuditPage is one page of audit events, newest first
+type AuditPage struct {
+	Events []*repository.AuditEvent
+	// NextCursor is passed as BeforeID to get the next page; 0 on the last page
+	NextCursor int64
+}
+
+// List returns one page of matching events
+func (s *AuditService) List(ctx context.Context, filter repository.AuditFilter) (*AuditPage, error) {
+	if err := validateAuditFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	events, err := s.events.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AuditPage{Events: events}
+	if len(events) == filter.Limit {
+		page.NextCursor = events[len(events)-1].ID
+	}
+	return page, nil
+}
+
+func validateAuditFilter(filter *repository.AuditFilter) error {
+	switch filter.Outcome {
+	case "", repository.AuditOutcomeSuccess, repository.AuditOutcomeFailure:
+	default:
+		return fmt.Errorf("%w: outcome must be success or failure", ErrInvalidAuditFilter)
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return fmt.Errorf("%w: since must be before until", ErrInvalidAuditFilter)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	return nil
+}
+
+// AuditRecord is the exported form of an audit event, used by the API and exports
+type AuditRecord struct {
+	ID         int64             `json:"id"`
+	OccurredAt string            `json:"occurredAt"`
+	ActorID    string            `json:"actorId,omitempty"`
+	ActorEmail string            `json:"actorEmail,omitempty"`
+	TokenID    string            `json:"tokenId,omitempty"`
+	IPAddress  string            `json:"ipAddress,omitempty"`
+	UserAgent  string            `json:"userAgent,omitempty"`
+	RequestID  string            `json:"requestId,omitempty"`
+	Action     string            `json:"action"`
+	BucketID   string            `json:"bucketId,omitempty"`
+	BucketName string            `json:"bucketName,omitempty"`
+	ObjectKey  string            `json:"objectKey,omitempty"`
+	TargetID   string            `json:"targetId,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+}
+
+func NewAuditRecord(event *repository.AuditEvent) AuditRecord {
+	record := AuditRecord{
+		ID:         event.ID,
+		OccurredAt: event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		ActorEmail: event.ActorEmail,
+		IPAddress:  event.IPAddress,
+		UserAgent:  event.UserAgent,
+		RequestID:  event.RequestID,
+		Action:     event.Action,
+		BucketName: event.BucketName,
+		ObjectKey:  event.ObjectKey,
+		TargetID:   event.TargetID,
+		Details:    event.Details,
+		Outcome:    event.Outcome,
+		Error:      event.Error,
+	}
+	if event.ActorID != nil {
+		record.ActorID = event.ActorID.String()
+	}
+	if event.TokenID != nil {
+		record.TokenID = event.TokenID.String()
+	}
+	if event.BucketID != nil {
+		record.BucketID = event.BucketID.String()
+	}
+	if len(record.Details) == 0 {
+		record.Details = nil
+	}
+	return record
+}
+
+var auditCSVHeader = []string{
+	"id", "occurred_at", "actor_id", "actor_email", "token_id", "ip_address", "user_agent", "request_id",
+	"action", "bucket_id", "bucket_name", "object_key", "target_id", "details", "outcome", "error",
+}
+
+// Export writes every matching event, newest first, as JSON lines or CSV.
+// filter.Limit caps the number of events; 0 exports all of them.
+func (s *AuditService) Export(ctx context.Context, w io.Writer, format string, filter repository.AuditFilter) error {
+	var write func(AuditRecord) error
+	var flush func() error
+	switch format {
+	case AuditFormatJSONL:
+		encoder := json.NewEncoder(w)
+		write = func(r AuditRecord) error { return encoder.Encode(r) }
+		flush = func() error { return nil }
+	case AuditFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(auditCSVHeader); err != nil {
+			return err
+		}
+		write = func(r AuditRecord) error {
+			details := ""
+			if len(r.Details) > 0 {
+				encoded, err := json.Marshal(r.Details)
+				if err != nil {
+					return err
+				}
+				details = string(encoded)
+			}
+			row := []string{
+				strconv.FormatInt(r.ID, 10), r.OccurredAt, r.ActorID, r.ActorEmail, r.TokenID, r.IPAddress, r.UserAgent, r.RequestID,
+				r.Action, r.BucketID, r.BucketName, r.ObjectKey, r.TargetID, details, r.Outcome, r.Error,
+			}
+			for i := range row {
+				row[i] = csvSafe(row[i])
+			}
+			return writer.Write(row)
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	default:
+		return ErrInvalidAuditFormat
+	}
+
+	remaining := filter.Limit
+	for {
+		filter.Limit = maxAuditPageSize
+		if remaining > 0 && remaining < maxAuditPageSize {
+			filter.Limit = remaining
+		}
+		page, err := s.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, event := range page.Events {
+			if err := write(NewAuditRecord(event)); err != nil {
+				return err
+			}
+		}
+		if remaining > 0 {
+			remaining -= len(page.Events)
+			if remaining <= 0 {
+				break
+			}
+		}
+		if page.NextCursor == 0 {
+			break
+		}
+		filter.BeforeID = page.NextCursor
+	}
+	return flush()
+}
+
+// csvSafe keeps spreadsheets from running a user-controlled value, such as an
+// email, user agent or object key, as a formula by prefixing it with a quote
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// Prune deletes events older than before
+func (s *AuditService) Prune(ctx context.Context, before time.Time) (int64, error) {
+	return s.events.DeleteBefore(ctx, before)
+}
+
+// Retention reports how long events are kept; 0 means forever
+func (s *AuditService) Retention() time.Duration {
+	return s.retention
+}
+
+// RunRetention deletes events older than the retention period every interval until ctx is done
+func (s *AuditService) RunRetention(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := s.Prune(ctx, time.Now().Add(-s.retention))
+		if err != nil {
+			s.logger.Error("failed to prune audit log", slog.Any("error", err))
+		} else if deleted > 0 {
+			s.logger.Info("pruned audit log", slog.Int64("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
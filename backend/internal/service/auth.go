@@ -15,27 +15,89 @@ import (
 )
 
 type AuthService struct {
-	users           repository.UserRepository
-	sessions        repository.SessionRepository
-	tokenManager    *jwt.TokenManager
-	refreshTokenTTL time.Duration
-	logger          *slog.Logger
+	users            repository.UserRepository
+	sessions         repository.SessionRepository
+	tokenManager     *jwt.TokenManager
+	refreshTokenTTL  time.Duration
+	passwordLogin    bool
+	registrationMode RegistrationMode
+	passwords        *PasswordPolicy
+	ldap             *LDAPAuthenticator
+	twoFactor        *TwoFactorService
+	lockout          *LockoutService
+	invites          *InviteService
+	emails           *EmailService
+	audit            *AuditService
+	events           *EventBus
+	logger           *slog.Logger
 }
 
+const (
+	// How long a user has to enter the second factor after a successful password check
+	mfaChallengeTTL = 5 * time.Minute
+
+	// A token rotated this recently is treated as a benign race between browser tabs
+	// refreshing at the same time rather than as reuse of a stolen token
+	refreshTokenReuseGrace = 10 * time.Second
+)
+
 func NewAuthService(
 	users repository.UserRepository,
 	sessions repository.SessionRepository,
 	tokenManager *jwt.TokenManager,
 	refreshTokenTTL time.Duration,
+	passwordLogin bool,
+	registrationMode RegistrationMode,
+	passwords *PasswordPolicy,
+	ldap *LDAPAuthenticator,
+	twoFactor *TwoFactorService,
+	lockout *LockoutService,
+	invites *InviteService,
+	emails *EmailService,
+	audit *AuditService,
+	events *EventBus,
 	logger *slog.Logger,
 ) *AuthService {
 	return &AuthService{
-		users:           users,
-		sessions:        sessions,
-		tokenManager:    tokenManager,
-		refreshTokenTTL: refreshTokenTTL,
-		logger:          logger,
+		users:            users,
+		sessions:         sessions,
+		tokenManager:     tokenManager,
+		refreshTokenTTL:  refreshTokenTTL,
+		passwordLogin:    passwordLogin,
+		registrationMode: registrationMode,
+		passwords:        passwords,
+		ldap:             ldap,
+		twoFactor:        twoFactor,
+		lockout:          lockout,
+		invites:          invites,
+		emails:           emails,
+		audit:            audit,
+		events:           events,
+		logger:           logger,
+	}
+}
+
+// PasswordPolicy returns the rules new passwords must meet
+func (s *AuthService) PasswordPolicy() PasswordPolicy {
+	if s.passwords == nil {
+		return DefaultPasswordPolicy
 	}
+	return *s.passwords
+}
+
+// PasswordLoginEnabled reports whether users may sign in with local email and password accounts
+func (s *AuthService) PasswordLoginEnabled() bool {
+	return s.passwordLogin
+}
+
+// LDAPEnabled reports whether logins are checked against an LDAP directory
+func (s *AuthService) LDAPEnabled() bool {
+	return s.ldap != nil
+}
+
+// RegistrationMode reports who may create an account through Register
+func (s *AuthService) RegistrationMode() RegistrationMode {
+	return s.registrationMode
 }
 
 type RegisterInput struct {
@@ -43,6 +105,8 @@ type RegisterInput struct {
 	Password  string
 	FirstName string
 	LastName  string
+	// InviteCode is required in invite mode and optional in open mode
+	InviteCode string
 }
 
 type AuthResult struct {
@@ -51,9 +115,41 @@ type AuthResult struct {
 	AccessExpiry  time.Time
 	RefreshToken  string
 	RefreshExpiry time.Time
+
+	// MFAToken is set instead of the tokens above when a second factor is required
+	MFAToken  string
+	MFAExpiry time.Time
+	// TwoFactorEnrollmentRequired is set when the instance enforces two-factor
+	// authentication and the user has not enrolled yet
+	TwoFactorEnrollmentRequired bool
+	// EmailVerificationRequired is set instead of tokens when a new account must
+	// confirm its email address before signing in
+	EmailVerificationRequired bool
+}
+
+// MFARequired reports whether the login must be completed with VerifyMFA
+func (r *AuthResult) MFARequired() bool {
+	return r.MFAToken != ""
 }
 
-func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthResult, error) {
+func (s *AuthService) Register(ctx context.Context, input RegisterInput) (result *AuthResult, err error) {
+	defer func() {
+		s.recordAuth(ctx, AuditAuthRegister, strings.TrimSpace(strings.ToLower(input.Email)), result, err)
+	}()
+
+	inviteCode := strings.TrimSpace(input.InviteCode)
+	switch s.registrationMode {
+	case RegistrationClosed:
+		return nil, ErrRegistrationClosed
+	case RegistrationInvite:
+		if inviteCode == "" {
+			return nil, ErrInviteRequired
+		}
+	}
+	if inviteCode != "" && s.invites == nil {
+		return nil, ErrInvalidInvite
+	}
+
 	// Normalize and validate email
 	email := strings.TrimSpace(strings.ToLower(input.Email))
 	if email == "" {
@@ -64,6 +160,13 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthR
 	if input.Password == "" {
 		return nil, errors.New("password is required")
 	}
+	if err := s.passwords.Check(input.Password, PasswordOwner{
+		Email:     email,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+	}); err != nil {
+		return nil, err
+	}
 
 	// Check if email already exists
 	if _, err := s.users.GetByEmail(ctx, email); err == nil {
@@ -78,16 +181,44 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthR
 		return nil, err
 	}
 
+	// Take one use of the invite before creating the account so concurrent
+	// registrations cannot exceed its limit
+	var invite *repository.Invite
+	if inviteCode != "" {
+		invite, err = s.invites.Redeem(ctx, inviteCode, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create user
 	firstName := strings.TrimSpace(input.FirstName)
 	lastName := strings.TrimSpace(input.LastName)
 	user, err := s.users.Create(ctx, email, hash, firstName, lastName)
 	if err != nil {
+		if invite != nil {
+			s.invites.Release(ctx, invite)
+		}
 		return nil, err
 	}
 
+	if invite != nil {
+		if err := s.invites.Accept(ctx, invite, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.emails != nil {
+		if err := s.emails.SendVerification(ctx, user); err != nil {
+			s.logger.Error("failed to send verification email", slog.String("user_id", user.ID.String()), slog.Any("error", err))
+		}
+		if s.emails.RequireVerification() {
+			return &AuthResult{User: user, EmailVerificationRequired: true}, nil
+		}
+	}
+
 	// Issue tokens
-	tokens, err := s.issueTokens(ctx, user.ID)
+	tokens, err := s.issueTokens(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +232,58 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthR
 	}, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthResult, error) {
+func (s *AuthService) Login(ctx context.Context, email, password string) (result *AuthResult, err error) {
+	defer func() {
+		s.recordAuth(ctx, AuditAuthLogin, email, result, err)
+	}()
+
+	if err := s.checkLockout(ctx, email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.authenticate(ctx, email, password)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			return nil, s.recordFailure(ctx, email, err)
+		}
+		return nil, err
+	}
+
+	if err := s.resetLockout(ctx, email); err != nil {
+		return nil, err
+	}
+
+	// Directory accounts have no local password and are vouched for by the directory
+	if s.emails != nil && s.emails.RequireVerification() && user.PasswordHash != "" && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	return s.completeLogin(ctx, user)
+}
+
+// authenticate verifies a password against the directory and local accounts
+func (s *AuthService) authenticate(ctx context.Context, email, password string) (*repository.User, error) {
+	// Directory accounts take precedence; local accounts remain available as a break-glass fallback
+	if s.ldap != nil {
+		user, err := s.ldap.Authenticate(ctx, email, password)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, ErrInvalidCredentials) {
+			s.logger.Warn("ldap authentication failed, falling back to local accounts", slog.Any("error", err))
+		}
+		if !s.passwordLogin {
+			if errors.Is(err, ErrSSOAccountConflict) {
+				return nil, err
+			}
+			return nil, ErrInvalidCredentials
+		}
+	}
+
+	if !s.passwordLogin {
+		return nil, ErrPasswordLoginDisabled
+	}
+
 	// Normalize email
 	normalized := strings.TrimSpace(strings.ToLower(email))
 
@@ -114,6 +296,11 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthR
 		return nil, err
 	}
 
+	// Users provisioned through single sign-on have no local password
+	if user.PasswordHash == "" {
+		return nil, ErrInvalidCredentials
+	}
+
 	// Verify password
 	ok, err := crypto.VerifyPassword(user.PasswordHash, password)
 	if err != nil {
@@ -123,19 +310,118 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthR
 		return nil, ErrInvalidCredentials
 	}
 
-	// Issue tokens
-	tokens, err := s.issueTokens(ctx, user.ID)
+	s.upgradePasswordHash(ctx, user, password)
+
+	return user, nil
+}
+
+// upgradePasswordHash rehashes the password after a successful login when its hash
+// was made with other Argon2 parameters than the current ones. Failures are only
+// logged; the old hash keeps working.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, user *repository.User, password string) {
+	if !crypto.PasswordNeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hash, err := crypto.HashPassword(password)
+	if err != nil {
+		s.logger.Warn("failed to rehash password", slog.String("user_id", user.ID.String()), slog.Any("error", err))
+		return
+	}
+	if err := s.users.UpdatePassword(ctx, user.ID, hash); err != nil {
+		s.logger.Warn("failed to store rehashed password", slog.String("user_id", user.ID.String()), slog.Any("error", err))
+		return
+	}
+	user.PasswordHash = hash
+	s.logger.Info("password rehashed with current parameters", slog.String("user_id", user.ID.String()))
+}
+
+func (s *AuthService) checkLockout(ctx context.Context, identifier string) error {
+	if s.lockout == nil {
+		return nil
+	}
+	return s.lockout.Check(ctx, identifier)
+}
+
+// recordFailure counts a failed attempt and returns either the lockout or the original error
+func (s *AuthService) recordFailure(ctx context.Context, identifier string, cause error) error {
+	if s.lockout == nil {
+		return cause
+	}
+	if err := s.lockout.RecordFailure(ctx, identifier); err != nil {
+		return err
+	}
+	return cause
+}
+
+func (s *AuthService) resetLockout(ctx context.Context, identifier string) error {
+	if s.lockout == nil {
+		return nil
+	}
+	return s.lockout.Reset(ctx, identifier)
+}
+
+// completeLogin either issues tokens or, when the user has two-factor
+// authentication enabled, an MFA challenge token to be redeemed with VerifyMFA.
+// Password, LDAP and single sign-on logins all finish here.
+func (s *AuthService) completeLogin(ctx context.Context, user *repository.User) (*AuthResult, error) {
+	if s.twoFactor != nil && TwoFactorEnabled(user) {
+		mfaToken, mfaExpiry, err := s.tokenManager.GenerateMFAChallenge(user.ID, mfaChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResult{
+			User:      user,
+			MFAToken:  mfaToken,
+			MFAExpiry: mfaExpiry,
+		}, nil
+	}
+
+	return s.newAuthResult(ctx, user)
+}
+
+// VerifyMFA completes a login with a TOTP or recovery code
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string) (result *AuthResult, err error) {
+	var userID uuid.UUID
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditAuthMFAVerify, ActorID: userID}, err)
+	}()
+
+	if s.twoFactor == nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	userID, err = s.tokenManager.ValidateMFAChallenge(mfaToken)
 	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
 		return nil, err
 	}
+	if !TwoFactorEnabled(user) {
+		return nil, ErrInvalidMFAToken
+	}
 
-	return &AuthResult{
-		User:          user,
-		AccessToken:   tokens.accessToken,
-		AccessExpiry:  tokens.accessExpiry,
-		RefreshToken:  tokens.refreshToken,
-		RefreshExpiry: tokens.refreshExpiry,
-	}, nil
+	// Failed codes count towards the same lockout as failed passwords
+	if err := s.checkLockout(ctx, user.Email); err != nil {
+		return nil, err
+	}
+	if err := s.twoFactor.Verify(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			return nil, s.recordFailure(ctx, user.Email, err)
+		}
+		return nil, err
+	}
+	if err := s.resetLockout(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	return s.newAuthResult(ctx, user)
 }
 
 func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthResult, error) {
@@ -148,7 +434,7 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthRe
 	session, err := s.sessions.GetByHash(ctx, hash)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrInvalidRefreshToken
+			return nil, s.detectRefreshTokenReuse(ctx, hash)
 		}
 		return nil, err
 	}
@@ -169,7 +455,7 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthRe
 	}
 
 	// Rotate session
-	tokens, err := s.rotateSession(ctx, session.ID, user.ID)
+	tokens, err := s.rotateSession(ctx, session, user)
 	if err != nil {
 		return nil, err
 	}
@@ -183,36 +469,111 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthRe
 	}, nil
 }
 
+// detectRefreshTokenReuse handles a refresh token that matches no session. If it was
+// rotated out or revoked earlier, someone is replaying it, so the whole session family
+// is revoked: either the legitimate client or an attacker holds the current token, and
+// neither can be trusted.
+func (s *AuthService) detectRefreshTokenReuse(ctx context.Context, hash string) error {
+	retired, err := s.sessions.GetRetired(ctx, hash)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		return err
+	}
+
+	if time.Now().After(retired.ExpiresAt) {
+		return ErrInvalidRefreshToken
+	}
+	if retired.Reason == repository.RefreshTokenRotated && time.Since(retired.RetiredAt) < refreshTokenReuseGrace {
+		return ErrInvalidRefreshToken
+	}
+
+	if err := s.sessions.DeleteFamily(ctx, retired.SessionID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:   AuditAuthRefreshTokenReuse,
+		ActorID:  retired.UserID,
+		TargetID: retired.SessionID.String(),
+		Details:  map[string]string{"reason": retired.Reason},
+	}, nil)
+
+	client := ClientInfoFromContext(ctx)
+	s.logger.Warn("refresh token reuse detected, session revoked",
+		slog.String("event", "refresh_token_reuse"),
+		slog.String("user_id", retired.UserID.String()),
+		slog.String("session_id", retired.SessionID.String()),
+		slog.String("reason", retired.Reason),
+		slog.Time("retired_at", retired.RetiredAt),
+		slog.String("ip_address", client.IPAddress),
+		slog.String("user_agent", client.UserAgent),
+	)
+	return ErrInvalidRefreshToken
+}
+
 func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	if refreshToken == "" {
 		return nil
 	}
 	hash := crypto.HashRefreshToken(refreshToken)
-	return s.sessions.DeleteByHash(ctx, hash)
+
+	entry := AuditEntry{Action: AuditAuthLogout}
+	if session, err := s.sessions.GetByHash(ctx, hash); err == nil {
+		entry.ActorID = session.UserID
+		entry.TargetID = session.ID.String()
+	}
+	err := s.sessions.DeleteByHash(ctx, hash)
+	s.audit.Record(ctx, entry, err)
+	return err
 }
 
-func (s *AuthService) ValidateAccessToken(ctx context.Context, token string) (*repository.User, error) {
+// ValidateAccessToken returns the token's user and the ID of the session it was issued for.
+// A token stops working as soon as its session is revoked or the user's token version is
+// bumped, e.g. by a password reset, rather than when it expires.
+func (s *AuthService) ValidateAccessToken(ctx context.Context, token string) (*repository.User, uuid.UUID, error) {
 	// Validate token
-	userID, err := s.tokenManager.Validate(token)
+	claims, err := s.tokenManager.Validate(token)
 	if err != nil {
-		return nil, ErrInvalidCredentials
+		return nil, uuid.Nil, ErrInvalidCredentials
+	}
+
+	// The session must still exist
+	session, err := s.sessions.GetByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, uuid.Nil, ErrInvalidCredentials
+		}
+		return nil, uuid.Nil, err
+	}
+	if session.UserID != claims.UserID || time.Now().After(session.ExpiresAt) {
+		return nil, uuid.Nil, ErrInvalidCredentials
 	}
 
 	// Get user
-	user, err := s.users.GetByID(ctx, userID)
+	user, err := s.users.GetByID(ctx, claims.UserID)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrInvalidCredentials
+			return nil, uuid.Nil, ErrInvalidCredentials
 		}
-		return nil, err
+		return nil, uuid.Nil, err
 	}
 
-	return user, nil
+	// Tokens issued before the user's token version was bumped are revoked
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, uuid.Nil, ErrInvalidCredentials
+	}
+
+	return user, claims.SessionID, nil
 }
 
 // DemoLogin authenticates as the demo user without password
-func (s *AuthService) DemoLogin(ctx context.Context) (*AuthResult, error) {
+func (s *AuthService) DemoLogin(ctx context.Context) (result *AuthResult, err error) {
 	const demoEmail = "demo@bucketbird.app"
+	defer func() {
+		s.recordAuth(ctx, AuditAuthDemoLogin, demoEmail, result, err)
+	}()
 
 	// Get demo user by email
 	user, err := s.users.GetByEmail(ctx, demoEmail)
@@ -229,7 +590,7 @@ func (s *AuthService) DemoLogin(ctx context.Context) (*AuthResult, error) {
 	}
 
 	// Issue tokens
-	tokens, err := s.issueTokens(ctx, user.ID)
+	tokens, err := s.issueTokens(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -243,6 +604,42 @@ func (s *AuthService) DemoLogin(ctx context.Context) (*AuthResult, error) {
 	}, nil
 }
 
+// recordAuth audits a sign-in attempt, which has no authenticated caller yet
+func (s *AuthService) recordAuth(ctx context.Context, action, email string, result *AuthResult, err error) {
+	entry := AuditEntry{Action: action, ActorEmail: email}
+	if result != nil && result.User != nil {
+		entry.ActorID = result.User.ID
+		entry.ActorEmail = result.User.Email
+		if result.MFARequired() {
+			entry.Details = map[string]string{"mfaRequired": "true"}
+		}
+	}
+	s.audit.Record(ctx, entry, err)
+}
+
+// newAuthResult issues a fresh session for an authenticated user
+func (s *AuthService) newAuthResult(ctx context.Context, user *repository.User) (*AuthResult, error) {
+	tokens, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuthResult{
+		User:          user,
+		AccessToken:   tokens.accessToken,
+		AccessExpiry:  tokens.accessExpiry,
+		RefreshToken:  tokens.refreshToken,
+		RefreshExpiry: tokens.refreshExpiry,
+	}
+	if s.twoFactor != nil {
+		result.TwoFactorEnrollmentRequired, err = s.twoFactor.EnrollmentRequired(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
 type tokens struct {
 	accessToken   string
 	accessExpiry  time.Time
@@ -250,13 +647,7 @@ type tokens struct {
 	refreshExpiry time.Time
 }
 
-func (s *AuthService) issueTokens(ctx context.Context, userID uuid.UUID) (*tokens, error) {
-	// Generate access token
-	accessToken, accessExpiry, err := s.tokenManager.Generate(userID)
-	if err != nil {
-		return nil, err
-	}
-
+func (s *AuthService) issueTokens(ctx context.Context, user *repository.User) (*tokens, error) {
 	// Generate refresh token
 	refreshToken, err := crypto.GenerateRandomToken(32)
 	if err != nil {
@@ -265,8 +656,33 @@ func (s *AuthService) issueTokens(ctx context.Context, userID uuid.UUID) (*token
 	refreshExpiry := time.Now().Add(s.refreshTokenTTL)
 	hash := crypto.HashRefreshToken(refreshToken)
 
+	// Opportunistically clean up retired tokens that can no longer be replayed
+	if err := s.sessions.DeleteExpiredRetired(ctx); err != nil {
+		s.logger.Warn("failed to delete expired refresh tokens", slog.Any("error", err))
+	}
+
 	// Create session
-	if _, err := s.sessions.Create(ctx, userID, hash, refreshExpiry); err != nil {
+	client := ClientInfoFromContext(ctx)
+	var session *repository.Session
+	err = s.events.InTx(ctx, func(tx *repository.Repositories) error {
+		var err error
+		session, err = tx.Sessions.Create(ctx, user.ID, hash, refreshExpiry, client.UserAgent, client.IPAddress)
+		if err != nil {
+			return err
+		}
+		return s.events.Append(ctx, tx, DomainEvent{
+			Type:    EventUserLoggedIn,
+			UserID:  user.ID,
+			Details: map[string]string{"sessionId": session.ID.String(), "ipAddress": client.IPAddress},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate access token bound to the session and the user's token version
+	accessToken, accessExpiry, err := s.tokenManager.Generate(user.ID, session.ID, user.TokenVersion)
+	if err != nil {
 		return nil, err
 	}
 
@@ -278,9 +694,9 @@ func (s *AuthService) issueTokens(ctx context.Context, userID uuid.UUID) (*token
 	}, nil
 }
 
-func (s *AuthService) rotateSession(ctx context.Context, sessionID, userID uuid.UUID) (*tokens, error) {
+func (s *AuthService) rotateSession(ctx context.Context, session *repository.Session, user *repository.User) (*tokens, error) {
 	// Generate new access token
-	accessToken, accessExpiry, err := s.tokenManager.Generate(userID)
+	accessToken, accessExpiry, err := s.tokenManager.Generate(user.ID, session.ID, user.TokenVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -293,10 +709,15 @@ func (s *AuthService) rotateSession(ctx context.Context, sessionID, userID uuid.
 	refreshExpiry := time.Now().Add(s.refreshTokenTTL)
 	hash := crypto.HashRefreshToken(refreshToken)
 
-	// Update session
-	if err := s.sessions.UpdateToken(ctx, sessionID, hash, refreshExpiry); err != nil {
+	// Rotate session; the previous token is retired so its reuse can be detected
+	client := ClientInfoFromContext(ctx)
+	rotated, err := s.sessions.Rotate(ctx, session, hash, refreshExpiry, client.UserAgent, client.IPAddress)
+	if err != nil {
 		return nil, err
 	}
+	if !rotated {
+		return nil, ErrInvalidRefreshToken
+	}
 
 	return &tokens{
 		accessToken:   accessToken,
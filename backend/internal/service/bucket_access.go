@@ -0,0 +1,780 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/storage"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+)
+
+const (
+	// S3 accepts up to 100 CORS rules and 20 KB policies per bucket
+	maxCorsRules        = 100
+	maxCorsRuleID       = 255
+	maxBucketPolicySize = 20 * 1024
+	bucketARNPrefix     = "arn:aws:s3:::"
+)
+
+var (
+	corsMethods = map[string]bool{"GET": true, "PUT": true, "POST": true, "DELETE": true, "HEAD": true}
+
+	policyVersions = map[string]bool{"2012-10-17": true, "2008-10-17": true}
+
+	featureNames = map[string]string{
+		storage.FeatureCORS:              "CORS rules",
+		storage.FeaturePolicy:            "bucket policies",
+		storage.FeaturePublicAccessBlock: "public access blocks",
+		storage.FeatureObjectTagging:     "object tags",
+	}
+)
+
+// AccessService edits who may reach a bucket: its CORS rules, bucket policy
+// and public access block. Settings are checked before they are sent to the
+// provider, and features the provider lacks are reported as such.
+type AccessService struct {
+	buckets   *BucketService
+	audit     *AuditService
+	appOrigin string
+	logger    *slog.Logger
+}
+
+// NewAccessService creates the service; publicURL is the web app's address,
+// whose origin the BucketBird CORS template allows
+func NewAccessService(buckets *BucketService, audit *AuditService, publicURL string, logger *slog.Logger) *AccessService {
+	appOrigin := ""
+	if parsed, err := url.Parse(publicURL); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		appOrigin = parsed.Scheme + "://" + parsed.Host
+	}
+	return &AccessService{
+		buckets:   buckets,
+		audit:     audit,
+		appOrigin: appOrigin,
+		logger:    logger,
+	}
+}
+
+// BucketAccess describes what can be configured on a bucket
+type BucketAccess struct {
+	Provider     string               `json:"provider"`
+	Capabilities storage.Capabilities `json:"capabilities"`
+	Templates    []AccessTemplate     `json:"templates"`
+}
+
+type CorsConfiguration struct {
+	Rules []CorsRule `json:"rules"`
+}
+
+type CorsRule struct {
+	ID             string   `json:"id,omitempty"`
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty"`
+	MaxAgeSeconds  *int32   `json:"maxAgeSeconds,omitempty"`
+}
+
+// BucketPolicy is a bucket's policy document, or null without one
+type BucketPolicy struct {
+	Policy json.RawMessage `json:"policy"`
+	// Public is set if a statement allows anyone in without conditions
+	Public bool `json:"public"`
+}
+
+// PublicAccessBlock mirrors the S3 settings; a bucket without them has all
+// four turned off
+type PublicAccessBlock struct {
+	BlockPublicAcls       bool `json:"blockPublicAcls"`
+	IgnorePublicAcls      bool `json:"ignorePublicAcls"`
+	BlockPublicPolicy     bool `json:"blockPublicPolicy"`
+	RestrictPublicBuckets bool `json:"restrictPublicBuckets"`
+}
+
+// Access returns the bucket's provider, what it supports and the templates
+func (s *AccessService) Access(ctx context.Context, bucketID, userID uuid.UUID) (*BucketAccess, error) {
+	bucket, err := s.buckets.Get(ctx, bucketID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, capabilities := storage.ProviderCapabilities(bucket.CredentialProvider)
+	return &BucketAccess{Provider: provider, Capabilities: capabilities, Templates: accessTemplates}, nil
+}
+
+func (s *AccessService) GetCors(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (*CorsConfiguration, error) {
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeatureCORS, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := store.GetBucketCors(ctx, bucket.Name)
+	if err != nil {
+		return nil, accessError(err, bucket, storage.FeatureCORS, nil)
+	}
+	return fromS3CorsRules(rules), nil
+}
+
+// PutCors validates the rules and replaces the bucket's CORS configuration
+// with them; with dryRun, the rules are only validated
+func (s *AccessService) PutCors(ctx context.Context, bucketID, userID uuid.UUID, config CorsConfiguration, dryRun bool, envelope *crypto.Envelope) (_ *CorsConfiguration, err error) {
+	var bucketName string
+	if !dryRun {
+		defer func() {
+			s.audit.Record(ctx, AuditEntry{
+				Action:     AuditBucketCorsSet,
+				BucketID:   bucketID,
+				BucketName: bucketName,
+				Details:    map[string]string{"rules": strconv.Itoa(len(config.Rules))},
+			}, err)
+		}()
+	}
+
+	rules, err := toS3CorsRules(config.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeatureCORS, envelope)
+	if err != nil {
+		return nil, err
+	}
+	bucketName = bucket.Name
+
+	if !dryRun {
+		if err := store.PutBucketCors(ctx, bucket.Name, rules); err != nil {
+			return nil, accessError(err, bucket, storage.FeatureCORS, ErrInvalidCors)
+		}
+	}
+	return fromS3CorsRules(rules), nil
+}
+
+func (s *AccessService) DeleteCors(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditBucketCorsDelete, BucketID: bucketID, BucketName: bucketName}, err)
+	}()
+
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeatureCORS, envelope)
+	if err != nil {
+		return err
+	}
+	bucketName = bucket.Name
+
+	if err := store.DeleteBucketCors(ctx, bucket.Name); err != nil {
+		return accessError(err, bucket, storage.FeatureCORS, nil)
+	}
+	return nil
+}
+
+func (s *AccessService) GetPolicy(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (*BucketPolicy, error) {
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeaturePolicy, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := store.GetBucketPolicy(ctx, bucket.Name)
+	if err != nil {
+		return nil, accessError(err, bucket, storage.FeaturePolicy, nil)
+	}
+	if policy == "" || !json.Valid([]byte(policy)) {
+		return &BucketPolicy{}, nil
+	}
+
+	// Policies set elsewhere may not pass validation; they are still shown
+	result := &BucketPolicy{Policy: json.RawMessage(policy)}
+	if document, err := parseBucketPolicy([]byte(policy), bucket.Name); err == nil {
+		result.Public = document.public()
+	}
+	return result, nil
+}
+
+// PutPolicy validates the policy document and replaces the bucket's policy
+// with it; with dryRun, the document is only validated
+func (s *AccessService) PutPolicy(ctx context.Context, bucketID, userID uuid.UUID, policy []byte, dryRun bool, envelope *crypto.Envelope) (_ *BucketPolicy, err error) {
+	var (
+		bucketName string
+		public     bool
+	)
+	if !dryRun {
+		defer func() {
+			s.audit.Record(ctx, AuditEntry{
+				Action:     AuditBucketPolicySet,
+				BucketID:   bucketID,
+				BucketName: bucketName,
+				Details:    map[string]string{"public": strconv.FormatBool(public)},
+			}, err)
+		}()
+	}
+
+	if len(policy) > maxBucketPolicySize {
+		return nil, fmt.Errorf("%w: policies may be at most %d bytes", ErrInvalidBucketPolicy, maxBucketPolicySize)
+	}
+
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeaturePolicy, envelope)
+	if err != nil {
+		return nil, err
+	}
+	bucketName = bucket.Name
+
+	// Resources must name the bucket, so the document is checked against it
+	document, err := parseBucketPolicy(policy, bucket.Name)
+	if err != nil {
+		return nil, err
+	}
+	public = document.public()
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, policy); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBucketPolicy, err)
+	}
+
+	if !dryRun {
+		if err := store.PutBucketPolicy(ctx, bucket.Name, compact.String()); err != nil {
+			return nil, accessError(err, bucket, storage.FeaturePolicy, ErrInvalidBucketPolicy)
+		}
+	}
+	return &BucketPolicy{Policy: compact.Bytes(), Public: public}, nil
+}
+
+func (s *AccessService) DeletePolicy(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditBucketPolicyDelete, BucketID: bucketID, BucketName: bucketName}, err)
+	}()
+
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeaturePolicy, envelope)
+	if err != nil {
+		return err
+	}
+	bucketName = bucket.Name
+
+	if err := store.DeleteBucketPolicy(ctx, bucket.Name); err != nil {
+		return accessError(err, bucket, storage.FeaturePolicy, nil)
+	}
+	return nil
+}
+
+func (s *AccessService) GetPublicAccessBlock(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (*PublicAccessBlock, error) {
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeaturePublicAccessBlock, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := store.GetPublicAccessBlock(ctx, bucket.Name)
+	if err != nil {
+		return nil, accessError(err, bucket, storage.FeaturePublicAccessBlock, nil)
+	}
+	if config == nil {
+		return &PublicAccessBlock{}, nil
+	}
+	return &PublicAccessBlock{
+		BlockPublicAcls:       aws.ToBool(config.BlockPublicAcls),
+		IgnorePublicAcls:      aws.ToBool(config.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.ToBool(config.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.ToBool(config.RestrictPublicBuckets),
+	}, nil
+}
+
+// PutPublicAccessBlock replaces the bucket's public access block settings;
+// with dryRun, nothing is written
+func (s *AccessService) PutPublicAccessBlock(ctx context.Context, bucketID, userID uuid.UUID, config PublicAccessBlock, dryRun bool, envelope *crypto.Envelope) (_ *PublicAccessBlock, err error) {
+	var bucketName string
+	if !dryRun {
+		defer func() {
+			s.audit.Record(ctx, AuditEntry{
+				Action:     AuditBucketPublicAccessBlockSet,
+				BucketID:   bucketID,
+				BucketName: bucketName,
+				Details: map[string]string{
+					"blockPublicAcls":       strconv.FormatBool(config.BlockPublicAcls),
+					"ignorePublicAcls":      strconv.FormatBool(config.IgnorePublicAcls),
+					"blockPublicPolicy":     strconv.FormatBool(config.BlockPublicPolicy),
+					"restrictPublicBuckets": strconv.FormatBool(config.RestrictPublicBuckets),
+				},
+			}, err)
+		}()
+	}
+
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeaturePublicAccessBlock, envelope)
+	if err != nil {
+		return nil, err
+	}
+	bucketName = bucket.Name
+
+	if !dryRun {
+		err := store.PutPublicAccessBlock(ctx, bucket.Name, types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(config.BlockPublicAcls),
+			IgnorePublicAcls:      aws.Bool(config.IgnorePublicAcls),
+			BlockPublicPolicy:     aws.Bool(config.BlockPublicPolicy),
+			RestrictPublicBuckets: aws.Bool(config.RestrictPublicBuckets),
+		})
+		if err != nil {
+			return nil, accessError(err, bucket, storage.FeaturePublicAccessBlock, ErrInvalidPublicAccessBlock)
+		}
+	}
+	return &config, nil
+}
+
+func (s *AccessService) DeletePublicAccessBlock(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditBucketPublicAccessBlockDelete, BucketID: bucketID, BucketName: bucketName}, err)
+	}()
+
+	bucket, store, err := s.open(ctx, bucketID, userID, storage.FeaturePublicAccessBlock, envelope)
+	if err != nil {
+		return err
+	}
+	bucketName = bucket.Name
+
+	if err := store.DeletePublicAccessBlock(ctx, bucket.Name); err != nil {
+		return accessError(err, bucket, storage.FeaturePublicAccessBlock, nil)
+	}
+	return nil
+}
+
+// open loads the bucket and a client for it, after checking that the
+// bucket's provider implements feature
+func (s *AccessService) open(ctx context.Context, bucketID, userID uuid.UUID, feature string, envelope *crypto.Envelope) (*repository.BucketWithCredential, *storage.ObjectStore, error) {
+	bucket, err := s.buckets.Get(ctx, bucketID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provider, capabilities := storage.ProviderCapabilities(bucket.CredentialProvider)
+	if !capabilities.Supports(feature) {
+		return nil, nil, unsupportedFeature(provider, feature)
+	}
+
+	store, err := s.buckets.GetObjectStore(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bucket, store, nil
+}
+
+func unsupportedFeature(provider, feature string) error {
+	if provider == "" {
+		provider = "The storage provider"
+	}
+	return fmt.Errorf("%w: %s does not support %s", ErrFeatureUnsupported, provider, featureNames[feature])
+}
+
+// accessError reports features the provider does not implement and, when
+// invalid is set, settings it rejected, so they are not mistaken for
+// internal failures
+func accessError(err error, bucket *repository.BucketWithCredential, feature string, invalid error) error {
+	code, message, ok := storage.APIError(err)
+	if !ok {
+		return err
+	}
+	if code == "NotImplemented" {
+		provider, _ := storage.ProviderCapabilities(bucket.CredentialProvider)
+		return unsupportedFeature(provider, feature)
+	}
+	if invalid == nil {
+		return err
+	}
+	switch code {
+	case "InvalidArgument", "InvalidRequest", "MalformedXML", "MalformedPolicy":
+		return fmt.Errorf("%w: rejected by the provider: %s", invalid, message)
+	case "AccessDenied":
+		// e.g. a public policy on a bucket that blocks public policies
+		return fmt.Errorf("%w: denied by the provider: %s", invalid, message)
+	}
+	return err
+}
+
+func toS3CorsRules(rules []CorsRule) ([]types.CORSRule, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%w: at least one rule is required; delete the configuration to remove all rules", ErrInvalidCors)
+	}
+	if len(rules) > maxCorsRules {
+		return nil, fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidCors, maxCorsRules)
+	}
+
+	result := make([]types.CORSRule, len(rules))
+	for i, rule := range rules {
+		invalid := func(format string, args ...any) error {
+			return fmt.Errorf("%w: rule %d: %s", ErrInvalidCors, i+1, fmt.Sprintf(format, args...))
+		}
+
+		if len(rule.ID) > maxCorsRuleID {
+			return nil, invalid("id may be at most %d characters", maxCorsRuleID)
+		}
+		if len(rule.AllowedOrigins) == 0 {
+			return nil, invalid("allowedOrigins is required")
+		}
+		for _, origin := range rule.AllowedOrigins {
+			if origin == "" || strings.ContainsAny(origin, " \t\r\n") || strings.Count(origin, "*") > 1 {
+				return nil, invalid("origin %q must be non-empty without spaces and may contain one *", origin)
+			}
+		}
+		if len(rule.AllowedMethods) == 0 {
+			return nil, invalid("allowedMethods is required")
+		}
+		methods := make([]string, len(rule.AllowedMethods))
+		for j, method := range rule.AllowedMethods {
+			methods[j] = strings.ToUpper(strings.TrimSpace(method))
+			if !corsMethods[methods[j]] {
+				return nil, invalid("method %q must be one of GET, PUT, POST, DELETE or HEAD", method)
+			}
+		}
+		for _, header := range rule.AllowedHeaders {
+			if header == "" || strings.Count(header, "*") > 1 {
+				return nil, invalid("allowed header %q must be non-empty and may contain one *", header)
+			}
+		}
+		for _, header := range rule.ExposeHeaders {
+			if header == "" || strings.Contains(header, "*") {
+				return nil, invalid("exposed header %q must be non-empty without wildcards", header)
+			}
+		}
+		if rule.MaxAgeSeconds != nil && *rule.MaxAgeSeconds < 0 {
+			return nil, invalid("maxAgeSeconds cannot be negative")
+		}
+
+		result[i] = types.CORSRule{
+			AllowedOrigins: rule.AllowedOrigins,
+			AllowedMethods: methods,
+			AllowedHeaders: rule.AllowedHeaders,
+			ExposeHeaders:  rule.ExposeHeaders,
+			MaxAgeSeconds:  rule.MaxAgeSeconds,
+		}
+		if rule.ID != "" {
+			result[i].ID = aws.String(rule.ID)
+		}
+	}
+	return result, nil
+}
+
+func fromS3CorsRules(rules []types.CORSRule) *CorsConfiguration {
+	config := &CorsConfiguration{Rules: make([]CorsRule, len(rules))}
+	for i, rule := range rules {
+		config.Rules[i] = CorsRule{
+			ID:             aws.ToString(rule.ID),
+			AllowedOrigins: rule.AllowedOrigins,
+			AllowedMethods: rule.AllowedMethods,
+			AllowedHeaders: rule.AllowedHeaders,
+			ExposeHeaders:  rule.ExposeHeaders,
+			MaxAgeSeconds:  rule.MaxAgeSeconds,
+		}
+	}
+	return config
+}
+
+type policyDocument struct {
+	Version   string          `json:"Version"`
+	ID        string          `json:"Id,omitempty"`
+	Statement json.RawMessage `json:"Statement"`
+
+	statements []policyStatement
+}
+
+type policyStatement struct {
+	Sid          string          `json:"Sid,omitempty"`
+	Effect       string          `json:"Effect"`
+	Principal    json.RawMessage `json:"Principal,omitempty"`
+	NotPrincipal json.RawMessage `json:"NotPrincipal,omitempty"`
+	Action       json.RawMessage `json:"Action,omitempty"`
+	NotAction    json.RawMessage `json:"NotAction,omitempty"`
+	Resource     json.RawMessage `json:"Resource,omitempty"`
+	NotResource  json.RawMessage `json:"NotResource,omitempty"`
+	Condition    json.RawMessage `json:"Condition,omitempty"`
+}
+
+// parseBucketPolicy checks the structure of a policy document: a known
+// version, statements with an effect, a principal, S3 actions and resources
+// within the bucket. Unknown keys are rejected, since S3 would ignore or
+// refuse them.
+func parseBucketPolicy(policy []byte, bucketName string) (*policyDocument, error) {
+	var document policyDocument
+	if err := strictUnmarshal(policy, &document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBucketPolicy, err)
+	}
+	if !policyVersions[document.Version] {
+		return nil, fmt.Errorf("%w: Version must be 2012-10-17", ErrInvalidBucketPolicy)
+	}
+
+	// Statement is either one statement or a list of them
+	statements := bytes.TrimSpace(document.Statement)
+	if len(statements) > 0 && statements[0] == '{' {
+		var statement policyStatement
+		if err := strictUnmarshal(statements, &statement); err != nil {
+			return nil, fmt.Errorf("%w: statement 1: %v", ErrInvalidBucketPolicy, err)
+		}
+		document.statements = []policyStatement{statement}
+	} else if len(statements) > 0 {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(statements, &raw); err != nil {
+			return nil, fmt.Errorf("%w: Statement must be an object or a list", ErrInvalidBucketPolicy)
+		}
+		for i, item := range raw {
+			var statement policyStatement
+			if err := strictUnmarshal(item, &statement); err != nil {
+				return nil, fmt.Errorf("%w: statement %d: %v", ErrInvalidBucketPolicy, i+1, err)
+			}
+			document.statements = append(document.statements, statement)
+		}
+	}
+	if len(document.statements) == 0 {
+		return nil, fmt.Errorf("%w: at least one statement is required", ErrInvalidBucketPolicy)
+	}
+
+	resourcePrefix := bucketARNPrefix + bucketName
+	for i, statement := range document.statements {
+		invalid := func(format string, args ...any) error {
+			return fmt.Errorf("%w: statement %d: %s", ErrInvalidBucketPolicy, i+1, fmt.Sprintf(format, args...))
+		}
+
+		if statement.Effect != "Allow" && statement.Effect != "Deny" {
+			return nil, invalid("Effect must be Allow or Deny")
+		}
+		if (statement.Principal == nil) == (statement.NotPrincipal == nil) {
+			return nil, invalid("exactly one of Principal or NotPrincipal is required")
+		}
+
+		if (statement.Action == nil) == (statement.NotAction == nil) {
+			return nil, invalid("exactly one of Action or NotAction is required")
+		}
+		actions, err := policyStrings(statement.Action, statement.NotAction)
+		if err != nil {
+			return nil, invalid("Action must be a string or a list of strings")
+		}
+		for _, action := range actions {
+			if action != "*" && !strings.HasPrefix(strings.ToLower(action), "s3:") {
+				return nil, invalid("action %q is not an S3 action", action)
+			}
+		}
+
+		if (statement.Resource == nil) == (statement.NotResource == nil) {
+			return nil, invalid("exactly one of Resource or NotResource is required")
+		}
+		resources, err := policyStrings(statement.Resource, statement.NotResource)
+		if err != nil {
+			return nil, invalid("Resource must be a string or a list of strings")
+		}
+		for _, resource := range resources {
+			if resource != resourcePrefix && !strings.HasPrefix(resource, resourcePrefix+"/") {
+				return nil, invalid("resource %q must be %s or within %s/", resource, resourcePrefix, resourcePrefix)
+			}
+		}
+	}
+	return &document, nil
+}
+
+// public reports whether a statement allows anyone in without conditions
+func (d *policyDocument) public() bool {
+	for _, statement := range d.statements {
+		if statement.Effect != "Allow" || statement.Principal == nil || statement.Condition != nil {
+			continue
+		}
+		var principal string
+		if json.Unmarshal(statement.Principal, &principal) == nil && principal == "*" {
+			return true
+		}
+		var principals map[string]json.RawMessage
+		if json.Unmarshal(statement.Principal, &principals) == nil {
+			if accounts, err := policyStrings(principals["AWS"]); err == nil {
+				for _, account := range accounts {
+					if account == "*" {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// policyStrings reads the first set value, a string or a list of strings
+func policyStrings(values ...json.RawMessage) ([]string, error) {
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			return []string{single}, nil
+		}
+		var list []string
+		if err := json.Unmarshal(value, &list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	return nil, nil
+}
+
+func strictUnmarshal(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// AccessTemplate fills in a CORS configuration, bucket policy or public
+// access block for a common case
+type AccessTemplate struct {
+	Name string `json:"name"`
+	// Kind is the setting the template is for: cors, policy or publicAccessBlock
+	Kind        string                    `json:"kind"`
+	Description string                    `json:"description"`
+	Parameters  []AccessTemplateParameter `json:"parameters"`
+}
+
+type AccessTemplateParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// RenderedAccessTemplate holds a template filled in for a bucket; Body can be
+// sent as is to the endpoint of the template's kind
+type RenderedAccessTemplate struct {
+	AccessTemplate
+	Body any `json:"body"`
+}
+
+var accessTemplates = []AccessTemplate{
+	{
+		Name:        "cors-bucketbird",
+		Kind:        storage.FeatureCORS,
+		Description: "Lets the BucketBird web app upload and download directly with presigned URLs",
+		Parameters: []AccessTemplateParameter{
+			{Name: "origin", Description: "Origin to allow; defaults to the origin of BB_PUBLIC_URL"},
+		},
+	},
+	{
+		Name:        "public-read-prefix",
+		Kind:        storage.FeaturePolicy,
+		Description: "Lets anyone download objects under a prefix",
+		Parameters: []AccessTemplateParameter{
+			{Name: "prefix", Description: "Key prefix to publish, e.g. public/", Required: true},
+		},
+	},
+	{
+		Name:        "upload-only",
+		Kind:        storage.FeaturePolicy,
+		Description: "Lets a role or user upload objects but not list, download or delete them",
+		Parameters: []AccessTemplateParameter{
+			{Name: "principal", Description: "ARN or account ID of the uploading role or user", Required: true},
+			{Name: "prefix", Description: "Key prefix uploads are limited to; defaults to the whole bucket"},
+		},
+	},
+	{
+		Name:        "block-public-access",
+		Kind:        storage.FeaturePublicAccessBlock,
+		Description: "Blocks public ACLs and policies on the bucket",
+	},
+}
+
+// RenderTemplate fills in a template for the bucket
+func (s *AccessService) RenderTemplate(ctx context.Context, bucketID, userID uuid.UUID, name string, params map[string]string) (*RenderedAccessTemplate, error) {
+	var template *AccessTemplate
+	for i := range accessTemplates {
+		if accessTemplates[i].Name == name {
+			template = &accessTemplates[i]
+		}
+	}
+	if template == nil {
+		return nil, ErrAccessTemplateNotFound
+	}
+	for _, param := range template.Parameters {
+		if param.Required && strings.TrimSpace(params[param.Name]) == "" {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidAccessTemplate, param.Name)
+		}
+	}
+
+	bucket, err := s.buckets.Get(ctx, bucketID, userID)
+	if err != nil {
+		return nil, err
+	}
+	objects := bucketARNPrefix + bucket.Name + "/"
+
+	prefix := strings.TrimSpace(params["prefix"])
+	if strings.ContainsAny(prefix, "*?") || strings.HasPrefix(prefix, "/") {
+		return nil, fmt.Errorf("%w: prefix cannot start with / or contain * or ?", ErrInvalidAccessTemplate)
+	}
+
+	rendered := &RenderedAccessTemplate{AccessTemplate: *template}
+	switch template.Name {
+	case "cors-bucketbird":
+		origin := strings.TrimRight(strings.TrimSpace(params["origin"]), "/")
+		if origin == "" {
+			origin = s.appOrigin
+		}
+		if origin == "" {
+			return nil, fmt.Errorf("%w: origin is required since BB_PUBLIC_URL is not set", ErrInvalidAccessTemplate)
+		}
+		rendered.Body = CorsConfiguration{Rules: []CorsRule{{
+			ID:             "bucketbird",
+			AllowedOrigins: []string{origin},
+			AllowedMethods: []string{"GET", "PUT", "HEAD"},
+			AllowedHeaders: []string{"*"},
+			ExposeHeaders:  []string{"ETag"},
+			MaxAgeSeconds:  aws.Int32(3600),
+		}}}
+
+	case "public-read-prefix":
+		rendered.Body = map[string]any{
+			"Version": "2012-10-17",
+			"Statement": []map[string]any{{
+				"Sid":       "PublicRead",
+				"Effect":    "Allow",
+				"Principal": "*",
+				"Action":    "s3:GetObject",
+				"Resource":  objects + prefix + "*",
+			}},
+		}
+
+	case "upload-only":
+		principal := map[string]string{"AWS": strings.TrimSpace(params["principal"])}
+		rendered.Body = map[string]any{
+			"Version": "2012-10-17",
+			"Statement": []map[string]any{
+				{
+					"Sid":       "AllowUpload",
+					"Effect":    "Allow",
+					"Principal": principal,
+					"Action":    []string{"s3:PutObject", "s3:AbortMultipartUpload"},
+					"Resource":  objects + prefix + "*",
+				},
+				{
+					// Overrides wider grants the principal has elsewhere
+					"Sid":       "DenyEverythingElse",
+					"Effect":    "Deny",
+					"Principal": principal,
+					"Action":    []string{"s3:GetObject", "s3:GetObjectVersion", "s3:DeleteObject", "s3:DeleteObjectVersion", "s3:ListBucket", "s3:ListBucketVersions"},
+					"Resource":  []string{bucketARNPrefix + bucket.Name, objects + "*"},
+				},
+			},
+		}
+
+	case "block-public-access":
+		rendered.Body = PublicAccessBlock{
+			BlockPublicAcls:       true,
+			IgnorePublicAcls:      true,
+			BlockPublicPolicy:     true,
+			RestrictPublicBuckets: true,
+		}
+	}
+	return rendered, nil
+}
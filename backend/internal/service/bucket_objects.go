@@ -3,15 +3,19 @@ package service
 import (
 	"archive/zip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"path"
 	"sort"
 	"strings"
 	"time"
 
+	"bucketbird/backend/internal/repository"
 	"bucketbird/backend/internal/storage"
+	"bucketbird/backend/pkg/crypto"
 
 	"github.com/google/uuid"
 )
@@ -25,6 +29,8 @@ type BucketObject struct {
 	LastModified time.Time `json:"lastModified"`
 	Icon         string    `json:"icon"`
 	IconColor    string    `json:"iconColor"`
+	// Tags are only looked up when listing with tag filters
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
 // PresignInput contains input for presigning a URL
@@ -33,12 +39,20 @@ type PresignInput struct {
 	Method      string
 	Expires     time.Duration
 	ContentType *string
+	// Size is the exact size of a PUT upload. It is required when a byte quota
+	// applies and is then signed into the URL.
+	Size *int64
+	// Tags are set on the object by a PUT upload
+	Tags map[string]string
 }
 
 // PresignOutput contains presigned URL information
 type PresignOutput struct {
 	URL     string `json:"url"`
 	Expires int64  `json:"expires"`
+	// Headers must be sent with the upload as they were signed into the URL
+	Headers       map[string]string `json:"headers,omitempty"`
+	QuotaWarnings []string          `json:"quotaWarnings,omitempty"`
 }
 
 // ObjectMetadata contains object metadata
@@ -49,6 +63,9 @@ type ObjectMetadata struct {
 	ContentType  string            `json:"contentType"`
 	ETag         string            `json:"etag"`
 	Metadata     map[string]string `json:"metadata"`
+	// Tags is nil when the provider does not implement object tags or they
+	// could not be read
+	Tags map[string]string `json:"tags"`
 }
 
 // ProxiedObject represents an object being proxied
@@ -66,8 +83,9 @@ type DeleteObjectsResult struct {
 
 // OperationResult represents a generic operation result
 type OperationResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success       bool     `json:"success"`
+	Message       string   `json:"message"`
+	QuotaWarnings []string `json:"quotaWarnings,omitempty"`
 }
 
 // FolderResult represents a created folder
@@ -75,8 +93,9 @@ type FolderResult struct {
 	Key string `json:"key"`
 }
 
-// ListObjects lists objects in a bucket with optional prefix
-func (s *BucketService) ListObjects(ctx context.Context, bucketID, userID uuid.UUID, prefix string, encryptionKey []byte) ([]BucketObject, error) {
+// ListObjects lists objects in a bucket with optional prefix. With tag
+// filters, only the files having all of the tags are listed.
+func (s *BucketService) ListObjects(ctx context.Context, bucketID, userID uuid.UUID, prefix string, tags []TagFilter, envelope *crypto.Envelope) ([]BucketObject, error) {
 	// Check if user is a demo user FIRST
 	user, err := s.users.GetByID(ctx, userID)
 	if err == nil && user.IsDemo {
@@ -85,7 +104,11 @@ func (s *BucketService) ListObjects(ctx context.Context, bucketID, userID uuid.U
 		if err != nil {
 			return nil, err
 		}
-		return getDemoObjects(bucketName, prefix), nil
+		objects := getDemoObjects(bucketName, prefix)
+		if len(tags) > 0 {
+			return s.filterObjectsByTags(ctx, bucketID, userID, objects, tags, envelope)
+		}
+		return objects, nil
 	}
 
 	// For regular users, proceed with normal flow
@@ -94,7 +117,7 @@ func (s *BucketService) ListObjects(ctx context.Context, bucketID, userID uuid.U
 		return nil, err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return nil, err
 	}
@@ -205,6 +228,9 @@ func (s *BucketService) ListObjects(ctx context.Context, bucketID, userID uuid.U
 
 	// Return folders first, then files
 	result := append(folders, files...)
+	if len(tags) > 0 {
+		return s.filterObjectsByTags(ctx, bucketID, userID, result, tags, envelope)
+	}
 	return result, nil
 }
 
@@ -223,10 +249,11 @@ func formatByteSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %s", f, units[i])
 }
 
-// SearchObjects searches for objects matching a query
-func (s *BucketService) SearchObjects(ctx context.Context, bucketID, userID uuid.UUID, query string, encryptionKey []byte) ([]BucketObject, error) {
+// SearchObjects searches for objects matching a query and, optionally, tag
+// filters
+func (s *BucketService) SearchObjects(ctx context.Context, bucketID, userID uuid.UUID, query string, tags []TagFilter, envelope *crypto.Envelope) ([]BucketObject, error) {
 	// Get all objects and filter by query
-	objects, err := s.ListObjects(ctx, bucketID, userID, "", encryptionKey)
+	objects, err := s.ListObjects(ctx, bucketID, userID, "", nil, envelope)
 	if err != nil {
 		return nil, err
 	}
@@ -239,90 +266,177 @@ func (s *BucketService) SearchObjects(ctx context.Context, bucketID, userID uuid
 		}
 	}
 
+	// Tags are looked up per object, so only for those matching the query
+	if len(tags) > 0 {
+		return s.filterObjectsByTags(ctx, bucketID, userID, filtered, tags, envelope)
+	}
 	return filtered, nil
 }
 
-// UploadObject uploads an object to a bucket
-func (s *BucketService) UploadObject(ctx context.Context, bucketID, userID uuid.UUID, key string, body io.Reader, contentType string, encryptionKey []byte) error {
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+// UploadObject uploads an object to a bucket. size is an upper bound of the
+// body's length, or -1 if unknown; it is held against the user's quotas while
+// uploading. The returned warnings name quotas that are nearly used up.
+func (s *BucketService) UploadObject(ctx context.Context, bucketID, userID uuid.UUID, key string, body io.Reader, size int64, contentType string, tags map[string]string, envelope *crypto.Envelope) (_ []string, err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditObjectUpload, BucketID: bucketID, BucketName: bucketName, ObjectKey: key}, err)
+	}()
+
+	if err := validateObjectTags(tags); err != nil {
+		return nil, err
+	}
+
+	bucket, err := s.Get(ctx, bucketID, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	bucketName = bucket.Name
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	if len(tags) > 0 {
+		if err := requireObjectTagging(bucket); err != nil {
+			return nil, err
+		}
+	}
+
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := store.PutObject(ctx, bucketName, key, body, contentType); err != nil {
-		return err
+	reservation, err := s.quotas.Reserve(ctx, ReserveQuotaInput{
+		UserID:   userID,
+		BucketID: bucketID,
+		Bytes:    size,
+		Objects:  1,
+		TTL:      uploadReservationTTL,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Update bucket size asynchronously (don't block on errors)
-	go func() {
-		if err := s.recalculateBucketSize(context.Background(), bucketID, userID, encryptionKey); err != nil {
-			s.logger.Error("failed to update bucket size after upload", slog.Any("error", err), slog.String("bucket_id", bucketID.String()))
+	if err := store.PutObject(ctx, bucketName, key, body, contentType, tags); err != nil {
+		s.quotas.Release(ctx, reservation)
+		if len(tags) > 0 {
+			return nil, taggingError(err, bucket)
 		}
-	}()
+		return nil, err
+	}
+	s.quotas.Commit(ctx, reservation)
+
+	// Subscribers such as size accounting pick this up asynchronously
+	s.events.Publish(ctx, objectEvent(EventObjectUploaded, bucketID, userID, bucketName, key))
 
-	return nil
+	return reservation.Warnings, nil
 }
 
 // PresignObject generates a presigned URL for an object
-func (s *BucketService) PresignObject(ctx context.Context, bucketID, userID uuid.UUID, input PresignInput, encryptionKey []byte) (*PresignOutput, error) {
+func (s *BucketService) PresignObject(ctx context.Context, bucketID, userID uuid.UUID, input PresignInput, envelope *crypto.Envelope) (_ *PresignOutput, err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditObjectPresign,
+			BucketID:   bucketID,
+			BucketName: bucketName,
+			ObjectKey:  input.Key,
+			Details:    map[string]string{"method": input.Method, "expires": input.Expires.String()},
+		}, err)
+	}()
+
 	// Check if user is a demo user
 	user, err := s.users.GetByID(ctx, userID)
 	if err == nil && user.IsDemo {
 		return nil, ErrDemoRestriction
 	}
 
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+	bucket, err := s.Get(ctx, bucketID, userID)
 	if err != nil {
 		return nil, err
 	}
+	bucketName = bucket.Name
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	isPut := strings.EqualFold(input.Method, http.MethodPut)
+	if len(input.Tags) > 0 {
+		if !isPut {
+			return nil, fmt.Errorf("%w: tags can only be set on PUT uploads", ErrInvalidObjectTags)
+		}
+		if err := validateObjectTags(input.Tags); err != nil {
+			return nil, err
+		}
+		if err := requireObjectTagging(bucket); err != nil {
+			return nil, err
+		}
+	}
+
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return nil, err
 	}
 
+	// The server does not see presigned uploads finish, so the space is held
+	// until the URL expires and the bucket is then recalculated
+	var reservation *QuotaReservation
+	if isPut {
+		size := int64(-1)
+		if input.Size != nil {
+			size = *input.Size
+		}
+		reservation, err = s.quotas.Reserve(ctx, ReserveQuotaInput{
+			UserID:   userID,
+			BucketID: bucketID,
+			Bytes:    size,
+			Objects:  1,
+			TTL:      input.Expires + presignReservationGrace,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	presigned, err := store.PresignObject(ctx, storage.PresignInput{
-		Bucket:      bucketName,
-		Key:         input.Key,
-		Method:      input.Method,
-		ExpiresIn:   input.Expires,
-		ContentType: input.ContentType,
+		Bucket:        bucketName,
+		Key:           input.Key,
+		Method:        input.Method,
+		ExpiresIn:     input.Expires,
+		ContentType:   input.ContentType,
+		ContentLength: input.Size,
+		Tags:          input.Tags,
 	})
 	if err != nil {
+		s.quotas.Release(ctx, reservation)
 		return nil, err
 	}
 
 	expiryTime := time.Now().Add(input.Expires).Unix()
-	return &PresignOutput{
+	output := &PresignOutput{
 		URL:     presigned.URL,
 		Expires: expiryTime,
-	}, nil
+		Headers: presigned.Headers,
+	}
+	if reservation != nil {
+		output.QuotaWarnings = reservation.Warnings
+	}
+	return output, nil
 }
 
 // GetObjectMetadata retrieves metadata for an object
-func (s *BucketService) GetObjectMetadata(ctx context.Context, bucketID, userID uuid.UUID, key string, encryptionKey []byte) (*ObjectMetadata, error) {
+func (s *BucketService) GetObjectMetadata(ctx context.Context, bucketID, userID uuid.UUID, key string, envelope *crypto.Envelope) (*ObjectMetadata, error) {
 	// Check if user is a demo user
 	user, err := s.users.GetByID(ctx, userID)
 	if err == nil && user.IsDemo {
 		return nil, ErrDemoRestriction
 	}
 
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+	bucket, err := s.Get(ctx, bucketID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return nil, err
 	}
 
-	head, err := store.HeadObject(ctx, bucketName, key)
+	head, err := store.HeadObject(ctx, bucket.Name, key)
 	if err != nil {
 		return nil, err
 	}
@@ -344,23 +458,45 @@ func (s *BucketService) GetObjectMetadata(ctx context.Context, bucketID, userID
 		ContentType:  contentType,
 		ETag:         strings.Trim(awsStringValue(head.ETag), "\""),
 		Metadata:     metadata,
+		Tags:         s.headObjectTags(ctx, store, bucket, key),
 	}, nil
 }
 
+// headObjectTags looks up the tags of an object for its metadata. Tags are
+// left out rather than failing the metadata request.
+func (s *BucketService) headObjectTags(ctx context.Context, store *storage.ObjectStore, bucket *repository.BucketWithCredential, key string) map[string]string {
+	if requireObjectTagging(bucket) != nil {
+		return nil
+	}
+	tags, err := store.GetObjectTagging(ctx, bucket.Name, key)
+	if err != nil {
+		if code, _, ok := storage.APIError(err); !ok || code != "NotImplemented" {
+			s.logger.Warn("failed to get object tags", slog.String("bucket", bucket.Name), slog.String("key", key), slog.Any("error", err))
+		}
+		return nil
+	}
+	return tags
+}
+
 // ProxyObject retrieves an object for proxying/download
-func (s *BucketService) ProxyObject(ctx context.Context, bucketID, userID uuid.UUID, key string, encryptionKey []byte) (*ProxiedObject, error) {
+func (s *BucketService) ProxyObject(ctx context.Context, bucketID, userID uuid.UUID, key string, envelope *crypto.Envelope) (_ *ProxiedObject, err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditObjectDownload, BucketID: bucketID, BucketName: bucketName, ObjectKey: key}, err)
+	}()
+
 	// Check if user is a demo user
 	user, err := s.users.GetByID(ctx, userID)
 	if err == nil && user.IsDemo {
 		return nil, ErrDemoRestriction
 	}
 
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+	bucketName, err = s.getBucketName(ctx, bucketID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return nil, err
 	}
@@ -383,13 +519,22 @@ func (s *BucketService) ProxyObject(ctx context.Context, bucketID, userID uuid.U
 }
 
 // CreateFolder creates an empty folder (0-byte object with trailing slash)
-func (s *BucketService) CreateFolder(ctx context.Context, bucketID, userID uuid.UUID, name string, prefix *string, encryptionKey []byte) (*FolderResult, error) {
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+func (s *BucketService) CreateFolder(ctx context.Context, bucketID, userID uuid.UUID, name string, prefix *string, envelope *crypto.Envelope) (result *FolderResult, err error) {
+	var bucketName string
+	defer func() {
+		entry := AuditEntry{Action: AuditFolderCreate, BucketID: bucketID, BucketName: bucketName}
+		if result != nil {
+			entry.ObjectKey = result.Key
+		}
+		s.audit.Record(ctx, entry, err)
+	}()
+
+	bucketName, err = s.getBucketName(ctx, bucketID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return nil, err
 	}
@@ -403,22 +548,42 @@ func (s *BucketService) CreateFolder(ctx context.Context, bucketID, userID uuid.
 		key += "/"
 	}
 
+	reservation, err := s.quotas.Reserve(ctx, ReserveQuotaInput{
+		UserID:   userID,
+		BucketID: bucketID,
+		Objects:  1,
+		TTL:      uploadReservationTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	contentType := "application/x-directory"
 	if err := store.PutEmptyObject(ctx, bucketName, key, &contentType); err != nil {
+		s.quotas.Release(ctx, reservation)
 		return nil, err
 	}
+	s.quotas.Commit(ctx, reservation)
+	s.events.Publish(ctx, objectEvent(EventFolderCreated, bucketID, userID, bucketName, key))
 
 	return &FolderResult{Key: key}, nil
 }
 
 // DeleteObjects deletes multiple objects
-func (s *BucketService) DeleteObjects(ctx context.Context, bucketID, userID uuid.UUID, keys []string, encryptionKey []byte) (*DeleteObjectsResult, error) {
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+func (s *BucketService) DeleteObjects(ctx context.Context, bucketID, userID uuid.UUID, keys []string, envelope *crypto.Envelope) (_ *DeleteObjectsResult, err error) {
+	var bucketName string
+	defer func() {
+		for _, key := range keys {
+			s.audit.Record(ctx, AuditEntry{Action: AuditObjectDelete, BucketID: bucketID, BucketName: bucketName, ObjectKey: key}, err)
+		}
+	}()
+
+	bucketName, err = s.getBucketName(ctx, bucketID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return nil, err
 	}
@@ -465,12 +630,7 @@ func (s *BucketService) DeleteObjects(ctx context.Context, bucketID, userID uuid
 		}, err
 	}
 
-	// Update bucket size asynchronously (don't block on errors)
-	go func() {
-		if err := s.recalculateBucketSize(context.Background(), bucketID, userID, encryptionKey); err != nil {
-			s.logger.Error("failed to update bucket size after delete", slog.Any("error", err), slog.String("bucket_id", bucketID.String()))
-		}
-	}()
+	s.events.Publish(ctx, objectEvent(EventObjectsDeleted, bucketID, userID, bucketName, allKeysToDelete...))
 
 	return &DeleteObjectsResult{
 		Deleted: keys,
@@ -479,17 +639,34 @@ func (s *BucketService) DeleteObjects(ctx context.Context, bucketID, userID uuid
 }
 
 // RenameObject renames an object (copy + delete)
-func (s *BucketService) RenameObject(ctx context.Context, bucketID, userID uuid.UUID, sourceKey, destinationKey string, encryptionKey []byte) (*OperationResult, error) {
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+func (s *BucketService) RenameObject(ctx context.Context, bucketID, userID uuid.UUID, sourceKey, destinationKey string, envelope *crypto.Envelope) (_ *OperationResult, err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditObjectRename,
+			BucketID:   bucketID,
+			BucketName: bucketName,
+			ObjectKey:  sourceKey,
+			Details:    map[string]string{"destination": destinationKey},
+		}, err)
+	}()
+
+	bucketName, err = s.getBucketName(ctx, bucketID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return nil, err
 	}
 
+	renamed := objectEvent(EventObjectsRenamed, bucketID, userID, bucketName)
+	rename := func(oldKey, newKey string) {
+		renamed.Keys = append(renamed.Keys, newKey)
+		renamed.PreviousKeys = append(renamed.PreviousKeys, oldKey)
+	}
+
 	// Check if it's a folder
 	if strings.HasSuffix(sourceKey, "/") {
 		// It's a folder - list all objects with this prefix
@@ -517,6 +694,9 @@ func (s *BucketService) RenameObject(ctx context.Context, bucketID, userID uuid.
 					Message: fmt.Sprintf("failed to copy object %s: %v", oldKey, err),
 				}, err
 			}
+			if oldKey != sourceKey {
+				rename(oldKey, newKey)
+			}
 		}
 
 		// Copy the folder marker itself
@@ -534,6 +714,7 @@ func (s *BucketService) RenameObject(ctx context.Context, bucketID, userID uuid.
 				Message: fmt.Sprintf("copied but failed to delete original: %v", err),
 			}, err
 		}
+		rename(sourceKey, destinationKey)
 	} else {
 		// It's a regular file
 		if err := store.CopyObject(ctx, bucketName, sourceKey, destinationKey); err != nil {
@@ -550,7 +731,9 @@ func (s *BucketService) RenameObject(ctx context.Context, bucketID, userID uuid.
 				Message: fmt.Sprintf("copied but failed to delete original: %v", err),
 			}, err
 		}
+		rename(sourceKey, destinationKey)
 	}
+	s.events.Publish(ctx, renamed)
 
 	return &OperationResult{
 		Success: true,
@@ -559,44 +742,83 @@ func (s *BucketService) RenameObject(ctx context.Context, bucketID, userID uuid.
 }
 
 // CopyObject copies an object
-func (s *BucketService) CopyObject(ctx context.Context, bucketID, userID uuid.UUID, sourceKey, destinationKey string, encryptionKey []byte) (*OperationResult, error) {
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+func (s *BucketService) CopyObject(ctx context.Context, bucketID, userID uuid.UUID, sourceKey, destinationKey string, envelope *crypto.Envelope) (_ *OperationResult, err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditObjectCopy,
+			BucketID:   bucketID,
+			BucketName: bucketName,
+			ObjectKey:  sourceKey,
+			Details:    map[string]string{"destination": destinationKey},
+		}, err)
+	}()
+
+	bucketName, err = s.getBucketName(ctx, bucketID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := store.HeadObject(ctx, bucketName, sourceKey)
+	if err != nil {
+		return &OperationResult{
+			Success: false,
+			Message: fmt.Sprintf("failed to read source object: %v", err),
+		}, err
+	}
+
+	reservation, err := s.quotas.Reserve(ctx, ReserveQuotaInput{
+		UserID:   userID,
+		BucketID: bucketID,
+		Bytes:    awsInt64Value(head.ContentLength),
+		Objects:  1,
+		TTL:      uploadReservationTTL,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	if err := store.CopyObject(ctx, bucketName, sourceKey, destinationKey); err != nil {
+		s.quotas.Release(ctx, reservation)
 		return &OperationResult{
 			Success: false,
 			Message: fmt.Sprintf("failed to copy object: %v", err),
 		}, err
 	}
+	s.quotas.Commit(ctx, reservation)
+	s.events.Publish(ctx, objectEvent(EventObjectCopied, bucketID, userID, bucketName, destinationKey))
 
 	return &OperationResult{
-		Success: true,
-		Message: "Object copied successfully",
+		Success:       true,
+		Message:       "Object copied successfully",
+		QuotaWarnings: reservation.Warnings,
 	}, nil
 }
 
 // ZipFolder creates a zip archive of a folder
-func (s *BucketService) ZipFolder(ctx context.Context, bucketID, userID uuid.UUID, prefix string, encryptionKey []byte) (io.ReadCloser, string, error) {
+func (s *BucketService) ZipFolder(ctx context.Context, bucketID, userID uuid.UUID, prefix string, envelope *crypto.Envelope) (_ io.ReadCloser, _ string, err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditFolderDownload, BucketID: bucketID, BucketName: bucketName, ObjectKey: prefix}, err)
+	}()
+
 	// Check if user is a demo user
 	user, err := s.users.GetByID(ctx, userID)
 	if err == nil && user.IsDemo {
 		return nil, "", ErrDemoRestriction
 	}
 
-	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+	bucketName, err = s.getBucketName(ctx, bucketID, userID)
 	if err != nil {
 		return nil, "", err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return nil, "", err
 	}
@@ -696,27 +918,55 @@ func awsTimeValue(t *time.Time) time.Time {
 	return *t
 }
 
-// recalculateBucketSize calculates and updates the bucket size in the database
-func (s *BucketService) recalculateBucketSize(ctx context.Context, bucketID, userID uuid.UUID, encryptionKey []byte) error {
+// recalculateBucketSize calculates and updates the bucket size and object
+// count in the database
+func (s *BucketService) recalculateBucketSize(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) error {
+	started := time.Now()
+
 	bucketName, err := s.getBucketName(ctx, bucketID, userID)
 	if err != nil {
 		return err
 	}
 
-	store, err := s.GetObjectStore(ctx, bucketID, userID, encryptionKey)
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
 	if err != nil {
 		return err
 	}
 
-	totalSize, err := store.CalculateBucketSize(ctx, bucketName)
+	totalSize, objectCount, err := store.CalculateBucketUsage(ctx, bucketName)
 	if err != nil {
 		return err
 	}
 
-	return s.UpdateSize(ctx, bucketID, totalSize)
+	return s.storeUsage(ctx, bucketID, totalSize, objectCount, started)
+}
+
+// storeUsage saves a bucket's recalculated usage. Quota reservations of writes
+// committed before the calculation started are then covered by it and dropped.
+func (s *BucketService) storeUsage(ctx context.Context, bucketID uuid.UUID, sizeBytes, objectCount int64, started time.Time) error {
+	if err := s.UpdateUsage(ctx, bucketID, sizeBytes, objectCount); err != nil {
+		return err
+	}
+	return s.quotas.ReleaseCommitted(ctx, bucketID, started)
 }
 
 // RecalculateBucketSize is a public wrapper for recalculateBucketSize
-func (s *BucketService) RecalculateBucketSize(ctx context.Context, bucketID, userID uuid.UUID, encryptionKey []byte) error {
-	return s.recalculateBucketSize(ctx, bucketID, userID, encryptionKey)
+func (s *BucketService) RecalculateBucketSize(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) error {
+	err := s.recalculateBucketSize(ctx, bucketID, userID, envelope)
+	s.audit.Record(ctx, AuditEntry{Action: AuditBucketRecalculateSize, BucketID: bucketID}, err)
+	return err
+}
+
+// SizeEventTypes are the domain events that change a bucket's size
+var SizeEventTypes = []string{EventObjectUploaded, EventObjectCopied, EventObjectsDeleted, EventFolderCreated, EventQuotaReservationExpired}
+
+// HandleSizeEvent recalculates the size of the event's bucket. The size is
+// recomputed from scratch, so handling an event twice is harmless.
+func (s *BucketService) HandleSizeEvent(ctx context.Context, event DomainEvent) error {
+	err := s.recalculateBucketSize(ctx, event.BucketID, event.UserID, s.envelope)
+	if errors.Is(err, ErrBucketNotFound) {
+		// The bucket was deleted since
+		return nil
+	}
+	return err
 }
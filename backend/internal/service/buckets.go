@@ -4,34 +4,48 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"strconv"
 
 	"bucketbird/backend/internal/repository"
 	"bucketbird/backend/internal/storage"
+	"bucketbird/backend/pkg/crypto"
 
 	"github.com/google/uuid"
 )
 
 type BucketService struct {
-	buckets       repository.BucketRepository
-	credentials   repository.CredentialRepository
-	users         repository.UserRepository
-	encryptionKey []byte
-	logger        *slog.Logger
+	buckets     repository.BucketRepository
+	credentials repository.CredentialRepository
+	users       repository.UserRepository
+	envelope    *crypto.Envelope
+	audit       *AuditService
+	events      *EventBus
+	quotas      *QuotaService
+	requests    *RequestCounter
+	logger      *slog.Logger
 }
 
 func NewBucketService(
 	buckets repository.BucketRepository,
 	credentials repository.CredentialRepository,
 	users repository.UserRepository,
-	encryptionKey []byte,
+	envelope *crypto.Envelope,
+	audit *AuditService,
+	events *EventBus,
+	quotas *QuotaService,
+	requests *RequestCounter,
 	logger *slog.Logger,
 ) *BucketService {
 	return &BucketService{
-		buckets:       buckets,
-		credentials:   credentials,
-		users:         users,
-		encryptionKey: encryptionKey,
-		logger:        logger,
+		buckets:     buckets,
+		credentials: credentials,
+		users:       users,
+		envelope:    envelope,
+		audit:       audit,
+		events:      events,
+		quotas:      quotas,
+		requests:    requests,
+		logger:      logger,
 	}
 }
 
@@ -43,7 +57,15 @@ type CreateBucketInput struct {
 	Description  *string
 }
 
-func (s *BucketService) Create(ctx context.Context, input CreateBucketInput) (*repository.BucketWithCredential, error) {
+func (s *BucketService) Create(ctx context.Context, input CreateBucketInput) (result *repository.BucketWithCredential, err error) {
+	defer func() {
+		entry := AuditEntry{Action: AuditBucketCreate, BucketName: input.Name}
+		if result != nil {
+			entry.BucketID = result.ID
+		}
+		s.audit.Record(ctx, entry, err)
+	}()
+
 	// Validate credential exists and belongs to user
 	cred, err := s.credentials.Get(ctx, input.CredentialID, input.UserID)
 	if err != nil {
@@ -62,12 +84,7 @@ func (s *BucketService) Create(ctx context.Context, input CreateBucketInput) (*r
 	}
 
 	// Ensure the bucket exists (create if needed) using the credential's keys
-	accessKey, err := decryptCredential(cred.EncryptedAccessKey, s.encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	secretKey, err := decryptCredential(cred.EncryptedSecretKey, s.encryptionKey)
+	accessKey, secretKey, err := decryptCredentialKeys(ctx, cred, s.envelope)
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +114,21 @@ func (s *BucketService) Create(ctx context.Context, input CreateBucketInput) (*r
 		Description:  input.Description,
 	}
 
-	created, err := s.buckets.Create(ctx, bucket)
+	var created *repository.Bucket
+	err = s.events.InTx(ctx, func(tx *repository.Repositories) error {
+		var err error
+		created, err = tx.Buckets.Create(ctx, bucket)
+		if err != nil {
+			return err
+		}
+		return s.events.Append(ctx, tx, DomainEvent{
+			Type:         EventBucketCreated,
+			UserID:       input.UserID,
+			BucketID:     created.ID,
+			BucketName:   created.Name,
+			CredentialID: created.CredentialID,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -125,19 +156,36 @@ func (s *BucketService) Get(ctx context.Context, id, userID uuid.UUID) (*reposit
 	return bucket, nil
 }
 
-func (s *BucketService) Update(ctx context.Context, id, userID uuid.UUID, description *string) error {
+func (s *BucketService) Update(ctx context.Context, id, userID uuid.UUID, description *string) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditBucketUpdate, BucketID: id, BucketName: bucketName}, err)
+	}()
+
 	// Verify bucket exists
-	if _, err := s.buckets.Get(ctx, id, userID); err != nil {
+	bucket, err := s.buckets.Get(ctx, id, userID)
+	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return ErrBucketNotFound
 		}
 		return err
 	}
+	bucketName = bucket.Name
 
 	return s.buckets.Update(ctx, id, userID, description)
 }
 
-func (s *BucketService) Delete(ctx context.Context, id, userID uuid.UUID, deleteRemote bool) error {
+func (s *BucketService) Delete(ctx context.Context, id, userID uuid.UUID, deleteRemote bool) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditBucketDelete,
+			BucketID:   id,
+			BucketName: bucketName,
+			Details:    map[string]string{"deleteRemote": strconv.FormatBool(deleteRemote)},
+		}, err)
+	}()
+
 	// Verify bucket exists
 	bucket, err := s.buckets.Get(ctx, id, userID)
 	if err != nil {
@@ -146,9 +194,10 @@ func (s *BucketService) Delete(ctx context.Context, id, userID uuid.UUID, delete
 		}
 		return err
 	}
+	bucketName = bucket.Name
 
 	if deleteRemote {
-		store, err := s.GetObjectStore(ctx, id, userID, s.encryptionKey)
+		store, err := s.GetObjectStore(ctx, id, userID, s.envelope)
 		if err != nil {
 			return err
 		}
@@ -158,15 +207,27 @@ func (s *BucketService) Delete(ctx context.Context, id, userID uuid.UUID, delete
 		}
 	}
 
-	return s.buckets.Delete(ctx, id, userID)
+	return s.events.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Buckets.Delete(ctx, id, userID); err != nil {
+			return err
+		}
+		return s.events.Append(ctx, tx, DomainEvent{
+			Type:         EventBucketDeleted,
+			UserID:       userID,
+			BucketID:     id,
+			BucketName:   bucket.Name,
+			CredentialID: bucket.CredentialID,
+			Details:      map[string]string{"deleteRemote": strconv.FormatBool(deleteRemote)},
+		})
+	})
 }
 
-func (s *BucketService) UpdateSize(ctx context.Context, bucketID uuid.UUID, sizeBytes int64) error {
-	return s.buckets.UpdateSize(ctx, bucketID, sizeBytes)
+func (s *BucketService) UpdateUsage(ctx context.Context, bucketID uuid.UUID, sizeBytes, objectCount int64) error {
+	return s.buckets.UpdateUsage(ctx, bucketID, sizeBytes, objectCount)
 }
 
 // GetObjectStore creates an object store client for a specific bucket
-func (s *BucketService) GetObjectStore(ctx context.Context, bucketID, userID uuid.UUID, encryptionKey []byte) (*storage.ObjectStore, error) {
+func (s *BucketService) GetObjectStore(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (*storage.ObjectStore, error) {
 	// Get bucket (includes credential info)
 	bucket, err := s.buckets.Get(ctx, bucketID, userID)
 	if err != nil {
@@ -186,18 +247,13 @@ func (s *BucketService) GetObjectStore(ctx context.Context, bucketID, userID uui
 	}
 
 	// Decrypt credentials
-	accessKey, err := decryptCredential(cred.EncryptedAccessKey, encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	secretKey, err := decryptCredential(cred.EncryptedSecretKey, encryptionKey)
+	accessKey, secretKey, err := decryptCredentialKeys(ctx, cred, envelope)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create object store client
-	return storage.NewObjectStoreWithCredentials(
+	store, err := storage.NewObjectStoreWithCredentials(
 		ctx,
 		cred.Endpoint,
 		cred.Region,
@@ -205,6 +261,12 @@ func (s *BucketService) GetObjectStore(ctx context.Context, bucketID, userID uui
 		secretKey,
 		cred.UseSSL,
 	)
+	if err != nil {
+		return nil, err
+	}
+	// Requests are counted for cost estimates
+	store.SetRequestMeter(s.requests.Meter(bucketID))
+	return store, nil
 }
 
 // Helper to get bucket name from bucket record
@@ -0,0 +1,24 @@
+package service
+
+import "context"
+
+// ClientInfo describes the device a request came from
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	// RequestID correlates audit entries with request logs
+	RequestID string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo returns a context carrying the requesting client's details
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext returns the client details stored by WithClientInfo, if any
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
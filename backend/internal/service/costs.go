@@ -0,0 +1,512 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"bucketbird/backend/internal/pricing"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Observed requests and egress of this many days make up a month
+	costRequestWindowDays = 30
+	// Daily request counts older than this are deleted
+	requestCountRetention = 90 * 24 * time.Hour
+	requestPruneInterval  = time.Hour
+)
+
+// RequestCounter counts the requests BucketBird sends to each bucket in
+// memory and adds them to the bucket's daily counts when flushed. Requests
+// made directly by clients through presigned URLs are not seen.
+type RequestCounter struct {
+	costs repository.CostRepository
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]*repository.RequestCounts
+
+	logger *slog.Logger
+}
+
+func NewRequestCounter(costs repository.CostRepository, logger *slog.Logger) *RequestCounter {
+	return &RequestCounter{
+		costs:   costs,
+		pending: make(map[uuid.UUID]*repository.RequestCounts),
+		logger:  logger,
+	}
+}
+
+// Meter returns a request meter that counts towards the bucket
+func (c *RequestCounter) Meter(bucketID uuid.UUID) storage.RequestMeter {
+	return func(kind string, egressBytes int64) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		counts, ok := c.pending[bucketID]
+		if !ok {
+			counts = &repository.RequestCounts{BucketID: bucketID}
+			c.pending[bucketID] = counts
+		}
+		switch kind {
+		case storage.RequestWrite:
+			counts.WriteRequests++
+		case storage.RequestList:
+			counts.ListRequests++
+		case storage.RequestRead:
+			counts.ReadRequests++
+		}
+		counts.EgressBytes += egressBytes
+	}
+}
+
+// Run flushes the counts every interval and prunes old ones until ctx is cancelled
+func (c *RequestCounter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		c.flush(ctx)
+
+		if time.Since(lastPrune) >= requestPruneInterval {
+			deleted, err := c.costs.DeleteRequestCountsBefore(ctx, time.Now().Add(-requestCountRetention))
+			if err != nil {
+				c.logger.Error("failed to prune request counts", slog.Any("error", err))
+			} else if deleted > 0 {
+				c.logger.Info("pruned request counts", slog.Int64("deleted", deleted))
+			}
+			lastPrune = time.Now()
+		}
+	}
+}
+
+func (c *RequestCounter) flush(ctx context.Context) {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[uuid.UUID]*repository.RequestCounts)
+	c.mu.Unlock()
+
+	// Counts that cannot be stored, e.g. of a bucket deleted since, are
+	// dropped; they only feed estimates
+	now := time.Now()
+	for _, counts := range pending {
+		counts.Day = now
+		if err := c.costs.AddRequestCounts(ctx, counts); err != nil {
+			c.logger.Warn("failed to store request counts",
+				slog.String("bucket_id", counts.BucketID.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+// CostBreakdown is an estimated monthly cost in the pricing table's currency
+type CostBreakdown struct {
+	Storage  float64 `json:"storage"`
+	Requests float64 `json:"requests"`
+	Egress   float64 `json:"egress"`
+	Total    float64 `json:"total"`
+}
+
+func (c *CostBreakdown) add(other CostBreakdown) {
+	c.Storage += other.Storage
+	c.Requests += other.Requests
+	c.Egress += other.Egress
+	c.Total += other.Total
+}
+
+func (c CostBreakdown) rounded() CostBreakdown {
+	return CostBreakdown{
+		Storage:  roundCost(c.Storage),
+		Requests: roundCost(c.Requests),
+		Egress:   roundCost(c.Egress),
+		Total:    roundCost(c.Total),
+	}
+}
+
+// roundCost keeps sub-cent precision so small buckets do not show up as free
+func roundCost(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+type StorageClassCost struct {
+	StorageClass string  `json:"storageClass"`
+	SizeBytes    int64   `json:"sizeBytes"`
+	Cost         float64 `json:"cost"`
+}
+
+// BucketCost is the estimated monthly cost of a bucket. Buckets whose
+// provider has no prices, such as self-hosted MinIO, are listed unpriced.
+type BucketCost struct {
+	BucketID       uuid.UUID `json:"bucketId"`
+	BucketName     string    `json:"bucketName"`
+	OwnerID        uuid.UUID `json:"ownerId"`
+	OwnerEmail     string    `json:"ownerEmail"`
+	CredentialID   uuid.UUID `json:"credentialId"`
+	CredentialName string    `json:"credentialName"`
+	Provider       string    `json:"provider"`
+	Region         string    `json:"region"`
+	Priced         bool      `json:"priced"`
+	// PricingProvider is the pricing table entry the provider matched
+	PricingProvider string             `json:"pricingProvider,omitempty"`
+	SizeBytes       int64              `json:"sizeBytes"`
+	ObjectCount     int64              `json:"objectCount"`
+	StorageClasses  []StorageClassCost `json:"storageClasses"`
+	WriteRequests   int64              `json:"writeRequests"`
+	ListRequests    int64              `json:"listRequests"`
+	ReadRequests    int64              `json:"readRequests"`
+	EgressBytes     int64              `json:"egressBytes"`
+	Cost            CostBreakdown      `json:"cost"`
+}
+
+// CostGroup sums the buckets of a credential or team
+type CostGroup struct {
+	ID              uuid.UUID     `json:"id"`
+	Name            string        `json:"name"`
+	Buckets         int           `json:"buckets"`
+	UnpricedBuckets int           `json:"unpricedBuckets"`
+	SizeBytes       int64         `json:"sizeBytes"`
+	Cost            CostBreakdown `json:"cost"`
+}
+
+// CostEstimate is the estimated monthly cost of a set of buckets. Storage is
+// priced at the buckets' current size; requests and egress are those
+// BucketBird observed over the last RequestWindowDays days.
+type CostEstimate struct {
+	Currency          string       `json:"currency"`
+	RequestWindowDays int          `json:"requestWindowDays"`
+	GeneratedAt       time.Time    `json:"generatedAt"`
+	Buckets           []BucketCost `json:"buckets"`
+	Credentials       []CostGroup  `json:"credentials"`
+	// Teams is only set for team and instance-wide estimates. A bucket counts
+	// towards every team its owner belongs to.
+	Teams []CostGroup   `json:"teams,omitempty"`
+	Total CostBreakdown `json:"total"`
+}
+
+// CostService estimates what buckets cost per month from the pricing table,
+// their tracked usage and the requests BucketBird sent to them
+type CostService struct {
+	repos   *repository.Repositories
+	pricing *pricing.Source
+	logger  *slog.Logger
+}
+
+func NewCostService(repos *repository.Repositories, prices *pricing.Source, logger *slog.Logger) *CostService {
+	return &CostService{
+		repos:   repos,
+		pricing: prices,
+		logger:  logger,
+	}
+}
+
+// Pricing returns the pricing table estimates are currently based on
+func (s *CostService) Pricing() (*pricing.Table, error) {
+	table, err := s.pricing.Table()
+	if err != nil {
+		if table == nil {
+			return nil, err
+		}
+		s.logger.Warn("failed to reload pricing file, using the previous prices", slog.Any("error", err))
+	}
+	return table, nil
+}
+
+// ForUser estimates the cost of the user's buckets
+func (s *CostService) ForUser(ctx context.Context, userID uuid.UUID) (*CostEstimate, error) {
+	buckets, err := s.repos.Costs.ListBuckets(ctx, &userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.estimate(ctx, buckets, nil)
+}
+
+// ForTeam estimates the cost of the buckets of the team's members. Any member
+// may see it.
+func (s *CostService) ForTeam(ctx context.Context, teamID, userID uuid.UUID) (*CostEstimate, error) {
+	if _, err := s.repos.Teams.GetMember(ctx, teamID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, err
+	}
+
+	buckets, err := s.repos.Costs.ListBuckets(ctx, nil, &teamID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repos.Costs.ListTeamMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var team []*repository.TeamAssignment
+	for _, member := range members {
+		if member.TeamID == teamID {
+			team = append(team, member)
+		}
+	}
+	return s.estimate(ctx, buckets, team)
+}
+
+// All estimates the cost of every bucket of the instance
+func (s *CostService) All(ctx context.Context) (*CostEstimate, error) {
+	buckets, err := s.repos.Costs.ListBuckets(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repos.Costs.ListTeamMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.estimate(ctx, buckets, members)
+}
+
+var costCSVHeader = []string{
+	"bucket_id", "bucket", "owner", "credential", "provider", "region", "priced",
+	"size_bytes", "object_count", "write_requests", "list_requests", "read_requests", "egress_bytes",
+	"storage_cost", "request_cost", "egress_cost", "total_cost", "currency",
+}
+
+// WriteCostCSV writes an estimate as one row per bucket
+func WriteCostCSV(w io.Writer, estimate *CostEstimate) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(costCSVHeader); err != nil {
+		return err
+	}
+	for _, bucket := range estimate.Buckets {
+		if err := writer.Write([]string{
+			bucket.BucketID.String(),
+			bucket.BucketName,
+			bucket.OwnerEmail,
+			bucket.CredentialName,
+			bucket.Provider,
+			bucket.Region,
+			strconv.FormatBool(bucket.Priced),
+			strconv.FormatInt(bucket.SizeBytes, 10),
+			strconv.FormatInt(bucket.ObjectCount, 10),
+			strconv.FormatInt(bucket.WriteRequests, 10),
+			strconv.FormatInt(bucket.ListRequests, 10),
+			strconv.FormatInt(bucket.ReadRequests, 10),
+			strconv.FormatInt(bucket.EgressBytes, 10),
+			strconv.FormatFloat(bucket.Cost.Storage, 'f', 4, 64),
+			strconv.FormatFloat(bucket.Cost.Requests, 'f', 4, 64),
+			strconv.FormatFloat(bucket.Cost.Egress, 'f', 4, 64),
+			strconv.FormatFloat(bucket.Cost.Total, 'f', 4, 64),
+			estimate.Currency,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (s *CostService) estimate(ctx context.Context, buckets []*repository.CostBucket, members []*repository.TeamAssignment) (*CostEstimate, error) {
+	table, err := s.Pricing()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	totals, err := s.repos.Costs.ListRequestTotals(ctx, now.AddDate(0, 0, -(costRequestWindowDays-1)))
+	if err != nil {
+		return nil, err
+	}
+	requests := make(map[uuid.UUID]*repository.RequestCounts, len(totals))
+	for _, counts := range totals {
+		requests[counts.BucketID] = counts
+	}
+
+	reports, err := s.repos.Costs.ListLatestRootReports(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &CostEstimate{
+		Currency:          table.Currency,
+		RequestWindowDays: costRequestWindowDays,
+		GeneratedAt:       now.UTC(),
+		Buckets:           make([]BucketCost, 0, len(buckets)),
+		Credentials:       []CostGroup{},
+	}
+
+	credentials := map[uuid.UUID]*CostGroup{}
+	byOwner := map[uuid.UUID][]*BucketCost{}
+	for _, bucket := range buckets {
+		cost := s.bucketCost(table, bucket, requests[bucket.ID], reports[bucket.ID])
+		estimate.Buckets = append(estimate.Buckets, cost)
+		estimate.Total.add(cost.Cost)
+
+		group, ok := credentials[bucket.CredentialID]
+		if !ok {
+			group = &CostGroup{ID: bucket.CredentialID, Name: bucket.CredentialName}
+			credentials[bucket.CredentialID] = group
+		}
+		group.addBucket(&cost)
+	}
+	for i := range estimate.Buckets {
+		byOwner[estimate.Buckets[i].OwnerID] = append(byOwner[estimate.Buckets[i].OwnerID], &estimate.Buckets[i])
+	}
+
+	for _, group := range credentials {
+		estimate.Credentials = append(estimate.Credentials, group.rounded())
+	}
+	sortCostGroups(estimate.Credentials)
+
+	if members != nil {
+		teams := map[uuid.UUID]*CostGroup{}
+		for _, member := range members {
+			team, ok := teams[member.TeamID]
+			if !ok {
+				team = &CostGroup{ID: member.TeamID, Name: member.TeamName}
+				teams[member.TeamID] = team
+			}
+			for _, cost := range byOwner[member.UserID] {
+				team.addBucket(cost)
+			}
+		}
+		estimate.Teams = make([]CostGroup, 0, len(teams))
+		for _, team := range teams {
+			estimate.Teams = append(estimate.Teams, team.rounded())
+		}
+		sortCostGroups(estimate.Teams)
+	}
+
+	for i := range estimate.Buckets {
+		estimate.Buckets[i].Cost = estimate.Buckets[i].Cost.rounded()
+	}
+	sort.SliceStable(estimate.Buckets, func(i, j int) bool {
+		return estimate.Buckets[i].Cost.Total > estimate.Buckets[j].Cost.Total
+	})
+	estimate.Total = estimate.Total.rounded()
+	return estimate, nil
+}
+
+func (g *CostGroup) addBucket(cost *BucketCost) {
+	g.Buckets++
+	if !cost.Priced {
+		g.UnpricedBuckets++
+	}
+	g.SizeBytes += cost.SizeBytes
+	g.Cost.add(cost.Cost)
+}
+
+func (g *CostGroup) rounded() CostGroup {
+	group := *g
+	group.Cost = g.Cost.rounded()
+	return group
+}
+
+func sortCostGroups(groups []CostGroup) {
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Cost.Total != groups[j].Cost.Total {
+			return groups[i].Cost.Total > groups[j].Cost.Total
+		}
+		return groups[i].Name < groups[j].Name
+	})
+}
+
+func (s *CostService) bucketCost(table *pricing.Table, bucket *repository.CostBucket, requests *repository.RequestCounts, report []byte) BucketCost {
+	region := bucket.Region
+	if region == "" {
+		region = bucket.CredentialRegion
+	}
+	cost := BucketCost{
+		BucketID:       bucket.ID,
+		BucketName:     bucket.Name,
+		OwnerID:        bucket.UserID,
+		OwnerEmail:     bucket.OwnerEmail,
+		CredentialID:   bucket.CredentialID,
+		CredentialName: bucket.CredentialName,
+		Provider:       bucket.CredentialProvider,
+		Region:         region,
+		SizeBytes:      bucket.SizeBytes,
+		ObjectCount:    bucket.ObjectCount,
+		StorageClasses: []StorageClassCost{},
+	}
+	if requests != nil {
+		cost.WriteRequests = requests.WriteRequests
+		cost.ListRequests = requests.ListRequests
+		cost.ReadRequests = requests.ReadRequests
+		cost.EgressBytes = requests.EgressBytes
+	}
+
+	provider, prices := table.Lookup(bucket.CredentialProvider, region)
+	if prices == nil {
+		return cost
+	}
+	cost.Priced = true
+	cost.PricingProvider = provider.Name
+
+	for _, class := range s.storageClasses(bucket, report) {
+		price := float64(class.SizeBytes) / pricing.BytesPerGB * prices.StoragePrice(class.StorageClass)
+		cost.StorageClasses = append(cost.StorageClasses, StorageClassCost{
+			StorageClass: class.StorageClass,
+			SizeBytes:    class.SizeBytes,
+			Cost:         roundCost(price),
+		})
+		cost.Cost.Storage += price
+	}
+	cost.Cost.Requests = float64(cost.WriteRequests)/1000*prices.Requests.Write +
+		float64(cost.ListRequests)/1000*prices.Requests.List +
+		float64(cost.ReadRequests)/1000*prices.Requests.Read
+	cost.Cost.Egress = float64(cost.EgressBytes) / pricing.BytesPerGB * prices.Egress
+	cost.Cost.Total = cost.Cost.Storage + cost.Cost.Requests + cost.Cost.Egress
+	return cost
+}
+
+// storageClasses splits the bucket's tracked size across storage classes in
+// the proportions of its latest usage breakdown. Without one, everything is
+// assumed to be STANDARD.
+func (s *CostService) storageClasses(bucket *repository.CostBucket, report []byte) []StorageClassCost {
+	standard := []StorageClassCost{{StorageClass: pricing.DefaultStorageClass, SizeBytes: bucket.SizeBytes}}
+	if report == nil || bucket.SizeBytes == 0 {
+		return standard
+	}
+
+	var tree UsageTree
+	if err := json.Unmarshal(report, &tree); err != nil {
+		s.logger.Warn("failed to decode usage report",
+			slog.String("bucket_id", bucket.ID.String()),
+			slog.Any("error", err),
+		)
+		return standard
+	}
+	var scanned int64
+	for _, class := range tree.StorageClasses {
+		scanned += class.SizeBytes
+	}
+	if scanned == 0 {
+		return standard
+	}
+
+	classes := make([]StorageClassCost, 0, len(tree.StorageClasses))
+	remaining := bucket.SizeBytes
+	for i, class := range tree.StorageClasses {
+		size := remaining
+		if i < len(tree.StorageClasses)-1 {
+			size = int64(float64(bucket.SizeBytes) * float64(class.SizeBytes) / float64(scanned))
+		}
+		remaining -= size
+		classes = append(classes, StorageClassCost{StorageClass: class.Name, SizeBytes: size})
+	}
+	return classes
+}
@@ -14,20 +14,26 @@ import (
 )
 
 type CredentialService struct {
-	credentials   repository.CredentialRepository
-	encryptionKey []byte
-	logger        *slog.Logger
+	credentials repository.CredentialRepository
+	envelope    *crypto.Envelope
+	audit       *AuditService
+	events      *EventBus
+	logger      *slog.Logger
 }
 
 func NewCredentialService(
 	credentials repository.CredentialRepository,
-	encryptionKey []byte,
+	envelope *crypto.Envelope,
+	audit *AuditService,
+	events *EventBus,
 	logger *slog.Logger,
 ) *CredentialService {
 	return &CredentialService{
-		credentials:   credentials,
-		encryptionKey: encryptionKey,
-		logger:        logger,
+		credentials: credentials,
+		envelope:    envelope,
+		audit:       audit,
+		events:      events,
+		logger:      logger,
 	}
 }
 
@@ -43,14 +49,17 @@ type CreateCredentialInput struct {
 	Logo      *string
 }
 
-func (s *CredentialService) Create(ctx context.Context, input CreateCredentialInput) (*repository.Credential, error) {
-	// Encrypt credentials
-	encryptedAccessKey, err := crypto.EncryptAES(input.AccessKey, s.encryptionKey)
-	if err != nil {
-		return nil, err
-	}
+func (s *CredentialService) Create(ctx context.Context, input CreateCredentialInput) (created *repository.Credential, err error) {
+	defer func() {
+		entry := AuditEntry{Action: AuditCredentialCreate, Details: map[string]string{"name": input.Name, "provider": input.Provider}}
+		if created != nil {
+			entry.TargetID = created.ID.String()
+		}
+		s.audit.Record(ctx, entry, err)
+	}()
 
-	encryptedSecretKey, err := crypto.EncryptAES(input.SecretKey, s.encryptionKey)
+	// Encrypt credentials
+	encryptedAccessKey, encryptedSecretKey, encryptedDataKey, err := encryptCredentialKeys(ctx, s.envelope, input.AccessKey, input.SecretKey)
 	if err != nil {
 		return nil, err
 	}
@@ -70,12 +79,29 @@ func (s *CredentialService) Create(ctx context.Context, input CreateCredentialIn
 		Endpoint:           input.Endpoint,
 		EncryptedAccessKey: encryptedAccessKey,
 		EncryptedSecretKey: encryptedSecretKey,
+		EncryptedDataKey:   encryptedDataKey,
 		UseSSL:             input.UseSSL,
 		Status:             "active",
 		Logo:               input.Logo,
 	}
 
-	return s.credentials.Create(ctx, cred)
+	err = s.events.InTx(ctx, func(tx *repository.Repositories) error {
+		var err error
+		created, err = tx.Credentials.Create(ctx, cred)
+		if err != nil {
+			return err
+		}
+		return s.events.Append(ctx, tx, DomainEvent{
+			Type:         EventCredentialCreated,
+			UserID:       input.UserID,
+			CredentialID: created.ID,
+			Details:      map[string]string{"provider": created.Provider},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
 func (s *CredentialService) List(ctx context.Context, userID uuid.UUID) ([]*repository.Credential, error) {
@@ -106,7 +132,15 @@ type UpdateCredentialInput struct {
 	Logo      *string
 }
 
-func (s *CredentialService) Update(ctx context.Context, input UpdateCredentialInput) error {
+func (s *CredentialService) Update(ctx context.Context, input UpdateCredentialInput) (err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:   AuditCredentialUpdate,
+			TargetID: input.ID.String(),
+			Details:  map[string]string{"name": input.Name, "provider": input.Provider},
+		}, err)
+	}()
+
 	// Verify credential exists
 	existing, err := s.credentials.Get(ctx, input.ID, input.UserID)
 	if err != nil {
@@ -116,13 +150,15 @@ func (s *CredentialService) Update(ctx context.Context, input UpdateCredentialIn
 		return err
 	}
 
-	// Encrypt new credentials
-	encryptedAccessKey, err := crypto.EncryptAES(input.AccessKey, s.encryptionKey)
-	if err != nil {
-		return err
+	// Replacing either key is a rotation; keys that cannot be read count as replaced
+	eventType := EventCredentialRotated
+	if accessKey, secretKey, err := decryptCredentialKeys(ctx, existing, s.envelope); err == nil &&
+		accessKey == input.AccessKey && secretKey == input.SecretKey {
+		eventType = EventCredentialUpdated
 	}
 
-	encryptedSecretKey, err := crypto.EncryptAES(input.SecretKey, s.encryptionKey)
+	// Encrypt new credentials
+	encryptedAccessKey, encryptedSecretKey, encryptedDataKey, err := encryptCredentialKeys(ctx, s.envelope, input.AccessKey, input.SecretKey)
 	if err != nil {
 		return err
 	}
@@ -139,13 +175,28 @@ func (s *CredentialService) Update(ctx context.Context, input UpdateCredentialIn
 	existing.Endpoint = input.Endpoint
 	existing.EncryptedAccessKey = encryptedAccessKey
 	existing.EncryptedSecretKey = encryptedSecretKey
+	existing.EncryptedDataKey = encryptedDataKey
 	existing.UseSSL = input.UseSSL
 	existing.Logo = input.Logo
 
-	return s.credentials.Update(ctx, existing)
+	return s.events.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Credentials.Update(ctx, existing); err != nil {
+			return err
+		}
+		return s.events.Append(ctx, tx, DomainEvent{
+			Type:         eventType,
+			UserID:       input.UserID,
+			CredentialID: existing.ID,
+			Details:      map[string]string{"provider": existing.Provider},
+		})
+	})
 }
 
-func (s *CredentialService) Delete(ctx context.Context, id, userID uuid.UUID) error {
+func (s *CredentialService) Delete(ctx context.Context, id, userID uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditCredentialDelete, TargetID: id.String()}, err)
+	}()
+
 	// Verify credential exists
 	if _, err := s.credentials.Get(ctx, id, userID); err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
@@ -155,7 +206,12 @@ func (s *CredentialService) Delete(ctx context.Context, id, userID uuid.UUID) er
 	}
 
 	// Delete will cascade to buckets automatically via database constraint
-	return s.credentials.Delete(ctx, id, userID)
+	return s.events.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Credentials.Delete(ctx, id, userID); err != nil {
+			return err
+		}
+		return s.events.Append(ctx, tx, DomainEvent{Type: EventCredentialDeleted, UserID: userID, CredentialID: id})
+	})
 }
 
 // GetDecryptedCredentials returns decrypted access and secret keys
@@ -168,17 +224,7 @@ func (s *CredentialService) GetDecryptedCredentials(ctx context.Context, id, use
 		return "", "", err
 	}
 
-	accessKey, err = crypto.DecryptAES(cred.EncryptedAccessKey, s.encryptionKey)
-	if err != nil {
-		return "", "", err
-	}
-
-	secretKey, err = crypto.DecryptAES(cred.EncryptedSecretKey, s.encryptionKey)
-	if err != nil {
-		return "", "", err
-	}
-
-	return accessKey, secretKey, nil
+	return decryptCredentialKeys(ctx, cred, s.envelope)
 }
 
 type TestCredentialResult struct {
@@ -208,19 +254,12 @@ func (s *CredentialService) Test(ctx context.Context, id, userID uuid.UUID) (*Te
 	}
 
 	// Decrypt credentials
-	accessKey, err := crypto.DecryptAES(cred.EncryptedAccessKey, s.encryptionKey)
-	if err != nil {
-		return &TestCredentialResult{
-			Success: false,
-			Message: "Failed to decrypt access key",
-		}, nil
-	}
-
-	secretKey, err := crypto.DecryptAES(cred.EncryptedSecretKey, s.encryptionKey)
+	accessKey, secretKey, err := decryptCredentialKeys(ctx, cred, s.envelope)
 	if err != nil {
+		s.logger.Error("failed to decrypt credential", slog.Any("error", err))
 		return &TestCredentialResult{
 			Success: false,
-			Message: "Failed to decrypt secret key",
+			Message: "Failed to decrypt credential",
 		}, nil
 	}
 
@@ -255,12 +294,7 @@ func (s *CredentialService) DiscoverBuckets(ctx context.Context, id, userID uuid
 		return nil, err
 	}
 
-	accessKey, err := crypto.DecryptAES(cred.EncryptedAccessKey, s.encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	secretKey, err := crypto.DecryptAES(cred.EncryptedSecretKey, s.encryptionKey)
+	accessKey, secretKey, err := decryptCredentialKeys(ctx, cred, s.envelope)
 	if err != nil {
 		return nil, err
 	}
@@ -303,7 +337,25 @@ func (s *CredentialService) DiscoverBuckets(ctx context.Context, id, userID uuid
 	return discovered, nil
 }
 
-// Helper function used by BucketService
-func decryptCredential(encrypted string, key []byte) (string, error) {
-	return crypto.DecryptAES(encrypted, key)
+// encryptCredentialKeys encrypts both keys under a new data key and returns the wrapped data key
+func encryptCredentialKeys(ctx context.Context, envelope *crypto.Envelope, accessKey, secretKey string) (string, string, *string, error) {
+	wrapped, ciphertexts, err := envelope.EncryptRecord(ctx, accessKey, secretKey)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return ciphertexts[0], ciphertexts[1], &wrapped, nil
+}
+
+// decryptCredentialKeys is shared with BucketService. Credentials without a
+// data key predate envelope encryption and are decrypted with the keyring.
+func decryptCredentialKeys(ctx context.Context, cred *repository.Credential, envelope *crypto.Envelope) (accessKey, secretKey string, err error) {
+	wrapped := ""
+	if cred.EncryptedDataKey != nil {
+		wrapped = *cred.EncryptedDataKey
+	}
+	plaintexts, err := envelope.DecryptRecord(ctx, wrapped, cred.EncryptedAccessKey, cred.EncryptedSecretKey)
+	if err != nil {
+		return "", "", err
+	}
+	return plaintexts[0], plaintexts[1], nil
 }
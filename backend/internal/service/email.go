@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"bucketbird/backend/internal/mail"
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Product name used in email subjects and bodies
+	emailProductName = "BucketBird"
+
+	// How long a single delivery attempt may take
+	emailSendTimeout = time.Minute
+)
+
+// EmailConfig configures account emails
+type EmailConfig struct {
+	// PublicURL is the frontend base URL; links point to /verify-email and /reset-password
+	PublicURL string
+	// RequireVerification blocks password logins until the address is verified
+	RequireVerification bool
+	VerificationTTL     time.Duration
+	PasswordResetTTL    time.Duration
+}
+
+// EmailService sends verification and password reset emails and redeems their links
+type EmailService struct {
+	mailer    mail.Mailer
+	templates *mail.Templates
+	users     repository.UserRepository
+	tokens    repository.EmailTokenRepository
+	sessions  repository.SessionRepository
+	lockout   *LockoutService
+	passwords *PasswordPolicy
+	cfg       EmailConfig
+	audit     *AuditService
+	logger    *slog.Logger
+}
+
+func NewEmailService(
+	mailer mail.Mailer,
+	templates *mail.Templates,
+	users repository.UserRepository,
+	tokens repository.EmailTokenRepository,
+	sessions repository.SessionRepository,
+	lockout *LockoutService,
+	passwords *PasswordPolicy,
+	cfg EmailConfig,
+	audit *AuditService,
+	logger *slog.Logger,
+) *EmailService {
+	return &EmailService{
+		mailer:    mailer,
+		templates: templates,
+		users:     users,
+		tokens:    tokens,
+		sessions:  sessions,
+		lockout:   lockout,
+		passwords: passwords,
+		cfg:       cfg,
+		audit:     audit,
+		logger:    logger,
+	}
+}
+
+// RequireVerification reports whether unverified local accounts are blocked from signing in
+func (s *EmailService) RequireVerification() bool {
+	return s.cfg.RequireVerification
+}
+
+type emailData struct {
+	AppName   string
+	FirstName string
+	Link      string
+	ExpiresIn string
+}
+
+// SendVerification emails a link confirming the user's current address
+func (s *EmailService) SendVerification(ctx context.Context, user *repository.User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	return s.sendVerification(ctx, user, user.Email, mail.TemplateVerifyEmail)
+}
+
+// RequestEmailChange emails a confirmation link to the new address. The account
+// keeps its current address until the link is opened.
+func (s *EmailService) RequestEmailChange(ctx context.Context, user *repository.User, newEmail string) error {
+	if existing, err := s.users.GetByEmail(ctx, newEmail); err == nil && existing.ID != user.ID {
+		return ErrEmailAlreadyInUse
+	} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	return s.sendVerification(ctx, user, newEmail, mail.TemplateEmailChange)
+}
+
+func (s *EmailService) sendVerification(ctx context.Context, user *repository.User, email, template string) error {
+	token, err := s.issueToken(ctx, user.ID, repository.EmailTokenVerifyEmail, email, s.cfg.VerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.send(template, email, emailData{
+		AppName:   emailProductName,
+		FirstName: user.FirstName,
+		Link:      s.link("/verify-email", token),
+		ExpiresIn: formatTTL(s.cfg.VerificationTTL),
+	})
+}
+
+// VerifyEmail redeems a verification link. For email changes this also switches the
+// account to the new address.
+func (s *EmailService) VerifyEmail(ctx context.Context, token string) (*repository.User, error) {
+	emailToken, err := s.consumeToken(ctx, token, repository.EmailTokenVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, emailToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if emailToken.Email != user.Email {
+		// The new address may have been taken since the change was requested
+		if existing, err := s.users.GetByEmail(ctx, emailToken.Email); err == nil && existing.ID != user.ID {
+			return nil, ErrEmailAlreadyInUse
+		} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	if err := s.users.VerifyEmail(ctx, user.ID, emailToken.Email); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("email address verified",
+		slog.String("user_id", user.ID.String()),
+		slog.Bool("changed", emailToken.Email != user.Email),
+	)
+
+	return s.users.GetByID(ctx, user.ID)
+}
+
+// RequestPasswordReset emails a reset link if a local account exists for the address.
+// It returns nil for unknown addresses so callers cannot probe which accounts exist.
+func (s *EmailService) RequestPasswordReset(ctx context.Context, email string) error {
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" {
+		return nil
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Accounts without a local password sign in through SSO or LDAP
+	if user.PasswordHash == "" || user.IsDemo {
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user.ID, repository.EmailTokenPasswordReset, user.Email, s.cfg.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("password reset requested", slog.String("user_id", user.ID.String()))
+
+	return s.send(mail.TemplatePasswordReset, user.Email, emailData{
+		AppName:   emailProductName,
+		FirstName: user.FirstName,
+		Link:      s.link("/reset-password", token),
+		ExpiresIn: formatTTL(s.cfg.PasswordResetTTL),
+	})
+}
+
+// ResetPassword redeems a reset link, sets the new password and signs out every session
+func (s *EmailService) ResetPassword(ctx context.Context, token, newPassword string) (err error) {
+	// The caller is not signed in, so the account is only known once the link is
+	var actorID uuid.UUID
+	var actorEmail string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditAuthPasswordReset, ActorID: actorID, ActorEmail: actorEmail}, err)
+	}()
+
+	if newPassword == "" {
+		return ErrPasswordRequired
+	}
+
+	// The link is only used up once the new password is accepted, so a rejected
+	// password can be corrected without requesting another email
+	emailToken, err := s.lookupToken(ctx, token, repository.EmailTokenPasswordReset)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.users.GetByID(ctx, emailToken.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidEmailToken
+		}
+		return err
+	}
+
+	actorID, actorEmail = user.ID, user.Email
+
+	// Links sent to a previous address stop working once the address changes
+	if emailToken.Email != user.Email {
+		return ErrInvalidEmailToken
+	}
+
+	if err := s.passwords.Check(newPassword, PasswordOwner{
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := s.consumeToken(ctx, token, repository.EmailTokenPasswordReset); err != nil {
+		return err
+	}
+
+	hash, err := crypto.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return err
+	}
+
+	// Any other outstanding reset links are no longer needed
+	if err := s.tokens.DeleteForUser(ctx, user.ID, repository.EmailTokenPasswordReset); err != nil {
+		return err
+	}
+
+	// Receiving the link proves ownership of the address
+	if user.EmailVerifiedAt == nil {
+		if err := s.users.VerifyEmail(ctx, user.ID, user.Email); err != nil {
+			return err
+		}
+	}
+
+	if err := s.sessions.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	// Access tokens already handed out stop working on every instance
+	if _, err := s.users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if s.lockout != nil {
+		if err := s.lockout.Reset(ctx, user.Email); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("password reset completed", slog.String("user_id", user.ID.String()))
+	return nil
+}
+
+// issueToken replaces any outstanding token of the same purpose so only the latest link works
+func (s *EmailService) issueToken(ctx context.Context, userID uuid.UUID, purpose, email string, ttl time.Duration) (string, error) {
+	// Opportunistically clean up links nobody used
+	if err := s.tokens.DeleteExpired(ctx); err != nil {
+		s.logger.Warn("failed to delete expired email tokens", slog.Any("error", err))
+	}
+
+	if err := s.tokens.DeleteForUser(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	token, err := crypto.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.tokens.Create(ctx, userID, purpose, crypto.HashEmailToken(token), email, time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// lookupToken returns a valid token without using it up
+func (s *EmailService) lookupToken(ctx context.Context, token, purpose string) (*repository.EmailToken, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrInvalidEmailToken
+	}
+
+	emailToken, err := s.tokens.Get(ctx, crypto.HashEmailToken(token), purpose)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidEmailToken
+		}
+		return nil, err
+	}
+	if !time.Now().Before(emailToken.ExpiresAt) {
+		return nil, ErrInvalidEmailToken
+	}
+	return emailToken, nil
+}
+
+func (s *EmailService) consumeToken(ctx context.Context, token, purpose string) (*repository.EmailToken, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrInvalidEmailToken
+	}
+
+	emailToken, err := s.tokens.Consume(ctx, crypto.HashEmailToken(token), purpose)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidEmailToken
+		}
+		return nil, err
+	}
+	if !time.Now().Before(emailToken.ExpiresAt) {
+		return nil, ErrInvalidEmailToken
+	}
+	return emailToken, nil
+}
+
+// send renders the message and delivers it in the background, so slow mail servers
+// do not hold up requests and response times do not reveal whether an account exists
+func (s *EmailService) send(template, to string, data emailData) error {
+	msg, err := s.templates.Render(template, to, data)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+		defer cancel()
+
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			s.logger.Error("failed to send email",
+				slog.String("template", template),
+				slog.Any("error", err),
+			)
+			return
+		}
+		s.logger.Debug("email sent", slog.String("template", template))
+	}()
+	return nil
+}
+
+func (s *EmailService) link(path, token string) string {
+	return s.cfg.PublicURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// formatTTL renders a token lifetime for humans, e.g. "24 hours" or "30 minutes"
+func formatTTL(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	case d >= time.Minute:
+		return plural(int(d/time.Minute), "minute")
+	default:
+		return plural(int(d/time.Second), "second")
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -1,6 +1,10 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Common errors used across services
 var (
@@ -8,6 +12,22 @@ var (
 	ErrInvalidCredentials  = errors.New("invalid credentials")
 	ErrInvalidRefreshToken = errors.New("invalid refresh token")
 	ErrEmailAlreadyInUse   = errors.New("email already in use")
+	ErrWeakPassword        = errors.New("password does not meet the requirements")
+
+	// Single sign-on errors
+	ErrPasswordLoginDisabled = errors.New("password login is disabled")
+	ErrNoLocalPassword       = errors.New("account has no local password")
+	ErrInvalidOIDCState      = errors.New("invalid or expired login request")
+	ErrOIDCLoginFailed       = errors.New("single sign-on failed")
+	ErrSSOUserNotProvisioned = errors.New("no account exists for this identity")
+	ErrSSOAccountConflict    = errors.New("an account with this email already exists and cannot be linked")
+
+	// Two-factor authentication errors
+	ErrInvalidMFAToken         = errors.New("invalid or expired mfa token")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp       = errors.New("two-factor setup has not been started")
 
 	// Credential errors
 	ErrCredentialNotFound      = errors.New("credential not found")
@@ -18,10 +38,125 @@ var (
 	ErrBucketNotFound      = errors.New("bucket not found")
 	ErrBucketAlreadyExists = errors.New("bucket already exists")
 
+	// Webhook errors
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhook          = errors.New("invalid webhook")
+
+	// Quota errors
+	ErrQuotaNotFound     = errors.New("quota not found")
+	ErrInvalidQuota      = errors.New("invalid quota")
+	ErrQuotaExceeded     = errors.New("storage quota exceeded")
+	ErrQuotaSizeRequired = errors.New("the upload size must be given because a storage quota applies")
+
+	// Usage history errors
+	ErrUsageAlertNotFound = errors.New("usage alert not found")
+	ErrInvalidUsageAlert  = errors.New("invalid usage alert")
+	ErrInvalidUsageQuery  = errors.New("invalid usage query")
+
+	// Lifecycle errors
+	ErrInvalidLifecycle     = errors.New("invalid lifecycle configuration")
+	ErrLifecycleUnsupported = errors.New("the storage provider does not support lifecycle rules")
+
+	// Bucket access errors
+	ErrInvalidCors              = errors.New("invalid CORS configuration")
+	ErrInvalidBucketPolicy      = errors.New("invalid bucket policy")
+	ErrInvalidPublicAccessBlock = errors.New("invalid public access block")
+	ErrFeatureUnsupported       = errors.New("feature not supported")
+	ErrAccessTemplateNotFound   = errors.New("access template not found")
+	ErrInvalidAccessTemplate    = errors.New("invalid access template parameters")
+
+	// Object tag errors
+	ErrObjectNotFound    = errors.New("object not found")
+	ErrInvalidObjectTags = errors.New("invalid object tags")
+	ErrInvalidTagFilter  = errors.New("invalid tag filter")
+	ErrTagFilterTooBroad = errors.New("too many objects to filter by tag")
+	ErrTagJobNotFound    = errors.New("tag job not found")
+	ErrInvalidTagJob     = errors.New("invalid tag job")
+	ErrTagJobFinished    = errors.New("tag job has already finished")
+	ErrTagSearchNotFound = errors.New("tag search not found")
+
+	// Personal access token errors
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+	ErrInvalidTokenName            = errors.New("token name is required")
+	ErrInvalidTokenScope           = errors.New("invalid token scope")
+	ErrInvalidTokenExpiry          = errors.New("token expiry must be in the future")
+
+	// Session errors
+	ErrSessionNotFound = errors.New("session not found")
+
+	// Email verification and password reset errors
+	ErrInvalidEmailToken    = errors.New("invalid or expired link")
+	ErrEmailNotVerified     = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+	ErrPasswordRequired     = errors.New("password is required")
+	ErrEmailNotConfigured   = errors.New("email delivery is not configured")
+
+	// Registration errors
+	ErrRegistrationClosed  = errors.New("registration is closed")
+	ErrInviteRequired      = errors.New("an invite code is required to register")
+	ErrInvalidInvite       = errors.New("invalid or expired invite code")
+	ErrInviteEmailMismatch = errors.New("invite code is for a different email address")
+	ErrInviteNotFound      = errors.New("invite not found")
+	ErrInviteForbidden     = errors.New("only admins and team owners can create invites")
+	ErrInvalidInviteUses   = errors.New("max uses must not be negative")
+	ErrInvalidInviteExpiry = errors.New("invite expiry must be in the future")
+
+	// Team errors
+	ErrTeamNotFound       = errors.New("team not found")
+	ErrTeamMemberNotFound = errors.New("team member not found")
+	ErrInvalidTeamName    = errors.New("team name is required")
+	ErrNotTeamOwner       = errors.New("only team owners can do this")
+	ErrLastTeamOwner      = errors.New("a team must keep at least one owner")
+
+	// Lockout errors
+	ErrAccountLocked   = errors.New("account temporarily locked")
+	ErrLockoutNotFound = errors.New("no lockout recorded for this account")
+
 	// Demo mode errors
 	ErrDemoRestriction = errors.New("file preview and download are not available in demo mode")
 )
 
+// AccountLockedError is returned while an account is locked after repeated failed logins
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account temporarily locked until " + e.Until.UTC().Format(time.RFC3339)
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// RetryAfter returns how long the caller has to wait, rounded up to whole seconds
+func (e *AccountLockedError) RetryAfter() time.Duration {
+	wait := time.Until(e.Until)
+	if wait < time.Second {
+		return time.Second
+	}
+	return wait.Truncate(time.Second) + time.Second
+}
+
+// QuotaExceededError is returned when a write does not fit into a quota
+type QuotaExceededError struct {
+	Scope string
+	// Resource is "bytes" or "objects"
+	Resource  string
+	Limit     int64
+	Used      int64
+	Requested int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota exceeded: %d of %d %s in use, %d more requested", e.Scope, e.Used, e.Limit, e.Resource, e.Requested)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
 // BucketProvisionError represents a failure when creating or ensuring the bucket
 type BucketProvisionError struct {
 	Reason string
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Domain event types
+const (
+	EventUserLoggedIn = "user.logged_in"
+
+	EventCredentialCreated = "credential.created"
+	EventCredentialUpdated = "credential.updated"
+	// EventCredentialRotated is an update that replaced the access or secret key
+	EventCredentialRotated = "credential.rotated"
+	EventCredentialDeleted = "credential.deleted"
+
+	EventBucketCreated = "bucket.created"
+	EventBucketDeleted = "bucket.deleted"
+
+	EventObjectUploaded = "object.uploaded"
+	EventObjectCopied   = "object.copied"
+	EventObjectsDeleted = "objects.deleted"
+	EventObjectsRenamed = "objects.renamed"
+	EventFolderCreated  = "folder.created"
+
+	// EventQuotaReservationExpired means a write may have changed the bucket
+	// without being seen to finish, such as an upload to a presigned URL
+	EventQuotaReservationExpired = "quota.reservation_expired"
+
+	// EventBucketGrowthExceeded means a bucket grew faster than its usage alert allows
+	EventBucketGrowthExceeded = "bucket.growth_exceeded"
+)
+
+const (
+	// Events claimed per poll; each is dispatched concurrently
+	eventBatchSize = 20
+	// A dispatch that fails this often is given up and left as failed
+	maxEventAttempts = 10
+	// First retry delay; it doubles with every failed attempt up to maxEventRetryDelay
+	eventBaseRetryDelay = 10 * time.Second
+	maxEventRetryDelay  = time.Hour
+	// How long a claimed event is hidden from other instances
+	eventLease = 5 * time.Minute
+	// Dispatched and failed events are kept this long for troubleshooting
+	eventRetention       = 7 * 24 * time.Hour
+	eventCleanupInterval = time.Hour
+)
+
+// DomainEvent is something that happened in BucketBird. Fields that do not
+// apply to an event type are left empty.
+type DomainEvent struct {
+	ID         uuid.UUID `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurredAt"`
+	// UserID is the user who caused the event
+	UserID       uuid.UUID `json:"userId"`
+	BucketID     uuid.UUID `json:"bucketId"`
+	BucketName   string    `json:"bucketName,omitempty"`
+	CredentialID uuid.UUID `json:"credentialId"`
+	// Keys are the affected object keys; for renames, the new keys
+	Keys []string `json:"keys,omitempty"`
+	// PreviousKeys are the old keys of renamed objects, in the order of Keys
+	PreviousKeys []string          `json:"previousKeys,omitempty"`
+	Details      map[string]string `json:"details,omitempty"`
+}
+
+// EventHandler reacts to a domain event. Events are delivered at least once,
+// so handlers must be idempotent; a returned error retries the event later.
+type EventHandler func(ctx context.Context, event DomainEvent) error
+
+type eventSubscriber struct {
+	name    string
+	types   []string
+	handler EventHandler
+}
+
+func (s eventSubscriber) wants(eventType string) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, eventType)
+}
+
+// EventBus records domain events in the outbox and dispatches them to
+// in-process subscribers. Events written with Append share the caller's
+// transaction, so they exist exactly when the change they describe does.
+type EventBus struct {
+	repos       *repository.Repositories
+	mu          sync.RWMutex
+	subscribers []eventSubscriber
+	// wake cuts the wait for the next poll short after new events are committed
+	wake   chan struct{}
+	logger *slog.Logger
+}
+
+func NewEventBus(repos *repository.Repositories, logger *slog.Logger) *EventBus {
+	return &EventBus{
+		repos:  repos,
+		wake:   make(chan struct{}, 1),
+		logger: logger,
+	}
+}
+
+// Subscribe registers handler for the given event types, or for every event
+// when none are given. The name identifies the subscriber in the outbox, so
+// it must be unique and stay the same across releases.
+func (b *EventBus) Subscribe(name string, handler EventHandler, types ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, eventSubscriber{name: name, types: types, handler: handler})
+}
+
+// InTx runs fn in a transaction. Events appended to the transaction are
+// dispatched once it commits.
+func (b *EventBus) InTx(ctx context.Context, fn func(tx *repository.Repositories) error) error {
+	if err := b.repos.InTx(ctx, fn); err != nil {
+		return err
+	}
+	b.notify()
+	return nil
+}
+
+// Append writes events to the outbox through tx, normally a transaction
+// opened with InTx
+func (b *EventBus) Append(ctx context.Context, tx *repository.Repositories, events ...DomainEvent) error {
+	for _, event := range events {
+		if event.ID == uuid.Nil {
+			event.ID = uuid.New()
+		}
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now().UTC()
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		record := &repository.OutboxEvent{
+			ID:         event.ID,
+			Type:       event.Type,
+			Payload:    payload,
+			OccurredAt: event.OccurredAt,
+		}
+		if event.BucketID != uuid.Nil {
+			record.BucketID = &event.BucketID
+		}
+		if err := tx.Outbox.Create(ctx, record); err != nil {
+			return fmt.Errorf("append %s event: %w", event.Type, err)
+		}
+	}
+	return nil
+}
+
+// Publish appends events outside of a transaction, for changes that are not
+// stored in the database, such as object operations. The change has already
+// happened, so failures are logged rather than returned.
+func (b *EventBus) Publish(ctx context.Context, events ...DomainEvent) {
+	if err := b.Append(context.WithoutCancel(ctx), b.repos, events...); err != nil {
+		b.logger.Error("failed to publish domain event", slog.Any("error", err))
+		return
+	}
+	b.notify()
+}
+
+func (b *EventBus) notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches outbox events every interval, or sooner when new events are
+// committed, until ctx is done. Several instances can run it at once; each
+// event is claimed by one of them.
+func (b *EventBus) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+
+	for {
+		for b.dispatchBatch(ctx) == eventBatchSize {
+			// A full batch means more events are probably waiting
+		}
+
+		if time.Since(lastCleanup) >= eventCleanupInterval {
+			b.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-b.wake:
+		}
+	}
+}
+
+// dispatchBatch claims and dispatches one batch of events and returns its size
+func (b *EventBus) dispatchBatch(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	events, err := b.repos.Outbox.Claim(ctx, eventBatchSize, time.Now().Add(eventLease))
+	if err != nil {
+		b.logger.Error("failed to claim outbox events", slog.Any("error", err))
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, event := range events {
+		wg.Add(1)
+		go func(event *repository.OutboxEvent) {
+			defer wg.Done()
+			b.dispatch(ctx, event)
+		}(event)
+	}
+	wg.Wait()
+	return len(events)
+}
+
+// dispatch hands an event to every interested subscriber that has not handled
+// it yet. The event is retried until all of them succeed.
+func (b *EventBus) dispatch(ctx context.Context, record *repository.OutboxEvent) {
+	// Results are recorded even during shutdown, so finished work is not repeated
+	recordCtx := context.WithoutCancel(ctx)
+
+	var event DomainEvent
+	if err := json.Unmarshal(record.Payload, &event); err != nil {
+		b.recordFailure(recordCtx, record, fmt.Errorf("decode payload: %w", err), true)
+		return
+	}
+
+	handled, err := b.repos.Outbox.HandledBy(ctx, record.ID)
+	if err != nil {
+		b.logger.Error("failed to load outbox event handlers", slog.String("event_id", record.ID.String()), slog.Any("error", err))
+		return
+	}
+
+	b.mu.RLock()
+	subscribers := slices.Clone(b.subscribers)
+	b.mu.RUnlock()
+
+	var failures []error
+	for _, subscriber := range subscribers {
+		if !subscriber.wants(event.Type) || slices.Contains(handled, subscriber.name) {
+			continue
+		}
+		if err := subscriber.handler(ctx, event); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", subscriber.name, err))
+			continue
+		}
+		if err := b.repos.Outbox.MarkHandled(recordCtx, record.ID, subscriber.name); err != nil {
+			failures = append(failures, fmt.Errorf("%s: record handled: %w", subscriber.name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		b.recordFailure(recordCtx, record, errors.Join(failures...), false)
+		return
+	}
+	if err := b.repos.Outbox.MarkDispatched(recordCtx, record.ID); err != nil {
+		b.logger.Error("failed to mark outbox event dispatched", slog.String("event_id", record.ID.String()), slog.Any("error", err))
+	}
+}
+
+func (b *EventBus) recordFailure(ctx context.Context, record *repository.OutboxEvent, cause error, permanent bool) {
+	attempts := record.Attempts + 1
+	status := repository.OutboxPending
+	nextAttemptAt := time.Now().Add(eventRetryDelay(attempts))
+	if permanent || attempts >= maxEventAttempts {
+		status = repository.OutboxFailed
+		nextAttemptAt = time.Now()
+	}
+
+	b.logger.Warn("failed to dispatch domain event",
+		slog.String("event_id", record.ID.String()),
+		slog.String("type", record.Type),
+		slog.Int("attempts", attempts),
+		slog.Bool("given_up", status == repository.OutboxFailed),
+		slog.Any("error", cause),
+	)
+	if err := b.repos.Outbox.RecordFailure(ctx, record.ID, status, nextAttemptAt, cause.Error()); err != nil {
+		b.logger.Error("failed to record outbox event failure", slog.String("event_id", record.ID.String()), slog.Any("error", err))
+	}
+}
+
+// eventRetryDelay is the wait after the given number of failed dispatches
+func eventRetryDelay(attempts int) time.Duration {
+	delay := eventBaseRetryDelay
+	for i := 1; i < attempts && delay < maxEventRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxEventRetryDelay)
+}
+
+// cleanup removes old dispatched and failed events
+func (b *EventBus) cleanup(ctx context.Context) {
+	deleted, err := b.repos.Outbox.DeleteFinishedBefore(ctx, time.Now().Add(-eventRetention))
+	if err != nil {
+		b.logger.Error("failed to prune outbox", slog.Any("error", err))
+	} else if deleted > 0 {
+		b.logger.Info("pruned outbox", slog.Int64("deleted", deleted))
+	}
+}
+
+// LogEvents returns a subscriber that writes every event to the log
+func LogEvents(logger *slog.Logger) EventHandler {
+	return func(_ context.Context, event DomainEvent) error {
+		attrs := []any{
+			slog.String("event_id", event.ID.String()),
+			slog.String("type", event.Type),
+			slog.String("user_id", event.UserID.String()),
+		}
+		if event.BucketID != uuid.Nil {
+			attrs = append(attrs, slog.String("bucket_id", event.BucketID.String()))
+		}
+		if event.CredentialID != uuid.Nil {
+			attrs = append(attrs, slog.String("credential_id", event.CredentialID.String()))
+		}
+		if len(event.Keys) > 0 {
+			attrs = append(attrs, slog.Int("objects", len(event.Keys)), slog.String("first_key", event.Keys[0]))
+		}
+		logger.Info("domain event", attrs...)
+		return nil
+	}
+}
+
+// objectEvent builds an event about objects in a bucket
+func objectEvent(eventType string, bucketID, userID uuid.UUID, bucketName string, keys ...string) DomainEvent {
+	return DomainEvent{
+		Type:       eventType,
+		UserID:     userID,
+		BucketID:   bucketID,
+		BucketName: bucketName,
+		Keys:       keys,
+	}
+}
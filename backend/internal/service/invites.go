@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/google/uuid"
+)
+
+// RegistrationMode controls who may create an account through /auth/register
+type RegistrationMode string
+
+const (
+	// RegistrationOpen lets anyone register
+	RegistrationOpen RegistrationMode = "open"
+	// RegistrationClosed disables self-service registration; admins create users with the CLI
+	RegistrationClosed RegistrationMode = "closed"
+	// RegistrationInvite requires a valid invite code
+	RegistrationInvite RegistrationMode = "invite"
+)
+
+const (
+	// InviteCodePrefix makes invite codes recognizable
+	InviteCodePrefix = "bbinv_"
+
+	// Number of characters of the code kept in clear text so invites can be told apart
+	inviteCodeDisplayLength = len(InviteCodePrefix) + 6
+)
+
+type InviteService struct {
+	invites repository.InviteRepository
+	teams   *TeamService
+	logger  *slog.Logger
+}
+
+func NewInviteService(invites repository.InviteRepository, teams *TeamService, logger *slog.Logger) *InviteService {
+	return &InviteService{
+		invites: invites,
+		teams:   teams,
+		logger:  logger,
+	}
+}
+
+type CreateInviteInput struct {
+	// Email optionally binds the invite to one address
+	Email string
+	// TeamID optionally adds the new user to a team
+	TeamID *uuid.UUID
+	// MaxUses of 0 allows unlimited uses
+	MaxUses   int
+	ExpiresAt *time.Time
+}
+
+// CreatedInvite holds a newly created invite together with its code.
+// The code is only available at creation time; afterwards only its hash is stored.
+type CreatedInvite struct {
+	Invite *repository.Invite
+	Code   string
+}
+
+// Create issues an invite. Admins may invite into any team or none;
+// team owners may only invite into teams they own.
+func (s *InviteService) Create(ctx context.Context, actor *repository.User, input CreateInviteInput) (*CreatedInvite, error) {
+	if !actor.IsAdmin {
+		if input.TeamID == nil {
+			return nil, ErrInviteForbidden
+		}
+		owner, err := s.teams.IsOwner(ctx, *input.TeamID, actor.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !owner {
+			return nil, ErrInviteForbidden
+		}
+	} else if input.TeamID != nil {
+		if _, err := s.teams.teams.Get(ctx, *input.TeamID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrTeamNotFound
+			}
+			return nil, err
+		}
+	}
+
+	if input.MaxUses < 0 {
+		return nil, ErrInvalidInviteUses
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidInviteExpiry
+	}
+
+	var email *string
+	if normalized := strings.TrimSpace(strings.ToLower(input.Email)); normalized != "" {
+		email = &normalized
+	}
+
+	random, err := crypto.GenerateRandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	code := InviteCodePrefix + random
+
+	invite, err := s.invites.Create(ctx, &repository.Invite{
+		CodeHash:   crypto.HashInviteCode(code),
+		CodePrefix: code[:inviteCodeDisplayLength],
+		CreatedBy:  actor.ID,
+		Email:      email,
+		TeamID:     input.TeamID,
+		MaxUses:    input.MaxUses,
+		ExpiresAt:  input.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("invite created",
+		slog.String("invite_id", invite.ID.String()),
+		slog.String("created_by", actor.ID.String()),
+		slog.Int("max_uses", invite.MaxUses),
+	)
+
+	return &CreatedInvite{
+		Invite: invite,
+		Code:   code,
+	}, nil
+}
+
+// List returns all invites for admins and the actor's own invites otherwise
+func (s *InviteService) List(ctx context.Context, actor *repository.User) ([]*repository.Invite, error) {
+	if actor.IsAdmin {
+		return s.invites.List(ctx)
+	}
+	return s.invites.ListByCreator(ctx, actor.ID)
+}
+
+// Revoke deletes an invite; admins can revoke any invite, others only their own
+func (s *InviteService) Revoke(ctx context.Context, actor *repository.User, id uuid.UUID) error {
+	invite, err := s.invites.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInviteNotFound
+		}
+		return err
+	}
+	if !actor.IsAdmin && invite.CreatedBy != actor.ID {
+		return ErrInviteNotFound
+	}
+
+	if err := s.invites.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("invite revoked",
+		slog.String("invite_id", id.String()),
+		slog.String("revoked_by", actor.ID.String()),
+	)
+	return nil
+}
+
+// InviteUsable reports whether an invite has uses left and has not expired
+func InviteUsable(invite *repository.Invite) bool {
+	if invite.ExpiresAt != nil && !time.Now().Before(*invite.ExpiresAt) {
+		return false
+	}
+	return invite.MaxUses == 0 || invite.UseCount < invite.MaxUses
+}
+
+// Redeem checks an invite code for the given email address and counts one use.
+// Call Release if the registration fails afterwards.
+func (s *InviteService) Redeem(ctx context.Context, code, email string) (*repository.Invite, error) {
+	invite, err := s.invites.GetByHash(ctx, crypto.HashInviteCode(strings.TrimSpace(code)))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+	if !InviteUsable(invite) {
+		return nil, ErrInvalidInvite
+	}
+	if invite.Email != nil && *invite.Email != email {
+		return nil, ErrInviteEmailMismatch
+	}
+
+	redeemed, err := s.invites.Redeem(ctx, invite.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !redeemed {
+		return nil, ErrInvalidInvite
+	}
+	return invite, nil
+}
+
+// Release returns a use taken by Redeem
+func (s *InviteService) Release(ctx context.Context, invite *repository.Invite) {
+	if err := s.invites.Release(ctx, invite.ID); err != nil {
+		s.logger.Warn("failed to release invite", slog.String("invite_id", invite.ID.String()), slog.Any("error", err))
+	}
+}
+
+// Accept applies the invite's team membership to a newly registered user
+func (s *InviteService) Accept(ctx context.Context, invite *repository.Invite, userID uuid.UUID) error {
+	if invite.TeamID != nil {
+		if err := s.teams.AddMember(ctx, *invite.TeamID, userID, TeamRoleMember); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("invite accepted",
+		slog.String("invite_id", invite.ID.String()),
+		slog.String("user_id", userID.String()),
+	)
+	return nil
+}
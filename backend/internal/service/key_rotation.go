@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/pkg/crypto"
+)
+
+// Kinds of encrypted values handled by key rotation
+const (
+	EncryptedCredentials = "credentials"
+	EncryptedTOTPSecrets = "totp_secrets"
+	// EncryptedWebhookSecrets are the signing secrets of outbound webhooks
+	EncryptedWebhookSecrets = "webhook_secrets"
+)
+
+// LegacyKeyID labels keyring ciphertexts written before key IDs were introduced
+const LegacyKeyID = "(legacy)"
+
+// KeyRotationService re-encrypts stored secrets so they are protected by the
+// current key provider and master key
+type KeyRotationService struct {
+	repos    *repository.Repositories
+	envelope *crypto.Envelope
+	logger   *slog.Logger
+}
+
+func NewKeyRotationService(repos *repository.Repositories, envelope *crypto.Envelope, logger *slog.Logger) *KeyRotationService {
+	return &KeyRotationService{
+		repos:    repos,
+		envelope: envelope,
+		logger:   logger,
+	}
+}
+
+// KeyRotationProgress is reported after each processed record
+type KeyRotationProgress struct {
+	Kind  string
+	Done  int
+	Total int
+}
+
+// KeyRotationCount summarizes one kind of encrypted value
+type KeyRotationCount struct {
+	Total   int
+	Rotated int
+}
+
+type KeyRotationResult struct {
+	Provider string
+	Counts   map[string]*KeyRotationCount
+}
+
+// Rotate moves every credential, TOTP secret and webhook secret to the current key provider.
+// Values encrypted directly with the keyring get a new data key; values that
+// already have one keep their ciphertext and only have the data key rewrapped,
+// which is written back when the wrapping key changed. All changes happen in
+// one transaction, so a value that cannot be decrypted aborts the rotation
+// without changing anything. With dryRun, nothing is written.
+func (s *KeyRotationService) Rotate(ctx context.Context, dryRun bool, progress func(KeyRotationProgress)) (*KeyRotationResult, error) {
+	if progress == nil {
+		progress = func(KeyRotationProgress) {}
+	}
+
+	result := &KeyRotationResult{
+		Provider: s.envelope.ProviderName(),
+		Counts: map[string]*KeyRotationCount{
+			EncryptedCredentials:    {},
+			EncryptedTOTPSecrets:    {},
+			EncryptedWebhookSecrets: {},
+		},
+	}
+
+	err := s.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := s.rotateCredentials(ctx, tx, dryRun, result.Counts[EncryptedCredentials], progress); err != nil {
+			return err
+		}
+		if err := s.rotateTOTPSecrets(ctx, tx, dryRun, result.Counts[EncryptedTOTPSecrets], progress); err != nil {
+			return err
+		}
+		return s.rotateWebhookSecrets(ctx, tx, dryRun, result.Counts[EncryptedWebhookSecrets], progress)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("encryption key rotation finished",
+		slog.String("key_provider", result.Provider),
+		slog.Bool("dry_run", dryRun),
+		slog.Int("credentials_rotated", result.Counts[EncryptedCredentials].Rotated),
+		slog.Int("totp_secrets_rotated", result.Counts[EncryptedTOTPSecrets].Rotated),
+		slog.Int("webhook_secrets_rotated", result.Counts[EncryptedWebhookSecrets].Rotated),
+	)
+	return result, nil
+}
+
+func (s *KeyRotationService) rotateCredentials(ctx context.Context, tx *repository.Repositories, dryRun bool, count *KeyRotationCount, progress func(KeyRotationProgress)) error {
+	creds, err := tx.Credentials.ListAllForUpdate(ctx)
+	if err != nil {
+		return err
+	}
+	count.Total = len(creds)
+
+	for i, cred := range creds {
+		accessKey, secretKey, dataKey, changed, err := s.rotateCredential(ctx, cred)
+		if err != nil {
+			return fmt.Errorf("credential %s: %w", cred.ID, err)
+		}
+		if changed {
+			if !dryRun {
+				if err := tx.Credentials.UpdateSecrets(ctx, cred.ID, accessKey, secretKey, dataKey); err != nil {
+					return err
+				}
+			}
+			count.Rotated++
+		}
+		progress(KeyRotationProgress{Kind: EncryptedCredentials, Done: i + 1, Total: len(creds)})
+	}
+	return nil
+}
+
+func (s *KeyRotationService) rotateCredential(ctx context.Context, cred *repository.Credential) (accessKey, secretKey string, dataKey *string, changed bool, err error) {
+	if cred.EncryptedDataKey == nil {
+		accessKey, secretKey, err = decryptCredentialKeys(ctx, cred, s.envelope)
+		if err != nil {
+			return "", "", nil, false, err
+		}
+		accessKey, secretKey, dataKey, err = encryptCredentialKeys(ctx, s.envelope, accessKey, secretKey)
+		return accessKey, secretKey, dataKey, err == nil, err
+	}
+
+	rewrapped, err := s.envelope.RewrapDataKey(ctx, *cred.EncryptedDataKey)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+	changed = s.envelope.WrappingKeyID(rewrapped) != s.envelope.WrappingKeyID(*cred.EncryptedDataKey)
+	return cred.EncryptedAccessKey, cred.EncryptedSecretKey, &rewrapped, changed, nil
+}
+
+func (s *KeyRotationService) rotateTOTPSecrets(ctx context.Context, tx *repository.Repositories, dryRun bool, count *KeyRotationCount, progress func(KeyRotationProgress)) error {
+	users, err := tx.Users.ListWithTOTPSecretForUpdate(ctx)
+	if err != nil {
+		return err
+	}
+	count.Total = len(users)
+
+	for i, user := range users {
+		secret, changed, err := s.rotateSealed(ctx, *user.TOTPSecret)
+		if err != nil {
+			return fmt.Errorf("totp secret of user %s: %w", user.ID, err)
+		}
+		if changed {
+			if !dryRun {
+				if err := tx.Users.UpdateTOTPSecretCiphertext(ctx, user.ID, secret); err != nil {
+					return err
+				}
+			}
+			count.Rotated++
+		}
+		progress(KeyRotationProgress{Kind: EncryptedTOTPSecrets, Done: i + 1, Total: len(users)})
+	}
+	return nil
+}
+
+func (s *KeyRotationService) rotateWebhookSecrets(ctx context.Context, tx *repository.Repositories, dryRun bool, count *KeyRotationCount, progress func(KeyRotationProgress)) error {
+	webhooks, err := tx.Webhooks.ListAllForUpdate(ctx)
+	if err != nil {
+		return err
+	}
+	count.Total = len(webhooks)
+
+	for i, webhook := range webhooks {
+		secret, changed, err := s.rotateSealed(ctx, webhook.Secret)
+		if err != nil {
+			return fmt.Errorf("secret of webhook %s: %w", webhook.ID, err)
+		}
+		if changed {
+			if !dryRun {
+				if err := tx.Webhooks.UpdateSecret(ctx, webhook.ID, secret); err != nil {
+					return err
+				}
+			}
+			count.Rotated++
+		}
+		progress(KeyRotationProgress{Kind: EncryptedWebhookSecrets, Done: i + 1, Total: len(webhooks)})
+	}
+	return nil
+}
+
+func (s *KeyRotationService) rotateSealed(ctx context.Context, value string) (string, bool, error) {
+	wrapped, _, ok := crypto.SplitSealed(value)
+	if !ok {
+		plaintext, err := s.envelope.DecryptLegacy(value)
+		if err != nil {
+			return "", false, err
+		}
+		sealed, err := s.envelope.Seal(ctx, plaintext)
+		return sealed, err == nil, err
+	}
+
+	resealed, err := s.envelope.RewrapSealed(ctx, value)
+	if err != nil {
+		return "", false, err
+	}
+	rewrapped, _, _ := crypto.SplitSealed(resealed)
+	return resealed, s.envelope.WrappingKeyID(rewrapped) != s.envelope.WrappingKeyID(wrapped), nil
+}
+
+// Status counts stored values per wrapping key, so operators can tell when an
+// old key is no longer used. Values encrypted directly with the keyring are
+// labelled "keyring:<id>".
+func (s *KeyRotationService) Status(ctx context.Context) (map[string]map[string]int, error) {
+	status := map[string]map[string]int{
+		EncryptedCredentials:    {},
+		EncryptedTOTPSecrets:    {},
+		EncryptedWebhookSecrets: {},
+	}
+
+	creds, err := s.repos.Credentials.ListAllForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, cred := range creds {
+		if cred.EncryptedDataKey == nil {
+			status[EncryptedCredentials][keyringKeyID(cred.EncryptedSecretKey)]++
+			continue
+		}
+		status[EncryptedCredentials][s.wrappingKeyID(*cred.EncryptedDataKey)]++
+	}
+
+	users, err := s.repos.Users.ListWithTOTPSecretForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		wrapped, _, ok := crypto.SplitSealed(*user.TOTPSecret)
+		if !ok {
+			status[EncryptedTOTPSecrets][keyringKeyID(*user.TOTPSecret)]++
+			continue
+		}
+		status[EncryptedTOTPSecrets][s.wrappingKeyID(wrapped)]++
+	}
+
+	webhooks, err := s.repos.Webhooks.ListAllForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		wrapped, _, ok := crypto.SplitSealed(webhook.Secret)
+		if !ok {
+			status[EncryptedWebhookSecrets][keyringKeyID(webhook.Secret)]++
+			continue
+		}
+		status[EncryptedWebhookSecrets][s.wrappingKeyID(wrapped)]++
+	}
+
+	return status, nil
+}
+
+func (s *KeyRotationService) wrappingKeyID(wrapped string) string {
+	if id := s.envelope.WrappingKeyID(wrapped); id != "" {
+		return id
+	}
+	return "(unknown)"
+}
+
+func keyringKeyID(ciphertext string) string {
+	if id, _, ok := crypto.SplitKeyID(ciphertext); ok {
+		return "keyring:" + id
+	}
+	return "keyring:" + LegacyKeyID
+}
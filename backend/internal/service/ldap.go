@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	// IdentityProviderLDAP is the provider name stored for identities linked through LDAP
+	IdentityProviderLDAP = "ldap"
+
+	ldapTimeout = 10 * time.Second
+)
+
+// errLDAPUserNotFound is returned when the directory has no entry for the username
+var errLDAPUserNotFound = errors.New("ldap user not found")
+
+// LDAPConfig configures authentication against an LDAP or Active Directory server
+type LDAPConfig struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+
+	// UserDNTemplate binds directly as the user, e.g. "uid=%s,ou=people,dc=example,dc=com".
+	// When empty, the user is looked up with UserFilter below BaseDN (search-then-bind).
+	UserDNTemplate string
+
+	// Service account used for searching; anonymous when empty
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter is the search filter; {username} is replaced with the escaped login name
+	UserFilter string
+
+	EmailAttribute     string
+	FirstNameAttribute string
+	LastNameAttribute  string
+	GroupAttribute     string
+
+	// Optional group search for servers without a memberOf overlay; {dn} is replaced with the user DN
+	GroupBaseDN string
+	GroupFilter string
+
+	// AdminGroups lists group DNs or common names granting admin. When empty, the admin flag is left untouched.
+	AdminGroups []string
+
+	// LinkByEmail links a directory entry logging in for the first time to an
+	// existing account with the same email. Demo accounts and accounts with a
+	// local password are never linked.
+	LinkByEmail bool
+}
+
+// LDAPAuthenticator verifies passwords against a directory and provisions matching users
+type LDAPAuthenticator struct {
+	cfg        LDAPConfig
+	users      repository.UserRepository
+	identities repository.UserIdentityRepository
+	logger     *slog.Logger
+}
+
+func NewLDAPAuthenticator(
+	cfg LDAPConfig,
+	users repository.UserRepository,
+	identities repository.UserIdentityRepository,
+	logger *slog.Logger,
+) *LDAPAuthenticator {
+	return &LDAPAuthenticator{
+		cfg:        cfg,
+		users:      users,
+		identities: identities,
+		logger:     logger,
+	}
+}
+
+// ldapEntry holds the attributes read from the directory for a user
+type ldapEntry struct {
+	DN        string
+	Email     string
+	FirstName string
+	LastName  string
+	Groups    []string
+}
+
+// Authenticate binds as the user and returns the synced BucketBird user.
+// It returns ErrInvalidCredentials when the directory rejects the username or password.
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, username, password string) (*repository.User, error) {
+	username = strings.TrimSpace(username)
+	// An empty password would result in an unauthenticated bind, which always succeeds
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	entry, err := a.lookup(username, password)
+	if err != nil {
+		if errors.Is(err, errLDAPUserNotFound) || ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	return a.syncUser(ctx, entry)
+}
+
+func (a *LDAPAuthenticator) connect() (*ldap.Conn, error) {
+	// InsecureSkipVerify is an explicit opt-in for directories with self-signed certificates
+	tlsConfig := &tls.Config{InsecureSkipVerify: a.cfg.InsecureSkipVerify}
+
+	conn, err := ldap.DialURL(a.cfg.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("connect to ldap server: %w", err)
+	}
+	conn.SetTimeout(ldapTimeout)
+
+	if a.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (a *LDAPAuthenticator) lookup(username, password string) (*ldapEntry, error) {
+	conn, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var entry *ldap.Entry
+	if a.cfg.UserDNTemplate != "" {
+		// Direct bind: the DN is derived from the username
+		dn := fmt.Sprintf(a.cfg.UserDNTemplate, ldap.EscapeDN(username))
+		if err := conn.Bind(dn, password); err != nil {
+			return nil, err
+		}
+		entry, err = a.searchOne(conn, dn, ldap.ScopeBaseObject, "(objectClass=*)")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Search-then-bind: find the entry with the service account, then verify the password
+		if a.cfg.BindDN != "" {
+			if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+				return nil, fmt.Errorf("ldap service account bind: %w", err)
+			}
+		}
+		filter := strings.ReplaceAll(a.cfg.UserFilter, "{username}", ldap.EscapeFilter(username))
+		entry, err = a.searchOne(conn, a.cfg.BaseDN, ldap.ScopeWholeSubtree, filter)
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.Bind(entry.DN, password); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ldapEntry{
+		DN:        entry.DN,
+		Email:     strings.TrimSpace(strings.ToLower(entry.GetAttributeValue(a.cfg.EmailAttribute))),
+		FirstName: strings.TrimSpace(entry.GetAttributeValue(a.cfg.FirstNameAttribute)),
+		LastName:  strings.TrimSpace(entry.GetAttributeValue(a.cfg.LastNameAttribute)),
+	}
+	if a.cfg.GroupAttribute != "" {
+		result.Groups = append(result.Groups, entry.GetAttributeValues(a.cfg.GroupAttribute)...)
+	}
+
+	if a.cfg.GroupBaseDN != "" {
+		filter := strings.ReplaceAll(a.cfg.GroupFilter, "{dn}", ldap.EscapeFilter(entry.DN))
+		res, err := conn.Search(ldap.NewSearchRequest(
+			a.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(ldapTimeout.Seconds()), false,
+			filter, []string{"cn"}, nil,
+		))
+		if err != nil {
+			return nil, fmt.Errorf("ldap group search: %w", err)
+		}
+		for _, group := range res.Entries {
+			result.Groups = append(result.Groups, group.DN)
+		}
+	}
+
+	return result, nil
+}
+
+func (a *LDAPAuthenticator) searchOne(conn *ldap.Conn, baseDN string, scope int, filter string) (*ldap.Entry, error) {
+	attributes := []string{a.cfg.EmailAttribute, a.cfg.FirstNameAttribute, a.cfg.LastNameAttribute}
+	if a.cfg.GroupAttribute != "" {
+		attributes = append(attributes, a.cfg.GroupAttribute)
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		baseDN, scope, ldap.NeverDerefAliases, 2, int(ldapTimeout.Seconds()), false,
+		filter, attributes, nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, errLDAPUserNotFound
+		}
+		return nil, fmt.Errorf("ldap user search: %w", err)
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, errLDAPUserNotFound
+	case 1:
+		return res.Entries[0], nil
+	default:
+		return nil, fmt.Errorf("ldap user search returned multiple entries for filter %q", filter)
+	}
+}
+
+// syncUser finds or creates the user for the directory entry and copies its attributes
+func (a *LDAPAuthenticator) syncUser(ctx context.Context, entry *ldapEntry) (*repository.User, error) {
+	subject := strings.ToLower(entry.DN)
+
+	var user *repository.User
+	identity, err := a.identities.Get(ctx, IdentityProviderLDAP, subject)
+	switch {
+	case err == nil:
+		user, err = a.users.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Email != "" && entry.Email != identity.Email {
+			if err := a.identities.UpdateEmail(ctx, identity.ID, entry.Email); err != nil {
+				a.logger.Warn("failed to update identity email", slog.Any("error", err))
+			}
+		}
+	case errors.Is(err, repository.ErrNotFound):
+		if entry.Email == "" {
+			return nil, fmt.Errorf("ldap entry %s has no %s attribute", entry.DN, a.cfg.EmailAttribute)
+		}
+
+		user, err = a.users.GetByEmail(ctx, entry.Email)
+		switch {
+		case err == nil:
+			// Anyone able to set mail on a directory entry could otherwise take
+			// over the account, including a break-glass local admin
+			if !a.cfg.LinkByEmail || user.IsDemo || user.PasswordHash != "" {
+				return nil, ErrSSOAccountConflict
+			}
+		case errors.Is(err, repository.ErrNotFound):
+			// Directory users have no local password
+			user, err = a.users.Create(ctx, entry.Email, "", entry.FirstName, entry.LastName)
+			if err == nil {
+				a.logger.Info("user created from ldap login", slog.String("user_id", user.ID.String()))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := a.identities.Create(ctx, user.ID, IdentityProviderLDAP, subject, entry.Email); err != nil {
+			return nil, err
+		}
+		a.logger.Info("ldap identity linked", slog.String("user_id", user.ID.String()))
+	default:
+		return nil, err
+	}
+
+	// Keep profile fields in sync with the directory
+	email, firstName, lastName := user.Email, user.FirstName, user.LastName
+	if entry.Email != "" {
+		email = entry.Email
+	}
+	if entry.FirstName != "" {
+		firstName = entry.FirstName
+	}
+	if entry.LastName != "" {
+		lastName = entry.LastName
+	}
+	if email != user.Email || firstName != user.FirstName || lastName != user.LastName {
+		if err := a.users.Update(ctx, user.ID, email, firstName, lastName); err != nil {
+			a.logger.Warn("failed to sync ldap attributes", slog.Any("error", err), slog.String("user_id", user.ID.String()))
+		} else {
+			user.Email, user.FirstName, user.LastName = email, firstName, lastName
+		}
+	}
+
+	if len(a.cfg.AdminGroups) > 0 {
+		isAdmin := ldapGroupsGrantAdmin(entry.Groups, a.cfg.AdminGroups)
+		if isAdmin != user.IsAdmin {
+			if err := a.users.SetAdmin(ctx, user.ID, isAdmin); err != nil {
+				return nil, err
+			}
+			user.IsAdmin = isAdmin
+			a.logger.Info("admin flag updated from ldap groups",
+				slog.String("user_id", user.ID.String()),
+				slog.Bool("is_admin", isAdmin),
+			)
+		}
+	}
+
+	return user, nil
+}
+
+// ldapGroupsGrantAdmin matches group DNs against admin groups given as DNs or common names
+func ldapGroupsGrantAdmin(groups, adminGroups []string) bool {
+	for _, group := range groups {
+		cn := ""
+		if dn, err := ldap.ParseDN(group); err == nil && len(dn.RDNs) > 0 && len(dn.RDNs[0].Attributes) > 0 {
+			cn = dn.RDNs[0].Attributes[0].Value
+		}
+		for _, admin := range adminGroups {
+			if strings.EqualFold(group, admin) || (cn != "" && strings.EqualFold(cn, admin)) {
+				return true
+			}
+		}
+	}
+	return false
+}
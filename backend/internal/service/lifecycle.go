@@ -0,0 +1,522 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bucketbird/backend/internal/storage"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+)
+
+const (
+	// S3 accepts up to 1000 rules per bucket
+	maxLifecycleRules    = 1000
+	maxLifecycleRuleID   = 255
+	maxLifecycleNewer    = 100
+	maxLifecycleTagKey   = 128
+	maxLifecycleTagValue = 256
+	lifecycleDateLayout  = "2006-01-02"
+)
+
+// LifecycleConfiguration is a bucket's lifecycle rules in a simpler shape
+// than the S3 XML schema
+type LifecycleConfiguration struct {
+	Rules []LifecycleRule `json:"rules"`
+}
+
+// LifecycleRule applies its actions to the objects matching its filter. At
+// least one action is required.
+type LifecycleRule struct {
+	ID      string          `json:"id,omitempty"`
+	Enabled bool            `json:"enabled"`
+	Filter  LifecycleFilter `json:"filter"`
+
+	Expiration                   *LifecycleExpiration          `json:"expiration,omitempty"`
+	Transitions                  []LifecycleTransition         `json:"transitions,omitempty"`
+	NoncurrentVersionExpiration  *NoncurrentVersionExpiration  `json:"noncurrentVersionExpiration,omitempty"`
+	NoncurrentVersionTransitions []NoncurrentVersionTransition `json:"noncurrentVersionTransitions,omitempty"`
+	// AbortIncompleteMultipartUploadDays removes the parts of uploads not
+	// completed within this many days
+	AbortIncompleteMultipartUploadDays *int32 `json:"abortIncompleteMultipartUploadDays,omitempty"`
+}
+
+// LifecycleFilter selects objects by key prefix, tags and size; an empty
+// filter matches the whole bucket. Objects must match every condition.
+type LifecycleFilter struct {
+	Prefix                string            `json:"prefix,omitempty"`
+	Tags                  map[string]string `json:"tags,omitempty"`
+	ObjectSizeGreaterThan *int64            `json:"objectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64            `json:"objectSizeLessThan,omitempty"`
+}
+
+// LifecycleExpiration deletes current object versions after a number of days
+// or on a date (YYYY-MM-DD, UTC). On versioned buckets, ExpiredObjectDeleteMarker
+// instead removes delete markers without noncurrent versions.
+type LifecycleExpiration struct {
+	Days                      *int32  `json:"days,omitempty"`
+	Date                      *string `json:"date,omitempty"`
+	ExpiredObjectDeleteMarker bool    `json:"expiredObjectDeleteMarker,omitempty"`
+}
+
+// LifecycleTransition moves current object versions to another storage class
+// after a number of days or on a date (YYYY-MM-DD, UTC)
+type LifecycleTransition struct {
+	Days         *int32  `json:"days,omitempty"`
+	Date         *string `json:"date,omitempty"`
+	StorageClass string  `json:"storageClass"`
+}
+
+// NoncurrentVersionExpiration deletes object versions a number of days after
+// they became noncurrent, keeping the newest NewerNoncurrentVersions of them
+type NoncurrentVersionExpiration struct {
+	NoncurrentDays          int32  `json:"noncurrentDays"`
+	NewerNoncurrentVersions *int32 `json:"newerNoncurrentVersions,omitempty"`
+}
+
+type NoncurrentVersionTransition struct {
+	NoncurrentDays          int32  `json:"noncurrentDays"`
+	NewerNoncurrentVersions *int32 `json:"newerNoncurrentVersions,omitempty"`
+	StorageClass            string `json:"storageClass"`
+}
+
+// GetLifecycle returns the bucket's lifecycle rules; a bucket without a
+// configuration has none
+func (s *BucketService) GetLifecycle(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (*LifecycleConfiguration, error) {
+	bucketName, err := s.getBucketName(ctx, bucketID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := store.GetBucketLifecycle(ctx, bucketName)
+	if err != nil {
+		return nil, lifecycleError(err)
+	}
+
+	return fromS3LifecycleRules(rules), nil
+}
+
+// PutLifecycle validates the rules and replaces the bucket's lifecycle
+// configuration with them
+func (s *BucketService) PutLifecycle(ctx context.Context, bucketID, userID uuid.UUID, config LifecycleConfiguration, envelope *crypto.Envelope) (_ *LifecycleConfiguration, err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditBucketLifecycleSet,
+			BucketID:   bucketID,
+			BucketName: bucketName,
+			Details:    map[string]string{"rules": strconv.Itoa(len(config.Rules))},
+		}, err)
+	}()
+
+	rules, err := toS3LifecycleRules(config.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketName, err = s.getBucketName(ctx, bucketID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.PutBucketLifecycle(ctx, bucketName, rules); err != nil {
+		return nil, lifecycleError(err)
+	}
+
+	// Answer with the rules as stored, e.g. with storage classes in upper case
+	return fromS3LifecycleRules(rules), nil
+}
+
+// DeleteLifecycle removes all of the bucket's lifecycle rules
+func (s *BucketService) DeleteLifecycle(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditBucketLifecycleDelete, BucketID: bucketID, BucketName: bucketName}, err)
+	}()
+
+	bucketName, err = s.getBucketName(ctx, bucketID, userID)
+	if err != nil {
+		return err
+	}
+
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return err
+	}
+
+	if err := store.DeleteBucketLifecycle(ctx, bucketName); err != nil {
+		return lifecycleError(err)
+	}
+	return nil
+}
+
+// lifecycleError reports configurations the provider rejected or does not
+// support as such, so they are not mistaken for internal failures
+func lifecycleError(err error) error {
+	code, message, ok := storage.APIError(err)
+	if !ok {
+		return err
+	}
+	switch code {
+	case "NotImplemented":
+		return ErrLifecycleUnsupported
+	case "InvalidArgument", "InvalidRequest", "MalformedXML", "InvalidStorageClass":
+		return fmt.Errorf("%w: rejected by the provider: %s", ErrInvalidLifecycle, message)
+	}
+	return err
+}
+
+func invalidLifecycle(rule int, format string, args ...any) error {
+	return fmt.Errorf("%w: rule %d: %s", ErrInvalidLifecycle, rule+1, fmt.Sprintf(format, args...))
+}
+
+func lifecycleStorageClass(rule int, class string) (types.TransitionStorageClass, error) {
+	name := strings.ToUpper(strings.TrimSpace(class))
+	for _, storageClass := range types.TransitionStorageClass("").Values() {
+		if string(storageClass) == name {
+			return storageClass, nil
+		}
+	}
+	return "", invalidLifecycle(rule, "unknown storage class %q", class)
+}
+
+// lifecycleDate parses a YYYY-MM-DD date; S3 requires midnight UTC
+func lifecycleDate(rule int, value string) (*time.Time, error) {
+	date, err := time.Parse(lifecycleDateLayout, value)
+	if err != nil {
+		return nil, invalidLifecycle(rule, "date %q must be formatted as YYYY-MM-DD", value)
+	}
+	return &date, nil
+}
+
+func formatLifecycleDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+	formatted := date.UTC().Format(lifecycleDateLayout)
+	return &formatted
+}
+
+func toS3LifecycleRules(rules []LifecycleRule) ([]types.LifecycleRule, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%w: at least one rule is required; delete the configuration to remove all rules", ErrInvalidLifecycle)
+	}
+	if len(rules) > maxLifecycleRules {
+		return nil, fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidLifecycle, maxLifecycleRules)
+	}
+
+	ids := map[string]bool{}
+	result := make([]types.LifecycleRule, len(rules))
+	for i, rule := range rules {
+		if len(rule.ID) > maxLifecycleRuleID {
+			return nil, invalidLifecycle(i, "id must be at most %d characters", maxLifecycleRuleID)
+		}
+		if rule.ID != "" {
+			if ids[rule.ID] {
+				return nil, invalidLifecycle(i, "id %q is used by another rule", rule.ID)
+			}
+			ids[rule.ID] = true
+		}
+
+		converted, err := toS3LifecycleRule(i, rule)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = converted
+	}
+	return result, nil
+}
+
+func toS3LifecycleRule(i int, rule LifecycleRule) (types.LifecycleRule, error) {
+	result := types.LifecycleRule{Status: types.ExpirationStatusDisabled}
+	if rule.ID != "" {
+		result.ID = aws.String(rule.ID)
+	}
+	if rule.Enabled {
+		result.Status = types.ExpirationStatusEnabled
+	}
+
+	filter, err := toS3LifecycleFilter(i, rule.Filter)
+	if err != nil {
+		return result, err
+	}
+	result.Filter = filter
+	hasTags := len(rule.Filter.Tags) > 0
+
+	if rule.Expiration == nil && len(rule.Transitions) == 0 && rule.NoncurrentVersionExpiration == nil &&
+		len(rule.NoncurrentVersionTransitions) == 0 && rule.AbortIncompleteMultipartUploadDays == nil {
+		return result, invalidLifecycle(i, "at least one action is required")
+	}
+
+	if expiration := rule.Expiration; expiration != nil {
+		set := 0
+		result.Expiration = &types.LifecycleExpiration{}
+		if expiration.Days != nil {
+			set++
+			if *expiration.Days < 1 {
+				return result, invalidLifecycle(i, "expiration days must be positive")
+			}
+			result.Expiration.Days = expiration.Days
+		}
+		if expiration.Date != nil {
+			set++
+			date, err := lifecycleDate(i, *expiration.Date)
+			if err != nil {
+				return result, err
+			}
+			result.Expiration.Date = date
+		}
+		if expiration.ExpiredObjectDeleteMarker {
+			set++
+			if hasTags {
+				return result, invalidLifecycle(i, "expiredObjectDeleteMarker cannot be combined with a tag filter")
+			}
+			result.Expiration.ExpiredObjectDeleteMarker = aws.Bool(true)
+		}
+		if set != 1 {
+			return result, invalidLifecycle(i, "expiration needs exactly one of days, date or expiredObjectDeleteMarker")
+		}
+	}
+
+	classes := map[types.TransitionStorageClass]bool{}
+	var byDays, byDate bool
+	for _, transition := range rule.Transitions {
+		class, err := lifecycleStorageClass(i, transition.StorageClass)
+		if err != nil {
+			return result, err
+		}
+		if classes[class] {
+			return result, invalidLifecycle(i, "more than one transition to %s", class)
+		}
+		classes[class] = true
+
+		converted := types.Transition{StorageClass: class}
+		switch {
+		case transition.Days != nil && transition.Date == nil:
+			if *transition.Days < 0 {
+				return result, invalidLifecycle(i, "transition days must not be negative")
+			}
+			converted.Days = transition.Days
+			byDays = true
+		case transition.Date != nil && transition.Days == nil:
+			date, err := lifecycleDate(i, *transition.Date)
+			if err != nil {
+				return result, err
+			}
+			converted.Date = date
+			byDate = true
+		default:
+			return result, invalidLifecycle(i, "a transition needs exactly one of days or date")
+		}
+		result.Transitions = append(result.Transitions, converted)
+	}
+	if byDays && byDate {
+		return result, invalidLifecycle(i, "transitions must all use days or all use dates")
+	}
+
+	if expiration := rule.NoncurrentVersionExpiration; expiration != nil {
+		if expiration.NoncurrentDays < 1 {
+			return result, invalidLifecycle(i, "noncurrentDays must be positive")
+		}
+		if err := validateNewerVersions(i, expiration.NewerNoncurrentVersions); err != nil {
+			return result, err
+		}
+		result.NoncurrentVersionExpiration = &types.NoncurrentVersionExpiration{
+			NoncurrentDays:          aws.Int32(expiration.NoncurrentDays),
+			NewerNoncurrentVersions: expiration.NewerNoncurrentVersions,
+		}
+	}
+
+	noncurrentClasses := map[types.TransitionStorageClass]bool{}
+	for _, transition := range rule.NoncurrentVersionTransitions {
+		class, err := lifecycleStorageClass(i, transition.StorageClass)
+		if err != nil {
+			return result, err
+		}
+		if noncurrentClasses[class] {
+			return result, invalidLifecycle(i, "more than one noncurrent version transition to %s", class)
+		}
+		noncurrentClasses[class] = true
+		if transition.NoncurrentDays < 0 {
+			return result, invalidLifecycle(i, "noncurrentDays must not be negative")
+		}
+		if err := validateNewerVersions(i, transition.NewerNoncurrentVersions); err != nil {
+			return result, err
+		}
+		result.NoncurrentVersionTransitions = append(result.NoncurrentVersionTransitions, types.NoncurrentVersionTransition{
+			NoncurrentDays:          aws.Int32(transition.NoncurrentDays),
+			NewerNoncurrentVersions: transition.NewerNoncurrentVersions,
+			StorageClass:            class,
+		})
+	}
+
+	if days := rule.AbortIncompleteMultipartUploadDays; days != nil {
+		if *days < 1 {
+			return result, invalidLifecycle(i, "abortIncompleteMultipartUploadDays must be positive")
+		}
+		if hasTags {
+			return result, invalidLifecycle(i, "abortIncompleteMultipartUploadDays cannot be combined with a tag filter")
+		}
+		result.AbortIncompleteMultipartUpload = &types.AbortIncompleteMultipartUpload{DaysAfterInitiation: days}
+	}
+
+	return result, nil
+}
+
+func validateNewerVersions(i int, newer *int32) error {
+	if newer != nil && (*newer < 1 || *newer > maxLifecycleNewer) {
+		return invalidLifecycle(i, "newerNoncurrentVersions must be between 1 and %d", maxLifecycleNewer)
+	}
+	return nil
+}
+
+// toS3LifecycleFilter uses a single predicate where possible, since some
+// S3-compatible providers only support the And operator partially
+func toS3LifecycleFilter(i int, filter LifecycleFilter) (types.LifecycleRuleFilter, error) {
+	tags := make([]types.Tag, 0, len(filter.Tags))
+	for key, value := range filter.Tags {
+		if key == "" || len(key) > maxLifecycleTagKey {
+			return nil, invalidLifecycle(i, "tag keys must be 1 to %d characters", maxLifecycleTagKey)
+		}
+		if len(value) > maxLifecycleTagValue {
+			return nil, invalidLifecycle(i, "tag values must be at most %d characters", maxLifecycleTagValue)
+		}
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	// Map order is random; keep the configuration stable
+	sort.Slice(tags, func(a, b int) bool { return aws.ToString(tags[a].Key) < aws.ToString(tags[b].Key) })
+
+	greater, less := filter.ObjectSizeGreaterThan, filter.ObjectSizeLessThan
+	if (greater != nil && *greater < 0) || (less != nil && *less < 1) {
+		return nil, invalidLifecycle(i, "object size limits must be positive")
+	}
+	if greater != nil && less != nil && *greater >= *less {
+		return nil, invalidLifecycle(i, "objectSizeGreaterThan must be less than objectSizeLessThan")
+	}
+
+	predicates := len(tags)
+	if filter.Prefix != "" {
+		predicates++
+	}
+	if greater != nil {
+		predicates++
+	}
+	if less != nil {
+		predicates++
+	}
+
+	switch {
+	case predicates > 1:
+		and := types.LifecycleRuleAndOperator{Tags: tags, ObjectSizeGreaterThan: greater, ObjectSizeLessThan: less}
+		if filter.Prefix != "" {
+			and.Prefix = aws.String(filter.Prefix)
+		}
+		return &types.LifecycleRuleFilterMemberAnd{Value: and}, nil
+	case len(tags) == 1:
+		return &types.LifecycleRuleFilterMemberTag{Value: tags[0]}, nil
+	case greater != nil:
+		return &types.LifecycleRuleFilterMemberObjectSizeGreaterThan{Value: *greater}, nil
+	case less != nil:
+		return &types.LifecycleRuleFilterMemberObjectSizeLessThan{Value: *less}, nil
+	default:
+		return &types.LifecycleRuleFilterMemberPrefix{Value: filter.Prefix}, nil
+	}
+}
+
+func fromS3LifecycleRules(rules []types.LifecycleRule) *LifecycleConfiguration {
+	config := &LifecycleConfiguration{Rules: make([]LifecycleRule, len(rules))}
+	for i, rule := range rules {
+		config.Rules[i] = fromS3LifecycleRule(rule)
+	}
+	return config
+}
+
+func fromS3LifecycleRule(rule types.LifecycleRule) LifecycleRule {
+	result := LifecycleRule{
+		ID:      aws.ToString(rule.ID),
+		Enabled: rule.Status == types.ExpirationStatusEnabled,
+		Filter:  fromS3LifecycleFilter(rule.Filter),
+	}
+	// Rules created with the deprecated top-level prefix have no filter
+	if rule.Filter == nil && rule.Prefix != nil {
+		result.Filter.Prefix = *rule.Prefix
+	}
+
+	if expiration := rule.Expiration; expiration != nil {
+		result.Expiration = &LifecycleExpiration{
+			Days:                      expiration.Days,
+			Date:                      formatLifecycleDate(expiration.Date),
+			ExpiredObjectDeleteMarker: aws.ToBool(expiration.ExpiredObjectDeleteMarker),
+		}
+	}
+	for _, transition := range rule.Transitions {
+		result.Transitions = append(result.Transitions, LifecycleTransition{
+			Days:         transition.Days,
+			Date:         formatLifecycleDate(transition.Date),
+			StorageClass: string(transition.StorageClass),
+		})
+	}
+	if expiration := rule.NoncurrentVersionExpiration; expiration != nil {
+		result.NoncurrentVersionExpiration = &NoncurrentVersionExpiration{
+			NoncurrentDays:          aws.ToInt32(expiration.NoncurrentDays),
+			NewerNoncurrentVersions: expiration.NewerNoncurrentVersions,
+		}
+	}
+	for _, transition := range rule.NoncurrentVersionTransitions {
+		result.NoncurrentVersionTransitions = append(result.NoncurrentVersionTransitions, NoncurrentVersionTransition{
+			NoncurrentDays:          aws.ToInt32(transition.NoncurrentDays),
+			NewerNoncurrentVersions: transition.NewerNoncurrentVersions,
+			StorageClass:            string(transition.StorageClass),
+		})
+	}
+	if abort := rule.AbortIncompleteMultipartUpload; abort != nil {
+		result.AbortIncompleteMultipartUploadDays = abort.DaysAfterInitiation
+	}
+	return result
+}
+
+func fromS3LifecycleFilter(filter types.LifecycleRuleFilter) LifecycleFilter {
+	var result LifecycleFilter
+	addTag := func(tag types.Tag) {
+		if result.Tags == nil {
+			result.Tags = map[string]string{}
+		}
+		result.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	switch f := filter.(type) {
+	case *types.LifecycleRuleFilterMemberPrefix:
+		result.Prefix = f.Value
+	case *types.LifecycleRuleFilterMemberTag:
+		addTag(f.Value)
+	case *types.LifecycleRuleFilterMemberObjectSizeGreaterThan:
+		result.ObjectSizeGreaterThan = aws.Int64(f.Value)
+	case *types.LifecycleRuleFilterMemberObjectSizeLessThan:
+		result.ObjectSizeLessThan = aws.Int64(f.Value)
+	case *types.LifecycleRuleFilterMemberAnd:
+		result.Prefix = aws.ToString(f.Value.Prefix)
+		result.ObjectSizeGreaterThan = f.Value.ObjectSizeGreaterThan
+		result.ObjectSizeLessThan = f.Value.ObjectSizeLessThan
+		for _, tag := range f.Value.Tags {
+			addTag(tag)
+		}
+	}
+	return result
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+)
+
+// LockoutPolicy controls how failed logins lock an account
+type LockoutPolicy struct {
+	// Threshold is the number of consecutive failures before locking; 0 disables lockout
+	Threshold int
+	// BaseDuration is the first lockout; every further failure doubles it up to MaxDuration
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+	// ResetAfter forgets failures once none occurred for this long
+	ResetAfter time.Duration
+}
+
+// LockoutService temporarily locks accounts after repeated failed logins.
+// Accounts are identified by the normalized login name, whether or not such a user exists.
+type LockoutService struct {
+	attempts repository.LoginAttemptRepository
+	policy   LockoutPolicy
+	logger   *slog.Logger
+}
+
+func NewLockoutService(attempts repository.LoginAttemptRepository, policy LockoutPolicy, logger *slog.Logger) *LockoutService {
+	return &LockoutService{
+		attempts: attempts,
+		policy:   policy,
+		logger:   logger,
+	}
+}
+
+func normalizeLoginIdentifier(identifier string) string {
+	return strings.TrimSpace(strings.ToLower(identifier))
+}
+
+// Check returns an AccountLockedError while the account is locked
+func (s *LockoutService) Check(ctx context.Context, identifier string) error {
+	if s.policy.Threshold <= 0 {
+		return nil
+	}
+
+	attempt, err := s.attempts.Get(ctx, normalizeLoginIdentifier(identifier))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if attempt.LockedUntil != nil && time.Now().Before(*attempt.LockedUntil) {
+		return &AccountLockedError{Until: *attempt.LockedUntil}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login. Once the threshold is reached the account is
+// locked and an AccountLockedError is returned.
+func (s *LockoutService) RecordFailure(ctx context.Context, identifier string) error {
+	if s.policy.Threshold <= 0 {
+		return nil
+	}
+	identifier = normalizeLoginIdentifier(identifier)
+	now := time.Now()
+
+	// The count is incremented by the database, so parallel guesses cannot
+	// overwrite each other's failures and stay under the threshold
+	attempt, err := s.attempts.RecordFailure(ctx, identifier, ClientInfoFromContext(ctx).IPAddress, now, now.Add(-s.policy.ResetAfter))
+	if err != nil {
+		return err
+	}
+	attempt.LockedUntil = nil
+	if attempt.FailedCount >= s.policy.Threshold {
+		until := now.Add(s.lockoutDuration(attempt.FailedCount))
+		if err := s.attempts.Lock(ctx, identifier, until); err != nil {
+			return err
+		}
+		attempt.LockedUntil = &until
+	}
+
+	if attempt.LockedUntil != nil {
+		s.logger.Warn("account locked after failed logins",
+			slog.String("event", "account_locked"),
+			slog.String("identifier", identifier),
+			slog.Int("failed_count", attempt.FailedCount),
+			slog.Time("locked_until", *attempt.LockedUntil),
+			slog.String("ip_address", attempt.LastIPAddress),
+		)
+		return &AccountLockedError{Until: *attempt.LockedUntil}
+	}
+	return nil
+}
+
+// lockoutDuration doubles the base duration for every failure past the threshold
+func (s *LockoutService) lockoutDuration(failedCount int) time.Duration {
+	duration := s.policy.BaseDuration
+	for i := s.policy.Threshold; i < failedCount && duration < s.policy.MaxDuration; i++ {
+		duration *= 2
+	}
+	if duration > s.policy.MaxDuration {
+		duration = s.policy.MaxDuration
+	}
+	return duration
+}
+
+// Reset clears recorded failures after a successful login
+func (s *LockoutService) Reset(ctx context.Context, identifier string) error {
+	if s.policy.Threshold <= 0 {
+		return nil
+	}
+	if _, err := s.attempts.Delete(ctx, normalizeLoginIdentifier(identifier)); err != nil {
+		return err
+	}
+
+	// Opportunistically clean up failures that no longer count
+	return s.attempts.DeleteStale(ctx, time.Now().Add(-s.policy.ResetAfter))
+}
+
+// ListLocked returns all currently locked accounts
+func (s *LockoutService) ListLocked(ctx context.Context) ([]*repository.LoginAttempt, error) {
+	return s.attempts.ListLocked(ctx)
+}
+
+// Unlock lifts a lockout and clears the failure count
+func (s *LockoutService) Unlock(ctx context.Context, identifier string) error {
+	identifier = normalizeLoginIdentifier(identifier)
+	deleted, err := s.attempts.Delete(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrLockoutNotFound
+	}
+
+	s.logger.Info("account unlocked", slog.String("identifier", identifier))
+	return nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/storage"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// S3 allows up to 10 tags per object
+	maxObjectTags     = 10
+	maxObjectTagKey   = 128
+	maxObjectTagValue = 256
+	// Tag filters look up the tags of this many objects at once
+	tagLookupConcurrency = 8
+	// Listings and searches look up the tags of at most this many files
+	// while the request waits; more are searched in the background
+	maxInlineTagLookups = 1000
+)
+
+// TagFilter matches objects that have a tag; a nil Value matches any value
+type TagFilter struct {
+	Key   string
+	Value *string
+}
+
+// ParseTagFilters reads filters written as key=value or just key
+func ParseTagFilters(values []string) ([]TagFilter, error) {
+	filters := make([]TagFilter, 0, len(values))
+	for _, value := range values {
+		key, tagValue, hasValue := strings.Cut(value, "=")
+		if key == "" {
+			return nil, fmt.Errorf("%w: %q needs a tag key", ErrInvalidTagFilter, value)
+		}
+		filter := TagFilter{Key: key}
+		if hasValue {
+			filter.Value = &tagValue
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+func matchTags(tags map[string]string, filters []TagFilter) bool {
+	for _, filter := range filters {
+		value, ok := tags[filter.Key]
+		if !ok || (filter.Value != nil && value != *filter.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateObjectTags applies the S3 limits on object tags
+func validateObjectTags(tags map[string]string) error {
+	if len(tags) > maxObjectTags {
+		return fmt.Errorf("%w: at most %d tags are allowed per object", ErrInvalidObjectTags, maxObjectTags)
+	}
+	for key, value := range tags {
+		if key == "" || utf8.RuneCountInString(key) > maxObjectTagKey {
+			return fmt.Errorf("%w: keys must be 1 to %d characters", ErrInvalidObjectTags, maxObjectTagKey)
+		}
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			return fmt.Errorf("%w: the aws: prefix is reserved", ErrInvalidObjectTags)
+		}
+		if utf8.RuneCountInString(value) > maxObjectTagValue {
+			return fmt.Errorf("%w: the value of %q exceeds %d characters", ErrInvalidObjectTags, key, maxObjectTagValue)
+		}
+	}
+	return nil
+}
+
+// GetObjectTags returns the object's tags
+func (s *BucketService) GetObjectTags(ctx context.Context, bucketID, userID uuid.UUID, key string, envelope *crypto.Envelope) (map[string]string, error) {
+	// Demo objects are static data without tags
+	user, err := s.users.GetByID(ctx, userID)
+	if err == nil && user.IsDemo {
+		if _, err := s.Get(ctx, bucketID, userID); err != nil {
+			return nil, err
+		}
+		return map[string]string{}, nil
+	}
+
+	bucket, store, err := s.openForTagging(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := store.GetObjectTagging(ctx, bucket.Name, key)
+	if err != nil {
+		return nil, taggingError(err, bucket)
+	}
+	return tags, nil
+}
+
+// PutObjectTags replaces the object's tags
+func (s *BucketService) PutObjectTags(ctx context.Context, bucketID, userID uuid.UUID, key string, tags map[string]string, envelope *crypto.Envelope) (_ map[string]string, err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditObjectTag,
+			BucketID:   bucketID,
+			BucketName: bucketName,
+			ObjectKey:  key,
+			Details:    map[string]string{"tags": strconv.Itoa(len(tags))},
+		}, err)
+	}()
+
+	if err := validateObjectTags(tags); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err == nil && user.IsDemo {
+		return nil, ErrDemoRestriction
+	}
+
+	bucket, store, err := s.openForTagging(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return nil, err
+	}
+	bucketName = bucket.Name
+
+	if len(tags) == 0 {
+		err = store.DeleteObjectTagging(ctx, bucket.Name, key)
+	} else {
+		err = store.PutObjectTagging(ctx, bucket.Name, key, tags)
+	}
+	if err != nil {
+		return nil, taggingError(err, bucket)
+	}
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return tags, nil
+}
+
+// DeleteObjectTags removes all of the object's tags
+func (s *BucketService) DeleteObjectTags(ctx context.Context, bucketID, userID uuid.UUID, key string, envelope *crypto.Envelope) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditObjectUntag, BucketID: bucketID, BucketName: bucketName, ObjectKey: key}, err)
+	}()
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err == nil && user.IsDemo {
+		return ErrDemoRestriction
+	}
+
+	bucket, store, err := s.openForTagging(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return err
+	}
+	bucketName = bucket.Name
+
+	if err := store.DeleteObjectTagging(ctx, bucket.Name, key); err != nil {
+		return taggingError(err, bucket)
+	}
+	return nil
+}
+
+// openForTagging loads the bucket and a client for it, after checking that
+// the bucket's provider implements object tags
+func (s *BucketService) openForTagging(ctx context.Context, bucketID, userID uuid.UUID, envelope *crypto.Envelope) (*repository.BucketWithCredential, *storage.ObjectStore, error) {
+	bucket, err := s.Get(ctx, bucketID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := requireObjectTagging(bucket); err != nil {
+		return nil, nil, err
+	}
+
+	store, err := s.GetObjectStore(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bucket, store, nil
+}
+
+// requireObjectTagging checks that the bucket's provider implements object tags
+func requireObjectTagging(bucket *repository.BucketWithCredential) error {
+	provider, capabilities := storage.ProviderCapabilities(bucket.CredentialProvider)
+	if !capabilities.ObjectTagging {
+		return unsupportedFeature(provider, storage.FeatureObjectTagging)
+	}
+	return nil
+}
+
+// taggingError reports missing objects, rejected tags and providers without
+// object tags as such, so they are not mistaken for internal failures
+func taggingError(err error, bucket *repository.BucketWithCredential) error {
+	code, message, ok := storage.APIError(err)
+	if !ok {
+		return err
+	}
+	switch code {
+	case "NotImplemented":
+		provider, _ := storage.ProviderCapabilities(bucket.CredentialProvider)
+		return unsupportedFeature(provider, storage.FeatureObjectTagging)
+	case "NoSuchKey", "NotFound":
+		return ErrObjectNotFound
+	case "InvalidTag", "BadRequest", "InvalidArgument", "MalformedXML":
+		return fmt.Errorf("%w: rejected by the provider: %s", ErrInvalidObjectTags, message)
+	}
+	return err
+}
+
+// filterObjectsByTags looks up the tags of the files among objects and keeps
+// those matching every filter, with their tags. Folders have no tags and are
+// dropped.
+func (s *BucketService) filterObjectsByTags(ctx context.Context, bucketID, userID uuid.UUID, objects []BucketObject, filters []TagFilter, envelope *crypto.Envelope) ([]BucketObject, error) {
+	files := 0
+	for _, object := range objects {
+		if object.Kind == "file" {
+			files++
+		}
+	}
+	if files > maxInlineTagLookups {
+		return nil, fmt.Errorf("%w: %d files match, at most %d can be filtered at once; narrow the search or start a tag search", ErrTagFilterTooBroad, files, maxInlineTagLookups)
+	}
+
+	// Demo objects are static data without tags, so none match
+	user, err := s.users.GetByID(ctx, userID)
+	if err == nil && user.IsDemo {
+		return []BucketObject{}, nil
+	}
+
+	bucket, store, err := s.openForTagging(ctx, bucketID, userID, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		indexes  = make(chan int)
+	)
+	for i := 0; i < tagLookupConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				tags, err := store.GetObjectTagging(ctx, bucket.Name, objects[index].Key)
+				if err != nil {
+					err = taggingError(err, bucket)
+					if err == ErrObjectNotFound {
+						// Deleted since it was listed
+						continue
+					}
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				objects[index].Tags = tags
+			}
+		}()
+	}
+
+send:
+	for i := range objects {
+		if objects[i].Kind != "file" {
+			continue
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	matched := make([]BucketObject, 0, len(objects))
+	for _, object := range objects {
+		if object.Tags != nil && matchTags(object.Tags, filters) {
+			matched = append(matched, object)
+		}
+	}
+	return matched, nil
+}
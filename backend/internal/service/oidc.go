@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// IdentityProviderOIDC is the provider name stored for identities linked through OIDC
+	IdentityProviderOIDC = "oidc"
+
+	// How long a user has to complete the login at the identity provider
+	oidcLoginRequestTTL = 10 * time.Minute
+)
+
+// OIDCConfig configures the OpenID Connect login flow
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// AdminClaim is the (optionally dotted) claim path that controls the admin flag.
+	// Boolean claims are used as-is; string and list claims grant admin when they
+	// contain one of AdminValues. When empty, the admin flag is left untouched.
+	AdminClaim  string
+	AdminValues []string
+
+	// AutoCreateUsers creates users just-in-time on first login
+	AutoCreateUsers bool
+	// LinkByEmail links the identity to an existing user with the same verified email
+	LinkByEmail bool
+}
+
+// OIDCService implements the OpenID Connect authorization code flow with PKCE
+type OIDCService struct {
+	cfg        OIDCConfig
+	users      repository.UserRepository
+	identities repository.UserIdentityRepository
+	requests   repository.OIDCLoginRequestRepository
+	auth       *AuthService
+	logger     *slog.Logger
+
+	// Provider discovery happens lazily so the API can start while the IdP is unreachable
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCService(
+	cfg OIDCConfig,
+	users repository.UserRepository,
+	identities repository.UserIdentityRepository,
+	requests repository.OIDCLoginRequestRepository,
+	auth *AuthService,
+	logger *slog.Logger,
+) *OIDCService {
+	return &OIDCService{
+		cfg:        cfg,
+		users:      users,
+		identities: identities,
+		requests:   requests,
+		auth:       auth,
+		logger:     logger,
+	}
+}
+
+// oidcClaims holds the standard claims used to provision users
+type oidcClaims struct {
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	GivenName     string      `json:"given_name"`
+	FamilyName    string      `json:"family_name"`
+	Name          string      `json:"name"`
+}
+
+func (s *OIDCService) getProvider(ctx context.Context) (*oidc.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, s.cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discover oidc provider: %w", err)
+	}
+	s.provider = provider
+	return provider, nil
+}
+
+func (s *OIDCService) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  s.cfg.RedirectURL,
+		Scopes:       s.cfg.Scopes,
+	}
+}
+
+// OIDCLoginStart is a new authorization request
+type OIDCLoginStart struct {
+	// URL is where to send the user
+	URL string
+	// State must be bound to the browser, e.g. with a cookie, and checked on
+	// the callback; otherwise a victim could be made to complete an
+	// attacker's login
+	State     string
+	ExpiresAt time.Time
+}
+
+// BeginLogin starts a new authorization request
+func (s *OIDCService) BeginLogin(ctx context.Context) (*OIDCLoginStart, error) {
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := crypto.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := crypto.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// Opportunistically clean up abandoned requests
+	if err := s.requests.DeleteExpired(ctx); err != nil {
+		s.logger.Warn("failed to delete expired oidc login requests", slog.Any("error", err))
+	}
+
+	expiresAt := time.Now().Add(oidcLoginRequestTTL)
+	if _, err := s.requests.Create(ctx, state, verifier, nonce, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &OIDCLoginStart{
+		URL: s.oauth2Config(provider).AuthCodeURL(state,
+			oauth2.S256ChallengeOption(verifier),
+			oidc.Nonce(nonce),
+		),
+		State:     state,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// CompleteLogin exchanges the authorization code, resolves the user and issues
+// BucketBird tokens, or an MFA challenge if the user has two-factor authentication enabled
+func (s *OIDCService) CompleteLogin(ctx context.Context, state, code string) (result *AuthResult, err error) {
+	defer func() {
+		s.auth.recordAuth(ctx, AuditAuthOIDCLogin, "", result, err)
+	}()
+
+	if state == "" || code == "" {
+		return nil, ErrInvalidOIDCState
+	}
+
+	req, err := s.requests.Consume(ctx, state)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, err
+	}
+	if time.Now().After(req.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(req.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: exchange code: %v", ErrOIDCLoginFailed, err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in token response", ErrOIDCLoginFailed)
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: verify id_token: %v", ErrOIDCLoginFailed, err)
+	}
+	if idToken.Nonce != req.Nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCLoginFailed)
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: decode claims: %v", ErrOIDCLoginFailed, err)
+	}
+	var rawClaims map[string]interface{}
+	if err := idToken.Claims(&rawClaims); err != nil {
+		return nil, fmt.Errorf("%w: decode claims: %v", ErrOIDCLoginFailed, err)
+	}
+
+	user, err := s.resolveUser(ctx, idToken.Subject, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.AdminClaim != "" {
+		isAdmin := claimGrantsAdmin(lookupClaim(rawClaims, s.cfg.AdminClaim), s.cfg.AdminValues)
+		if isAdmin != user.IsAdmin {
+			if err := s.users.SetAdmin(ctx, user.ID, isAdmin); err != nil {
+				return nil, err
+			}
+			user.IsAdmin = isAdmin
+			s.logger.Info("admin flag updated from oidc claims",
+				slog.String("user_id", user.ID.String()),
+				slog.Bool("is_admin", isAdmin),
+			)
+		}
+	}
+
+	// The identity provider's own MFA does not replace the TOTP the user
+	// enrolled in BucketBird
+	return s.auth.completeLogin(ctx, user)
+}
+
+// resolveUser finds the user linked to the identity, links an existing user by verified
+// email, or creates a new user
+func (s *OIDCService) resolveUser(ctx context.Context, subject string, claims oidcClaims) (*repository.User, error) {
+	email := strings.TrimSpace(strings.ToLower(claims.Email))
+	verified := emailVerified(claims.EmailVerified)
+
+	identity, err := s.identities.Get(ctx, IdentityProviderOIDC, subject)
+	if err == nil {
+		if email != "" && email != identity.Email {
+			if err := s.identities.UpdateEmail(ctx, identity.ID, email); err != nil {
+				s.logger.Warn("failed to update identity email", slog.Any("error", err))
+			}
+		}
+		return s.users.GetByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if email == "" {
+		return nil, fmt.Errorf("%w: identity provider did not return an email address", ErrOIDCLoginFailed)
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		// Only link when the IdP vouches for the address; otherwise anyone able to set
+		// an arbitrary email at the IdP could take over the local account
+		if !s.cfg.LinkByEmail || !verified || user.IsDemo {
+			return nil, ErrSSOAccountConflict
+		}
+	case errors.Is(err, repository.ErrNotFound):
+		if !s.cfg.AutoCreateUsers {
+			return nil, ErrSSOUserNotProvisioned
+		}
+		firstName, lastName := claims.GivenName, claims.FamilyName
+		if firstName == "" && lastName == "" {
+			firstName, lastName = splitName(claims.Name)
+		}
+		// SSO users have no local password
+		user, err = s.users.Create(ctx, email, "", strings.TrimSpace(firstName), strings.TrimSpace(lastName))
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Info("user created from oidc login", slog.String("user_id", user.ID.String()))
+	default:
+		return nil, err
+	}
+
+	if _, err := s.identities.Create(ctx, user.ID, IdentityProviderOIDC, subject, email); err != nil {
+		return nil, err
+	}
+	s.logger.Info("oidc identity linked", slog.String("user_id", user.ID.String()))
+
+	return user, nil
+}
+
+// emailVerified accepts both boolean and string representations of email_verified
+func emailVerified(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	default:
+		return false
+	}
+}
+
+// lookupClaim resolves a dotted claim path such as "realm_access.roles"
+func lookupClaim(claims map[string]interface{}, path string) interface{} {
+	if value, ok := claims[path]; ok {
+		return value
+	}
+
+	var current interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	return current
+}
+
+func claimGrantsAdmin(value interface{}, allowed []string) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return containsString(allowed, v)
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok && containsString(allowed, str) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func splitName(name string) (string, string) {
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return "", ""
+	}
+	return parts[0], strings.Join(parts[1:], " ")
+}
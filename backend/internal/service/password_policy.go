@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy rule codes reported in PasswordPolicyError
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBannedWord   = "banned_word"
+	PasswordRuleBreached     = "breached"
+)
+
+// minPersonalInfoLength skips name and email parts too short to be meaningful, such as initials
+const minPersonalInfoLength = 3
+
+// BreachedPasswordChecker reports whether a password appears in a list of breached passwords
+type BreachedPasswordChecker interface {
+	Contains(password string) bool
+}
+
+// PasswordPolicy controls which new passwords are accepted
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength is the longest password accepted; 0 means no limit
+	MaxLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// BannedWords may not appear anywhere in a password, ignoring case
+	BannedWords []string
+	// Breached rejects known breached passwords when set
+	Breached BreachedPasswordChecker
+}
+
+// DefaultPasswordPolicy applies when no policy is configured
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 8}
+
+// PasswordViolation is a single failed rule
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password failed, so all of them can be shown at once
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password does not meet the requirements: " + strings.Join(messages, "; ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// PasswordOwner is the account a password is checked for. Its name and email
+// address must not appear in the password.
+type PasswordOwner struct {
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// Check returns a PasswordPolicyError listing every rule the password fails.
+// A nil policy applies DefaultPasswordPolicy.
+func (p *PasswordPolicy) Check(password string, owner PasswordOwner) error {
+	if p == nil {
+		p = &DefaultPasswordPolicy
+	}
+
+	var violations []PasswordViolation
+	add := func(rule, message string) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		add(PasswordRuleMinLength, fmt.Sprintf("Must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(PasswordRuleMaxLength, fmt.Sprintf("Must be at most %d characters long", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUppercase && !hasUpper {
+		add(PasswordRuleUppercase, "Must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		add(PasswordRuleLowercase, "Must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(PasswordRuleDigit, "Must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(PasswordRuleSymbol, "Must contain a symbol")
+	}
+
+	lowered := strings.ToLower(password)
+	for _, part := range owner.parts() {
+		if strings.Contains(lowered, part) {
+			add(PasswordRulePersonalInfo, "Must not contain your name or email address")
+			break
+		}
+	}
+	for _, word := range p.BannedWords {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(lowered, word) {
+			add(PasswordRuleBannedWord, fmt.Sprintf("Must not contain %q", word))
+		}
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		add(PasswordRuleBreached, "Appears in a list of breached passwords; choose a different one")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// parts returns the lowercased name and email parts that must not appear in a password
+func (o PasswordOwner) parts() []string {
+	local, _, _ := strings.Cut(o.Email, "@")
+	candidates := []string{o.FirstName, o.LastName, local}
+
+	var parts []string
+	for _, c := range candidates {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if utf8.RuneCountInString(c) >= minPersonalInfoLength {
+			parts = append(parts, c)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PersonalAccessTokenPrefix marks bearer tokens that are personal access tokens rather than JWTs
+	PersonalAccessTokenPrefix = "bbpat_"
+
+	// TokenScopeFull grants the same access as an interactive session
+	TokenScopeFull = "full"
+	// TokenScopeRead grants read-only access
+	TokenScopeRead = "read"
+
+	// Number of characters of the secret kept in clear text so users can tell tokens apart
+	personalAccessTokenDisplayLength = len(PersonalAccessTokenPrefix) + 6
+)
+
+type PersonalAccessTokenService struct {
+	tokens  repository.PersonalAccessTokenRepository
+	users   repository.UserRepository
+	buckets repository.BucketRepository
+	audit   *AuditService
+	logger  *slog.Logger
+}
+
+func NewPersonalAccessTokenService(
+	tokens repository.PersonalAccessTokenRepository,
+	users repository.UserRepository,
+	buckets repository.BucketRepository,
+	audit *AuditService,
+	logger *slog.Logger,
+) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		tokens:  tokens,
+		users:   users,
+		buckets: buckets,
+		audit:   audit,
+		logger:  logger,
+	}
+}
+
+type CreatePersonalAccessTokenInput struct {
+	UserID    uuid.UUID
+	Name      string
+	Scope     string
+	BucketIDs []uuid.UUID
+	ExpiresAt *time.Time
+}
+
+// CreatedPersonalAccessToken holds a newly created token together with its secret.
+// The secret is only available at creation time; afterwards only its hash is stored.
+type CreatedPersonalAccessToken struct {
+	Token  *repository.PersonalAccessToken
+	Secret string
+}
+
+// IsPersonalAccessToken reports whether a bearer token looks like a personal access token
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// TokenIsReadOnly reports whether the token only grants read access
+func TokenIsReadOnly(token *repository.PersonalAccessToken) bool {
+	return token.Scope == TokenScopeRead
+}
+
+// TokenIsBucketScoped reports whether the token is restricted to specific buckets
+func TokenIsBucketScoped(token *repository.PersonalAccessToken) bool {
+	return len(token.BucketIDs) > 0
+}
+
+// TokenAllowsBucket reports whether the token may access the given bucket
+func TokenAllowsBucket(token *repository.PersonalAccessToken, bucketID uuid.UUID) bool {
+	if !TokenIsBucketScoped(token) {
+		return true
+	}
+	for _, id := range token.BucketIDs {
+		if id == bucketID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *PersonalAccessTokenService) Create(ctx context.Context, input CreatePersonalAccessTokenInput) (created *CreatedPersonalAccessToken, err error) {
+	defer func() {
+		entry := AuditEntry{
+			Action:  AuditTokenCreate,
+			ActorID: input.UserID,
+			Details: map[string]string{
+				"name":    strings.TrimSpace(input.Name),
+				"scope":   input.Scope,
+				"buckets": strconv.Itoa(len(input.BucketIDs)),
+			},
+		}
+		if created != nil {
+			entry.TargetID = created.Token.ID.String()
+			entry.Details["scope"] = created.Token.Scope
+		}
+		s.audit.Record(ctx, entry, err)
+	}()
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrInvalidTokenName
+	}
+
+	scope := strings.ToLower(strings.TrimSpace(input.Scope))
+	if scope == "" {
+		scope = TokenScopeFull
+	}
+	if scope != TokenScopeFull && scope != TokenScopeRead {
+		return nil, ErrInvalidTokenScope
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidTokenExpiry
+	}
+
+	// Bucket restrictions may only reference the user's own buckets
+	bucketIDs := make([]uuid.UUID, 0, len(input.BucketIDs))
+	seen := make(map[uuid.UUID]bool, len(input.BucketIDs))
+	for _, bucketID := range input.BucketIDs {
+		if seen[bucketID] {
+			continue
+		}
+		if _, err := s.buckets.Get(ctx, bucketID, input.UserID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrBucketNotFound
+			}
+			return nil, err
+		}
+		seen[bucketID] = true
+		bucketIDs = append(bucketIDs, bucketID)
+	}
+
+	random, err := crypto.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	secret := PersonalAccessTokenPrefix + random
+
+	token, err := s.tokens.Create(ctx, &repository.PersonalAccessToken{
+		UserID:      input.UserID,
+		Name:        name,
+		TokenHash:   crypto.HashPersonalAccessToken(secret),
+		TokenPrefix: secret[:personalAccessTokenDisplayLength],
+		Scope:       scope,
+		BucketIDs:   bucketIDs,
+		ExpiresAt:   input.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("personal access token created",
+		slog.String("user_id", input.UserID.String()),
+		slog.String("token_id", token.ID.String()),
+		slog.String("scope", scope),
+	)
+
+	return &CreatedPersonalAccessToken{
+		Token:  token,
+		Secret: secret,
+	}, nil
+}
+
+func (s *PersonalAccessTokenService) List(ctx context.Context, userID uuid.UUID) ([]*repository.PersonalAccessToken, error) {
+	return s.tokens.List(ctx, userID)
+}
+
+func (s *PersonalAccessTokenService) Revoke(ctx context.Context, id, userID uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditTokenRevoke, ActorID: userID, TargetID: id.String()}, err)
+	}()
+
+	// Verify token exists
+	if _, err := s.tokens.Get(ctx, id, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrPersonalAccessTokenNotFound
+		}
+		return err
+	}
+
+	if err := s.tokens.Delete(ctx, id, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("personal access token revoked",
+		slog.String("user_id", userID.String()),
+		slog.String("token_id", id.String()),
+	)
+	return nil
+}
+
+// Validate resolves a personal access token secret to its owner and records its use
+func (s *PersonalAccessTokenService) Validate(ctx context.Context, secret string) (*repository.User, *repository.PersonalAccessToken, error) {
+	if !IsPersonalAccessToken(secret) {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	token, err := s.tokens.GetByHash(ctx, crypto.HashPersonalAccessToken(secret))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrInvalidCredentials
+		}
+		return nil, nil, err
+	}
+
+	// Check expiry
+	if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	user, err := s.users.GetByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrInvalidCredentials
+		}
+		return nil, nil, err
+	}
+
+	// Last-used tracking is best effort and must not fail the request
+	if err := s.tokens.TouchLastUsed(ctx, token.ID); err != nil {
+		s.logger.Warn("failed to update token last used time", slog.Any("error", err), slog.String("token_id", token.ID.String()))
+	}
+
+	return user, token, nil
+}
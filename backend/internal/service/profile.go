@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 
 	"bucketbird/backend/internal/repository"
 	"bucketbird/backend/pkg/crypto"
@@ -10,20 +11,33 @@ import (
 )
 
 type ProfileService struct {
-	users repository.UserRepository
+	users     repository.UserRepository
+	sessions  repository.SessionRepository
+	emails    *EmailService
+	passwords *PasswordPolicy
+	audit     *AuditService
 }
 
-func NewProfileService(users repository.UserRepository) *ProfileService {
+// NewProfileService creates the profile service. emails may be nil when email delivery
+// is not configured; email changes then apply immediately.
+func NewProfileService(users repository.UserRepository, sessions repository.SessionRepository, emails *EmailService, passwords *PasswordPolicy, audit *AuditService) *ProfileService {
 	return &ProfileService{
-		users: users,
+		users:     users,
+		sessions:  sessions,
+		emails:    emails,
+		passwords: passwords,
+		audit:     audit,
 	}
 }
 
 type ProfileData struct {
-	ID        uuid.UUID
-	FirstName string
-	LastName  string
-	Email     string
+	ID            uuid.UUID
+	FirstName     string
+	LastName      string
+	Email         string
+	EmailVerified bool
+	// PendingEmail is set after an email change was requested and awaits confirmation
+	PendingEmail string
 }
 
 type UpdateProfileInput struct {
@@ -39,33 +53,90 @@ func (s *ProfileService) Get(ctx context.Context, userID uuid.UUID) (*ProfileDat
 	}
 
 	return &ProfileData{
-		ID:        user.ID,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
+		ID:            user.ID,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
 	}, nil
 }
 
-func (s *ProfileService) Update(ctx context.Context, userID uuid.UUID, input UpdateProfileInput) (*ProfileData, error) {
+func (s *ProfileService) Update(ctx context.Context, userID uuid.UUID, input UpdateProfileInput) (profile *ProfileData, err error) {
+	defer func() {
+		entry := AuditEntry{Action: AuditProfileUpdate, ActorID: userID}
+		if profile != nil && profile.PendingEmail != "" {
+			entry.Details = map[string]string{"pendingEmail": profile.PendingEmail}
+		}
+		s.audit.Record(ctx, entry, err)
+	}()
+
 	// Check if email is already in use by another user
 	existingUser, err := s.users.GetByEmail(ctx, input.Email)
 	if err == nil && existingUser.ID != userID {
 		return nil, ErrEmailAlreadyInUse
 	}
 
-	if err := s.users.Update(ctx, userID, input.Email, input.FirstName, input.LastName); err != nil {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.Get(ctx, userID)
+	// With email delivery configured, a new address only takes effect once confirmed
+	email := input.Email
+	var pendingEmail string
+	if s.emails != nil && !strings.EqualFold(strings.TrimSpace(input.Email), user.Email) {
+		pendingEmail = strings.TrimSpace(strings.ToLower(input.Email))
+		if err := s.emails.RequestEmailChange(ctx, user, pendingEmail); err != nil {
+			return nil, err
+		}
+		email = user.Email
+	}
+
+	if err := s.users.Update(ctx, userID, email, input.FirstName, input.LastName); err != nil {
+		return nil, err
+	}
+
+	profile, err = s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	profile.PendingEmail = pendingEmail
+	return profile, nil
+}
+
+// SendVerification emails a new verification link for the user's current address
+func (s *ProfileService) SendVerification(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditProfileVerificationSent, ActorID: userID}, err)
+	}()
+
+	if s.emails == nil {
+		return ErrEmailNotConfigured
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.emails.SendVerification(ctx, user)
 }
 
-func (s *ProfileService) UpdatePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
+// UpdatePassword changes the user's password and signs out every session except currentSessionID
+func (s *ProfileService) UpdatePassword(ctx context.Context, userID, currentSessionID uuid.UUID, currentPassword, newPassword string) (err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditProfilePasswordChange, ActorID: userID}, err)
+	}()
+
 	user, err := s.users.GetByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
+	// Users provisioned through single sign-on have no local password
+	if user.PasswordHash == "" {
+		return ErrNoLocalPassword
+	}
+
 	// Verify current password
 	valid, err := crypto.VerifyPassword(user.PasswordHash, currentPassword)
 	if err != nil {
@@ -75,6 +146,14 @@ func (s *ProfileService) UpdatePassword(ctx context.Context, userID uuid.UUID, c
 		return ErrInvalidCredentials
 	}
 
+	if err := s.passwords.Check(newPassword, PasswordOwner{
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}); err != nil {
+		return err
+	}
+
 	// Hash new password
 	newHash, err := crypto.HashPassword(newPassword)
 	if err != nil {
@@ -82,5 +161,11 @@ func (s *ProfileService) UpdatePassword(ctx context.Context, userID uuid.UUID, c
 	}
 
 	// Update password
-	return s.users.UpdatePassword(ctx, userID, newHash)
+	if err := s.users.UpdatePassword(ctx, userID, newHash); err != nil {
+		return err
+	}
+
+	// Sign out other devices that may have used the old password
+	_, err = s.sessions.DeleteOthers(ctx, userID, currentSessionID)
+	return err
 }
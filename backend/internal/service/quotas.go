@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultQuotaWarnPercent = 80
+	// An upload through the API commits or releases its reservation when it
+	// finishes; this only matters if the server stops mid-upload
+	uploadReservationTTL = time.Hour
+	// Presigned uploads may start until the URL expires and then take a while
+	presignReservationGrace = 15 * time.Minute
+	// Committed reservations are removed by the next size recalculation of
+	// their bucket; this bounds how long they count if it never happens
+	committedReservationTTL = time.Hour
+	quotaCleanupInterval    = time.Hour
+)
+
+// QuotaService enforces storage quotas. Writes reserve their size against
+// every quota they count towards before they start, so concurrent writes
+// cannot overshoot a limit together; the reservation is dropped once the
+// bucket's recalculated usage includes the write.
+type QuotaService struct {
+	repos  *repository.Repositories
+	events *EventBus
+	audit  *AuditService
+	logger *slog.Logger
+}
+
+func NewQuotaService(repos *repository.Repositories, events *EventBus, audit *AuditService, logger *slog.Logger) *QuotaService {
+	return &QuotaService{
+		repos:  repos,
+		events: events,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// QuotaStatus is a quota together with the usage it limits
+type QuotaStatus struct {
+	Quota *repository.Quota
+	Usage *repository.QuotaUsage
+	// Warning is set once usage, including reservations, reaches the warning
+	// threshold of either limit
+	Warning bool
+	// Exceeded is set once usage reaches either limit
+	Exceeded bool
+}
+
+type SetQuotaInput struct {
+	Scope     string
+	SubjectID uuid.UUID
+	// MaxBytes and MaxObjects are nil for no limit
+	MaxBytes   *int64
+	MaxObjects *int64
+	// WarnPercent of 0 uses the default of 80
+	WarnPercent int
+}
+
+// Set creates or replaces the quota of a user, team or bucket and returns it with its usage
+func (s *QuotaService) Set(ctx context.Context, input SetQuotaInput) (_ *QuotaStatus, err error) {
+	defer func() {
+		details := map[string]string{"scope": input.Scope}
+		if input.MaxBytes != nil {
+			details["maxBytes"] = strconv.FormatInt(*input.MaxBytes, 10)
+		}
+		if input.MaxObjects != nil {
+			details["maxObjects"] = strconv.FormatInt(*input.MaxObjects, 10)
+		}
+		s.audit.Record(ctx, AuditEntry{Action: AuditQuotaSet, TargetID: input.SubjectID.String(), Details: details}, err)
+	}()
+
+	if input.WarnPercent == 0 {
+		input.WarnPercent = defaultQuotaWarnPercent
+	}
+	switch {
+	case input.MaxBytes != nil && *input.MaxBytes < 0:
+		return nil, fmt.Errorf("%w: maxBytes must not be negative", ErrInvalidQuota)
+	case input.MaxObjects != nil && *input.MaxObjects < 0:
+		return nil, fmt.Errorf("%w: maxObjects must not be negative", ErrInvalidQuota)
+	case input.WarnPercent < 1 || input.WarnPercent > 100:
+		return nil, fmt.Errorf("%w: warnPercent must be between 1 and 100", ErrInvalidQuota)
+	}
+
+	name, err := s.subjectName(ctx, input.Scope, input.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := s.repos.Quotas.Upsert(ctx, &repository.Quota{
+		Scope:       input.Scope,
+		SubjectID:   input.SubjectID,
+		SubjectName: name,
+		MaxBytes:    input.MaxBytes,
+		MaxObjects:  input.MaxObjects,
+		WarnPercent: input.WarnPercent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.statuses(ctx, []*repository.Quota{quota})
+	if err != nil {
+		return nil, err
+	}
+	return statuses[0], nil
+}
+
+func (s *QuotaService) Delete(ctx context.Context, scope string, subjectID uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditQuotaDelete, TargetID: subjectID.String(), Details: map[string]string{"scope": scope}}, err)
+	}()
+
+	deleted, err := s.repos.Quotas.Delete(ctx, scope, subjectID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrQuotaNotFound
+	}
+	return nil
+}
+
+// List returns every quota with its usage
+func (s *QuotaService) List(ctx context.Context) ([]*QuotaStatus, error) {
+	quotas, err := s.repos.Quotas.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.statuses(ctx, quotas)
+}
+
+// ListForUser returns the quotas that limit the user's writes, with their usage
+func (s *QuotaService) ListForUser(ctx context.Context, userID uuid.UUID) ([]*QuotaStatus, error) {
+	quotas, err := s.repos.Quotas.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.statuses(ctx, quotas)
+}
+
+func (s *QuotaService) statuses(ctx context.Context, quotas []*repository.Quota) ([]*QuotaStatus, error) {
+	statuses := make([]*QuotaStatus, len(quotas))
+	for i, quota := range quotas {
+		usage, err := s.repos.Quotas.Usage(ctx, quota.Scope, quota.SubjectID)
+		if err != nil {
+			return nil, err
+		}
+		bytes, objects := usage.Bytes+usage.ReservedBytes, usage.Objects+usage.ReservedObjects
+		statuses[i] = &QuotaStatus{
+			Quota:    quota,
+			Usage:    usage,
+			Warning:  overThreshold(bytes, quota.MaxBytes, quota.WarnPercent) || overThreshold(objects, quota.MaxObjects, quota.WarnPercent),
+			Exceeded: overThreshold(bytes, quota.MaxBytes, 100) || overThreshold(objects, quota.MaxObjects, 100),
+		}
+	}
+	return statuses, nil
+}
+
+// QuotaReservation holds space for a write until it is committed or released
+type QuotaReservation struct {
+	// reservation is nil when no quota applies to the write
+	reservation *repository.QuotaReservation
+	// Warnings describe the quotas the write takes past their warning threshold
+	Warnings []string
+}
+
+type ReserveQuotaInput struct {
+	UserID   uuid.UUID
+	BucketID uuid.UUID
+	// Bytes is the most the write can add; -1 if unknown, which is rejected
+	// when a byte limit applies
+	Bytes   int64
+	Objects int64
+	// TTL is how long the reservation counts if it is never committed
+	TTL time.Duration
+}
+
+// Reserve checks that a write fits into every quota it counts towards and
+// holds its size until it is committed or released. Quotas are locked while
+// checking, so concurrent writes are admitted one after another.
+func (s *QuotaService) Reserve(ctx context.Context, input ReserveQuotaInput) (*QuotaReservation, error) {
+	result := &QuotaReservation{}
+	err := s.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		quotas, err := tx.Quotas.LockForWrite(ctx, input.UserID, input.BucketID)
+		if err != nil || len(quotas) == 0 {
+			return err
+		}
+
+		for _, quota := range quotas {
+			usage, err := tx.Quotas.Usage(ctx, quota.Scope, quota.SubjectID)
+			if err != nil {
+				return err
+			}
+			bytes := usage.Bytes + usage.ReservedBytes + max(input.Bytes, 0)
+			objects := usage.Objects + usage.ReservedObjects + input.Objects
+
+			if quota.MaxBytes != nil {
+				if input.Bytes < 0 {
+					return ErrQuotaSizeRequired
+				}
+				if input.Bytes > 0 && bytes > *quota.MaxBytes {
+					return &QuotaExceededError{Scope: quota.Scope, Resource: "bytes", Limit: *quota.MaxBytes, Used: bytes - input.Bytes, Requested: input.Bytes}
+				}
+			}
+			if quota.MaxObjects != nil && input.Objects > 0 && objects > *quota.MaxObjects {
+				return &QuotaExceededError{Scope: quota.Scope, Resource: "objects", Limit: *quota.MaxObjects, Used: objects - input.Objects, Requested: input.Objects}
+			}
+
+			if overThreshold(bytes, quota.MaxBytes, quota.WarnPercent) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s quota is %d%% used (%d of %d bytes)", quota.Scope, percentOf(bytes, *quota.MaxBytes), bytes, *quota.MaxBytes))
+			}
+			if overThreshold(objects, quota.MaxObjects, quota.WarnPercent) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s quota is %d%% used (%d of %d objects)", quota.Scope, percentOf(objects, *quota.MaxObjects), objects, *quota.MaxObjects))
+			}
+		}
+
+		result.reservation, err = tx.Quotas.CreateReservation(ctx, &repository.QuotaReservation{
+			UserID:    input.UserID,
+			BucketID:  input.BucketID,
+			Bytes:     max(input.Bytes, 0),
+			Objects:   input.Objects,
+			ExpiresAt: time.Now().Add(input.TTL),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Commit marks a reserved write as done. The reservation keeps counting until
+// the bucket's usage has been recalculated.
+func (s *QuotaService) Commit(ctx context.Context, reservation *QuotaReservation) {
+	if reservation == nil || reservation.reservation == nil {
+		return
+	}
+	now := time.Now()
+	if err := s.repos.Quotas.CommitReservation(context.WithoutCancel(ctx), reservation.reservation.ID, now, now.Add(committedReservationTTL)); err != nil {
+		s.logger.Error("failed to commit quota reservation", slog.String("reservation_id", reservation.reservation.ID.String()), slog.Any("error", err))
+	}
+}
+
+// Release gives back the space of a write that failed
+func (s *QuotaService) Release(ctx context.Context, reservation *QuotaReservation) {
+	if reservation == nil || reservation.reservation == nil {
+		return
+	}
+	if err := s.repos.Quotas.DeleteReservation(context.WithoutCancel(ctx), reservation.reservation.ID); err != nil {
+		s.logger.Error("failed to release quota reservation", slog.String("reservation_id", reservation.reservation.ID.String()), slog.Any("error", err))
+	}
+}
+
+// ReleaseCommitted drops the bucket's reservations for writes that were
+// committed before its usage recalculation started at recalculatedFrom
+func (s *QuotaService) ReleaseCommitted(ctx context.Context, bucketID uuid.UUID, recalculatedFrom time.Time) error {
+	return s.repos.Quotas.DeleteCommittedReservations(ctx, bucketID, recalculatedFrom)
+}
+
+// Run removes lapsed reservations every interval until ctx is done. A
+// reservation that lapses uncommitted may belong to a presigned upload that
+// went ahead, so its bucket's usage is recalculated.
+func (s *QuotaService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+
+	for {
+		s.expireReservations(ctx)
+
+		if time.Since(lastCleanup) >= quotaCleanupInterval {
+			if deleted, err := s.repos.Quotas.DeleteOrphaned(ctx); err != nil {
+				s.logger.Error("failed to remove orphaned quotas", slog.Any("error", err))
+			} else if deleted > 0 {
+				s.logger.Info("removed orphaned quotas", slog.Int64("deleted", deleted))
+			}
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *QuotaService) expireReservations(ctx context.Context) {
+	expired, err := s.repos.Quotas.DeleteExpiredReservations(ctx)
+	if err != nil {
+		s.logger.Error("failed to remove expired quota reservations", slog.Any("error", err))
+		return
+	}
+
+	stale := make(map[uuid.UUID]bool)
+	for _, reservation := range expired {
+		if reservation.CommittedAt != nil || stale[reservation.BucketID] {
+			continue
+		}
+		stale[reservation.BucketID] = true
+		s.events.Publish(ctx, DomainEvent{
+			Type:     EventQuotaReservationExpired,
+			UserID:   reservation.UserID,
+			BucketID: reservation.BucketID,
+		})
+	}
+}
+
+// subjectName checks that the subject of a quota exists and returns its name
+func (s *QuotaService) subjectName(ctx context.Context, scope string, subjectID uuid.UUID) (string, error) {
+	var (
+		name string
+		err  error
+	)
+	switch scope {
+	case repository.QuotaScopeUser:
+		var user *repository.User
+		if user, err = s.repos.Users.GetByID(ctx, subjectID); err == nil {
+			name = user.Email
+		}
+	case repository.QuotaScopeTeam:
+		var team *repository.Team
+		if team, err = s.repos.Teams.Get(ctx, subjectID); err == nil {
+			name = team.Name
+		}
+	case repository.QuotaScopeBucket:
+		var bucket *repository.Bucket
+		if bucket, err = s.repos.Buckets.GetByID(ctx, subjectID); err == nil {
+			name = bucket.Name
+		}
+	default:
+		return "", fmt.Errorf("%w: scope must be user, team or bucket", ErrInvalidQuota)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", fmt.Errorf("%w: no %s with this ID exists", ErrInvalidQuota, scope)
+	}
+	return name, err
+}
+
+// overThreshold reports whether used reaches percent of limit; a nil limit is never reached
+func overThreshold(used int64, limit *int64, percent int) bool {
+	if limit == nil {
+		return false
+	}
+	return used*100 >= *limit*int64(percent)
+}
+
+func percentOf(used, limit int64) int64 {
+	if limit == 0 {
+		return 100
+	}
+	return used * 100 / limit
+}
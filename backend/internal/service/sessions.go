@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"bucketbird/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type SessionService struct {
+	sessions repository.SessionRepository
+	logger   *slog.Logger
+}
+
+func NewSessionService(sessions repository.SessionRepository, logger *slog.Logger) *SessionService {
+	return &SessionService{
+		sessions: sessions,
+		logger:   logger,
+	}
+}
+
+// List returns the user's active sessions, most recently used first
+func (s *SessionService) List(ctx context.Context, userID uuid.UUID) ([]*repository.Session, error) {
+	return s.sessions.ListForUser(ctx, userID)
+}
+
+// Revoke signs out a single session. It can no longer be refreshed, and the
+// access tokens issued for it are rejected from the next request on, since
+// every request checks that its session still exists.
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	deleted, err := s.sessions.Delete(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSessionNotFound
+	}
+
+	s.logger.Info("session revoked",
+		slog.String("user_id", userID.String()),
+		slog.String("session_id", sessionID.String()),
+	)
+	return nil
+}
+
+// RevokeOthers signs out every session except the current one and returns how many were revoked
+func (s *SessionService) RevokeOthers(ctx context.Context, userID, currentSessionID uuid.UUID) (int64, error) {
+	revoked, err := s.sessions.DeleteOthers(ctx, userID, currentSessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("other sessions revoked",
+		slog.String("user_id", userID.String()),
+		slog.Int64("count", revoked),
+	)
+	return revoked, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+)
+
+const (
+	// SettingRequireTwoFactor forces every user to enroll in two-factor authentication
+	SettingRequireTwoFactor = "require_two_factor"
+
+	// Settings are read on every request by middleware, so they are cached briefly
+	settingsCacheTTL = 30 * time.Second
+)
+
+// InstanceSettings holds settings administrators can change at runtime
+type InstanceSettings struct {
+	RequireTwoFactor bool
+}
+
+type UpdateInstanceSettingsInput struct {
+	RequireTwoFactor *bool
+}
+
+type SettingsService struct {
+	settings repository.SettingsRepository
+	logger   *slog.Logger
+
+	mu       sync.RWMutex
+	cached   *InstanceSettings
+	cachedAt time.Time
+}
+
+func NewSettingsService(settings repository.SettingsRepository, logger *slog.Logger) *SettingsService {
+	return &SettingsService{
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+func (s *SettingsService) Get(ctx context.Context) (*InstanceSettings, error) {
+	s.mu.RLock()
+	if s.cached != nil && time.Since(s.cachedAt) < settingsCacheTTL {
+		cached := *s.cached
+		s.mu.RUnlock()
+		return &cached, nil
+	}
+	s.mu.RUnlock()
+
+	settings := &InstanceSettings{}
+	if err := s.load(ctx, SettingRequireTwoFactor, &settings.RequireTwoFactor); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cached = settings
+	s.cachedAt = time.Now()
+	s.mu.Unlock()
+
+	result := *settings
+	return &result, nil
+}
+
+func (s *SettingsService) Update(ctx context.Context, input UpdateInstanceSettingsInput) (*InstanceSettings, error) {
+	if input.RequireTwoFactor != nil {
+		if err := s.store(ctx, SettingRequireTwoFactor, *input.RequireTwoFactor); err != nil {
+			return nil, err
+		}
+		s.logger.Info("instance setting updated",
+			slog.String("key", SettingRequireTwoFactor),
+			slog.Bool("value", *input.RequireTwoFactor),
+		)
+	}
+
+	// Invalidate cache
+	s.mu.Lock()
+	s.cached = nil
+	s.mu.Unlock()
+
+	return s.Get(ctx)
+}
+
+// load decodes a setting into dest, leaving dest untouched when the setting was never stored
+func (s *SettingsService) load(ctx context.Context, key string, dest interface{}) error {
+	raw, err := s.settings.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+func (s *SettingsService) store(ctx context.Context, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.settings.Set(ctx, key, raw)
+}
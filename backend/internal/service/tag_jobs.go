@@ -0,0 +1,520 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// A job lists at most this many keys; larger sets are tagged by prefix
+	maxTagJobKeys = 10000
+	// Objects tagged at once by each job
+	tagJobConcurrency = 8
+	// Jobs claimed per poll; they run one after another
+	tagJobBatchSize = 2
+	// A job keeps the first failures it runs into, with their errors
+	maxTagJobFailures = 20
+	tagJobListLimit   = 50
+	// How often the worker looks for queued jobs; new jobs wake it right away
+	tagJobPollInterval = 30 * time.Second
+	// Running jobs store their progress this often, which is also their heartbeat
+	tagJobProgressInterval = 5 * time.Second
+	// A running job whose worker has not sent a heartbeat for this long is claimed again
+	tagJobLease         = 10 * time.Minute
+	tagJobPruneInterval = time.Hour
+	// Finished jobs are deleted after this long
+	tagJobRetention = 7 * 24 * time.Hour
+)
+
+// TagJobService tags many objects of a bucket in the background, from a list
+// of keys or everything under a prefix. Jobs are stored, so they survive
+// restarts and are picked up by whichever instance claims them first.
+type TagJobService struct {
+	repos   *repository.Repositories
+	buckets *BucketService
+	audit   *AuditService
+	// wake cuts the wait for the next poll short after a job is created
+	wake   chan struct{}
+	logger *slog.Logger
+}
+
+func NewTagJobService(
+	repos *repository.Repositories,
+	buckets *BucketService,
+	audit *AuditService,
+	logger *slog.Logger,
+) *TagJobService {
+	return &TagJobService{
+		repos:   repos,
+		buckets: buckets,
+		audit:   audit,
+		wake:    make(chan struct{}, 1),
+		logger:  logger,
+	}
+}
+
+// CreateTagJobInput names the objects to tag, by Keys or by Prefix, and the
+// change to make to their tags
+type CreateTagJobInput struct {
+	BucketID uuid.UUID
+	UserID   uuid.UUID
+	Keys     []string
+	Prefix   *string
+	// SetTags are added or overwritten and RemoveTags are deleted. With
+	// ReplaceTags, the objects' tags become exactly SetTags instead.
+	SetTags     map[string]string
+	RemoveTags  []string
+	ReplaceTags bool
+}
+
+// Create queues a tag job
+func (s *TagJobService) Create(ctx context.Context, input CreateTagJobInput) (job *repository.TagJob, err error) {
+	var bucketName string
+	defer func() {
+		entry := AuditEntry{Action: AuditTagJobCreate, BucketID: input.BucketID, BucketName: bucketName}
+		if input.Prefix != nil {
+			entry.ObjectKey = *input.Prefix
+		}
+		if job != nil {
+			entry.TargetID = job.ID.String()
+		}
+		entry.Details = map[string]string{
+			"keys":    strconv.Itoa(len(input.Keys)),
+			"set":     strconv.Itoa(len(input.SetTags)),
+			"remove":  strconv.Itoa(len(input.RemoveTags)),
+			"replace": strconv.FormatBool(input.ReplaceTags),
+		}
+		s.audit.Record(ctx, entry, err)
+	}()
+
+	keys, err := validateTagJob(input)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repos.Users.GetByID(ctx, input.UserID)
+	if err == nil && user.IsDemo {
+		return nil, ErrDemoRestriction
+	}
+
+	bucket, err := s.buckets.Get(ctx, input.BucketID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	bucketName = bucket.Name
+	if err := requireObjectTagging(bucket); err != nil {
+		return nil, err
+	}
+
+	newJob := &repository.TagJob{
+		BucketID:    input.BucketID,
+		UserID:      input.UserID,
+		Prefix:      input.Prefix,
+		SetTags:     input.SetTags,
+		RemoveTags:  input.RemoveTags,
+		ReplaceTags: input.ReplaceTags,
+	}
+	if input.Prefix == nil {
+		total := int64(len(keys))
+		newJob.Keys = keys
+		newJob.Total = &total
+	}
+
+	job, err = s.repos.TagJobs.Create(ctx, newJob)
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+	return job, nil
+}
+
+// validateTagJob checks the input and returns its keys without duplicates
+func validateTagJob(input CreateTagJobInput) ([]string, error) {
+	if (len(input.Keys) > 0) == (input.Prefix != nil) {
+		return nil, fmt.Errorf("%w: give either keys or a prefix", ErrInvalidTagJob)
+	}
+	if len(input.Keys) > maxTagJobKeys {
+		return nil, fmt.Errorf("%w: at most %d keys are allowed; tag a prefix instead", ErrInvalidTagJob, maxTagJobKeys)
+	}
+
+	seen := make(map[string]bool, len(input.Keys))
+	keys := make([]string, 0, len(input.Keys))
+	for _, key := range input.Keys {
+		if key == "" {
+			return nil, fmt.Errorf("%w: keys must not be empty", ErrInvalidTagJob)
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	if err := validateObjectTags(input.SetTags); err != nil {
+		return nil, err
+	}
+	if input.ReplaceTags {
+		if len(input.RemoveTags) > 0 {
+			return nil, fmt.Errorf("%w: removeTags cannot be combined with replaceTags", ErrInvalidTagJob)
+		}
+		return keys, nil
+	}
+	if len(input.SetTags) == 0 && len(input.RemoveTags) == 0 {
+		return nil, fmt.Errorf("%w: give tags to set or remove", ErrInvalidTagJob)
+	}
+	for _, key := range input.RemoveTags {
+		if key == "" {
+			return nil, fmt.Errorf("%w: tag keys to remove must not be empty", ErrInvalidTagJob)
+		}
+		if _, ok := input.SetTags[key]; ok {
+			return nil, fmt.Errorf("%w: %q is both set and removed", ErrInvalidTagJob, key)
+		}
+	}
+	return keys, nil
+}
+
+// Get returns one of the bucket's tag jobs
+func (s *TagJobService) Get(ctx context.Context, bucketID, userID, jobID uuid.UUID) (*repository.TagJob, error) {
+	if _, err := s.buckets.Get(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+
+	job, err := s.repos.TagJobs.Get(ctx, jobID, bucketID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTagJobNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// List returns the bucket's most recent tag jobs, newest first
+func (s *TagJobService) List(ctx context.Context, bucketID, userID uuid.UUID) ([]*repository.TagJob, error) {
+	if _, err := s.buckets.Get(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+	return s.repos.TagJobs.List(ctx, bucketID, tagJobListLimit)
+}
+
+// Cancel stops a pending or running job. Objects a running job has already
+// tagged keep their new tags.
+func (s *TagJobService) Cancel(ctx context.Context, bucketID, userID, jobID uuid.UUID) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditTagJobCancel, BucketID: bucketID, BucketName: bucketName, TargetID: jobID.String()}, err)
+	}()
+
+	bucket, err := s.buckets.Get(ctx, bucketID, userID)
+	if err != nil {
+		return err
+	}
+	bucketName = bucket.Name
+
+	canceled, err := s.repos.TagJobs.Cancel(ctx, jobID, bucketID)
+	if err != nil {
+		return err
+	}
+	if !canceled {
+		if _, err := s.repos.TagJobs.Get(ctx, jobID, bucketID); errors.Is(err, repository.ErrNotFound) {
+			return ErrTagJobNotFound
+		}
+		return ErrTagJobFinished
+	}
+	return nil
+}
+
+// Run claims and runs queued tag jobs until ctx is canceled. Jobs interrupted
+// by a shutdown are claimed again once their lease runs out.
+func (s *TagJobService) Run(ctx context.Context) {
+	ticker := time.NewTicker(tagJobPollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+
+	for {
+		for s.runBatch(ctx) == tagJobBatchSize {
+			// A full batch means more jobs are probably queued
+		}
+		for s.runSearchBatch(ctx) == tagJobBatchSize {
+			// Likewise for searches
+		}
+
+		if time.Since(lastPrune) >= tagJobPruneInterval {
+			if deleted, err := s.repos.TagJobs.DeleteFinishedBefore(ctx, time.Now().Add(-tagJobRetention)); err != nil {
+				s.logger.Error("failed to prune tag jobs", slog.Any("error", err))
+			} else if deleted > 0 {
+				s.logger.Info("pruned tag jobs", slog.Int64("deleted", deleted))
+			}
+			if deleted, err := s.repos.TagSearches.DeleteBefore(ctx, time.Now().Add(-tagSearchRetention)); err != nil {
+				s.logger.Error("failed to prune tag searches", slog.Any("error", err))
+			} else if deleted > 0 {
+				s.logger.Info("pruned tag searches", slog.Int64("deleted", deleted))
+			}
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// notify wakes Run on this instance to pick up a newly created job or search
+func (s *TagJobService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runBatch claims and runs one batch of jobs and returns its size
+func (s *TagJobService) runBatch(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	jobs, err := s.repos.TagJobs.Claim(ctx, time.Now().Add(-tagJobLease), tagJobBatchSize)
+	if err != nil {
+		s.logger.Error("failed to claim tag jobs", slog.Any("error", err))
+		return 0
+	}
+
+	for _, job := range jobs {
+		if err := s.runJob(ctx, job); err != nil {
+			s.logger.Error("failed to store tag job result",
+				slog.String("job_id", job.ID.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+	return len(jobs)
+}
+
+// tagJobProgress collects the results of a job's workers
+type tagJobProgress struct {
+	mu       sync.Mutex
+	job      *repository.TagJob
+	fatalErr error
+}
+
+func (p *tagJobProgress) record(key string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Processed++
+	if err == nil {
+		return
+	}
+	p.job.Failed++
+	if len(p.job.Failures) < maxTagJobFailures {
+		p.job.Failures = append(p.job.Failures, repository.TagJobFailure{Key: key, Error: tagJobError(err)})
+	}
+}
+
+// fail records an error that stops the whole job
+func (p *tagJobProgress) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fatalErr == nil {
+		p.fatalErr = err
+	}
+}
+
+// snapshot copies the job's counts so they can be stored without holding the lock
+func (p *tagJobProgress) snapshot() *repository.TagJob {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	job := *p.job
+	job.Failures = append([]repository.TagJobFailure(nil), p.job.Failures...)
+	return &job
+}
+
+func (s *TagJobService) runJob(ctx context.Context, job *repository.TagJob) error {
+	job.Failures = nil
+	progress := &tagJobProgress{job: job}
+
+	bucket, err := s.repos.Buckets.GetByID(ctx, job.BucketID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// The job was deleted along with the bucket
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	store, err := s.buckets.GetObjectStore(ctx, bucket.ID, bucket.UserID, s.buckets.envelope)
+	if err != nil {
+		return s.finishJob(ctx, progress, err)
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var canceled bool
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(tagJobProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			running, err := s.repos.TagJobs.UpdateProgress(jobCtx, progress.snapshot())
+			if err != nil {
+				if jobCtx.Err() == nil {
+					s.logger.Warn("failed to store tag job progress", slog.String("job_id", job.ID.String()), slog.Any("error", err))
+				}
+				continue
+			}
+			if !running {
+				canceled = true
+				cancel()
+				return
+			}
+		}
+	}()
+
+	keys := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < tagJobConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				err := s.tagObject(jobCtx, store, bucket.Name, key, job)
+				if jobCtx.Err() != nil {
+					// Canceled or shutting down; the object was not necessarily tagged
+					continue
+				}
+				if code, _, ok := storage.APIError(err); ok && code == "NotImplemented" {
+					progress.fail(fmt.Errorf("%w: the provider does not implement object tags", ErrFeatureUnsupported))
+					cancel()
+					continue
+				}
+				progress.record(key, err)
+			}
+		}()
+	}
+
+	send := func(key string) error {
+		select {
+		case keys <- key:
+			return nil
+		case <-jobCtx.Done():
+			return jobCtx.Err()
+		}
+	}
+	var listErr error
+	if job.Prefix != nil {
+		listErr = store.WalkObjects(jobCtx, bucket.Name, *job.Prefix, func(obj storage.ObjectSummary) error {
+			// Folder markers are not tagged
+			if strings.HasSuffix(obj.Key, "/") {
+				return nil
+			}
+			return send(obj.Key)
+		})
+	} else {
+		for _, key := range job.Keys {
+			if listErr = send(key); listErr != nil {
+				break
+			}
+		}
+	}
+	close(keys)
+	wg.Wait()
+	cancel()
+	<-heartbeatDone
+
+	switch {
+	case canceled:
+		return nil
+	case progress.fatalErr != nil:
+		return s.finishJob(ctx, progress, progress.fatalErr)
+	case ctx.Err() != nil:
+		// Shutting down; the job is claimed again once its lease runs out
+		return nil
+	case listErr != nil:
+		return s.finishJob(ctx, progress, fmt.Errorf("list objects: %w", listErr))
+	}
+	return s.finishJob(ctx, progress, nil)
+}
+
+// finishJob stores the job as completed, or as failed if err is set
+func (s *TagJobService) finishJob(ctx context.Context, progress *tagJobProgress, err error) error {
+	job := progress.snapshot()
+	job.Status = repository.TagJobCompleted
+	if err != nil {
+		job.Status = repository.TagJobFailed
+		job.Error = tagJobError(err)
+		s.logger.Warn("tag job failed",
+			slog.String("job_id", job.ID.String()),
+			slog.String("bucket_id", job.BucketID.String()),
+			slog.Any("error", err),
+		)
+	}
+	return s.repos.TagJobs.Finish(ctx, job)
+}
+
+// tagObject applies the job's change to the tags of one object
+func (s *TagJobService) tagObject(ctx context.Context, store *storage.ObjectStore, bucketName, key string, job *repository.TagJob) error {
+	if job.ReplaceTags {
+		if len(job.SetTags) == 0 {
+			return store.DeleteObjectTagging(ctx, bucketName, key)
+		}
+		return store.PutObjectTagging(ctx, bucketName, key, job.SetTags)
+	}
+
+	tags, err := store.GetObjectTagging(ctx, bucketName, key)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, name := range job.RemoveTags {
+		if _, ok := tags[name]; ok {
+			delete(tags, name)
+			changed = true
+		}
+	}
+	for name, value := range job.SetTags {
+		if current, ok := tags[name]; !ok || current != value {
+			tags[name] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if len(tags) > maxObjectTags {
+		return fmt.Errorf("%w: the object would have %d tags, more than the %d allowed", ErrInvalidObjectTags, len(tags), maxObjectTags)
+	}
+	if len(tags) == 0 {
+		return store.DeleteObjectTagging(ctx, bucketName, key)
+	}
+	return store.PutObjectTagging(ctx, bucketName, key, tags)
+}
+
+// tagJobError shortens provider errors to their code and message
+func tagJobError(err error) string {
+	if code, message, ok := storage.APIError(err); ok {
+		if message == "" {
+			return code
+		}
+		return code + ": " + message
+	}
+	return err.Error()
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// A search keeps at most this many matches and stops once it has them
+	maxTagSearchMatches = 1000
+	// Searches are read-only, so they are kept for a day only
+	tagSearchRetention = 24 * time.Hour
+)
+
+// CreateSearch queues a search for the objects under prefix having the tags
+// in filters, written as key=value or key. Searching by tags looks up the
+// tags of every object, so it runs in the background; the matches are
+// fetched with GetSearch.
+func (s *TagJobService) CreateSearch(ctx context.Context, bucketID, userID uuid.UUID, prefix string, filters []string) (*repository.TagSearch, error) {
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("%w: give at least one tag to search for", ErrInvalidTagFilter)
+	}
+	if _, err := ParseTagFilters(filters); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err == nil && user.IsDemo {
+		return nil, ErrDemoRestriction
+	}
+
+	bucket, err := s.buckets.Get(ctx, bucketID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireObjectTagging(bucket); err != nil {
+		return nil, err
+	}
+
+	search, err := s.repos.TagSearches.Create(ctx, &repository.TagSearch{
+		BucketID: bucketID,
+		UserID:   userID,
+		Prefix:   prefix,
+		Filters:  filters,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+	return search, nil
+}
+
+// GetSearch returns one of the bucket's tag searches, with its matches once
+// it has completed
+func (s *TagJobService) GetSearch(ctx context.Context, bucketID, userID, searchID uuid.UUID) (*repository.TagSearch, error) {
+	if _, err := s.buckets.Get(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+
+	search, err := s.repos.TagSearches.Get(ctx, searchID, bucketID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTagSearchNotFound
+		}
+		return nil, err
+	}
+	return search, nil
+}
+
+// runSearchBatch claims and runs one batch of searches and returns its size
+func (s *TagJobService) runSearchBatch(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	searches, err := s.repos.TagSearches.Claim(ctx, time.Now().Add(-tagJobLease), tagJobBatchSize)
+	if err != nil {
+		s.logger.Error("failed to claim tag searches", slog.Any("error", err))
+		return 0
+	}
+
+	for _, search := range searches {
+		if err := s.runSearch(ctx, search); err != nil {
+			s.logger.Error("failed to store tag search result",
+				slog.String("search_id", search.ID.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+	return len(searches)
+}
+
+// tagSearchProgress collects the results of a search's workers
+type tagSearchProgress struct {
+	mu       sync.Mutex
+	search   *repository.TagSearch
+	fatalErr error
+}
+
+// record counts a looked up object and keeps it if it matched; it returns
+// false once the search has all the matches it keeps
+func (p *tagSearchProgress) record(match *repository.TagSearchMatch) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.search.Truncated {
+		return false
+	}
+	p.search.Scanned++
+	if match == nil {
+		return true
+	}
+	if len(p.search.Matches) == maxTagSearchMatches {
+		p.search.Truncated = true
+		return false
+	}
+	p.search.Matches = append(p.search.Matches, *match)
+	return true
+}
+
+func (p *tagSearchProgress) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fatalErr == nil {
+		p.fatalErr = err
+	}
+}
+
+func (p *tagSearchProgress) snapshot() *repository.TagSearch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	search := *p.search
+	search.Matches = append([]repository.TagSearchMatch(nil), p.search.Matches...)
+	return &search
+}
+
+func (s *TagJobService) runSearch(ctx context.Context, search *repository.TagSearch) error {
+	search.Matches = nil
+	progress := &tagSearchProgress{search: search}
+
+	filters, err := ParseTagFilters(search.Filters)
+	if err != nil {
+		return s.finishSearch(ctx, progress, err)
+	}
+
+	bucket, err := s.repos.Buckets.GetByID(ctx, search.BucketID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// The search was deleted along with the bucket
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	store, err := s.buckets.GetObjectStore(ctx, bucket.ID, bucket.UserID, s.buckets.envelope)
+	if err != nil {
+		return s.finishSearch(ctx, progress, err)
+	}
+
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(tagJobProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-searchCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := s.repos.TagSearches.UpdateProgress(searchCtx, progress.snapshot()); err != nil && searchCtx.Err() == nil {
+				s.logger.Warn("failed to store tag search progress", slog.String("search_id", search.ID.String()), slog.Any("error", err))
+			}
+		}
+	}()
+
+	objects := make(chan storage.ObjectSummary)
+	var wg sync.WaitGroup
+	for i := 0; i < tagLookupConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range objects {
+				tags, err := store.GetObjectTagging(searchCtx, bucket.Name, obj.Key)
+				if searchCtx.Err() != nil {
+					continue
+				}
+				if err != nil {
+					code, _, ok := storage.APIError(err)
+					switch {
+					case ok && (code == "NoSuchKey" || code == "NotFound"):
+						// Deleted since it was listed
+						continue
+					case ok && code == "NotImplemented":
+						progress.fail(fmt.Errorf("%w: the provider does not implement object tags", ErrFeatureUnsupported))
+					default:
+						progress.fail(fmt.Errorf("get tags of %s: %w", obj.Key, err))
+					}
+					cancel()
+					continue
+				}
+
+				var match *repository.TagSearchMatch
+				if matchTags(tags, filters) {
+					match = &repository.TagSearchMatch{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified, Tags: tags}
+				}
+				if !progress.record(match) {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	listErr := store.WalkObjects(searchCtx, bucket.Name, search.Prefix, func(obj storage.ObjectSummary) error {
+		// Folder markers have no tags worth searching
+		if strings.HasSuffix(obj.Key, "/") {
+			return nil
+		}
+		select {
+		case objects <- obj:
+			return nil
+		case <-searchCtx.Done():
+			return searchCtx.Err()
+		}
+	})
+	close(objects)
+	wg.Wait()
+	cancel()
+	<-heartbeatDone
+
+	switch {
+	case progress.fatalErr != nil:
+		return s.finishSearch(ctx, progress, progress.fatalErr)
+	case ctx.Err() != nil:
+		// Shutting down; the search is claimed again once its lease runs out
+		return nil
+	// A full search stops its listing on purpose, which is not a failure
+	case listErr != nil && !progress.search.Truncated:
+		return s.finishSearch(ctx, progress, fmt.Errorf("list objects: %w", listErr))
+	}
+	return s.finishSearch(ctx, progress, nil)
+}
+
+// finishSearch stores the search as completed, or as failed if err is set
+func (s *TagJobService) finishSearch(ctx context.Context, progress *tagSearchProgress, err error) error {
+	search := progress.snapshot()
+	search.Status = repository.TagJobCompleted
+	if err != nil {
+		search.Status = repository.TagJobFailed
+		search.Error = tagJobError(err)
+		s.logger.Warn("tag search failed",
+			slog.String("search_id", search.ID.String()),
+			slog.String("bucket_id", search.BucketID.String()),
+			slog.Any("error", err),
+		)
+	}
+	return s.repos.TagSearches.Finish(ctx, search)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"bucketbird/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TeamRoleOwner members manage the team and can invite new users into it
+	TeamRoleOwner = "owner"
+	// TeamRoleMember is the default role
+	TeamRoleMember = "member"
+)
+
+type TeamService struct {
+	teams  repository.TeamRepository
+	logger *slog.Logger
+}
+
+func NewTeamService(teams repository.TeamRepository, logger *slog.Logger) *TeamService {
+	return &TeamService{
+		teams:  teams,
+		logger: logger,
+	}
+}
+
+// TeamDetails is a team together with its members
+type TeamDetails struct {
+	Team    *repository.Team
+	Role    string
+	Members []*repository.TeamMember
+}
+
+// Create creates a team owned by the given user
+func (s *TeamService) Create(ctx context.Context, userID uuid.UUID, name string) (*repository.Team, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidTeamName
+	}
+
+	team, err := s.teams.Create(ctx, name, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.teams.AddMember(ctx, team.ID, userID, TeamRoleOwner); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("team created",
+		slog.String("team_id", team.ID.String()),
+		slog.String("user_id", userID.String()),
+	)
+	return team, nil
+}
+
+func (s *TeamService) List(ctx context.Context, userID uuid.UUID) ([]*repository.TeamMembership, error) {
+	return s.teams.ListForUser(ctx, userID)
+}
+
+// Get returns a team and its members; only members can see a team
+func (s *TeamService) Get(ctx context.Context, teamID, userID uuid.UUID) (*TeamDetails, error) {
+	membership, err := s.membership(ctx, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := s.teams.Get(ctx, teamID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, err
+	}
+
+	members, err := s.teams.ListMembers(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TeamDetails{
+		Team:    team,
+		Role:    membership.Role,
+		Members: members,
+	}, nil
+}
+
+// IsOwner reports whether the user owns the team
+func (s *TeamService) IsOwner(ctx context.Context, teamID, userID uuid.UUID) (bool, error) {
+	membership, err := s.membership(ctx, teamID, userID)
+	if err != nil {
+		if errors.Is(err, ErrTeamNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return membership.Role == TeamRoleOwner, nil
+}
+
+// AddMember adds a user to a team, keeping the role of existing members
+func (s *TeamService) AddMember(ctx context.Context, teamID, userID uuid.UUID, role string) error {
+	return s.teams.AddMember(ctx, teamID, userID, role)
+}
+
+// Delete removes a team; only owners can delete it
+func (s *TeamService) Delete(ctx context.Context, teamID, userID uuid.UUID) error {
+	membership, err := s.membership(ctx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if membership.Role != TeamRoleOwner {
+		return ErrNotTeamOwner
+	}
+
+	if err := s.teams.Delete(ctx, teamID); err != nil {
+		return err
+	}
+
+	s.logger.Info("team deleted",
+		slog.String("team_id", teamID.String()),
+		slog.String("user_id", userID.String()),
+	)
+	return nil
+}
+
+// RemoveMember removes memberID from the team. Owners can remove anyone; members can only leave.
+func (s *TeamService) RemoveMember(ctx context.Context, teamID, actorID, memberID uuid.UUID) error {
+	actor, err := s.membership(ctx, teamID, actorID)
+	if err != nil {
+		return err
+	}
+	if actorID != memberID && actor.Role != TeamRoleOwner {
+		return ErrNotTeamOwner
+	}
+
+	target, err := s.teams.GetMember(ctx, teamID, memberID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrTeamMemberNotFound
+		}
+		return err
+	}
+
+	if target.Role == TeamRoleOwner {
+		members, err := s.teams.ListMembers(ctx, teamID)
+		if err != nil {
+			return err
+		}
+		owners := 0
+		for _, m := range members {
+			if m.Role == TeamRoleOwner {
+				owners++
+			}
+		}
+		if owners <= 1 {
+			return ErrLastTeamOwner
+		}
+	}
+
+	removed, err := s.teams.RemoveMember(ctx, teamID, memberID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrTeamMemberNotFound
+	}
+
+	s.logger.Info("team member removed",
+		slog.String("team_id", teamID.String()),
+		slog.String("user_id", memberID.String()),
+		slog.String("removed_by", actorID.String()),
+	)
+	return nil
+}
+
+func (s *TeamService) membership(ctx context.Context, teamID, userID uuid.UUID) (*repository.TeamMember, error) {
+	member, err := s.teams.GetMember(ctx, teamID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, err
+	}
+	return member, nil
+}
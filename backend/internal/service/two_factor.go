@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/google/uuid"
+)
+
+const recoveryCodeCount = 10
+
+// TwoFactorStatus describes a user's two-factor enrollment
+type TwoFactorStatus struct {
+	Enabled                bool
+	EnabledAt              *time.Time
+	RecoveryCodesRemaining int64
+	Required               bool
+}
+
+// TwoFactorSetup holds a pending TOTP secret to be confirmed with a code
+type TwoFactorSetup struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type TwoFactorService struct {
+	users    repository.UserRepository
+	recovery repository.RecoveryCodeRepository
+	settings *SettingsService
+	envelope *crypto.Envelope
+	issuer   string
+	audit    *AuditService
+	logger   *slog.Logger
+}
+
+func NewTwoFactorService(
+	users repository.UserRepository,
+	recovery repository.RecoveryCodeRepository,
+	settings *SettingsService,
+	envelope *crypto.Envelope,
+	issuer string,
+	audit *AuditService,
+	logger *slog.Logger,
+) *TwoFactorService {
+	return &TwoFactorService{
+		users:    users,
+		recovery: recovery,
+		settings: settings,
+		envelope: envelope,
+		issuer:   issuer,
+		audit:    audit,
+		logger:   logger,
+	}
+}
+
+// TwoFactorEnabled reports whether the user has confirmed a TOTP authenticator
+func TwoFactorEnabled(user *repository.User) bool {
+	return user.TOTPEnabledAt != nil && user.TOTPSecret != nil
+}
+
+func (s *TwoFactorService) Status(ctx context.Context, userID uuid.UUID) (*TwoFactorStatus, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := s.EnrollmentRequired(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{
+		Enabled:   TwoFactorEnabled(user),
+		EnabledAt: user.TOTPEnabledAt,
+		Required:  required,
+	}
+	if status.Enabled {
+		status.RecoveryCodesRemaining, err = s.recovery.CountUnused(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// EnrollmentRequired reports whether the instance requires two-factor authentication
+// and the user has not enrolled yet
+func (s *TwoFactorService) EnrollmentRequired(ctx context.Context, user *repository.User) (bool, error) {
+	if TwoFactorEnabled(user) || user.IsDemo {
+		return false, nil
+	}
+	settings, err := s.settings.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+	return settings.RequireTwoFactor, nil
+}
+
+// BeginSetup generates a new TOTP secret. It only takes effect once confirmed with Enable.
+func (s *TwoFactorService) BeginSetup(ctx context.Context, userID uuid.UUID) (*TwoFactorSetup, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if TwoFactorEnabled(user) {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.envelope.Seal(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.SetTOTPSecret(ctx, userID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: crypto.TOTPProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms the pending secret with a code and returns freshly generated recovery codes
+func (s *TwoFactorService) Enable(ctx context.Context, userID uuid.UUID, code string) (_ []string, err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditAuth2FAEnable, ActorID: userID}, err)
+	}()
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if TwoFactorEnabled(user) {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	if err := s.verifyTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	if err := s.users.EnableTOTP(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("two-factor authentication enabled", slog.String("user_id", userID.String()))
+	return codes, nil
+}
+
+// Disable turns off two-factor authentication. Users with a local password must confirm it;
+// users without one (SSO or LDAP) confirm with a current code instead.
+func (s *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID, password, code string) (err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditAuth2FADisable, ActorID: userID}, err)
+	}()
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !TwoFactorEnabled(user) {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if user.PasswordHash != "" {
+		ok, err := crypto.VerifyPassword(user.PasswordHash, password)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidCredentials
+		}
+	} else if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.users.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.recovery.DeleteAll(ctx, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("two-factor authentication disabled", slog.String("user_id", userID.String()))
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates all previous recovery codes after verifying a TOTP code
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) (_ []string, err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditAuth2FARecoveryCodes, ActorID: userID}, err)
+	}()
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !TwoFactorEnabled(user) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.verifyTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// Verify checks a TOTP code or, failing that, consumes a recovery code
+func (s *TwoFactorService) Verify(ctx context.Context, user *repository.User, code string) error {
+	if err := s.verifyTOTP(ctx, user, code); err == nil {
+		return nil
+	} else if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		return err
+	}
+
+	used, err := s.recovery.Use(ctx, user.ID, crypto.HashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	s.logger.Info("recovery code used", slog.String("user_id", user.ID.String()))
+	return nil
+}
+
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, user *repository.User, code string) error {
+	if user.TOTPSecret == nil {
+		return ErrInvalidTwoFactorCode
+	}
+	secret, err := s.envelope.Open(ctx, *user.TOTPSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := crypto.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	// Each code may only be used once
+	fresh, err := s.users.UpdateTOTPLastStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := crypto.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = crypto.HashRecoveryCode(code)
+	}
+
+	if err := s.recovery.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
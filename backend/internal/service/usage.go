@@ -0,0 +1,528 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Usage history rollup periods
+const (
+	UsagePeriodDay  = "day"
+	UsagePeriodWeek = "week"
+)
+
+const (
+	// Buckets claimed per poll; they are snapshotted one after another
+	usageSnapshotBatchSize = 10
+	// Only the largest top-level prefixes of a bucket are kept in its history
+	maxSnapshotPrefixes = 50
+	// How often the worker looks for buckets that are due a snapshot
+	usagePollInterval  = time.Minute
+	usagePruneInterval = time.Hour
+	// Growth alerts compare against the usage a day earlier and fire at most once per window
+	usageGrowthWindow = 24 * time.Hour
+
+	defaultUsageHistoryDays = 30
+	maxUsageHistoryDays     = 366
+	defaultPrefixGrowthDays = 7
+	maxPrefixGrowthDays     = 31
+)
+
+// UsageConfig controls how usage history is collected
+type UsageConfig struct {
+	// SnapshotInterval is how often each bucket's usage is captured; 0 disables snapshots
+	SnapshotInterval time.Duration
+	// Retention is how long snapshots are kept; 0 keeps them forever
+	Retention time.Duration
+}
+
+// UsageService records the size and object count of buckets and their
+// top-level prefixes over time, and raises alerts when a bucket grows faster
+// than its owner allows. Alerts are published as domain events, which reach
+// webhooks subscribed to bucket.growth_alert. It also computes disk usage
+// breakdowns, in the background for large buckets.
+type UsageService struct {
+	repos   *repository.Repositories
+	buckets *BucketService
+	events  *EventBus
+	audit   *AuditService
+	config  UsageConfig
+	// wake cuts the wait for the next poll short after a report is queued
+	wake   chan struct{}
+	logger *slog.Logger
+}
+
+func NewUsageService(
+	repos *repository.Repositories,
+	buckets *BucketService,
+	events *EventBus,
+	audit *AuditService,
+	config UsageConfig,
+	logger *slog.Logger,
+) *UsageService {
+	return &UsageService{
+		repos:   repos,
+		buckets: buckets,
+		events:  events,
+		audit:   audit,
+		config:  config,
+		wake:    make(chan struct{}, 1),
+		logger:  logger,
+	}
+}
+
+// UsagePoint is the usage at the end of one rollup period
+type UsagePoint struct {
+	PeriodStart time.Time
+	// CapturedAt is the time of the period's last snapshot, which the values are taken from
+	CapturedAt  time.Time
+	SizeBytes   int64
+	ObjectCount int64
+	// Growth is the change since the previous period; for the first period,
+	// since its first snapshot
+	GrowthBytes   int64
+	GrowthObjects int64
+}
+
+// UsageHistory is the rolled up usage of a bucket, or of one of its
+// top-level prefixes
+type UsageHistory struct {
+	Prefix string
+	Period string
+	Points []UsagePoint
+	// Average growth per day between the first and the last snapshot
+	GrowthBytesPerDay   float64
+	GrowthObjectsPerDay float64
+}
+
+type UsageHistoryInput struct {
+	BucketID uuid.UUID
+	UserID   uuid.UUID
+	// Prefix is a top-level prefix such as "logs/", or empty for the whole bucket
+	Prefix string
+	Period string
+	Days   int
+}
+
+// History returns the bucket's usage over the last Days days, one point per period
+func (s *UsageService) History(ctx context.Context, input UsageHistoryInput) (*UsageHistory, error) {
+	if input.Period == "" {
+		input.Period = UsagePeriodDay
+	}
+	if input.Period != UsagePeriodDay && input.Period != UsagePeriodWeek {
+		return nil, fmt.Errorf("%w: period must be day or week", ErrInvalidUsageQuery)
+	}
+	days, err := usageDays(input.Days, defaultUsageHistoryDays, maxUsageHistoryDays)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.buckets.Get(ctx, input.BucketID, input.UserID); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	snapshots, err := s.repos.Usage.ListSnapshots(ctx, input.BucketID, &input.Prefix, since)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &UsageHistory{
+		Prefix: input.Prefix,
+		Period: input.Period,
+		Points: rollUpUsage(snapshots, input.Period),
+	}
+	if len(snapshots) > 1 {
+		first, last := snapshots[0], snapshots[len(snapshots)-1]
+		if elapsed := last.CapturedAt.Sub(first.CapturedAt); elapsed > 0 {
+			days := elapsed.Hours() / 24
+			history.GrowthBytesPerDay = float64(last.SizeBytes-first.SizeBytes) / days
+			history.GrowthObjectsPerDay = float64(last.ObjectCount-first.ObjectCount) / days
+		}
+	}
+	return history, nil
+}
+
+// rollUpUsage keeps the last snapshot of each day or week, in UTC. Weeks start on Monday.
+func rollUpUsage(snapshots []*repository.UsageSnapshot, period string) []UsagePoint {
+	points := []UsagePoint{}
+	for i, snapshot := range snapshots {
+		captured := snapshot.CapturedAt.UTC()
+		start := time.Date(captured.Year(), captured.Month(), captured.Day(), 0, 0, 0, 0, time.UTC)
+		if period == UsagePeriodWeek {
+			start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		}
+
+		if n := len(points); n > 0 && points[n-1].PeriodStart.Equal(start) {
+			last := &points[n-1]
+			last.GrowthBytes += snapshot.SizeBytes - last.SizeBytes
+			last.GrowthObjects += snapshot.ObjectCount - last.ObjectCount
+			last.SizeBytes, last.ObjectCount, last.CapturedAt = snapshot.SizeBytes, snapshot.ObjectCount, snapshot.CapturedAt
+			continue
+		}
+
+		point := UsagePoint{
+			PeriodStart: start,
+			CapturedAt:  snapshot.CapturedAt,
+			SizeBytes:   snapshot.SizeBytes,
+			ObjectCount: snapshot.ObjectCount,
+		}
+		if i > 0 {
+			point.GrowthBytes = snapshot.SizeBytes - snapshots[i-1].SizeBytes
+			point.GrowthObjects = snapshot.ObjectCount - snapshots[i-1].ObjectCount
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// PrefixGrowth is the latest usage of a top-level prefix and how much it grew
+type PrefixGrowth struct {
+	Prefix      string
+	CapturedAt  time.Time
+	SizeBytes   int64
+	ObjectCount int64
+	// Growth is the change since the prefix's first snapshot in the window
+	GrowthBytes   int64
+	GrowthObjects int64
+}
+
+// Prefixes returns the top-level prefixes of the bucket's latest snapshot,
+// largest first, with their growth over the last days days
+func (s *UsageService) Prefixes(ctx context.Context, bucketID, userID uuid.UUID, days int) ([]*PrefixGrowth, error) {
+	days, err := usageDays(days, defaultPrefixGrowthDays, maxPrefixGrowthDays)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.buckets.Get(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.repos.Usage.ListSnapshots(ctx, bucketID, nil, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	var latest time.Time
+	byPrefix := map[string]*PrefixGrowth{}
+	for _, snapshot := range snapshots {
+		if snapshot.CapturedAt.After(latest) {
+			latest = snapshot.CapturedAt
+		}
+		if snapshot.Prefix == "" {
+			continue
+		}
+		growth, ok := byPrefix[snapshot.Prefix]
+		if !ok {
+			growth = &PrefixGrowth{Prefix: snapshot.Prefix}
+			byPrefix[snapshot.Prefix] = growth
+		} else {
+			growth.GrowthBytes += snapshot.SizeBytes - growth.SizeBytes
+			growth.GrowthObjects += snapshot.ObjectCount - growth.ObjectCount
+		}
+		growth.CapturedAt, growth.SizeBytes, growth.ObjectCount = snapshot.CapturedAt, snapshot.SizeBytes, snapshot.ObjectCount
+	}
+
+	// Prefixes missing from the latest snapshot were emptied or fell out of the largest ones
+	prefixes := []*PrefixGrowth{}
+	for _, growth := range byPrefix {
+		if growth.CapturedAt.Equal(latest) {
+			prefixes = append(prefixes, growth)
+		}
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if prefixes[i].SizeBytes != prefixes[j].SizeBytes {
+			return prefixes[i].SizeBytes > prefixes[j].SizeBytes
+		}
+		return prefixes[i].Prefix < prefixes[j].Prefix
+	})
+	return prefixes, nil
+}
+
+func usageDays(days, fallback, limit int) (int, error) {
+	if days == 0 {
+		return fallback, nil
+	}
+	if days < 1 || days > limit {
+		return 0, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidUsageQuery, limit)
+	}
+	return days, nil
+}
+
+func (s *UsageService) GetAlert(ctx context.Context, bucketID, userID uuid.UUID) (*repository.UsageAlert, error) {
+	if _, err := s.buckets.Get(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+
+	alert, err := s.repos.Usage.GetAlert(ctx, bucketID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUsageAlertNotFound
+		}
+		return nil, err
+	}
+	return alert, nil
+}
+
+type SetUsageAlertInput struct {
+	BucketID uuid.UUID
+	UserID   uuid.UUID
+	// MaxDailyGrowthBytes and MaxDailyGrowthObjects are nil when not checked;
+	// at least one must be set
+	MaxDailyGrowthBytes   *int64
+	MaxDailyGrowthObjects *int64
+}
+
+// SetAlert creates or replaces the bucket's growth alert
+func (s *UsageService) SetAlert(ctx context.Context, input SetUsageAlertInput) (alert *repository.UsageAlert, err error) {
+	var bucketName string
+	defer func() {
+		details := map[string]string{}
+		if input.MaxDailyGrowthBytes != nil {
+			details["maxDailyGrowthBytes"] = strconv.FormatInt(*input.MaxDailyGrowthBytes, 10)
+		}
+		if input.MaxDailyGrowthObjects != nil {
+			details["maxDailyGrowthObjects"] = strconv.FormatInt(*input.MaxDailyGrowthObjects, 10)
+		}
+		s.audit.Record(ctx, AuditEntry{Action: AuditUsageAlertSet, BucketID: input.BucketID, BucketName: bucketName, Details: details}, err)
+	}()
+
+	if input.MaxDailyGrowthBytes == nil && input.MaxDailyGrowthObjects == nil {
+		return nil, fmt.Errorf("%w: maxDailyGrowthBytes or maxDailyGrowthObjects is required", ErrInvalidUsageAlert)
+	}
+	if (input.MaxDailyGrowthBytes != nil && *input.MaxDailyGrowthBytes <= 0) ||
+		(input.MaxDailyGrowthObjects != nil && *input.MaxDailyGrowthObjects <= 0) {
+		return nil, fmt.Errorf("%w: growth limits must be positive", ErrInvalidUsageAlert)
+	}
+
+	bucket, err := s.buckets.Get(ctx, input.BucketID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	bucketName = bucket.Name
+
+	return s.repos.Usage.UpsertAlert(ctx, &repository.UsageAlert{
+		BucketID:              input.BucketID,
+		MaxDailyGrowthBytes:   input.MaxDailyGrowthBytes,
+		MaxDailyGrowthObjects: input.MaxDailyGrowthObjects,
+	})
+}
+
+func (s *UsageService) DeleteAlert(ctx context.Context, bucketID, userID uuid.UUID) (err error) {
+	var bucketName string
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditUsageAlertDelete, BucketID: bucketID, BucketName: bucketName}, err)
+	}()
+
+	bucket, err := s.buckets.Get(ctx, bucketID, userID)
+	if err != nil {
+		return err
+	}
+	bucketName = bucket.Name
+
+	deleted, err := s.repos.Usage.DeleteAlert(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrUsageAlertNotFound
+	}
+	return nil
+}
+
+// Run computes queued usage reports, snapshots buckets that are due and
+// prunes old snapshots and reports until ctx is done. Several instances can
+// run it at once; each bucket and report is claimed by one of them.
+func (s *UsageService) Run(ctx context.Context) {
+	ticker := time.NewTicker(usagePollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+
+	for {
+		for s.runReports(ctx) == usageReportBatchSize {
+			// A full batch means more reports are probably queued
+		}
+
+		if s.config.SnapshotInterval > 0 {
+			for s.snapshotBatch(ctx) == usageSnapshotBatchSize {
+				// A full batch means more buckets are probably due
+			}
+		}
+
+		if time.Since(lastPrune) >= usagePruneInterval {
+			s.prune(ctx)
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// notify wakes Run on this instance to pick up a newly queued report
+func (s *UsageService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *UsageService) prune(ctx context.Context) {
+	if s.config.Retention > 0 {
+		deleted, err := s.repos.Usage.DeleteSnapshotsBefore(ctx, time.Now().Add(-s.config.Retention))
+		if err != nil {
+			s.logger.Error("failed to prune usage history", slog.Any("error", err))
+		} else if deleted > 0 {
+			s.logger.Info("pruned usage history", slog.Int64("deleted", deleted))
+		}
+	}
+
+	if _, err := s.repos.Usage.DeleteReportsBefore(ctx, time.Now().Add(-usageReportRetention)); err != nil {
+		s.logger.Error("failed to delete old usage reports", slog.Any("error", err))
+	}
+}
+
+// snapshotBatch claims and snapshots one batch of buckets and returns its size
+func (s *UsageService) snapshotBatch(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	buckets, err := s.repos.Usage.ClaimBucketsForSnapshot(ctx, time.Now().Add(-s.config.SnapshotInterval), usageSnapshotBatchSize)
+	if err != nil {
+		s.logger.Error("failed to claim buckets for usage snapshots", slog.Any("error", err))
+		return 0
+	}
+
+	for _, bucket := range buckets {
+		if err := s.snapshot(ctx, bucket); err != nil {
+			// The bucket is tried again at its next interval
+			s.logger.Warn("failed to snapshot bucket usage",
+				slog.String("bucket_id", bucket.ID.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+	return len(buckets)
+}
+
+// snapshot records the bucket's current usage and that of its largest
+// top-level prefixes, refreshes its cached usage and checks its growth alert
+func (s *UsageService) snapshot(ctx context.Context, bucket *repository.Bucket) error {
+	started := time.Now()
+
+	store, err := s.buckets.GetObjectStore(ctx, bucket.ID, bucket.UserID, s.buckets.envelope)
+	if err != nil {
+		return err
+	}
+	total, prefixes, err := store.CalculatePrefixUsage(ctx, bucket.Name)
+	if err != nil {
+		return err
+	}
+	if len(prefixes) > maxSnapshotPrefixes {
+		prefixes = prefixes[:maxSnapshotPrefixes]
+	}
+
+	// The listing also picks up changes made directly in S3
+	if err := s.buckets.storeUsage(ctx, bucket.ID, total.SizeBytes, total.ObjectCount, started); err != nil {
+		return err
+	}
+
+	capturedAt := started.UTC()
+	err = s.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		for _, usage := range append(prefixes, total) {
+			if err := tx.Usage.CreateSnapshot(ctx, &repository.UsageSnapshot{
+				BucketID:    bucket.ID,
+				Prefix:      usage.Prefix,
+				SizeBytes:   usage.SizeBytes,
+				ObjectCount: usage.ObjectCount,
+				CapturedAt:  capturedAt,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.checkAlert(ctx, bucket, total.SizeBytes, total.ObjectCount, capturedAt)
+}
+
+// checkAlert compares the bucket's usage with the snapshot from a day earlier,
+// or its oldest one if the history is shorter, and raises its growth alert
+// if either limit is exceeded
+func (s *UsageService) checkAlert(ctx context.Context, bucket *repository.Bucket, sizeBytes, objectCount int64, capturedAt time.Time) error {
+	alert, err := s.repos.Usage.GetAlert(ctx, bucket.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if alert.LastTriggeredAt != nil && capturedAt.Sub(*alert.LastTriggeredAt) < usageGrowthWindow {
+		return nil
+	}
+
+	baseline, err := s.repos.Usage.GetBaseline(ctx, bucket.ID, "", capturedAt.Add(-usageGrowthWindow))
+	if err != nil {
+		return err
+	}
+	growthBytes := sizeBytes - baseline.SizeBytes
+	growthObjects := objectCount - baseline.ObjectCount
+
+	details := map[string]string{
+		"growthBytes":   strconv.FormatInt(growthBytes, 10),
+		"growthObjects": strconv.FormatInt(growthObjects, 10),
+		"sizeBytes":     strconv.FormatInt(sizeBytes, 10),
+		"objectCount":   strconv.FormatInt(objectCount, 10),
+		"since":         baseline.CapturedAt.UTC().Format(time.RFC3339),
+	}
+	exceeded := false
+	if alert.MaxDailyGrowthBytes != nil {
+		details["maxDailyGrowthBytes"] = strconv.FormatInt(*alert.MaxDailyGrowthBytes, 10)
+		exceeded = exceeded || growthBytes > *alert.MaxDailyGrowthBytes
+	}
+	if alert.MaxDailyGrowthObjects != nil {
+		details["maxDailyGrowthObjects"] = strconv.FormatInt(*alert.MaxDailyGrowthObjects, 10)
+		exceeded = exceeded || growthObjects > *alert.MaxDailyGrowthObjects
+	}
+	if !exceeded {
+		return nil
+	}
+
+	s.logger.Warn("bucket growth alert",
+		slog.String("bucket_id", bucket.ID.String()),
+		slog.Int64("growth_bytes", growthBytes),
+		slog.Int64("growth_objects", growthObjects),
+	)
+	return s.events.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Usage.MarkAlertTriggered(ctx, alert.ID, capturedAt); err != nil {
+			return err
+		}
+		return s.events.Append(ctx, tx, DomainEvent{
+			Type:         EventBucketGrowthExceeded,
+			OccurredAt:   capturedAt,
+			UserID:       bucket.UserID,
+			BucketID:     bucket.ID,
+			BucketName:   bucket.Name,
+			CredentialID: bucket.CredentialID,
+			Details:      details,
+		})
+	})
+}
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultUsageTreeDepth = 3
+	maxUsageTreeDepth     = 10
+	defaultLargestObjects = 20
+	// Reports keep this many of the largest objects; requests can ask for fewer
+	maxLargestObjects = 100
+	// Sub-prefixes kept per tree node, largest first
+	maxUsageTreeChildren = 100
+	// Storage classes and extensions kept in a breakdown, largest first
+	maxUsageBreakdownEntries = 50
+	// Buckets with up to this many objects, as of their cached count, are
+	// scanned while the request waits; larger ones by the background worker.
+	// An inline scan that finds more hands the bucket to the worker as well.
+	usageTreeInlineObjects = 10000
+	// Cached counts older than this are not trusted to be small
+	usageTreeCountMaxAge = 24 * time.Hour
+	// A ready report is served from the cache for this long
+	usageReportTTL = time.Hour
+	// A running report whose worker has not finished by then is claimed again
+	usageReportLease = time.Hour
+	// Reports not requested for this long are deleted
+	usageReportRetention = 7 * 24 * time.Hour
+	usageReportBatchSize = 2
+)
+
+// errUsageTreeTooLarge stops an inline scan that passes its object limit
+var errUsageTreeTooLarge = errors.New("too many objects to scan inline")
+
+// UsageTree is a disk usage breakdown of a bucket or prefix. It is stored as
+// JSON in the report cache.
+type UsageTree struct {
+	Prefix string `json:"prefix"`
+	Depth  int    `json:"depth"`
+	// Root covers everything under Prefix
+	Root           *UsageTreeNode   `json:"root"`
+	StorageClasses []UsageBreakdown `json:"storageClasses"`
+	Extensions     []UsageBreakdown `json:"extensions"`
+	LargestObjects []LargeObject    `json:"largestObjects"`
+	ComputedAt     time.Time        `json:"computedAt"`
+}
+
+// UsageTreeNode is the usage under a prefix, including every level below it
+type UsageTreeNode struct {
+	Prefix      string           `json:"prefix"`
+	SizeBytes   int64            `json:"sizeBytes"`
+	ObjectCount int64            `json:"objectCount"`
+	Children    []*UsageTreeNode `json:"children,omitempty"`
+	// OmittedChildren counts the smallest sub-prefixes left out of Children;
+	// their usage is still part of this node's totals
+	OmittedChildren int `json:"omittedChildren,omitempty"`
+
+	byName map[string]*UsageTreeNode
+}
+
+// UsageBreakdown is the usage of one storage class or file extension. Objects
+// without an extension are listed under an empty name.
+type UsageBreakdown struct {
+	Name        string `json:"name"`
+	SizeBytes   int64  `json:"sizeBytes"`
+	ObjectCount int64  `json:"objectCount"`
+}
+
+type LargeObject struct {
+	Key          string    `json:"key"`
+	SizeBytes    int64     `json:"sizeBytes"`
+	StorageClass string    `json:"storageClass"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// UsageTreeReport is the state of a cached usage breakdown
+type UsageTreeReport struct {
+	Status      string
+	Error       string
+	RequestedAt time.Time
+	CompletedAt *time.Time
+	// Tree is nil until the first scan has finished; while a refresh is
+	// pending or running, it is the previous result
+	Tree *UsageTree
+}
+
+type UsageTreeInput struct {
+	BucketID uuid.UUID
+	UserID   uuid.UUID
+	Prefix   string
+	Depth    int
+	// Top is how many of the largest objects to return
+	Top int
+	// Refresh rescans the bucket even if a cached report is still fresh
+	Refresh bool
+}
+
+// Tree returns the usage breakdown of a bucket or prefix. Fresh reports come
+// from the cache. Otherwise small buckets are scanned right away, while large
+// ones, and those whose size was not recalculated recently, are queued for
+// the background worker and returned as pending; callers poll until the
+// report is ready.
+func (s *UsageService) Tree(ctx context.Context, input UsageTreeInput) (*UsageTreeReport, error) {
+	if input.Depth == 0 {
+		input.Depth = defaultUsageTreeDepth
+	}
+	if input.Depth < 1 || input.Depth > maxUsageTreeDepth {
+		return nil, fmt.Errorf("%w: depth must be between 1 and %d", ErrInvalidUsageQuery, maxUsageTreeDepth)
+	}
+	if input.Top == 0 {
+		input.Top = defaultLargestObjects
+	}
+	if input.Top < 1 || input.Top > maxLargestObjects {
+		return nil, fmt.Errorf("%w: top must be between 1 and %d", ErrInvalidUsageQuery, maxLargestObjects)
+	}
+
+	bucket, err := s.buckets.Get(ctx, input.BucketID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.repos.Usage.GetReport(ctx, input.BucketID, input.Prefix, input.Depth)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if report != nil && !input.Refresh {
+		switch report.Status {
+		case repository.UsageReportPending, repository.UsageReportRunning:
+			return toUsageTreeReport(report, input.Top)
+		case repository.UsageReportReady:
+			if report.CompletedAt != nil && time.Since(*report.CompletedAt) < usageReportTTL {
+				return toUsageTreeReport(report, input.Top)
+			}
+		}
+	}
+
+	// A count that was never or not recently recalculated may be far behind
+	countFresh := bucket.SizeUpdatedAt != nil && time.Since(*bucket.SizeUpdatedAt) < usageTreeCountMaxAge
+	if !countFresh || bucket.ObjectCount > usageTreeInlineObjects {
+		return s.queueReport(ctx, input)
+	}
+
+	started := time.Now()
+	result, err := s.scanTree(ctx, &bucket.Bucket, input.Prefix, input.Depth, usageTreeInlineObjects)
+	if errors.Is(err, errUsageTreeTooLarge) {
+		// The bucket grew since its count was recalculated
+		return s.queueReport(ctx, input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	report, err = s.repos.Usage.SaveReport(ctx, &repository.UsageReport{
+		BucketID:  input.BucketID,
+		Prefix:    input.Prefix,
+		Depth:     input.Depth,
+		Result:    result,
+		StartedAt: &started,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toUsageTreeReport(report, input.Top)
+}
+
+// queueReport asks the background worker for the report and returns it as pending
+func (s *UsageService) queueReport(ctx context.Context, input UsageTreeInput) (*UsageTreeReport, error) {
+	report, err := s.repos.Usage.RequestReport(ctx, input.BucketID, input.Prefix, input.Depth)
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+	return toUsageTreeReport(report, input.Top)
+}
+
+func toUsageTreeReport(report *repository.UsageReport, top int) (*UsageTreeReport, error) {
+	result := &UsageTreeReport{
+		Status:      report.Status,
+		Error:       report.Error,
+		RequestedAt: report.RequestedAt,
+		CompletedAt: report.CompletedAt,
+	}
+	if len(report.Result) > 0 {
+		var tree UsageTree
+		if err := json.Unmarshal(report.Result, &tree); err != nil {
+			return nil, fmt.Errorf("decode usage report: %w", err)
+		}
+		if len(tree.LargestObjects) > top {
+			tree.LargestObjects = tree.LargestObjects[:top]
+		}
+		result.Tree = &tree
+	}
+	return result, nil
+}
+
+// runReports claims and computes one batch of queued reports and returns its size
+func (s *UsageService) runReports(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	reports, err := s.repos.Usage.ClaimReports(ctx, time.Now().Add(-usageReportLease), usageReportBatchSize)
+	if err != nil {
+		s.logger.Error("failed to claim usage reports", slog.Any("error", err))
+		return 0
+	}
+
+	for _, report := range reports {
+		if err := s.runReport(ctx, report); err != nil {
+			s.logger.Error("failed to store usage report",
+				slog.String("report_id", report.ID.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+	return len(reports)
+}
+
+func (s *UsageService) runReport(ctx context.Context, report *repository.UsageReport) error {
+	bucket, err := s.repos.Buckets.GetByID(ctx, report.BucketID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// The report was deleted along with the bucket
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := s.scanTree(ctx, bucket, report.Prefix, report.Depth, 0)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down; the report is claimed again once its lease runs out
+			return nil
+		}
+		s.logger.Warn("usage report failed",
+			slog.String("report_id", report.ID.String()),
+			slog.String("bucket_id", report.BucketID.String()),
+			slog.Any("error", err),
+		)
+		return s.repos.Usage.FailReport(ctx, report.ID, err.Error())
+	}
+	return s.repos.Usage.CompleteReport(ctx, report.ID, result)
+}
+
+// scanTree lists everything under prefix and returns the JSON encoded
+// UsageTree. With a limit above 0, it gives up with errUsageTreeTooLarge once
+// it finds more objects than that.
+func (s *UsageService) scanTree(ctx context.Context, bucket *repository.Bucket, prefix string, depth int, limit int64) ([]byte, error) {
+	store, err := s.buckets.GetObjectStore(ctx, bucket.ID, bucket.UserID, s.buckets.envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &UsageTreeNode{Prefix: prefix}
+	classes := map[string]*UsageBreakdown{}
+	extensions := map[string]*UsageBreakdown{}
+	largest := &largeObjectHeap{}
+
+	err = store.WalkObjects(ctx, bucket.Name, prefix, func(obj storage.ObjectSummary) error {
+		if limit > 0 && root.ObjectCount >= limit {
+			return errUsageTreeTooLarge
+		}
+		node := root
+		node.add(obj.Size)
+		parts := strings.Split(strings.TrimPrefix(obj.Key, prefix), "/")
+		// The last part is the object name; a folder marker's is empty
+		for i := 0; i < len(parts)-1 && i < depth; i++ {
+			node = node.child(node.Prefix + parts[i] + "/")
+			node.add(obj.Size)
+		}
+
+		addBreakdown(classes, obj.StorageClass, obj.Size)
+		if name := parts[len(parts)-1]; name != "" {
+			addBreakdown(extensions, strings.ToLower(path.Ext(name)), obj.Size)
+		}
+
+		heap.Push(largest, LargeObject{Key: obj.Key, SizeBytes: obj.Size, StorageClass: obj.StorageClass, LastModified: obj.LastModified})
+		if largest.Len() > maxLargestObjects {
+			heap.Pop(largest)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	root.finish()
+	objects := []LargeObject(*largest)
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].SizeBytes != objects[j].SizeBytes {
+			return objects[i].SizeBytes > objects[j].SizeBytes
+		}
+		return objects[i].Key < objects[j].Key
+	})
+
+	return json.Marshal(UsageTree{
+		Prefix:         prefix,
+		Depth:          depth,
+		Root:           root,
+		StorageClasses: sortBreakdown(classes),
+		Extensions:     sortBreakdown(extensions),
+		LargestObjects: objects,
+		ComputedAt:     time.Now().UTC(),
+	})
+}
+
+func (n *UsageTreeNode) add(size int64) {
+	n.SizeBytes += size
+	n.ObjectCount++
+}
+
+func (n *UsageTreeNode) child(prefix string) *UsageTreeNode {
+	if n.byName == nil {
+		n.byName = map[string]*UsageTreeNode{}
+	}
+	child, ok := n.byName[prefix]
+	if !ok {
+		child = &UsageTreeNode{Prefix: prefix}
+		n.byName[prefix] = child
+	}
+	return child
+}
+
+// finish turns the collected sub-prefixes into sorted, capped Children
+func (n *UsageTreeNode) finish() {
+	for _, child := range n.byName {
+		child.finish()
+		n.Children = append(n.Children, child)
+	}
+	n.byName = nil
+	sort.Slice(n.Children, func(i, j int) bool {
+		if n.Children[i].SizeBytes != n.Children[j].SizeBytes {
+			return n.Children[i].SizeBytes > n.Children[j].SizeBytes
+		}
+		return n.Children[i].Prefix < n.Children[j].Prefix
+	})
+	if len(n.Children) > maxUsageTreeChildren {
+		n.OmittedChildren = len(n.Children) - maxUsageTreeChildren
+		n.Children = n.Children[:maxUsageTreeChildren]
+	}
+}
+
+func addBreakdown(entries map[string]*UsageBreakdown, name string, size int64) {
+	entry, ok := entries[name]
+	if !ok {
+		entry = &UsageBreakdown{Name: name}
+		entries[name] = entry
+	}
+	entry.SizeBytes += size
+	entry.ObjectCount++
+}
+
+func sortBreakdown(entries map[string]*UsageBreakdown) []UsageBreakdown {
+	sorted := make([]UsageBreakdown, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, *entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].SizeBytes != sorted[j].SizeBytes {
+			return sorted[i].SizeBytes > sorted[j].SizeBytes
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	if len(sorted) > maxUsageBreakdownEntries {
+		sorted = sorted[:maxUsageBreakdownEntries]
+	}
+	return sorted
+}
+
+// largeObjectHeap is a min-heap by size, so the smallest of the kept objects
+// is dropped first
+type largeObjectHeap []LargeObject
+
+func (h largeObjectHeap) Len() int           { return len(h) }
+func (h largeObjectHeap) Less(i, j int) bool { return h[i].SizeBytes < h[j].SizeBytes }
+func (h largeObjectHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *largeObjectHeap) Push(x any) { *h = append(*h, x.(LargeObject)) }
+
+func (h *largeObjectHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
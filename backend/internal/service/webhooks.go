@@ -0,0 +1,758 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"bucketbird/backend/internal/repository"
+	"bucketbird/backend/pkg/crypto"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types
+const (
+	WebhookObjectCreated = "object.created"
+	WebhookObjectDeleted = "object.deleted"
+	WebhookObjectRenamed = "object.renamed"
+	WebhookBucketDeleted = "bucket.deleted"
+	// WebhookBucketGrowthAlert is sent when the bucket's usage alert fires
+	WebhookBucketGrowthAlert = "bucket.growth_alert"
+	// WebhookPing is only sent by the test endpoint
+	WebhookPing = "ping"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []string{WebhookObjectCreated, WebhookObjectDeleted, WebhookObjectRenamed, WebhookBucketDeleted, WebhookBucketGrowthAlert}
+
+// Headers sent with every delivery
+const (
+	WebhookEventHeader     = "X-BucketBird-Event"
+	WebhookEventIDHeader   = "X-BucketBird-Event-ID"
+	WebhookDeliveryHeader  = "X-BucketBird-Delivery"
+	WebhookTimestampHeader = "X-BucketBird-Timestamp"
+	WebhookSignatureHeader = "X-BucketBird-Signature"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	maxWebhookKeyFilter = 1024
+	// Deliveries claimed per poll; each is sent concurrently
+	webhookBatchSize = 10
+	// First retry delay; it doubles with every failed attempt up to webhookMaxRetryDelay
+	webhookBaseRetryDelay = 30 * time.Second
+	webhookMaxRetryDelay  = 6 * time.Hour
+	// Only the start of the receiver's response is kept in the delivery log
+	maxWebhookResponseBody = 4096
+	defaultDeliveryLimit   = 50
+	maxDeliveryLimit       = 200
+	webhookCleanupInterval = time.Hour
+)
+
+// WebhookConfig controls how deliveries are sent
+type WebhookConfig struct {
+	// MaxAttempts is how often a delivery is tried before it is marked failed
+	MaxAttempts int
+	Timeout     time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback, private and link-local addresses
+	AllowPrivateNetworks bool
+	// DeliveryRetention is how long finished deliveries stay in the log; 0 keeps them forever
+	DeliveryRetention time.Duration
+}
+
+// WebhookService manages webhook subscriptions and sends their deliveries
+// from a queue in the database, so requests never wait on receivers. Bucket
+// changes reach it as domain events through HandleEvent.
+type WebhookService struct {
+	webhooks   repository.WebhookRepository
+	deliveries repository.WebhookDeliveryRepository
+	buckets    repository.BucketRepository
+	envelope   *crypto.Envelope
+	audit      *AuditService
+	config     WebhookConfig
+	client     *http.Client
+	logger     *slog.Logger
+}
+
+func NewWebhookService(
+	webhooks repository.WebhookRepository,
+	deliveries repository.WebhookDeliveryRepository,
+	buckets repository.BucketRepository,
+	envelope *crypto.Envelope,
+	audit *AuditService,
+	config WebhookConfig,
+	logger *slog.Logger,
+) *WebhookService {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		// Checked on the resolved address, so DNS names cannot point webhooks at internal services
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		}
+	}
+
+	return &WebhookService{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		buckets:    buckets,
+		envelope:   envelope,
+		audit:      audit,
+		config:     config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: config.Timeout,
+				MaxIdleConnsPerHost: 2,
+			},
+			// A redirect could lead anywhere, so it counts as a failed delivery
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+	}
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+type CreateWebhookInput struct {
+	BucketID  uuid.UUID
+	UserID    uuid.UUID
+	URL       string
+	Events    []string
+	KeyPrefix string
+	KeySuffix string
+}
+
+// Create adds a webhook to a bucket and returns it with its signing secret,
+// which is only available here
+func (s *WebhookService) Create(ctx context.Context, input CreateWebhookInput) (webhook *repository.Webhook, secret string, err error) {
+	defer func() {
+		entry := AuditEntry{Action: AuditWebhookCreate, BucketID: input.BucketID, Details: map[string]string{"url": input.URL}}
+		if webhook != nil {
+			entry.TargetID = webhook.ID.String()
+		}
+		s.audit.Record(ctx, entry, err)
+	}()
+
+	if err := s.checkBucket(ctx, input.BucketID, input.UserID); err != nil {
+		return nil, "", err
+	}
+
+	events, err := s.validate(input.URL, input.Events, input.KeyPrefix, input.KeySuffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := crypto.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	secret = webhookSecretPrefix + token
+	sealed, err := s.envelope.Seal(ctx, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	webhook, err = s.webhooks.Create(ctx, &repository.Webhook{
+		BucketID:  &input.BucketID,
+		URL:       strings.TrimSpace(input.URL),
+		Events:    events,
+		KeyPrefix: input.KeyPrefix,
+		KeySuffix: input.KeySuffix,
+		Secret:    sealed,
+		Active:    true,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return webhook, secret, nil
+}
+
+func (s *WebhookService) List(ctx context.Context, bucketID, userID uuid.UUID) ([]*repository.Webhook, error) {
+	if err := s.checkBucket(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+	return s.webhooks.List(ctx, bucketID)
+}
+
+func (s *WebhookService) Get(ctx context.Context, id, bucketID, userID uuid.UUID) (*repository.Webhook, error) {
+	if err := s.checkBucket(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+	return s.get(ctx, id, bucketID)
+}
+
+type UpdateWebhookInput struct {
+	ID        uuid.UUID
+	BucketID  uuid.UUID
+	UserID    uuid.UUID
+	URL       string
+	Events    []string
+	KeyPrefix string
+	KeySuffix string
+	Active    bool
+}
+
+func (s *WebhookService) Update(ctx context.Context, input UpdateWebhookInput) (webhook *repository.Webhook, err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:   AuditWebhookUpdate,
+			BucketID: input.BucketID,
+			TargetID: input.ID.String(),
+			Details:  map[string]string{"url": input.URL, "active": strconv.FormatBool(input.Active)},
+		}, err)
+	}()
+
+	if err := s.checkBucket(ctx, input.BucketID, input.UserID); err != nil {
+		return nil, err
+	}
+
+	events, err := s.validate(input.URL, input.Events, input.KeyPrefix, input.KeySuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err = s.webhooks.Update(ctx, &repository.Webhook{
+		ID:        input.ID,
+		BucketID:  &input.BucketID,
+		URL:       strings.TrimSpace(input.URL),
+		Events:    events,
+		KeyPrefix: input.KeyPrefix,
+		KeySuffix: input.KeySuffix,
+		Active:    input.Active,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Delete removes a webhook together with its pending deliveries and log
+func (s *WebhookService) Delete(ctx context.Context, id, bucketID, userID uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditWebhookDelete, BucketID: bucketID, TargetID: id.String()}, err)
+	}()
+
+	if err := s.checkBucket(ctx, bucketID, userID); err != nil {
+		return err
+	}
+	if _, err := s.get(ctx, id, bucketID); err != nil {
+		return err
+	}
+	return s.webhooks.Delete(ctx, id, bucketID)
+}
+
+// Ping sends a test delivery right away, once, and returns its logged result
+func (s *WebhookService) Ping(ctx context.Context, id, bucketID, userID uuid.UUID) (delivery *repository.WebhookDelivery, err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{Action: AuditWebhookPing, BucketID: bucketID, TargetID: id.String()}, err)
+	}()
+
+	bucket, err := s.buckets.Get(ctx, bucketID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrBucketNotFound
+		}
+		return nil, err
+	}
+	webhook, err := s.get(ctx, id, bucketID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID := uuid.New()
+	payload, err := json.Marshal(webhookPayload{
+		ID:         eventID.String(),
+		Event:      WebhookPing,
+		OccurredAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Bucket:     webhookBucket{ID: bucket.ID.String(), Name: bucket.Name},
+		Webhook:    &webhookRef{ID: webhook.ID.String(), Events: webhook.Events},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Leased like a claimed delivery, so the worker leaves it alone
+	delivery, err = s.deliveries.Create(ctx, &repository.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       eventID,
+		Event:         WebhookPing,
+		Payload:       payload,
+		NextAttemptAt: time.Now().Add(s.lease()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Pings are not retried; the caller sees the outcome immediately
+	attempt := s.send(ctx, webhook, delivery)
+	if attempt.Status == repository.WebhookDeliveryPending {
+		attempt.Status = repository.WebhookDeliveryFailed
+	}
+	if err := s.deliveries.RecordAttempt(ctx, attempt); err != nil {
+		return nil, err
+	}
+	return s.deliveries.Get(ctx, delivery.ID, webhook.ID)
+}
+
+// ListDeliveries returns a webhook's most recent deliveries; an empty status matches all
+func (s *WebhookService) ListDeliveries(ctx context.Context, id, bucketID, userID uuid.UUID, status string, limit int) ([]*repository.WebhookDelivery, error) {
+	switch status {
+	case "", repository.WebhookDeliveryPending, repository.WebhookDeliverySucceeded, repository.WebhookDeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: status must be pending, succeeded or failed", ErrInvalidWebhook)
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	if limit > maxDeliveryLimit {
+		limit = maxDeliveryLimit
+	}
+
+	if err := s.checkBucket(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.get(ctx, id, bucketID); err != nil {
+		return nil, err
+	}
+	return s.deliveries.List(ctx, id, status, limit)
+}
+
+// Redeliver queues a new delivery of the same event with the same event ID,
+// so receivers can tell it apart from a new event
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryID, id, bucketID, userID uuid.UUID) (delivery *repository.WebhookDelivery, err error) {
+	defer func() {
+		s.audit.Record(ctx, AuditEntry{
+			Action:   AuditWebhookRedeliver,
+			BucketID: bucketID,
+			TargetID: id.String(),
+			Details:  map[string]string{"deliveryId": deliveryID.String()},
+		}, err)
+	}()
+
+	if err := s.checkBucket(ctx, bucketID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.get(ctx, id, bucketID); err != nil {
+		return nil, err
+	}
+
+	original, err := s.deliveries.Get(ctx, deliveryID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	return s.deliveries.Create(ctx, &repository.WebhookDelivery{
+		WebhookID:    original.WebhookID,
+		EventID:      original.EventID,
+		Event:        original.Event,
+		Payload:      original.Payload,
+		RedeliveryOf: &original.ID,
+	})
+}
+
+// webhookEvent is a change in a bucket that webhooks may subscribe to
+type webhookEvent struct {
+	Type string
+	// Key is the object key; for renames it is the new key
+	Key string
+	// PreviousKey is the old key of a renamed object
+	PreviousKey string
+	Details     map[string]string
+}
+
+type webhookPayload struct {
+	ID         string         `json:"id"`
+	Event      string         `json:"event"`
+	OccurredAt string         `json:"occurredAt"`
+	Bucket     webhookBucket  `json:"bucket"`
+	Object     *webhookObject `json:"object,omitempty"`
+	Webhook    *webhookRef    `json:"webhook,omitempty"`
+	// Details describe bucket events, such as the growth behind an alert
+	Details map[string]string `json:"details,omitempty"`
+}
+
+type webhookBucket struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type webhookObject struct {
+	Key         string `json:"key"`
+	PreviousKey string `json:"previousKey,omitempty"`
+}
+
+type webhookRef struct {
+	ID     string   `json:"id"`
+	Events []string `json:"events"`
+}
+
+// WebhookEventTypes are the domain events HandleEvent turns into webhook events
+var WebhookEventTypes = []string{
+	EventObjectUploaded, EventObjectCopied, EventFolderCreated,
+	EventObjectsDeleted, EventObjectsRenamed, EventBucketDeleted, EventBucketGrowthExceeded,
+}
+
+// webhookEvents splits a domain event into one webhook event per object
+func webhookEvents(event DomainEvent) []webhookEvent {
+	var events []webhookEvent
+	switch event.Type {
+	case EventObjectUploaded, EventObjectCopied, EventFolderCreated:
+		for _, key := range event.Keys {
+			events = append(events, webhookEvent{Type: WebhookObjectCreated, Key: key})
+		}
+	case EventObjectsDeleted:
+		for _, key := range event.Keys {
+			events = append(events, webhookEvent{Type: WebhookObjectDeleted, Key: key})
+		}
+	case EventObjectsRenamed:
+		for i, key := range event.Keys {
+			if i < len(event.PreviousKeys) {
+				events = append(events, webhookEvent{Type: WebhookObjectRenamed, Key: key, PreviousKey: event.PreviousKeys[i]})
+			}
+		}
+	case EventBucketDeleted:
+		events = append(events, webhookEvent{Type: WebhookBucketDeleted})
+	case EventBucketGrowthExceeded:
+		events = append(events, webhookEvent{Type: WebhookBucketGrowthAlert, Details: event.Details})
+	}
+	return events
+}
+
+// HandleEvent queues a delivery to every matching webhook of the event's
+// bucket. Webhook event IDs are derived from the domain event, and each is
+// queued once per webhook, so handling an event again sends nothing twice.
+func (s *WebhookService) HandleEvent(ctx context.Context, event DomainEvent) error {
+	subscribers := map[string][]*repository.Webhook{}
+	for _, we := range webhookEvents(event) {
+		webhooks, ok := subscribers[we.Type]
+		if !ok {
+			var err error
+			webhooks, err = s.webhooks.ListForEvent(ctx, event.BucketID, we.Type)
+			if err != nil {
+				return err
+			}
+			subscribers[we.Type] = webhooks
+		}
+		if len(webhooks) == 0 {
+			continue
+		}
+
+		eventID := uuid.NewSHA1(event.ID, []byte(we.Type+"\x00"+we.Key))
+		payload := webhookPayload{
+			ID:         eventID.String(),
+			Event:      we.Type,
+			OccurredAt: event.OccurredAt.UTC().Format(time.RFC3339Nano),
+			Bucket:     webhookBucket{ID: event.BucketID.String(), Name: event.BucketName},
+			Details:    we.Details,
+		}
+		if we.Key != "" {
+			payload.Object = &webhookObject{Key: we.Key, PreviousKey: we.PreviousKey}
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		for _, webhook := range webhooks {
+			if !webhookMatches(webhook, we) {
+				continue
+			}
+			if err := s.deliveries.Enqueue(ctx, &repository.WebhookDelivery{
+				WebhookID: webhook.ID,
+				EventID:   eventID,
+				Event:     we.Type,
+				Payload:   encoded,
+			}); err != nil {
+				return fmt.Errorf("queue delivery for webhook %s: %w", webhook.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// webhookMatches applies the key filters. Bucket events have no key and
+// always match; a rename matches if either its old or new key does.
+func webhookMatches(webhook *repository.Webhook, event webhookEvent) bool {
+	if event.Key == "" {
+		return true
+	}
+	matches := func(key string) bool {
+		return key != "" && strings.HasPrefix(key, webhook.KeyPrefix) && strings.HasSuffix(key, webhook.KeySuffix)
+	}
+	return matches(event.Key) || matches(event.PreviousKey)
+}
+
+// Run sends due deliveries every interval until ctx is done. Several
+// instances can run it at once; each delivery is claimed by one of them.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+
+	for {
+		for s.processBatch(ctx) == webhookBatchSize {
+			// A full batch means more deliveries are probably waiting
+		}
+
+		if time.Since(lastCleanup) >= webhookCleanupInterval {
+			s.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processBatch claims and sends one batch of deliveries and returns its size
+func (s *WebhookService) processBatch(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	// A worker that dies mid-delivery releases it when the lease runs out
+	leaseUntil := time.Now().Add(s.lease())
+	deliveries, err := s.deliveries.Claim(ctx, webhookBatchSize, leaseUntil)
+	if err != nil {
+		s.logger.Error("failed to claim webhook deliveries", slog.Any("error", err))
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *repository.WebhookDelivery) {
+			defer wg.Done()
+			s.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+	return len(deliveries)
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery *repository.WebhookDelivery) {
+	webhook, err := s.webhooks.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		// A deleted webhook takes its deliveries with it
+		if !errors.Is(err, repository.ErrNotFound) {
+			s.logger.Error("failed to load webhook", slog.String("webhook_id", delivery.WebhookID.String()), slog.Any("error", err))
+		}
+		return
+	}
+
+	var attempt repository.WebhookAttempt
+	if !webhook.Active {
+		attempt = repository.WebhookAttempt{
+			DeliveryID:    delivery.ID,
+			Status:        repository.WebhookDeliveryFailed,
+			NextAttemptAt: time.Now(),
+			Error:         "webhook is disabled",
+		}
+	} else {
+		attempt = s.send(ctx, webhook, delivery)
+	}
+
+	// The result is recorded even during shutdown, so the delivery is not sent twice
+	recordCtx := context.WithoutCancel(ctx)
+	if err := s.deliveries.RecordAttempt(recordCtx, attempt); err != nil {
+		s.logger.Error("failed to record webhook attempt", slog.String("delivery_id", delivery.ID.String()), slog.Any("error", err))
+		return
+	}
+	if attempt.Status == repository.WebhookDeliveryFailed {
+		s.logger.Warn("webhook delivery failed",
+			slog.String("webhook_id", webhook.ID.String()),
+			slog.String("delivery_id", delivery.ID.String()),
+			slog.Int("attempts", delivery.Attempts+1),
+			slog.String("error", attempt.Error),
+		)
+	}
+}
+
+// send makes one delivery attempt and works out what happens next
+func (s *WebhookService) send(ctx context.Context, webhook *repository.Webhook, delivery *repository.WebhookDelivery) repository.WebhookAttempt {
+	attempt := repository.WebhookAttempt{DeliveryID: delivery.ID}
+
+	status, body, err := s.post(ctx, webhook, delivery)
+	attempt.ResponseStatus = status
+	attempt.ResponseBody = body
+	if err == nil {
+		attempt.Status = repository.WebhookDeliverySucceeded
+		attempt.NextAttemptAt = time.Now()
+		return attempt
+	}
+
+	attempt.Error = err.Error()
+	attempts := delivery.Attempts + 1
+	if attempts >= s.config.MaxAttempts {
+		attempt.Status = repository.WebhookDeliveryFailed
+		attempt.NextAttemptAt = time.Now()
+		return attempt
+	}
+	attempt.Status = repository.WebhookDeliveryPending
+	attempt.NextAttemptAt = time.Now().Add(webhookRetryDelay(attempts))
+	return attempt
+}
+
+// lease is how long a delivery being sent is hidden from other workers
+func (s *WebhookService) lease() time.Duration {
+	return 2*s.config.Timeout + time.Minute
+}
+
+// webhookRetryDelay is the wait after the given number of failed attempts
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookBaseRetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxRetryDelay)
+}
+
+func (s *WebhookService) post(ctx context.Context, webhook *repository.Webhook, delivery *repository.WebhookDelivery) (int, string, error) {
+	secret, err := s.envelope.Open(ctx, webhook.Secret)
+	if err != nil {
+		return 0, "", fmt.Errorf("decrypt signing secret: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(string(delivery.Payload)))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BucketBird-Webhook/1")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookEventIDHeader, delivery.EventID.String())
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(body), fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of "<timestamp>.<payload>",
+// which receivers recompute to check the X-BucketBird-Signature header
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// cleanup removes old finished deliveries and webhooks of deleted buckets
+func (s *WebhookService) cleanup(ctx context.Context) {
+	if s.config.DeliveryRetention > 0 {
+		deleted, err := s.deliveries.DeleteFinishedBefore(ctx, time.Now().Add(-s.config.DeliveryRetention))
+		if err != nil {
+			s.logger.Error("failed to prune webhook deliveries", slog.Any("error", err))
+		} else if deleted > 0 {
+			s.logger.Info("pruned webhook deliveries", slog.Int64("deleted", deleted))
+		}
+	}
+
+	if _, err := s.webhooks.DeleteOrphaned(ctx); err != nil {
+		s.logger.Error("failed to remove webhooks of deleted buckets", slog.Any("error", err))
+	}
+}
+
+func (s *WebhookService) checkBucket(ctx context.Context, bucketID, userID uuid.UUID) error {
+	if _, err := s.buckets.Get(ctx, bucketID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrBucketNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *WebhookService) get(ctx context.Context, id, bucketID uuid.UUID) (*repository.Webhook, error) {
+	webhook, err := s.webhooks.Get(ctx, id, bucketID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// validate checks a webhook's settings and returns its events without duplicates
+func (s *WebhookService) validate(rawURL string, events []string, keyPrefix, keySuffix string) ([]string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !s.config.AllowPrivateNetworks && isPrivateIP(ip) {
+		return nil, fmt.Errorf("%w: url must not point to a private address", ErrInvalidWebhook)
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: at least one event is required", ErrInvalidWebhook)
+	}
+	unique := make([]string, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(WebhookEvents, event) {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+		if !slices.Contains(unique, event) {
+			unique = append(unique, event)
+		}
+	}
+
+	if len(keyPrefix) > maxWebhookKeyFilter || len(keySuffix) > maxWebhookKeyFilter {
+		return nil, fmt.Errorf("%w: key filters must be at most %d bytes", ErrInvalidWebhook, maxWebhookKeyFilter)
+	}
+	return unique, nil
+}
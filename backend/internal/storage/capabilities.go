@@ -0,0 +1,63 @@
+package storage
+
+import "strings"
+
+// Bucket and object APIs that not every S3-compatible provider implements
+const (
+	FeatureCORS              = "cors"
+	FeaturePolicy            = "policy"
+	FeaturePublicAccessBlock = "publicAccessBlock"
+	FeatureObjectTagging     = "objectTagging"
+)
+
+// Capabilities reports which of those APIs a provider implements
+type Capabilities struct {
+	CORS              bool `json:"cors"`
+	Policy            bool `json:"policy"`
+	PublicAccessBlock bool `json:"publicAccessBlock"`
+	ObjectTagging     bool `json:"objectTagging"`
+}
+
+// Supports reports whether the provider implements one of the Feature APIs
+func (c Capabilities) Supports(feature string) bool {
+	switch feature {
+	case FeatureCORS:
+		return c.CORS
+	case FeaturePolicy:
+		return c.Policy
+	case FeaturePublicAccessBlock:
+		return c.PublicAccessBlock
+	case FeatureObjectTagging:
+		return c.ObjectTagging
+	}
+	return false
+}
+
+// knownProviders lists providers whose S3 APIs lack some features. A
+// credential's free-text provider is matched against the names, ignoring case.
+var knownProviders = []struct {
+	names        []string
+	capabilities Capabilities
+}{
+	{[]string{"Cloudflare R2", "cloudflare", "r2"}, Capabilities{CORS: true}},
+	{[]string{"Backblaze B2", "backblaze", "b2"}, Capabilities{CORS: true, ObjectTagging: true}},
+	{[]string{"Wasabi"}, Capabilities{CORS: true, Policy: true, ObjectTagging: true}},
+	{[]string{"DigitalOcean Spaces", "digitalocean", "do spaces", "spaces"}, Capabilities{CORS: true, Policy: true, ObjectTagging: true}},
+	// MinIO only applies CORS settings from its server configuration
+	{[]string{"MinIO"}, Capabilities{Policy: true, ObjectTagging: true}},
+}
+
+// ProviderCapabilities returns the display name and capabilities of a
+// provider. Other providers, such as AWS S3, are assumed to implement every
+// feature; a NotImplemented error then tells otherwise.
+func ProviderCapabilities(provider string) (string, Capabilities) {
+	key := strings.Join(strings.Fields(strings.ToLower(provider)), " ")
+	for _, known := range knownProviders {
+		for _, name := range known.names {
+			if strings.ToLower(name) == key {
+				return known.names[0], known.capabilities
+			}
+		}
+	}
+	return strings.TrimSpace(provider), Capabilities{CORS: true, Policy: true, PublicAccessBlock: true, ObjectTagging: true}
+}
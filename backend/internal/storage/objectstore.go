@@ -3,11 +3,13 @@ package storage
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,12 +18,26 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 )
 
+// Kinds of billable requests. Providers group them into price classes
+// differently, e.g. AWS bills listings like writes while B2 does not.
+const (
+	RequestWrite = "write"
+	RequestList  = "list"
+	RequestRead  = "read"
+)
+
+// RequestMeter is told about every billable request an ObjectStore sends,
+// with the bytes it downloaded for reads
+type RequestMeter func(kind string, egressBytes int64)
+
 type ObjectStore struct {
 	client             *s3.Client
 	presignClient      *s3.PresignClient
 	bucketNamingPrefix string
+	meter              RequestMeter
 }
 
 type ObjectStoreConfig struct {
@@ -93,6 +109,18 @@ func NewObjectStoreWithCredentials(ctx context.Context, endpoint, region, access
 	})
 }
 
+// SetRequestMeter reports the store's object requests to meter. Bucket-level
+// calls and presigned URLs are not metered.
+func (o *ObjectStore) SetRequestMeter(meter RequestMeter) {
+	o.meter = meter
+}
+
+func (o *ObjectStore) record(kind string, egressBytes int64) {
+	if o.meter != nil {
+		o.meter(kind, egressBytes)
+	}
+}
+
 func (o *ObjectStore) TestConnection(ctx context.Context) error {
 	// Try to list buckets as a simple connection test
 	_, err := o.client.ListBuckets(ctx, &s3.ListBucketsInput{})
@@ -152,7 +180,123 @@ func (o *ObjectStore) DeleteBucket(ctx context.Context, name string) error {
 	return err
 }
 
+// APIError returns the error code and message of an error response from the
+// provider, such as InvalidArgument or NotImplemented
+func APIError(err error) (code, message string, ok bool) {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return "", "", false
+	}
+	return apiErr.ErrorCode(), apiErr.ErrorMessage(), true
+}
+
+// GetBucketLifecycle returns the bucket's lifecycle rules, or none if it has no configuration
+func (o *ObjectStore) GetBucketLifecycle(ctx context.Context, bucket string) ([]types.LifecycleRule, error) {
+	out, err := o.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if code, _, ok := APIError(err); ok && code == "NoSuchLifecycleConfiguration" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out.Rules, nil
+}
+
+// PutBucketLifecycle replaces the bucket's lifecycle configuration
+func (o *ObjectStore) PutBucketLifecycle(ctx context.Context, bucket string, rules []types.LifecycleRule) error {
+	_, err := o.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucket),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: rules},
+	})
+	return err
+}
+
+func (o *ObjectStore) DeleteBucketLifecycle(ctx context.Context, bucket string) error {
+	_, err := o.client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: aws.String(bucket)})
+	return err
+}
+
+// GetBucketCors returns the bucket's CORS rules, or none if it has no configuration
+func (o *ObjectStore) GetBucketCors(ctx context.Context, bucket string) ([]types.CORSRule, error) {
+	out, err := o.client.GetBucketCors(ctx, &s3.GetBucketCorsInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if code, _, ok := APIError(err); ok && code == "NoSuchCORSConfiguration" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out.CORSRules, nil
+}
+
+// PutBucketCors replaces the bucket's CORS configuration
+func (o *ObjectStore) PutBucketCors(ctx context.Context, bucket string, rules []types.CORSRule) error {
+	_, err := o.client.PutBucketCors(ctx, &s3.PutBucketCorsInput{
+		Bucket:            aws.String(bucket),
+		CORSConfiguration: &types.CORSConfiguration{CORSRules: rules},
+	})
+	return err
+}
+
+func (o *ObjectStore) DeleteBucketCors(ctx context.Context, bucket string) error {
+	_, err := o.client.DeleteBucketCors(ctx, &s3.DeleteBucketCorsInput{Bucket: aws.String(bucket)})
+	return err
+}
+
+// GetBucketPolicy returns the bucket's policy document, or "" if it has none
+func (o *ObjectStore) GetBucketPolicy(ctx context.Context, bucket string) (string, error) {
+	out, err := o.client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if code, _, ok := APIError(err); ok && code == "NoSuchBucketPolicy" {
+			return "", nil
+		}
+		return "", err
+	}
+	return aws.ToString(out.Policy), nil
+}
+
+func (o *ObjectStore) PutBucketPolicy(ctx context.Context, bucket, policy string) error {
+	_, err := o.client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucket),
+		Policy: aws.String(policy),
+	})
+	return err
+}
+
+func (o *ObjectStore) DeleteBucketPolicy(ctx context.Context, bucket string) error {
+	_, err := o.client.DeleteBucketPolicy(ctx, &s3.DeleteBucketPolicyInput{Bucket: aws.String(bucket)})
+	return err
+}
+
+// GetPublicAccessBlock returns the bucket's public access block settings, or
+// nil if it has none
+func (o *ObjectStore) GetPublicAccessBlock(ctx context.Context, bucket string) (*types.PublicAccessBlockConfiguration, error) {
+	out, err := o.client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if code, _, ok := APIError(err); ok && code == "NoSuchPublicAccessBlockConfiguration" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out.PublicAccessBlockConfiguration, nil
+}
+
+func (o *ObjectStore) PutPublicAccessBlock(ctx context.Context, bucket string, config types.PublicAccessBlockConfiguration) error {
+	_, err := o.client.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+		Bucket:                         aws.String(bucket),
+		PublicAccessBlockConfiguration: &config,
+	})
+	return err
+}
+
+func (o *ObjectStore) DeletePublicAccessBlock(ctx context.Context, bucket string) error {
+	_, err := o.client.DeletePublicAccessBlock(ctx, &s3.DeletePublicAccessBlockInput{Bucket: aws.String(bucket)})
+	return err
+}
+
 func (o *ObjectStore) ListObjects(ctx context.Context, bucket string, prefix string) ([]types.Object, error) {
+	o.record(RequestList, 0)
 	out, err := o.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
 		Bucket: aws.String(bucket),
 		Prefix: aws.String(prefix),
@@ -169,11 +313,17 @@ type PresignInput struct {
 	Method      string
 	ExpiresIn   time.Duration
 	ContentType *string
+	// ContentLength, if set, is signed into PUT URLs so uploads must have exactly this size
+	ContentLength *int64
+	// Tags, if set, are signed into PUT URLs as the x-amz-tagging header
+	Tags map[string]string
 }
 
 type PresignOutput struct {
 	URL    string
 	Method string
+	// Headers must be sent with the request as they were signed
+	Headers map[string]string
 }
 
 func (o *ObjectStore) PresignObject(ctx context.Context, input PresignInput) (PresignOutput, error) {
@@ -184,17 +334,25 @@ func (o *ObjectStore) PresignObject(ctx context.Context, input PresignInput) (Pr
 	method := strings.ToUpper(input.Method)
 	switch method {
 	case http.MethodPut:
-		req, err := o.presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
-			Bucket:      aws.String(input.Bucket),
-			Key:         aws.String(input.Key),
-			ContentType: input.ContentType,
-		}, func(opts *s3.PresignOptions) {
+		put := &s3.PutObjectInput{
+			Bucket:        aws.String(input.Bucket),
+			Key:           aws.String(input.Key),
+			ContentType:   input.ContentType,
+			ContentLength: input.ContentLength,
+		}
+		var headers map[string]string
+		if len(input.Tags) > 0 {
+			// The signer cannot move x-amz-tagging into the query string
+			put.Tagging = aws.String(EncodeTagging(input.Tags))
+			headers = map[string]string{"x-amz-tagging": *put.Tagging}
+		}
+		req, err := o.presignClient.PresignPutObject(ctx, put, func(opts *s3.PresignOptions) {
 			opts.Expires = input.ExpiresIn
 		})
 		if err != nil {
 			return PresignOutput{}, err
 		}
-		return PresignOutput{URL: req.URL, Method: http.MethodPut}, nil
+		return PresignOutput{URL: req.URL, Method: http.MethodPut, Headers: headers}, nil
 	case http.MethodGet:
 		req, err := o.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 			Bucket: aws.String(input.Bucket),
@@ -212,6 +370,7 @@ func (o *ObjectStore) PresignObject(ctx context.Context, input PresignInput) (Pr
 }
 
 func (o *ObjectStore) PutEmptyObject(ctx context.Context, bucket, key string, contentType *string) error {
+	o.record(RequestWrite, 0)
 	_, err := o.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(bucket),
 		Key:         aws.String(key),
@@ -248,20 +407,92 @@ func (o *ObjectStore) DeleteObjects(ctx context.Context, bucket string, keys []s
 }
 
 func (o *ObjectStore) HeadObject(ctx context.Context, bucket, key string) (*s3.HeadObjectOutput, error) {
+	o.record(RequestRead, 0)
 	return o.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
 }
 
+// GetObjectTagging returns the object's tags
+func (o *ObjectStore) GetObjectTagging(ctx context.Context, bucket, key string) (map[string]string, error) {
+	o.record(RequestRead, 0)
+	out, err := o.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(out.TagSet))
+	for _, tag := range out.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// PutObjectTagging replaces the object's tags
+func (o *ObjectStore) PutObjectTagging(ctx context.Context, bucket, key string, tags map[string]string) error {
+	tagSet := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	sort.Slice(tagSet, func(i, j int) bool { return aws.ToString(tagSet[i].Key) < aws.ToString(tagSet[j].Key) })
+
+	o.record(RequestWrite, 0)
+	_, err := o.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: tagSet},
+	})
+	return err
+}
+
+func (o *ObjectStore) DeleteObjectTagging(ctx context.Context, bucket, key string) error {
+	o.record(RequestWrite, 0)
+	_, err := o.client.DeleteObjectTagging(ctx, &s3.DeleteObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// EncodeTagging formats tags for the x-amz-tagging header: URL query
+// parameters, sorted by key, with spaces as %20
+func EncodeTagging(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = escapeTag(key) + "=" + escapeTag(tags[key])
+	}
+	return strings.Join(pairs, "&")
+}
+
+func escapeTag(value string) string {
+	// QueryEscape writes spaces as +, which it escapes in the value itself
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
 func (o *ObjectStore) GetObject(ctx context.Context, bucket, key string) (*s3.GetObjectOutput, error) {
-	return o.client.GetObject(ctx, &s3.GetObjectInput{
+	out, err := o.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
+	if err != nil {
+		o.record(RequestRead, 0)
+		return nil, err
+	}
+	o.record(RequestRead, aws.ToInt64(out.ContentLength))
+	return out, nil
 }
 
-func (o *ObjectStore) PutObject(ctx context.Context, bucket, key string, body io.Reader, contentType string) error {
+// PutObject uploads body; tags, if any, are stored with the object
+func (o *ObjectStore) PutObject(ctx context.Context, bucket, key string, body io.Reader, contentType string, tags map[string]string) error {
 	input := &s3.PutObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
@@ -269,6 +500,9 @@ func (o *ObjectStore) PutObject(ctx context.Context, bucket, key string, body io
 	if contentType != "" {
 		input.ContentType = aws.String(contentType)
 	}
+	if len(tags) > 0 {
+		input.Tagging = aws.String(EncodeTagging(tags))
+	}
 
 	var (
 		tmpFile *os.File
@@ -303,6 +537,7 @@ func (o *ObjectStore) PutObject(ctx context.Context, bucket, key string, body io
 		input.ContentLength = aws.Int64(size)
 	}
 
+	o.record(RequestWrite, 0)
 	_, err = o.client.PutObject(ctx, input)
 	return err
 }
@@ -310,6 +545,7 @@ func (o *ObjectStore) PutObject(ctx context.Context, bucket, key string, body io
 func (o *ObjectStore) CopyObject(ctx context.Context, bucket, sourceKey, destinationKey string) error {
 	escapedKey := strings.ReplaceAll(url.PathEscape(sourceKey), "%2F", "/")
 	copySource := fmt.Sprintf("%s/%s", bucket, escapedKey)
+	o.record(RequestWrite, 0)
 	_, err := o.client.CopyObject(ctx, &s3.CopyObjectInput{
 		Bucket:     aws.String(bucket),
 		CopySource: aws.String(copySource),
@@ -322,6 +558,7 @@ func (o *ObjectStore) ListAllObjects(ctx context.Context, bucket, prefix string)
 	var result []types.Object
 	var continuationToken *string
 	for {
+		o.record(RequestList, 0)
 		out, err := o.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
 			Bucket:            aws.String(bucket),
 			Prefix:            aws.String(prefix),
@@ -340,11 +577,56 @@ func (o *ObjectStore) ListAllObjects(ctx context.Context, bucket, prefix string)
 	return result, nil
 }
 
-// CalculateBucketSize calculates the total size of all objects in a bucket
-func (o *ObjectStore) CalculateBucketSize(ctx context.Context, bucket string) (int64, error) {
+// ObjectSummary is an object as returned by a bucket listing
+type ObjectSummary struct {
+	Key          string
+	Size         int64
+	StorageClass string
+	LastModified time.Time
+}
+
+// WalkObjects calls fn for every object under prefix, one listing page at a
+// time, so buckets of any size can be scanned without holding them in memory.
+// An error from fn stops the walk and is returned.
+func (o *ObjectStore) WalkObjects(ctx context.Context, bucket, prefix string, fn func(ObjectSummary) error) error {
+	var continuationToken *string
+	for {
+		o.record(RequestList, 0)
+		out, err := o.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(bucket),
+			Prefix:            aws.String(prefix),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return err
+		}
+		for _, obj := range out.Contents {
+			storageClass := string(obj.StorageClass)
+			if storageClass == "" {
+				storageClass = string(types.ObjectStorageClassStandard)
+			}
+			if err := fn(ObjectSummary{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				StorageClass: storageClass,
+				LastModified: aws.ToTime(obj.LastModified),
+			}); err != nil {
+				return err
+			}
+		}
+		if out.IsTruncated != nil && *out.IsTruncated && out.NextContinuationToken != nil {
+			continuationToken = out.NextContinuationToken
+			continue
+		}
+		return nil
+	}
+}
+
+// CalculateBucketUsage calculates the total size and number of all objects in a bucket
+func (o *ObjectStore) CalculateBucketUsage(ctx context.Context, bucket string) (int64, int64, error) {
 	objects, err := o.ListAllObjects(ctx, bucket, "")
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	var totalSize int64
@@ -354,5 +636,55 @@ func (o *ObjectStore) CalculateBucketSize(ctx context.Context, bucket string) (i
 		}
 	}
 
-	return totalSize, nil
+	return totalSize, int64(len(objects)), nil
+}
+
+// PrefixUsage is the size and number of the objects under a prefix
+type PrefixUsage struct {
+	Prefix      string
+	SizeBytes   int64
+	ObjectCount int64
+}
+
+// CalculatePrefixUsage lists a bucket once and returns its total usage and
+// the usage of each top-level prefix, largest first. Objects at the root
+// only count towards the total.
+func (o *ObjectStore) CalculatePrefixUsage(ctx context.Context, bucket string) (PrefixUsage, []PrefixUsage, error) {
+	objects, err := o.ListAllObjects(ctx, bucket, "")
+	if err != nil {
+		return PrefixUsage{}, nil, err
+	}
+
+	total := PrefixUsage{ObjectCount: int64(len(objects))}
+	byPrefix := map[string]*PrefixUsage{}
+	for _, obj := range objects {
+		size := aws.ToInt64(obj.Size)
+		total.SizeBytes += size
+
+		key := aws.ToString(obj.Key)
+		slash := strings.Index(key, "/")
+		if slash < 0 {
+			continue
+		}
+		prefix := key[:slash+1]
+		usage, ok := byPrefix[prefix]
+		if !ok {
+			usage = &PrefixUsage{Prefix: prefix}
+			byPrefix[prefix] = usage
+		}
+		usage.SizeBytes += size
+		usage.ObjectCount++
+	}
+
+	prefixes := make([]PrefixUsage, 0, len(byPrefix))
+	for _, usage := range byPrefix {
+		prefixes = append(prefixes, *usage)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if prefixes[i].SizeBytes != prefixes[j].SizeBytes {
+			return prefixes[i].SizeBytes > prefixes[j].SizeBytes
+		}
+		return prefixes[i].Prefix < prefixes[j].Prefix
+	})
+	return total, prefixes, nil
 }
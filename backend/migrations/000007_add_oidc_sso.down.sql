@@ -0,0 +1,8 @@
+-- Drop oidc_login_requests table
+DROP TABLE IF EXISTS oidc_login_requests;
+
+-- Drop user_identities table
+DROP TABLE IF EXISTS user_identities;
+
+-- Remove is_admin column from users table
+ALTER TABLE users DROP COLUMN is_admin;
@@ -0,0 +1,10 @@
+-- Drop instance_settings table
+DROP TABLE IF EXISTS instance_settings;
+
+-- Drop recovery_codes table
+DROP TABLE IF EXISTS recovery_codes;
+
+-- Remove TOTP columns from users table
+ALTER TABLE users DROP COLUMN totp_last_step;
+ALTER TABLE users DROP COLUMN totp_enabled_at;
+ALTER TABLE users DROP COLUMN totp_secret;
@@ -0,0 +1,5 @@
+-- Remove session metadata
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS ip_address,
+    DROP COLUMN IF EXISTS user_agent;
@@ -0,0 +1,2 @@
+-- Drop retired_refresh_tokens table
+DROP TABLE IF EXISTS retired_refresh_tokens;
@@ -0,0 +1,13 @@
+-- Refresh tokens that were rotated or revoked. Each session is a token family;
+-- presenting a retired token again revokes the whole family.
+CREATE TABLE retired_refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    -- The session may already be deleted, so there is no foreign key
+    session_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    retired_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX retired_refresh_tokens_expires_at_idx ON retired_refresh_tokens(expires_at);
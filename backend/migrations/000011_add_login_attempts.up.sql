@@ -0,0 +1,12 @@
+-- Track failed logins per account identifier (normalized email or directory username).
+-- Rows exist for unknown identifiers too, so lockouts do not reveal which accounts exist.
+CREATE TABLE login_attempts (
+    identifier TEXT PRIMARY KEY,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_ip_address TEXT NOT NULL DEFAULT '',
+    locked_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX login_attempts_locked_until_idx ON login_attempts(locked_until);
@@ -0,0 +1,4 @@
+-- Drop invites, team_members and teams tables
+DROP TABLE IF EXISTS invites;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
@@ -0,0 +1,37 @@
+-- Create teams table
+CREATE TABLE teams (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create team_members table; role is 'owner' or 'member'
+CREATE TABLE team_members (
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX team_members_user_id_idx ON team_members(user_id);
+
+-- Create invites table for invite-only registration. Codes are stored hashed.
+-- max_uses = 0 means the code can be used any number of times.
+CREATE TABLE invites (
+    id UUID PRIMARY KEY,
+    code_hash TEXT NOT NULL UNIQUE,
+    code_prefix TEXT NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT,
+    team_id UUID REFERENCES teams(id) ON DELETE CASCADE,
+    max_uses INTEGER NOT NULL DEFAULT 1,
+    use_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX invites_created_by_idx ON invites(created_by);
+CREATE INDEX invites_team_id_idx ON invites(team_id);
@@ -0,0 +1,3 @@
+-- Drop email_tokens table and verification column
+DROP TABLE IF EXISTS email_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,20 @@
+-- Track when a user's email address was confirmed.
+-- Existing accounts predate verification and are treated as verified.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+UPDATE users SET email_verified_at = created_at;
+
+-- Single-use tokens sent by email for address verification and password resets.
+-- Only a hash of the token is stored. For verification tokens, email is the
+-- address being confirmed, which differs from users.email during an email change.
+CREATE TABLE email_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('verify_email', 'password_reset')),
+    token_hash TEXT NOT NULL UNIQUE,
+    email TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX email_tokens_user_id_idx ON email_tokens(user_id, purpose);
+CREATE INDEX email_tokens_expires_at_idx ON email_tokens(expires_at);
@@ -0,0 +1,5 @@
+-- Envelope encryption: each credential's keys are encrypted with a random data
+-- key, stored here wrapped by the configured key provider (local keyring or
+-- Vault Transit). NULL marks credentials encrypted directly with the keyring,
+-- which `bucketbird keys rotate` converts.
+ALTER TABLE credentials ADD COLUMN encrypted_data_key TEXT;
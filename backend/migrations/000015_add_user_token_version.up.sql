@@ -0,0 +1,3 @@
+-- Access tokens carry the user's token version; bumping it invalidates every
+-- outstanding access token for the user at once.
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Drop the audit log
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS audit_events_reject_update();
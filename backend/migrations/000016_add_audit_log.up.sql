@@ -0,0 +1,38 @@
+-- Append-only record of user and admin actions. Actor and bucket IDs are not
+-- foreign keys, so entries outlive the users and buckets they mention; names
+-- are copied in for the same reason. Rows are only ever deleted by retention.
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_id UUID,
+    actor_email TEXT,
+    -- Set when the action was authenticated with a personal access token
+    token_id UUID,
+    ip_address TEXT,
+    user_agent TEXT,
+    request_id TEXT,
+    action TEXT NOT NULL,
+    bucket_id UUID,
+    bucket_name TEXT,
+    object_key TEXT,
+    -- ID of a non-bucket target such as a credential
+    target_id TEXT,
+    details JSONB NOT NULL DEFAULT '{}',
+    outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure')),
+    error TEXT
+);
+
+CREATE INDEX audit_events_occurred_at_idx ON audit_events(occurred_at);
+CREATE INDEX audit_events_actor_id_idx ON audit_events(actor_id, id);
+CREATE INDEX audit_events_bucket_id_idx ON audit_events(bucket_id, id);
+CREATE INDEX audit_events_action_idx ON audit_events(action, id);
+
+CREATE FUNCTION audit_events_reject_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+BEFORE UPDATE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_reject_update();
@@ -0,0 +1,41 @@
+-- Outbound webhook subscriptions. bucket_id is cleared rather than cascaded
+-- when the bucket is deleted, so its bucket.deleted delivery can still be sent;
+-- the delivery worker removes such webhooks once nothing is pending.
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY,
+    bucket_id UUID REFERENCES buckets(id) ON DELETE SET NULL,
+    url TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    key_prefix TEXT NOT NULL DEFAULT '',
+    key_suffix TEXT NOT NULL DEFAULT '',
+    -- Signing secret, sealed with the envelope key provider
+    secret TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhooks_bucket_id_idx ON webhooks(bucket_id);
+
+-- Delivery queue and log. Pending rows are claimed by the delivery worker;
+-- finished rows are kept as the delivery log until retention removes them.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ,
+    response_status INTEGER,
+    response_body TEXT,
+    error TEXT,
+    redelivery_of UUID REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries(webhook_id, created_at DESC);
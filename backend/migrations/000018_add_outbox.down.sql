@@ -0,0 +1,10 @@
+-- Restore the webhook bucket reference and drop the outbox
+DROP INDEX IF EXISTS webhook_deliveries_event_idx;
+
+UPDATE webhooks SET bucket_id = NULL
+WHERE bucket_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM buckets WHERE buckets.id = webhooks.bucket_id);
+ALTER TABLE webhooks ADD CONSTRAINT webhooks_bucket_id_fkey
+    FOREIGN KEY (bucket_id) REFERENCES buckets(id) ON DELETE SET NULL;
+
+DROP TABLE IF EXISTS outbox_event_handlers;
+DROP TABLE IF EXISTS outbox_events;
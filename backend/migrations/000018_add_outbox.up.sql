@@ -0,0 +1,33 @@
+-- Transactional outbox of domain events. Events are written in the same
+-- transaction as the change they describe and dispatched to in-process
+-- subscribers by a background worker.
+CREATE TABLE outbox_events (
+    id UUID PRIMARY KEY,
+    type TEXT NOT NULL,
+    bucket_id UUID,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'dispatched', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX outbox_events_bucket_id_idx ON outbox_events(bucket_id) WHERE status = 'pending';
+
+-- Subscribers that have handled an event, so a retried event skips them
+CREATE TABLE outbox_event_handlers (
+    event_id UUID NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    subscriber TEXT NOT NULL,
+    handled_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, subscriber)
+);
+
+-- Webhooks now learn about bucket deletion from the outbox, after the bucket
+-- row is gone, so they keep their bucket ID until the worker removes them.
+ALTER TABLE webhooks DROP CONSTRAINT webhooks_bucket_id_fkey;
+
+-- Each event is queued once per webhook, however often its handler runs
+CREATE UNIQUE INDEX webhook_deliveries_event_idx ON webhook_deliveries(webhook_id, event_id) WHERE redelivery_of IS NULL;
@@ -0,0 +1,4 @@
+-- Drop storage quotas and the bucket object count
+DROP TABLE IF EXISTS quota_reservations;
+DROP TABLE IF EXISTS quotas;
+ALTER TABLE buckets DROP COLUMN IF EXISTS object_count;
@@ -0,0 +1,35 @@
+-- Object count alongside the cached size, so quotas can limit both
+ALTER TABLE buckets ADD COLUMN object_count BIGINT NOT NULL DEFAULT 0;
+
+-- Storage quotas per user, team or bucket. subject_id points at the row in
+-- users, teams or buckets named by scope; quotas of deleted subjects are
+-- removed by the quota worker. A NULL limit is unlimited.
+CREATE TABLE quotas (
+    id UUID PRIMARY KEY,
+    scope TEXT NOT NULL CHECK (scope IN ('user', 'team', 'bucket')),
+    subject_id UUID NOT NULL,
+    max_bytes BIGINT CHECK (max_bytes >= 0),
+    max_objects BIGINT CHECK (max_objects >= 0),
+    warn_percent INTEGER NOT NULL DEFAULT 80 CHECK (warn_percent BETWEEN 1 AND 100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (scope, subject_id)
+);
+
+-- Space held for writes that are in flight or not yet reflected in the
+-- cached bucket usage. Uncommitted reservations lapse at expires_at; committed
+-- ones are removed once the bucket usage has been recalculated.
+CREATE TABLE quota_reservations (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    bucket_id UUID NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    bytes BIGINT NOT NULL,
+    objects BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    committed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX quota_reservations_user_id_idx ON quota_reservations(user_id);
+CREATE INDEX quota_reservations_bucket_id_idx ON quota_reservations(bucket_id);
+CREATE INDEX quota_reservations_expires_at_idx ON quota_reservations(expires_at);
@@ -0,0 +1,4 @@
+-- Drop usage history and growth alerts
+DROP TABLE IF EXISTS usage_alerts;
+DROP TABLE IF EXISTS usage_snapshots;
+ALTER TABLE buckets DROP COLUMN IF EXISTS usage_snapshot_at;
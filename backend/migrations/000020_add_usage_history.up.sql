@@ -0,0 +1,29 @@
+-- When a bucket's usage was last captured by the snapshot worker
+ALTER TABLE buckets ADD COLUMN usage_snapshot_at TIMESTAMPTZ;
+
+-- Periodic size and object count of each bucket (prefix '') and of its
+-- largest top-level prefixes. Rows of one snapshot share captured_at.
+CREATE TABLE usage_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    bucket_id UUID NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    prefix TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL,
+    object_count BIGINT NOT NULL,
+    captured_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX usage_snapshots_bucket_prefix_idx ON usage_snapshots(bucket_id, prefix, captured_at);
+CREATE INDEX usage_snapshots_captured_at_idx ON usage_snapshots(captured_at);
+
+-- Alert raised when a bucket grows by more than a limit within a day. A NULL
+-- limit is not checked.
+CREATE TABLE usage_alerts (
+    id UUID PRIMARY KEY,
+    bucket_id UUID NOT NULL UNIQUE REFERENCES buckets(id) ON DELETE CASCADE,
+    max_daily_growth_bytes BIGINT CHECK (max_daily_growth_bytes > 0),
+    max_daily_growth_objects BIGINT CHECK (max_daily_growth_objects > 0),
+    last_triggered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (max_daily_growth_bytes IS NOT NULL OR max_daily_growth_objects IS NOT NULL)
+);
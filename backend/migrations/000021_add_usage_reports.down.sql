@@ -0,0 +1,2 @@
+-- Drop cached disk usage breakdowns
+DROP TABLE IF EXISTS usage_reports;
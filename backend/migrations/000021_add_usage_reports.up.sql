@@ -0,0 +1,19 @@
+-- Cached disk usage breakdowns of a bucket or prefix. Large buckets are
+-- scanned by a background worker: pending reports wait to be claimed, running
+-- ones are reclaimed if their worker stops. A refresh keeps the previous
+-- result until the new one is ready.
+CREATE TABLE usage_reports (
+    id UUID PRIMARY KEY,
+    bucket_id UUID NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    prefix TEXT NOT NULL,
+    depth INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'running', 'ready', 'failed')),
+    result JSONB,
+    error TEXT,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    UNIQUE (bucket_id, prefix, depth)
+);
+
+CREATE INDEX usage_reports_status_idx ON usage_reports(status, requested_at);
@@ -0,0 +1,2 @@
+-- Drop observed request counts
+DROP TABLE IF EXISTS bucket_request_counts;
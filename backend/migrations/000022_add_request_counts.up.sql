@@ -0,0 +1,13 @@
+-- Requests BucketBird sent to each bucket per UTC day, by billing kind, and
+-- the bytes it downloaded. Used to estimate request and egress costs.
+CREATE TABLE bucket_request_counts (
+    bucket_id UUID NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    write_requests BIGINT NOT NULL DEFAULT 0,
+    list_requests BIGINT NOT NULL DEFAULT 0,
+    read_requests BIGINT NOT NULL DEFAULT 0,
+    egress_bytes BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (bucket_id, day)
+);
+
+CREATE INDEX bucket_request_counts_day_idx ON bucket_request_counts(day);
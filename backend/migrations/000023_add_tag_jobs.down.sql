@@ -0,0 +1,2 @@
+-- Drop bulk object tagging jobs
+DROP TABLE IF EXISTS tag_jobs;
@@ -0,0 +1,30 @@
+-- Bulk object tagging jobs. A job tags a list of keys or every object under a
+-- prefix in the background: pending jobs wait to be claimed, running ones are
+-- reclaimed if their worker stops sending heartbeats.
+CREATE TABLE tag_jobs (
+    id UUID PRIMARY KEY,
+    bucket_id UUID NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Either a JSON list of keys or a prefix names the objects
+    keys JSONB,
+    prefix TEXT,
+    -- Tags to set and tag keys to remove; replace_tags drops all other tags
+    set_tags JSONB NOT NULL,
+    remove_tags JSONB NOT NULL,
+    replace_tags BOOLEAN NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'running', 'completed', 'failed', 'canceled')),
+    total BIGINT,
+    processed BIGINT NOT NULL DEFAULT 0,
+    failed BIGINT NOT NULL DEFAULT 0,
+    -- The first objects that could not be tagged, with their errors
+    failures JSONB,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    heartbeat_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    CHECK ((keys IS NULL) <> (prefix IS NULL))
+);
+
+CREATE INDEX tag_jobs_status_idx ON tag_jobs(status, created_at);
+CREATE INDEX tag_jobs_bucket_idx ON tag_jobs(bucket_id, created_at DESC);
@@ -0,0 +1,2 @@
+-- Drop background tag searches
+DROP TABLE IF EXISTS tag_searches;
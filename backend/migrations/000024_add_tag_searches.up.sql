@@ -0,0 +1,24 @@
+-- Searches for objects by tag across a whole bucket or prefix. Tags are not
+-- part of S3 listings, so every object's tags are looked up; larger searches
+-- run in the background like tag jobs and keep their matches here.
+CREATE TABLE tag_searches (
+    id UUID PRIMARY KEY,
+    bucket_id UUID NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    prefix TEXT NOT NULL,
+    -- JSON list of filters written as key=value or key
+    filters JSONB NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    scanned BIGINT NOT NULL DEFAULT 0,
+    -- Matching objects with their tags, up to a limit; truncated is set once it is reached
+    matches JSONB,
+    truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    heartbeat_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX tag_searches_status_idx ON tag_searches(status, created_at);
+CREATE INDEX tag_searches_bucket_idx ON tag_searches(bucket_id, created_at DESC);
@@ -0,0 +1,2 @@
+-- Drop the bucket usage timestamp
+ALTER TABLE buckets DROP COLUMN IF EXISTS size_updated_at;
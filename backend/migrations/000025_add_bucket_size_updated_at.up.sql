@@ -0,0 +1,3 @@
+-- When the cached size and object count were last recalculated, so callers
+-- can tell a stale or never computed count from an empty bucket
+ALTER TABLE buckets ADD COLUMN size_updated_at TIMESTAMPTZ;
@@ -0,0 +1,193 @@
+// Package breached checks passwords against an offline list of breached
+// passwords, stored as a bloom filter over their SHA-1 hashes.
+package breached
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// fileMagic starts every filter file, followed by the format version
+const fileMagic = "BBPWBLM1"
+
+// maxFilterBits caps the filter at 1 GiB, which holds billions of hashes
+const maxFilterBits = 8 << 30
+
+var ErrInvalidFilter = errors.New("invalid breached password filter")
+
+// Filter is a bloom filter keyed by the SHA-1 hash of a password. SHA-1 keeps it
+// compatible with the Pwned Passwords downloads, which list hashes rather than
+// passwords. Contains may report false positives at the filter's configured
+// rate, but never false negatives.
+type Filter struct {
+	bits  []uint64
+	m     uint64 // number of bits
+	k     uint32 // number of hash functions
+	count uint64 // number of hashes added
+}
+
+// NewFilter sizes a filter for n hashes at the given false positive rate
+func NewFilter(n uint64, falsePositiveRate float64) (*Filter, error) {
+	if n == 0 {
+		n = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, errors.New("false positive rate must be between 0 and 1")
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m > maxFilterBits {
+		return nil, fmt.Errorf("filter for %d hashes would exceed %d MiB", n, maxFilterBits/8/1024/1024)
+	}
+	m = (m + 63) / 64 * 64
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &Filter{bits: make([]uint64, m/64), m: m, k: k}, nil
+}
+
+// Add adds a plaintext password
+func (f *Filter) Add(password string) {
+	f.AddHash(sha1.Sum([]byte(password)))
+}
+
+// AddHash adds the SHA-1 hash of a password
+func (f *Filter) AddHash(sum [sha1.Size]byte) {
+	h1, h2 := split(sum)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.count++
+}
+
+// Contains reports whether the password is probably on the list
+func (f *Filter) Contains(password string) bool {
+	return f.ContainsHash(sha1.Sum([]byte(password)))
+}
+
+// ContainsHash reports whether the SHA-1 hash is probably on the list
+func (f *Filter) ContainsHash(sum [sha1.Size]byte) bool {
+	h1, h2 := split(sum)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of hashes added to the filter
+func (f *Filter) Count() uint64 {
+	return f.count
+}
+
+// split derives the two base hashes for double hashing. SHA-1 output is
+// already uniform, so its first 16 bytes serve directly.
+func split(sum [sha1.Size]byte) (uint64, uint64) {
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	return h1, h2
+}
+
+// WriteTo writes the filter in its file format: the magic, then m, k and the
+// count as little-endian integers, then the bit array
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var written int64
+
+	header := make([]byte, len(fileMagic)+8+4+8)
+	copy(header, fileMagic)
+	binary.LittleEndian.PutUint64(header[8:], f.m)
+	binary.LittleEndian.PutUint32(header[16:], f.k)
+	binary.LittleEndian.PutUint64(header[20:], f.count)
+	n, err := bw.Write(header)
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	buf := make([]byte, 8)
+	for _, word := range f.bits {
+		binary.LittleEndian.PutUint64(buf, word)
+		n, err := bw.Write(buf)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, bw.Flush()
+}
+
+// ReadFilter reads a filter written by WriteTo
+func ReadFilter(r io.Reader) (*Filter, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(fileMagic)+8+4+8)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, ErrInvalidFilter
+	}
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return nil, ErrInvalidFilter
+	}
+	f := &Filter{
+		m:     binary.LittleEndian.Uint64(header[8:]),
+		k:     binary.LittleEndian.Uint32(header[16:]),
+		count: binary.LittleEndian.Uint64(header[20:]),
+	}
+	if f.m == 0 || f.m%64 != 0 || f.m > maxFilterBits || f.k == 0 || f.k > 64 {
+		return nil, ErrInvalidFilter
+	}
+
+	f.bits = make([]uint64, f.m/64)
+	buf := make([]byte, 8)
+	for i := range f.bits {
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, ErrInvalidFilter
+		}
+		f.bits[i] = binary.LittleEndian.Uint64(buf)
+	}
+	return f, nil
+}
+
+// Load reads a filter file
+func Load(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	f, err := ReadFilter(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// ParseHashLine parses a line of a Pwned Passwords SHA-1 download, "<40 hex digits>[:count]".
+// ok is false for blank or malformed lines.
+func ParseHashLine(line string) (sum [sha1.Size]byte, ok bool) {
+	line = strings.TrimSpace(line)
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+	if len(line) != hex.EncodedLen(sha1.Size) {
+		return sum, false
+	}
+	if _, err := hex.Decode(sum[:], []byte(line)); err != nil {
+		return sum, false
+	}
+	return sum, true
+}
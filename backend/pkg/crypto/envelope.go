@@ -0,0 +1,279 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownWrappedKey = errors.New("data key was wrapped by an unknown key provider")
+	ErrNoLegacyKeyring   = errors.New("value predates envelope encryption and no local encryption key is configured")
+)
+
+// KeyProvider wraps and unwraps data keys with a master key it holds.
+// BucketBird only ever sees wrapped data keys and the plaintext data keys
+// returned by UnwrapKey, never the master key itself.
+type KeyProvider interface {
+	// Name identifies the provider, e.g. "local" or "vault"
+	Name() string
+	// KeyID labels the master key that wrapped a data key, or returns ""
+	// if the wrapped key was not produced by this provider
+	KeyID(wrapped string) string
+	WrapKey(ctx context.Context, dataKey []byte) (string, error)
+	UnwrapKey(ctx context.Context, wrapped string) ([]byte, error)
+}
+
+// sealedPrefix marks standalone values produced by Envelope.Seal
+const sealedPrefix = "env."
+
+const (
+	dataKeyCacheTTL  = 5 * time.Minute
+	dataKeyCacheSize = 1024
+)
+
+// Envelope encrypts values with random per-record data keys and stores each
+// data key wrapped by a KeyProvider. Unwrapped data keys are cached briefly,
+// so remote providers are not called on every request.
+type Envelope struct {
+	provider KeyProvider
+	// unwrappers includes the provider plus any providers kept for decryption only
+	unwrappers []KeyProvider
+	// legacy decrypts values written directly with the keyring before envelope encryption; may be nil
+	legacy *Keyring
+
+	mu    sync.Mutex
+	cache map[string]cachedDataKey
+}
+
+type cachedDataKey struct {
+	key       []byte
+	expiresAt time.Time
+}
+
+// NewEnvelope creates an envelope that wraps new data keys with provider.
+// legacy may be nil; when set, it decrypts values from before envelope
+// encryption and unwraps data keys from the local provider, which allows
+// moving from the local provider to another one.
+func NewEnvelope(provider KeyProvider, legacy *Keyring) *Envelope {
+	unwrappers := []KeyProvider{provider}
+	if legacy != nil && provider.Name() != LocalKeyProviderName {
+		unwrappers = append(unwrappers, NewLocalKeyProvider(legacy))
+	}
+	return &Envelope{
+		provider:   provider,
+		unwrappers: unwrappers,
+		legacy:     legacy,
+		cache:      make(map[string]cachedDataKey),
+	}
+}
+
+// ProviderName returns the name of the provider that wraps new data keys
+func (e *Envelope) ProviderName() string {
+	return e.provider.Name()
+}
+
+// Check wraps and unwraps a throwaway data key to verify the provider is reachable
+func (e *Envelope) Check(ctx context.Context) error {
+	dataKey, wrapped, err := e.GenerateDataKey(ctx)
+	if err != nil {
+		return err
+	}
+	unwrapped, err := e.unwrap(ctx, wrapped)
+	if err != nil {
+		return err
+	}
+	if !constantTimeEquals(dataKey, unwrapped) {
+		return fmt.Errorf("%s key provider returned a different data key", e.provider.Name())
+	}
+	return nil
+}
+
+// GenerateDataKey returns a new random AES-256 data key and its wrapped form
+func (e *Envelope) GenerateDataKey(ctx context.Context) ([]byte, string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, "", fmt.Errorf("generate data key: %w", err)
+	}
+	wrapped, err := e.provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("wrap data key: %w", err)
+	}
+	return dataKey, wrapped, nil
+}
+
+// UnwrapDataKey returns the plaintext data key for a wrapped key produced by any known provider
+func (e *Envelope) UnwrapDataKey(ctx context.Context, wrapped string) ([]byte, error) {
+	e.mu.Lock()
+	if cached, ok := e.cache[wrapped]; ok && time.Now().Before(cached.expiresAt) {
+		e.mu.Unlock()
+		return cached.key, nil
+	}
+	e.mu.Unlock()
+
+	dataKey, err := e.unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.cache) >= dataKeyCacheSize {
+		now := time.Now()
+		for k, cached := range e.cache {
+			if now.After(cached.expiresAt) {
+				delete(e.cache, k)
+			}
+		}
+		if len(e.cache) >= dataKeyCacheSize {
+			e.cache = make(map[string]cachedDataKey)
+		}
+	}
+	e.cache[wrapped] = cachedDataKey{key: dataKey, expiresAt: time.Now().Add(dataKeyCacheTTL)}
+	return dataKey, nil
+}
+
+func (e *Envelope) unwrap(ctx context.Context, wrapped string) ([]byte, error) {
+	for _, p := range e.unwrappers {
+		if p.KeyID(wrapped) == "" {
+			continue
+		}
+		dataKey, err := p.UnwrapKey(ctx, wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("unwrap data key: %w", err)
+		}
+		if len(dataKey) != 32 {
+			return nil, fmt.Errorf("unwrap data key: %w", ErrInvalidKey)
+		}
+		return dataKey, nil
+	}
+	return nil, ErrUnknownWrappedKey
+}
+
+// RewrapDataKey wraps an existing data key again with the current provider and master key
+func (e *Envelope) RewrapDataKey(ctx context.Context, wrapped string) (string, error) {
+	dataKey, err := e.unwrap(ctx, wrapped)
+	if err != nil {
+		return "", err
+	}
+	return e.provider.WrapKey(ctx, dataKey)
+}
+
+// WrappingKeyID labels the master key that wrapped a data key, e.g. "local:k1" or "vault:v2"
+func (e *Envelope) WrappingKeyID(wrapped string) string {
+	for _, p := range e.unwrappers {
+		if id := p.KeyID(wrapped); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// EncryptRecord encrypts related values with one new data key, returning the
+// wrapped data key and the ciphertexts in the order of plaintexts
+func (e *Envelope) EncryptRecord(ctx context.Context, plaintexts ...string) (string, []string, error) {
+	dataKey, wrapped, err := e.GenerateDataKey(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	ciphertexts := make([]string, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		ciphertexts[i], err = EncryptAES(plaintext, dataKey)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return wrapped, ciphertexts, nil
+}
+
+// DecryptRecord decrypts values produced by EncryptRecord. An empty wrapped
+// key marks values written by the keyring before envelope encryption.
+func (e *Envelope) DecryptRecord(ctx context.Context, wrapped string, ciphertexts ...string) ([]string, error) {
+	plaintexts := make([]string, len(ciphertexts))
+	if wrapped == "" {
+		for i, ciphertext := range ciphertexts {
+			plaintext, err := e.DecryptLegacy(ciphertext)
+			if err != nil {
+				return nil, err
+			}
+			plaintexts[i] = plaintext
+		}
+		return plaintexts, nil
+	}
+
+	dataKey, err := e.UnwrapDataKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	for i, ciphertext := range ciphertexts {
+		plaintext, err := DecryptAES(ciphertext, dataKey)
+		if err != nil {
+			return nil, err
+		}
+		plaintexts[i] = plaintext
+	}
+	return plaintexts, nil
+}
+
+// DecryptLegacy decrypts a value encrypted directly with the keyring
+func (e *Envelope) DecryptLegacy(ciphertext string) (string, error) {
+	if e.legacy == nil {
+		return "", ErrNoLegacyKeyring
+	}
+	return e.legacy.Decrypt(ciphertext)
+}
+
+// Seal encrypts a standalone value with its own data key. The result has the
+// form "env.<wrapped key>.<ciphertext>"; neither part contains a '.'.
+func (e *Envelope) Seal(ctx context.Context, plaintext string) (string, error) {
+	wrapped, ciphertexts, err := e.EncryptRecord(ctx, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + wrapped + "." + ciphertexts[0], nil
+}
+
+// Open decrypts a value produced by Seal, or a legacy keyring ciphertext
+func (e *Envelope) Open(ctx context.Context, sealed string) (string, error) {
+	wrapped, ciphertext, ok := SplitSealed(sealed)
+	if !ok {
+		return e.DecryptLegacy(sealed)
+	}
+	plaintexts, err := e.DecryptRecord(ctx, wrapped, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return plaintexts[0], nil
+}
+
+// SplitSealed splits a value produced by Seal into its wrapped data key and
+// ciphertext. ok is false for legacy keyring ciphertexts.
+func SplitSealed(sealed string) (wrapped, ciphertext string, ok bool) {
+	rest, found := strings.CutPrefix(sealed, sealedPrefix)
+	if !found {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 || i == len(rest)-1 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+// RewrapSealed wraps the data key of a sealed value again with the current
+// provider. The ciphertext itself is unchanged.
+func (e *Envelope) RewrapSealed(ctx context.Context, sealed string) (string, error) {
+	wrapped, ciphertext, ok := SplitSealed(sealed)
+	if !ok {
+		return "", ErrInvalidCiphertext
+	}
+	rewrapped, err := e.RewrapDataKey(ctx, wrapped)
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + rewrapped + "." + ciphertext, nil
+}
@@ -0,0 +1,116 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnknownKeyID = errors.New("ciphertext was encrypted with an unknown key")
+	ErrInvalidKeyID = errors.New("key IDs may only contain letters, digits, '-' and '_'")
+	ErrNoActiveKey  = errors.New("active key is not in the keyring")
+)
+
+// keyIDSeparator separates the key ID from the base64 ciphertext
+const keyIDSeparator = ":"
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Keyring encrypts with one active key and decrypts with any key it holds.
+// Ciphertexts are prefixed with the ID of the key that produced them, e.g.
+// "k2:base64...". Ciphertexts without a prefix predate key IDs and are tried
+// against every key, which is safe because AES-GCM authenticates the result.
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewKeyring creates a keyring. keys maps key IDs to 32-byte AES-256 keys and must contain activeID.
+func NewKeyring(activeID string, keys map[string][]byte) (*Keyring, error) {
+	ring := &Keyring{
+		activeID: activeID,
+		keys:     make(map[string][]byte, len(keys)),
+	}
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyID, id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q: %w", id, ErrInvalidKey)
+		}
+		ring.keys[id] = key
+	}
+	if _, ok := ring.keys[activeID]; !ok {
+		return nil, ErrNoActiveKey
+	}
+	return ring, nil
+}
+
+// ActiveKeyID returns the ID of the key used for new ciphertexts
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// KeyIDs returns the IDs of all keys in the keyring, sorted
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt encrypts plaintext with the active key
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	ciphertext, err := EncryptAES(plaintext, k.keys[k.activeID])
+	if err != nil {
+		return "", err
+	}
+	return k.activeID + keyIDSeparator + ciphertext, nil
+}
+
+// Decrypt decrypts a ciphertext produced by any key in the keyring
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	id, data, ok := SplitKeyID(ciphertext)
+	if ok {
+		key, found := k.keys[id]
+		if !found {
+			return "", fmt.Errorf("%w: %q", ErrUnknownKeyID, id)
+		}
+		return DecryptAES(data, key)
+	}
+
+	// Legacy ciphertext: try the active key first, then the others
+	if plaintext, err := DecryptAES(ciphertext, k.keys[k.activeID]); err == nil {
+		return plaintext, nil
+	}
+	for _, id := range k.KeyIDs() {
+		if id == k.activeID {
+			continue
+		}
+		if plaintext, err := DecryptAES(ciphertext, k.keys[id]); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", ErrInvalidCiphertext
+}
+
+// NeedsRotation reports whether the ciphertext was not produced by the active key
+func (k *Keyring) NeedsRotation(ciphertext string) bool {
+	id, _, ok := SplitKeyID(ciphertext)
+	return !ok || id != k.activeID
+}
+
+// SplitKeyID splits a key ID prefix from a ciphertext. ok is false for legacy
+// ciphertexts without a prefix; base64 never contains the separator.
+func SplitKeyID(ciphertext string) (keyID, data string, ok bool) {
+	id, data, found := strings.Cut(ciphertext, keyIDSeparator)
+	if !found || !keyIDPattern.MatchString(id) {
+		return "", ciphertext, false
+	}
+	return id, data, true
+}
@@ -0,0 +1,63 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+)
+
+// LocalKeyProviderName is the name of the keyring-backed key provider
+const LocalKeyProviderName = "local"
+
+// localWrappedPrefix marks data keys wrapped by the local provider, e.g. "local:k1:base64..."
+const localWrappedPrefix = LocalKeyProviderName + ":"
+
+// LocalKeyProvider wraps data keys with a keyring loaded from the
+// environment or a key file. The master key stays in process memory.
+type LocalKeyProvider struct {
+	keyring *Keyring
+}
+
+func NewLocalKeyProvider(keyring *Keyring) *LocalKeyProvider {
+	return &LocalKeyProvider{keyring: keyring}
+}
+
+func (p *LocalKeyProvider) Name() string {
+	return LocalKeyProviderName
+}
+
+func (p *LocalKeyProvider) KeyID(wrapped string) string {
+	rest, ok := strings.CutPrefix(wrapped, localWrappedPrefix)
+	if !ok {
+		return ""
+	}
+	id, _, ok := SplitKeyID(rest)
+	if !ok {
+		return ""
+	}
+	return localWrappedPrefix + id
+}
+
+func (p *LocalKeyProvider) WrapKey(ctx context.Context, dataKey []byte) (string, error) {
+	wrapped, err := p.keyring.Encrypt(base64.StdEncoding.EncodeToString(dataKey))
+	if err != nil {
+		return "", err
+	}
+	return localWrappedPrefix + wrapped, nil
+}
+
+func (p *LocalKeyProvider) UnwrapKey(ctx context.Context, wrapped string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(wrapped, localWrappedPrefix)
+	if !ok {
+		return nil, ErrUnknownWrappedKey
+	}
+	encoded, err := p.keyring.Decrypt(rest)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return dataKey, nil
+}
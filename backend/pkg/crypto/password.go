@@ -5,19 +5,76 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 
 	"golang.org/x/crypto/argon2"
 )
 
 const (
-	argonTime    uint32 = 1
-	argonMemory  uint32 = 64 * 1024
-	argonThreads uint8  = 4
-	argonKeyLen  uint32 = 32
-	saltLength          = 16
+	argonKeyLen uint32 = 32
+	saltLength         = 16
 )
 
+// Bounds for Argon2 parameters, both configured and read from stored hashes
+const (
+	MinArgon2Memory  uint32 = 8 * 1024        // 8 MiB
+	MaxArgon2Memory  uint32 = 4 * 1024 * 1024 // 4 GiB
+	MaxArgon2Time    uint32 = 100
+	MaxArgon2Threads uint8  = 64
+)
+
+var ErrInvalidPasswordHash = errors.New("invalid hash format")
+
+// Argon2Params are the argon2id cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// DefaultArgon2Params are used until SetPasswordParams is called
+var DefaultArgon2Params = Argon2Params{Time: 1, Memory: 64 * 1024, Threads: 4}
+
+var (
+	paramsMu       sync.RWMutex
+	passwordParams = DefaultArgon2Params
+)
+
+// Validate checks the parameters are within the supported bounds
+func (p Argon2Params) Validate() error {
+	if p.Time < 1 || p.Time > MaxArgon2Time {
+		return fmt.Errorf("argon2 time must be between 1 and %d", MaxArgon2Time)
+	}
+	if p.Memory < MinArgon2Memory || p.Memory > MaxArgon2Memory {
+		return fmt.Errorf("argon2 memory must be between %d and %d KiB", MinArgon2Memory, MaxArgon2Memory)
+	}
+	if p.Threads < 1 || p.Threads > MaxArgon2Threads {
+		return fmt.Errorf("argon2 threads must be between 1 and %d", MaxArgon2Threads)
+	}
+	return nil
+}
+
+// SetPasswordParams sets the parameters HashPassword uses for new hashes.
+// Existing hashes keep verifying with the parameters encoded in them.
+func SetPasswordParams(p Argon2Params) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+	passwordParams = p
+	return nil
+}
+
+// PasswordParams returns the parameters HashPassword currently uses
+func PasswordParams() Argon2Params {
+	paramsMu.RLock()
+	defer paramsMu.RUnlock()
+	return passwordParams
+}
+
 // HashPassword returns an argon2id hash for the provided plaintext password.
 func HashPassword(password string) (string, error) {
 	if len(password) < 8 {
@@ -29,38 +86,82 @@ func HashPassword(password string) (string, error) {
 		return "", fmt.Errorf("generate salt: %w", err)
 	}
 
-	hash := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	p := PasswordParams()
+	hash := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, argonKeyLen)
 
 	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
 	b64Hash := base64.RawStdEncoding.EncodeToString(hash)
 
-	encoded := fmt.Sprintf("argon2id$%d$%d$%d$%s$%s", argonTime, argonMemory, argonThreads, b64Salt, b64Hash)
+	encoded := fmt.Sprintf("argon2id$%d$%d$%d$%s$%s", p.Time, p.Memory, p.Threads, b64Salt, b64Hash)
 	return encoded, nil
 }
 
-// VerifyPassword compares a hashed password with its plaintext equivalent.
+// VerifyPassword compares a hashed password with its plaintext equivalent,
+// using the parameters encoded in the hash.
 func VerifyPassword(encodedHash, password string) (bool, error) {
+	p, salt, hash, err := decodePasswordHash(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	derived := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(hash)))
+
+	if !constantTimeEquals(hash, derived) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// PasswordNeedsRehash reports whether a hash was made with parameters other
+// than the current ones, so it should be replaced after the next successful login
+func PasswordNeedsRehash(encodedHash string) bool {
+	p, _, hash, err := decodePasswordHash(encodedHash)
+	if err != nil {
+		return false
+	}
+	return p != PasswordParams() || uint32(len(hash)) != argonKeyLen
+}
+
+// decodePasswordHash parses "argon2id$<time>$<memory>$<threads>$<salt>$<hash>"
+func decodePasswordHash(encodedHash string) (Argon2Params, []byte, []byte, error) {
 	parts := strings.Split(encodedHash, "$")
 	if len(parts) != 6 || parts[0] != "argon2id" {
-		return false, errors.New("invalid hash format")
+		return Argon2Params{}, nil, nil, ErrInvalidPasswordHash
+	}
+
+	t, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("decode time: %w", err)
+	}
+	m, err := strconv.ParseUint(parts[2], 10, 32)
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("decode memory: %w", err)
+	}
+	th, err := strconv.ParseUint(parts[3], 10, 8)
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("decode threads: %w", err)
+	}
+	p := Argon2Params{Time: uint32(t), Memory: uint32(m), Threads: uint8(th)}
+	// Refuse parameters no configuration could have produced rather than
+	// spending unbounded time or memory on them
+	if err := p.Validate(); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %v", ErrInvalidPasswordHash, err)
 	}
 
 	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
-		return false, fmt.Errorf("decode salt: %w", err)
+		return Argon2Params{}, nil, nil, fmt.Errorf("decode salt: %w", err)
 	}
 
 	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
 	if err != nil {
-		return false, fmt.Errorf("decode hash: %w", err)
+		return Argon2Params{}, nil, nil, fmt.Errorf("decode hash: %w", err)
 	}
-
-	derived := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, uint32(len(hash)))
-
-	if !constantTimeEquals(hash, derived) {
-		return false, nil
+	if len(hash) == 0 {
+		return Argon2Params{}, nil, nil, ErrInvalidPasswordHash
 	}
-	return true, nil
+
+	return p, salt, hash, nil
 }
 
 func constantTimeEquals(a, b []byte) bool {
@@ -0,0 +1,147 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultKeyProviderName is the name of the Vault Transit key provider
+const VaultKeyProviderName = "vault"
+
+// VaultTransitConfig configures a VaultTransitProvider
+type VaultTransitConfig struct {
+	// Address is the Vault server URL, e.g. "https://vault.example.com:8200"
+	Address string
+	Token   string
+	// Mount is the path the transit secrets engine is mounted at
+	Mount string
+	// KeyName is the transit key that wraps data keys
+	KeyName string
+	// Namespace is sent as X-Vault-Namespace when set (Vault Enterprise)
+	Namespace  string
+	HTTPClient *http.Client
+}
+
+// VaultTransitProvider wraps data keys with HashiCorp Vault's transit secrets
+// engine. The master key never leaves Vault; wrapped keys look like "vault:v1:...".
+type VaultTransitProvider struct {
+	cfg    VaultTransitConfig
+	client *http.Client
+}
+
+func NewVaultTransitProvider(cfg VaultTransitConfig) (*VaultTransitProvider, error) {
+	if cfg.Address == "" || cfg.Token == "" || cfg.KeyName == "" {
+		return nil, errors.New("vault transit provider requires an address, token and key name")
+	}
+	if _, err := url.Parse(cfg.Address); err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
+	}
+	cfg.Address = strings.TrimRight(cfg.Address, "/")
+	cfg.Mount = strings.Trim(cfg.Mount, "/")
+	if cfg.Mount == "" {
+		cfg.Mount = "transit"
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &VaultTransitProvider{cfg: cfg, client: client}, nil
+}
+
+func (p *VaultTransitProvider) Name() string {
+	return VaultKeyProviderName
+}
+
+func (p *VaultTransitProvider) KeyID(wrapped string) string {
+	parts := strings.SplitN(wrapped, ":", 3)
+	if len(parts) != 3 || parts[0] != VaultKeyProviderName || !strings.HasPrefix(parts[1], "v") {
+		return ""
+	}
+	return parts[0] + ":" + parts[1]
+}
+
+func (p *VaultTransitProvider) WrapKey(ctx context.Context, dataKey []byte) (string, error) {
+	var resp struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}
+	if err := p.call(ctx, "encrypt", body, &resp); err != nil {
+		return "", err
+	}
+	if p.KeyID(resp.Ciphertext) == "" {
+		return "", errors.New("vault returned an unexpected ciphertext format")
+	}
+	return resp.Ciphertext, nil
+}
+
+func (p *VaultTransitProvider) UnwrapKey(ctx context.Context, wrapped string) ([]byte, error) {
+	var resp struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := p.call(ctx, "decrypt", map[string]string{"ciphertext": wrapped}, &resp); err != nil {
+		return nil, err
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(resp.Plaintext)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return dataKey, nil
+}
+
+// call POSTs to <mount>/<operation>/<key> and decodes the response's data field into out
+func (p *VaultTransitProvider) call(ctx context.Context, operation string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/%s/%s", p.cfg.Address, p.cfg.Mount, operation, url.PathEscape(p.cfg.KeyName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", p.cfg.Token)
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault transit %s: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("vault transit %s: %w", operation, err)
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []string        `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("vault transit %s: decode response: %w", operation, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(envelope.Errors) > 0 {
+			return fmt.Errorf("vault transit %s: %s (status %d)", operation, strings.Join(envelope.Errors, "; "), resp.StatusCode)
+		}
+		return fmt.Errorf("vault transit %s: unexpected status %d", operation, resp.StatusCode)
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("vault transit %s: decode response: %w", operation, err)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package jwt
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,60 +14,211 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 )
 
+// PurposeMFAChallenge marks tokens that only allow completing a two-factor login
+const PurposeMFAChallenge = "mfa_challenge"
+
 type Claims struct {
 	UserID string `json:"user_id"`
+	// SessionID ties an access token to the session that issued it
+	SessionID string `json:"sid,omitempty"`
+	// TokenVersion must match the user's current token version
+	TokenVersion int32 `json:"ver,omitempty"`
+	// Purpose is empty for access tokens
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// AccessToken holds the validated contents of an access token
+type AccessToken struct {
+	UserID       uuid.UUID
+	SessionID    uuid.UUID
+	TokenVersion int32
+}
+
+// Options configures a TokenManager
+type Options struct {
+	TTL time.Duration
+	// Issuer and Audience are set on issued tokens and required when validating
+	Issuer   string
+	Audience string
+	// SigningKey signs new tokens and also verifies them
+	SigningKey *Key
+	// VerificationKeys are older or external keys still accepted during a key rollover
+	VerificationKeys []*Key
+}
+
 type TokenManager struct {
-	secret []byte
-	ttl    time.Duration
+	ttl        time.Duration
+	issuer     string
+	audience   string
+	signingKey *Key
+	// keys holds every verification key by ID
+	keys map[string]*Key
+	// legacyKey verifies tokens without a kid header, issued before key IDs were added
+	legacyKey *Key
+	methods   []string
 }
 
-func NewTokenManager(secret string, ttl time.Duration) *TokenManager {
-	return &TokenManager{
-		secret: []byte(secret),
-		ttl:    ttl,
+func NewTokenManager(opts Options) (*TokenManager, error) {
+	if opts.SigningKey == nil || !opts.SigningKey.CanSign() {
+		return nil, errors.New("a signing key with private key material is required")
+	}
+
+	tm := &TokenManager{
+		ttl:        opts.TTL,
+		issuer:     opts.Issuer,
+		audience:   opts.Audience,
+		signingKey: opts.SigningKey,
+		keys:       make(map[string]*Key),
+	}
+	methods := make(map[string]bool)
+	for _, key := range append([]*Key{opts.SigningKey}, opts.VerificationKeys...) {
+		if _, dup := tm.keys[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		tm.keys[key.ID] = key
+		if key.Algorithm == AlgorithmHS256 && tm.legacyKey == nil {
+			tm.legacyKey = key
+		}
+		if !methods[key.Algorithm] {
+			methods[key.Algorithm] = true
+			tm.methods = append(tm.methods, key.Algorithm)
+		}
+	}
+	return tm, nil
+}
+
+// JWKS returns the public verification keys. HMAC keys are never included.
+func (tm *TokenManager) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	if jwk, ok := tm.signingKey.JWK(); ok {
+		set.Keys = append(set.Keys, jwk)
+	}
+	ids := make([]string, 0, len(tm.keys))
+	for id := range tm.keys {
+		if id != tm.signingKey.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if jwk, ok := tm.keys[id].JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
 	}
+	return set
 }
 
-// Generate creates a new JWT token for the given user ID
-func (tm *TokenManager) Generate(userID uuid.UUID) (string, time.Time, error) {
-	expires := time.Now().Add(tm.ttl)
+// Generate creates a new access token for the given user and session. The
+// token stops working once the session is revoked or the user's token version changes.
+func (tm *TokenManager) Generate(userID, sessionID uuid.UUID, tokenVersion int32) (string, time.Time, error) {
+	return tm.generate(userID, sessionID.String(), tokenVersion, "", tm.ttl)
+}
+
+// GenerateMFAChallenge creates a short-lived token proving the first login factor was verified
+func (tm *TokenManager) GenerateMFAChallenge(userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	return tm.generate(userID, "", 0, PurposeMFAChallenge, ttl)
+}
+
+func (tm *TokenManager) generate(userID uuid.UUID, sessionID string, tokenVersion int32, purpose string, ttl time.Duration) (string, time.Time, error) {
+	expires := time.Now().Add(ttl)
 	claims := Claims{
-		UserID: userID.String(),
+		UserID:       userID.String(),
+		SessionID:    sessionID,
+		TokenVersion: tokenVersion,
+		Purpose:      purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tm.issuer,
 			Subject:   userID.String(),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(expires),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(tm.secret)
+	if tm.audience != "" {
+		claims.Audience = jwt.ClaimStrings{tm.audience}
+	}
+	token := jwt.NewWithClaims(tm.signingKey.method(), claims)
+	token.Header["kid"] = tm.signingKey.ID
+	signed, err := token.SignedString(tm.signingKey.signKey)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 	return signed, expires, nil
 }
 
-// Validate verifies a JWT token and returns the user ID
-func (tm *TokenManager) Validate(tokenString string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return tm.secret, nil
-	})
-	if err != nil || !token.Valid {
-		return uuid.Nil, ErrInvalidToken
+// Validate verifies an access token and returns its user and session
+func (tm *TokenManager) Validate(tokenString string) (*AccessToken, error) {
+	claims, err := tm.validate(tokenString, "")
+	if err != nil {
+		return nil, err
 	}
-	
-	claims, ok := token.Claims.(*Claims)
-	if !ok {
-		return uuid.Nil, ErrInvalidToken
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
 	}
-	
+
+	// Every access token is bound to a session so it can be revoked
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &AccessToken{UserID: userID, SessionID: sessionID, TokenVersion: claims.TokenVersion}, nil
+}
+
+// ValidateMFAChallenge verifies an MFA challenge token and returns the user ID
+func (tm *TokenManager) ValidateMFAChallenge(tokenString string) (uuid.UUID, error) {
+	claims, err := tm.validate(tokenString, PurposeMFAChallenge)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
 		return uuid.Nil, ErrInvalidToken
 	}
-	
 	return userID, nil
 }
+
+func (tm *TokenManager) validate(tokenString, purpose string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(tm.methods),
+		jwt.WithExpirationRequired(),
+	}
+	if tm.issuer != "" {
+		options = append(options, jwt.WithIssuer(tm.issuer))
+	}
+	if tm.audience != "" {
+		options = append(options, jwt.WithAudience(tm.audience))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, tm.keyFunc, options...)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || claims.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// keyFunc picks the verification key named by the kid header and rejects
+// tokens whose alg does not match that key, so an RSA public key can never be
+// used as an HMAC secret
+func (tm *TokenManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := tm.legacyKey
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = tm.keys[kid]
+	}
+	if key == nil {
+		return nil, ErrInvalidToken
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrInvalidToken
+	}
+	return key.verifyKey, nil
+}
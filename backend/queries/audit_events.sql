@@ -0,0 +1,23 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+    actor_id, actor_email, token_id, ip_address, user_agent, request_id,
+    action, bucket_id, bucket_name, object_key, target_id, details, outcome, error
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(bucket_id)::uuid IS NULL OR bucket_id = sqlc.narg(bucket_id))
+  AND (sqlc.narg(outcome)::text IS NULL OR outcome = sqlc.narg(outcome))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR occurred_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR occurred_at < sqlc.narg(until))
+  AND (sqlc.narg(before_id)::bigint IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: DeleteAuditEventsBefore :execrows
+DELETE FROM audit_events
+WHERE occurred_at < $1;
@@ -22,6 +22,9 @@ FROM buckets b
 JOIN credentials c ON c.id = b.credential_id
 WHERE b.id = $1 AND b.user_id = $2;
 
+-- name: GetBucketByID :one
+SELECT * FROM buckets WHERE id = $1;
+
 -- name: GetBucketByName :one
 SELECT
     sqlc.embed(b),
@@ -31,9 +34,9 @@ FROM buckets b
 JOIN credentials c ON c.id = b.credential_id
 WHERE b.user_id = $1 AND b.name = $2;
 
--- name: UpdateBucketSize :exec
+-- name: UpdateBucketUsage :exec
 UPDATE buckets
-SET size_bytes = $2, updated_at = NOW()
+SET size_bytes = $2, object_count = $3, size_updated_at = NOW(), updated_at = NOW()
 WHERE id = $1;
 
 -- name: UpdateBucket :exec
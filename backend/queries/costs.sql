@@ -0,0 +1,48 @@
+-- name: AddBucketRequestCounts :exec
+INSERT INTO bucket_request_counts (bucket_id, day, write_requests, list_requests, read_requests, egress_bytes)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (bucket_id, day) DO UPDATE
+SET write_requests = bucket_request_counts.write_requests + EXCLUDED.write_requests,
+    list_requests = bucket_request_counts.list_requests + EXCLUDED.list_requests,
+    read_requests = bucket_request_counts.read_requests + EXCLUDED.read_requests,
+    egress_bytes = bucket_request_counts.egress_bytes + EXCLUDED.egress_bytes;
+
+-- name: ListBucketRequestTotals :many
+SELECT
+    bucket_id,
+    COALESCE(SUM(write_requests), 0)::bigint AS write_requests,
+    COALESCE(SUM(list_requests), 0)::bigint AS list_requests,
+    COALESCE(SUM(read_requests), 0)::bigint AS read_requests,
+    COALESCE(SUM(egress_bytes), 0)::bigint AS egress_bytes
+FROM bucket_request_counts
+WHERE day >= sqlc.arg(since)::date
+GROUP BY bucket_id;
+
+-- name: DeleteBucketRequestCountsBefore :execrows
+DELETE FROM bucket_request_counts WHERE day < $1;
+
+-- name: ListBucketsForCosts :many
+SELECT
+    sqlc.embed(b),
+    c.name AS credential_name,
+    c.provider AS credential_provider,
+    c.region AS credential_region,
+    u.email AS owner_email
+FROM buckets b
+JOIN credentials c ON c.id = b.credential_id
+JOIN users u ON u.id = b.user_id
+WHERE (sqlc.narg(user_id)::uuid IS NULL OR b.user_id = sqlc.narg(user_id)::uuid)
+  AND (sqlc.narg(team_id)::uuid IS NULL OR b.user_id IN (SELECT m.user_id FROM team_members m WHERE m.team_id = sqlc.narg(team_id)::uuid))
+ORDER BY c.name, b.name;
+
+-- name: ListAllTeamMembers :many
+SELECT m.team_id, t.name AS team_name, m.user_id
+FROM team_members m
+JOIN teams t ON t.id = m.team_id
+ORDER BY t.name;
+
+-- name: ListRootUsageReports :many
+SELECT bucket_id, result, completed_at
+FROM usage_reports
+WHERE prefix = '' AND result IS NOT NULL AND completed_at IS NOT NULL
+ORDER BY bucket_id, completed_at;
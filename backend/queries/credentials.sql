@@ -2,9 +2,9 @@
 INSERT INTO credentials (
     id, user_id, name, provider, region, endpoint,
     encrypted_access_key, encrypted_secret_key,
-    use_ssl, status, logo
+    use_ssl, status, logo, encrypted_data_key
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *;
 
 -- name: ListCredentials :many
@@ -20,8 +20,19 @@ WHERE id = $1 AND user_id = $2;
 UPDATE credentials
 SET name = $3, provider = $4, region = $5, endpoint = $6,
     encrypted_access_key = $7, encrypted_secret_key = $8,
-    use_ssl = $9, status = $10, logo = $11, updated_at = NOW()
+    use_ssl = $9, status = $10, logo = $11, encrypted_data_key = $12,
+    updated_at = NOW()
 WHERE id = $1 AND user_id = $2;
 
 -- name: DeleteCredential :exec
 DELETE FROM credentials WHERE id = $1 AND user_id = $2;
+
+-- name: ListAllCredentialsForUpdate :many
+SELECT * FROM credentials
+ORDER BY created_at
+FOR UPDATE;
+
+-- name: UpdateCredentialSecrets :exec
+UPDATE credentials
+SET encrypted_access_key = $2, encrypted_secret_key = $3, encrypted_data_key = $4
+WHERE id = $1;
@@ -0,0 +1,21 @@
+-- name: CreateEmailToken :one
+INSERT INTO email_tokens (id, user_id, purpose, token_hash, email, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetEmailToken :one
+SELECT * FROM email_tokens
+WHERE token_hash = $1 AND purpose = $2;
+
+-- name: ConsumeEmailToken :one
+DELETE FROM email_tokens
+WHERE token_hash = $1 AND purpose = $2
+RETURNING *;
+
+-- name: DeleteEmailTokensForUser :exec
+DELETE FROM email_tokens
+WHERE user_id = $1 AND purpose = $2;
+
+-- name: DeleteExpiredEmailTokens :exec
+DELETE FROM email_tokens
+WHERE expires_at <= NOW();
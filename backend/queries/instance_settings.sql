@@ -0,0 +1,7 @@
+-- name: GetInstanceSetting :one
+SELECT * FROM instance_settings WHERE key = $1;
+
+-- name: UpsertInstanceSetting :exec
+INSERT INTO instance_settings (key, value)
+VALUES ($1, $2)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW();
@@ -0,0 +1,29 @@
+-- name: CreateInvite :one
+INSERT INTO invites (id, code_hash, code_prefix, created_by, email, team_id, max_uses, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetInviteByHash :one
+SELECT * FROM invites WHERE code_hash = $1;
+
+-- name: GetInvite :one
+SELECT * FROM invites WHERE id = $1;
+
+-- name: ListInvites :many
+SELECT * FROM invites ORDER BY created_at DESC;
+
+-- name: ListInvitesByCreator :many
+SELECT * FROM invites WHERE created_by = $1 ORDER BY created_at DESC;
+
+-- name: RedeemInvite :execrows
+UPDATE invites
+SET use_count = use_count + 1
+WHERE id = $1
+  AND (max_uses = 0 OR use_count < max_uses)
+  AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: ReleaseInvite :exec
+UPDATE invites SET use_count = use_count - 1 WHERE id = $1 AND use_count > 0;
+
+-- name: DeleteInvite :exec
+DELETE FROM invites WHERE id = $1;
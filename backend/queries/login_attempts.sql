@@ -0,0 +1,35 @@
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts WHERE identifier = $1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (identifier, failed_count, last_failed_at, last_ip_address)
+VALUES (sqlc.arg(identifier)::text, 1, sqlc.arg(failed_at)::timestamptz, sqlc.arg(ip_address)::text)
+ON CONFLICT (identifier) DO UPDATE
+SET failed_count = CASE
+        WHEN login_attempts.last_failed_at < sqlc.arg(reset_before)::timestamptz THEN 1
+        ELSE login_attempts.failed_count + 1
+    END,
+    locked_until = CASE
+        WHEN login_attempts.last_failed_at < sqlc.arg(reset_before)::timestamptz THEN NULL
+        ELSE login_attempts.locked_until
+    END,
+    last_failed_at = EXCLUDED.last_failed_at,
+    last_ip_address = EXCLUDED.last_ip_address
+RETURNING *;
+
+-- name: LockLoginAttempt :exec
+UPDATE login_attempts
+SET locked_until = GREATEST(locked_until, sqlc.arg(locked_until)::timestamptz)
+WHERE identifier = sqlc.arg(identifier)::text;
+
+-- name: ListLockedLoginAttempts :many
+SELECT * FROM login_attempts
+WHERE locked_until > NOW()
+ORDER BY locked_until DESC;
+
+-- name: DeleteLoginAttempt :execrows
+DELETE FROM login_attempts WHERE identifier = $1;
+
+-- name: DeleteStaleLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < NOW());
@@ -0,0 +1,12 @@
+-- name: CreateOIDCLoginRequest :one
+INSERT INTO oidc_login_requests (id, state, code_verifier, nonce, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ConsumeOIDCLoginRequest :one
+DELETE FROM oidc_login_requests
+WHERE state = $1
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginRequests :exec
+DELETE FROM oidc_login_requests WHERE expires_at < NOW();
@@ -0,0 +1,38 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (id, type, bucket_id, payload, occurred_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox_events
+SET next_attempt_at = sqlc.arg(lease_until)::timestamptz
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY occurred_at
+    LIMIT sqlc.arg(max_rows)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventDispatched :exec
+UPDATE outbox_events
+SET status = 'dispatched', attempts = attempts + 1, last_error = NULL, dispatched_at = NOW()
+WHERE id = $1;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = $4
+WHERE id = $1;
+
+-- name: ListOutboxEventHandlers :many
+SELECT subscriber FROM outbox_event_handlers
+WHERE event_id = $1;
+
+-- name: CreateOutboxEventHandler :exec
+INSERT INTO outbox_event_handlers (event_id, subscriber)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteOutboxEventsBefore :execrows
+DELETE FROM outbox_events
+WHERE status <> 'pending' AND occurred_at < $1;
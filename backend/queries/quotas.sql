@@ -0,0 +1,115 @@
+-- name: UpsertQuota :one
+INSERT INTO quotas (id, scope, subject_id, max_bytes, max_objects, warn_percent)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (scope, subject_id) DO UPDATE
+SET max_bytes = EXCLUDED.max_bytes,
+    max_objects = EXCLUDED.max_objects,
+    warn_percent = EXCLUDED.warn_percent,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: ListQuotas :many
+SELECT
+    sqlc.embed(q),
+    COALESCE(u.email, t.name, b.name, '')::text AS subject_name
+FROM quotas q
+LEFT JOIN users u ON q.scope = 'user' AND u.id = q.subject_id
+LEFT JOIN teams t ON q.scope = 'team' AND t.id = q.subject_id
+LEFT JOIN buckets b ON q.scope = 'bucket' AND b.id = q.subject_id
+ORDER BY q.scope, subject_name;
+
+-- name: ListQuotasForUser :many
+SELECT
+    sqlc.embed(q),
+    COALESCE(u.email, t.name, b.name, '')::text AS subject_name
+FROM quotas q
+LEFT JOIN users u ON q.scope = 'user' AND u.id = q.subject_id
+LEFT JOIN teams t ON q.scope = 'team' AND t.id = q.subject_id
+LEFT JOIN buckets b ON q.scope = 'bucket' AND b.id = q.subject_id
+WHERE (q.scope = 'user' AND q.subject_id = sqlc.arg(user_id)::uuid)
+   OR (q.scope = 'team' AND q.subject_id IN (SELECT m.team_id FROM team_members m WHERE m.user_id = sqlc.arg(user_id)::uuid))
+   OR (q.scope = 'bucket' AND b.user_id = sqlc.arg(user_id)::uuid)
+ORDER BY q.scope, subject_name;
+
+-- name: LockQuotasForWrite :many
+SELECT * FROM quotas
+WHERE (scope = 'user' AND subject_id = sqlc.arg(user_id)::uuid)
+   OR (scope = 'team' AND subject_id IN (SELECT m.team_id FROM team_members m WHERE m.user_id = sqlc.arg(user_id)::uuid))
+   OR (scope = 'bucket' AND subject_id = sqlc.arg(bucket_id)::uuid)
+ORDER BY id
+FOR UPDATE;
+
+-- name: DeleteQuota :execrows
+DELETE FROM quotas WHERE scope = $1 AND subject_id = $2;
+
+-- name: DeleteOrphanedQuotas :execrows
+DELETE FROM quotas q
+WHERE (q.scope = 'user' AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = q.subject_id))
+   OR (q.scope = 'team' AND NOT EXISTS (SELECT 1 FROM teams t WHERE t.id = q.subject_id))
+   OR (q.scope = 'bucket' AND NOT EXISTS (SELECT 1 FROM buckets b WHERE b.id = q.subject_id));
+
+-- name: GetUserStorageUsage :one
+SELECT
+    COALESCE(SUM(size_bytes), 0)::bigint AS bytes,
+    COALESCE(SUM(object_count), 0)::bigint AS objects
+FROM buckets
+WHERE user_id = $1;
+
+-- name: GetTeamStorageUsage :one
+SELECT
+    COALESCE(SUM(b.size_bytes), 0)::bigint AS bytes,
+    COALESCE(SUM(b.object_count), 0)::bigint AS objects
+FROM buckets b
+JOIN team_members m ON m.user_id = b.user_id
+WHERE m.team_id = $1;
+
+-- name: GetBucketStorageUsage :one
+SELECT
+    COALESCE(SUM(size_bytes), 0)::bigint AS bytes,
+    COALESCE(SUM(object_count), 0)::bigint AS objects
+FROM buckets
+WHERE id = $1;
+
+-- name: GetUserReservedStorage :one
+SELECT
+    COALESCE(SUM(bytes), 0)::bigint AS bytes,
+    COALESCE(SUM(objects), 0)::bigint AS objects
+FROM quota_reservations
+WHERE user_id = $1 AND expires_at > NOW();
+
+-- name: GetTeamReservedStorage :one
+SELECT
+    COALESCE(SUM(r.bytes), 0)::bigint AS bytes,
+    COALESCE(SUM(r.objects), 0)::bigint AS objects
+FROM quota_reservations r
+JOIN team_members m ON m.user_id = r.user_id
+WHERE m.team_id = $1 AND r.expires_at > NOW();
+
+-- name: GetBucketReservedStorage :one
+SELECT
+    COALESCE(SUM(bytes), 0)::bigint AS bytes,
+    COALESCE(SUM(objects), 0)::bigint AS objects
+FROM quota_reservations
+WHERE bucket_id = $1 AND expires_at > NOW();
+
+-- name: CreateQuotaReservation :one
+INSERT INTO quota_reservations (id, user_id, bucket_id, bytes, objects, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: CommitQuotaReservation :exec
+UPDATE quota_reservations
+SET committed_at = $2, expires_at = $3
+WHERE id = $1;
+
+-- name: DeleteQuotaReservation :exec
+DELETE FROM quota_reservations WHERE id = $1;
+
+-- name: DeleteCommittedQuotaReservations :exec
+DELETE FROM quota_reservations
+WHERE bucket_id = $1 AND committed_at <= $2;
+
+-- name: DeleteExpiredQuotaReservations :many
+DELETE FROM quota_reservations
+WHERE expires_at <= NOW()
+RETURNING *;
@@ -0,0 +1,10 @@
+-- name: RetireRefreshToken :exec
+INSERT INTO retired_refresh_tokens (token_hash, session_id, user_id, reason, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (token_hash) DO NOTHING;
+
+-- name: GetRetiredRefreshToken :one
+SELECT * FROM retired_refresh_tokens WHERE token_hash = $1;
+
+-- name: DeleteExpiredRetiredRefreshTokens :exec
+DELETE FROM retired_refresh_tokens WHERE expires_at < NOW();
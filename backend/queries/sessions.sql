@@ -1,18 +1,45 @@
 -- name: CreateSession :one
-INSERT INTO sessions (id, user_id, refresh_token_hash, expires_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO sessions (id, user_id, refresh_token_hash, expires_at, user_agent, ip_address)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetSessionByHash :one
 SELECT * FROM sessions WHERE refresh_token_hash = $1;
 
--- name: UpdateSessionToken :exec
+-- name: GetSession :one
+SELECT * FROM sessions WHERE id = $1;
+
+-- name: ListSessionsForUser :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND expires_at > NOW()
+ORDER BY last_used_at DESC;
+
+-- name: RotateSessionToken :execrows
 UPDATE sessions
-SET refresh_token_hash = $2, expires_at = $3, updated_at = NOW()
-WHERE id = $1;
+SET refresh_token_hash = sqlc.arg(new_token_hash),
+    expires_at = sqlc.arg(expires_at),
+    user_agent = sqlc.arg(user_agent),
+    ip_address = sqlc.arg(ip_address),
+    last_used_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND refresh_token_hash = sqlc.arg(current_token_hash);
+
+-- name: DeleteSessionByHash :many
+DELETE FROM sessions WHERE refresh_token_hash = $1
+RETURNING *;
+
+-- name: DeleteSession :many
+DELETE FROM sessions WHERE id = $1
+RETURNING *;
 
--- name: DeleteSessionByHash :exec
-DELETE FROM sessions WHERE refresh_token_hash = $1;
+-- name: DeleteSessionForUser :many
+DELETE FROM sessions WHERE id = $1 AND user_id = $2
+RETURNING *;
 
--- name: DeleteSessionsForUser :exec
-DELETE FROM sessions WHERE user_id = $1;
+-- name: DeleteOtherSessionsForUser :many
+DELETE FROM sessions WHERE user_id = $1 AND id <> $2
+RETURNING *;
+
+-- name: DeleteSessionsForUser :many
+DELETE FROM sessions WHERE user_id = $1
+RETURNING *;
@@ -0,0 +1,45 @@
+-- name: CreateTagJob :one
+INSERT INTO tag_jobs (id, bucket_id, user_id, keys, prefix, set_tags, remove_tags, replace_tags, status, total)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9)
+RETURNING *;
+
+-- name: GetTagJob :one
+SELECT * FROM tag_jobs
+WHERE id = $1 AND bucket_id = $2;
+
+-- name: ListTagJobs :many
+SELECT * FROM tag_jobs
+WHERE bucket_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: ClaimTagJobs :many
+UPDATE tag_jobs
+SET status = 'running', started_at = NOW(), heartbeat_at = NOW(), processed = 0, failed = 0, failures = NULL
+WHERE id IN (
+    SELECT id FROM tag_jobs
+    WHERE status = 'pending' OR (status = 'running' AND heartbeat_at <= sqlc.arg(stale_before)::timestamptz)
+    ORDER BY created_at
+    LIMIT sqlc.arg(max_rows)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateTagJobProgress :execrows
+UPDATE tag_jobs
+SET processed = $2, failed = $3, failures = $4, heartbeat_at = NOW()
+WHERE id = $1 AND status = 'running';
+
+-- name: FinishTagJob :execrows
+UPDATE tag_jobs
+SET status = $2, processed = $3, failed = $4, failures = $5, error = $6, completed_at = NOW()
+WHERE id = $1 AND status = 'running';
+
+-- name: CancelTagJob :execrows
+UPDATE tag_jobs
+SET status = 'canceled', completed_at = NOW()
+WHERE id = $1 AND bucket_id = $2 AND status IN ('pending', 'running');
+
+-- name: DeleteTagJobsBefore :execrows
+DELETE FROM tag_jobs
+WHERE status IN ('completed', 'failed', 'canceled') AND completed_at < $1;
@@ -0,0 +1,34 @@
+-- name: CreateTagSearch :one
+INSERT INTO tag_searches (id, bucket_id, user_id, prefix, filters, status)
+VALUES ($1, $2, $3, $4, $5, 'pending')
+RETURNING *;
+
+-- name: GetTagSearch :one
+SELECT * FROM tag_searches
+WHERE id = $1 AND bucket_id = $2;
+
+-- name: ClaimTagSearches :many
+UPDATE tag_searches
+SET status = 'running', started_at = NOW(), heartbeat_at = NOW(), scanned = 0, matches = NULL, truncated = FALSE
+WHERE id IN (
+    SELECT id FROM tag_searches
+    WHERE status = 'pending' OR (status = 'running' AND heartbeat_at <= sqlc.arg(stale_before)::timestamptz)
+    ORDER BY created_at
+    LIMIT sqlc.arg(max_rows)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateTagSearchProgress :execrows
+UPDATE tag_searches
+SET scanned = $2, heartbeat_at = NOW()
+WHERE id = $1 AND status = 'running';
+
+-- name: FinishTagSearch :execrows
+UPDATE tag_searches
+SET status = $2, scanned = $3, matches = $4, truncated = $5, error = $6, completed_at = NOW()
+WHERE id = $1 AND status = 'running';
+
+-- name: DeleteTagSearchesBefore :execrows
+DELETE FROM tag_searches
+WHERE created_at < $1;
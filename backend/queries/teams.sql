@@ -0,0 +1,35 @@
+-- name: CreateTeam :one
+INSERT INTO teams (id, name, created_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetTeam :one
+SELECT * FROM teams WHERE id = $1;
+
+-- name: ListTeamsForUser :many
+SELECT t.id, t.name, t.created_by, t.created_at, t.updated_at, m.role
+FROM teams t
+JOIN team_members m ON m.team_id = t.id
+WHERE m.user_id = $1
+ORDER BY t.name;
+
+-- name: DeleteTeam :exec
+DELETE FROM teams WHERE id = $1;
+
+-- name: AddTeamMember :exec
+INSERT INTO team_members (team_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (team_id, user_id) DO NOTHING;
+
+-- name: GetTeamMember :one
+SELECT * FROM team_members WHERE team_id = $1 AND user_id = $2;
+
+-- name: ListTeamMembers :many
+SELECT m.team_id, m.user_id, m.role, m.created_at, u.email, u.first_name, u.last_name
+FROM team_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.team_id = $1
+ORDER BY u.email;
+
+-- name: RemoveTeamMember :execrows
+DELETE FROM team_members WHERE team_id = $1 AND user_id = $2;
@@ -0,0 +1,101 @@
+-- name: ClaimBucketsForUsageSnapshot :many
+UPDATE buckets
+SET usage_snapshot_at = NOW()
+WHERE id IN (
+    SELECT id FROM buckets
+    WHERE usage_snapshot_at IS NULL OR usage_snapshot_at <= sqlc.arg(due_before)::timestamptz
+    ORDER BY usage_snapshot_at NULLS FIRST
+    LIMIT sqlc.arg(max_rows)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CreateUsageSnapshot :exec
+INSERT INTO usage_snapshots (bucket_id, prefix, size_bytes, object_count, captured_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListUsageSnapshots :many
+SELECT * FROM usage_snapshots
+WHERE bucket_id = sqlc.arg(bucket_id)
+  AND (sqlc.narg(prefix)::text IS NULL OR prefix = sqlc.narg(prefix)::text)
+  AND captured_at >= sqlc.arg(since)::timestamptz
+ORDER BY captured_at, prefix;
+
+-- name: GetLatestUsageSnapshotBefore :one
+SELECT * FROM usage_snapshots
+WHERE bucket_id = $1 AND prefix = $2 AND captured_at <= $3
+ORDER BY captured_at DESC
+LIMIT 1;
+
+-- name: GetFirstUsageSnapshot :one
+SELECT * FROM usage_snapshots
+WHERE bucket_id = $1 AND prefix = $2
+ORDER BY captured_at
+LIMIT 1;
+
+-- name: DeleteUsageSnapshotsBefore :execrows
+DELETE FROM usage_snapshots WHERE captured_at < $1;
+
+-- name: GetUsageAlert :one
+SELECT * FROM usage_alerts WHERE bucket_id = $1;
+
+-- name: UpsertUsageAlert :one
+INSERT INTO usage_alerts (id, bucket_id, max_daily_growth_bytes, max_daily_growth_objects)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (bucket_id) DO UPDATE
+SET max_daily_growth_bytes = EXCLUDED.max_daily_growth_bytes,
+    max_daily_growth_objects = EXCLUDED.max_daily_growth_objects,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteUsageAlert :execrows
+DELETE FROM usage_alerts WHERE bucket_id = $1;
+
+-- name: MarkUsageAlertTriggered :exec
+UPDATE usage_alerts SET last_triggered_at = $2 WHERE id = $1;
+
+-- name: GetUsageReport :one
+SELECT * FROM usage_reports
+WHERE bucket_id = $1 AND prefix = $2 AND depth = $3;
+
+-- name: RequestUsageReport :one
+INSERT INTO usage_reports (id, bucket_id, prefix, depth, status)
+VALUES ($1, $2, $3, $4, 'pending')
+ON CONFLICT (bucket_id, prefix, depth) DO UPDATE
+SET status = 'pending', error = NULL, requested_at = NOW(), started_at = NULL, completed_at = NULL
+WHERE usage_reports.status NOT IN ('pending', 'running')
+RETURNING *;
+
+-- name: SaveUsageReport :one
+INSERT INTO usage_reports (id, bucket_id, prefix, depth, status, result, started_at, completed_at)
+VALUES ($1, $2, $3, $4, 'ready', $5, $6, NOW())
+ON CONFLICT (bucket_id, prefix, depth) DO UPDATE
+SET status = 'ready', result = EXCLUDED.result, error = NULL, requested_at = NOW(),
+    started_at = EXCLUDED.started_at, completed_at = NOW()
+RETURNING *;
+
+-- name: ClaimUsageReports :many
+UPDATE usage_reports
+SET status = 'running', started_at = NOW()
+WHERE id IN (
+    SELECT id FROM usage_reports
+    WHERE status = 'pending' OR (status = 'running' AND started_at <= sqlc.arg(stale_before)::timestamptz)
+    ORDER BY requested_at
+    LIMIT sqlc.arg(max_rows)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteUsageReport :exec
+UPDATE usage_reports
+SET status = 'ready', result = $2, error = NULL, completed_at = NOW()
+WHERE id = $1;
+
+-- name: FailUsageReport :exec
+UPDATE usage_reports
+SET status = 'failed', error = $2, completed_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteUsageReportsBefore :execrows
+DELETE FROM usage_reports
+WHERE status IN ('ready', 'failed') AND requested_at < $1;
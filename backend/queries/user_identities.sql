@@ -0,0 +1,12 @@
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (id, user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities WHERE provider = $1 AND subject = $2;
+
+-- name: UpdateUserIdentityEmail :exec
+UPDATE user_identities
+SET email = $2, updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,54 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (id, bucket_id, url, events, key_prefix, key_suffix, secret, active)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1 AND bucket_id = $2;
+
+-- name: GetWebhookByID :one
+SELECT * FROM webhooks WHERE id = $1;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+WHERE bucket_id = $1
+ORDER BY created_at;
+
+-- name: ListWebhooksForEvent :many
+SELECT * FROM webhooks
+WHERE bucket_id = sqlc.arg(bucket_id)
+  AND active
+  AND sqlc.arg(event)::text = ANY(events)
+ORDER BY created_at;
+
+-- name: UpdateWebhook :one
+UPDATE webhooks
+SET url = $3, events = $4, key_prefix = $5, key_suffix = $6, active = $7, updated_at = NOW()
+WHERE id = $1 AND bucket_id = $2
+RETURNING *;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = $1 AND bucket_id = $2;
+
+-- name: DeleteOrphanedWebhooks :execrows
+DELETE FROM webhooks
+WHERE (bucket_id IS NULL OR NOT EXISTS (SELECT 1 FROM buckets WHERE buckets.id = webhooks.bucket_id))
+  AND NOT EXISTS (
+    SELECT 1 FROM outbox_events
+    WHERE outbox_events.bucket_id = webhooks.bucket_id AND outbox_events.status = 'pending'
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM webhook_deliveries
+    WHERE webhook_deliveries.webhook_id = webhooks.id AND webhook_deliveries.status = 'pending'
+  );
+
+-- name: ListWebhooksForUpdate :many
+SELECT * FROM webhooks
+ORDER BY created_at
+FOR UPDATE;
+
+-- name: UpdateWebhookSecret :exec
+UPDATE webhooks
+SET secret = $2
+WHERE id = $1;